@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/types"
 )
 
@@ -16,6 +17,33 @@ var (
 		Long:  "Print the current state of consensus such as current block, block height, and target.",
 		Run:   wrap(consensuscmd),
 	}
+
+	consensusSnapshotCmd = &cobra.Command{
+		Use:   "snapshot",
+		Short: "Export or import consensus snapshots",
+		Long: `Export or import consensus snapshots. A snapshot contains the state of the
+consensus set at a given height, and allows a new node to skip syncing the
+blockchain up to that height.`,
+	}
+
+	consensusSnapshotExportCmd = &cobra.Command{
+		Use:   "export [destination]",
+		Short: "Export a consensus snapshot",
+		Long: `Export a snapshot of the consensus set to the destination file. By default, the
+snapshot is taken at the current height. Share the block ID and checksum that
+are printed together with the snapshot, so that they can be verified on import.`,
+		Run: wrap(consensussnapshotexportcmd),
+	}
+
+	consensusSnapshotImportCmd = &cobra.Command{
+		Use:   "import [source] [blockid]",
+		Short: "Import a consensus snapshot",
+		Long: `Import a consensus snapshot into a node that has only the genesis block. The
+snapshot must end in the given block. The blocks below the snapshot are not
+validated, so the block ID and checksum must be obtained from a trusted source.
+After the import, the node continues to sync from the height of the snapshot.`,
+		Run: wrap(consensussnapshotimportcmd),
+	}
 )
 
 // consensuscmd is the handler for the command `siac consensus`.
@@ -42,7 +70,58 @@ Difficulty: %v
 Height: %v
 Progress (estimated): %.1f%%
 `, yesNo(cg.Synced), cg.Height, estimatedProgress)
+		if cg.HeaderHeight > cg.Height {
+			fmt.Printf(`Headers: %v
+Blocks downloading: %v
+`, cg.HeaderHeight, cg.BodiesInFlight)
+		}
+	}
+	fmt.Printf("Disk usage: %v\n", filesizeUnits(int64(cg.DiskUsage)))
+	if cg.PruneDepth != 0 {
+		fmt.Printf("Pruning: keeping the last %v blocks, pruned below height %v\n", cg.PruneDepth, cg.PrunedHeight)
+	}
+}
+
+// consensussnapshotexportcmd is the handler for the command `siac consensus
+// snapshot export [destination]`.
+func consensussnapshotexportcmd(destination string) {
+	height := types.BlockHeight(consensusSnapshotHeight)
+	if height == 0 {
+		cg, err := httpClient.ConsensusGet()
+		if err != nil {
+			die("Could not get current consensus state:", err)
+		}
+		height = cg.Height
+	}
+	csp, err := httpClient.ConsensusSnapshotExportPost(height, abs(destination))
+	if err != nil {
+		die("Could not export snapshot:", err)
+	}
+	fmt.Printf(`Exported snapshot to %v
+Height:   %v
+Block:    %v
+Checksum: %v
+`, abs(destination), csp.Height, csp.BlockID, csp.Checksum)
+}
+
+// consensussnapshotimportcmd is the handler for the command `siac consensus
+// snapshot import [source] [blockid]`.
+func consensussnapshotimportcmd(source, blockid string) {
+	var id types.BlockID
+	if err := id.LoadString(blockid); err != nil {
+		die("Could not parse block ID:", err)
+	}
+	var checksum crypto.Hash
+	if consensusSnapshotChecksum != "" {
+		if err := checksum.LoadString(consensusSnapshotChecksum); err != nil {
+			die("Could not parse checksum:", err)
+		}
+	}
+	csp, err := httpClient.ConsensusSnapshotImportPost(abs(source), id, checksum)
+	if err != nil {
+		die("Could not import snapshot:", err)
 	}
+	fmt.Printf("Imported snapshot at height %v (block %v)\n", csp.Height, csp.BlockID)
 }
 
 // estimatedHeightAt returns the estimated block height for the given time.
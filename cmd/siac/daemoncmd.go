@@ -2,12 +2,104 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/node/api"
+	"github.com/acejam/Sia/persist"
 )
 
 var (
+	daemonCmd = &cobra.Command{
+		Use:   "daemon",
+		Short: "Perform daemon actions",
+		Long:  "Manage the Sia daemon.",
+	}
+
+	daemonAlertsCmd = &cobra.Command{
+		Use:   "alerts",
+		Short: "View the alerts",
+		Long: `View the alerts of the modules of the daemon, most severe first. An alert
+remains until the condition that raised it is resolved. Critical alerts are
+also printed before the output of every other command.`,
+		Run: wrap(daemonalertscmd),
+	}
+
+	daemonConfigCmd = &cobra.Command{
+		Use:   "config",
+		Short: "View the effective configuration",
+		Long: `View the effective configuration of the daemon in the format of the config
+file: the value of every flag after the config file has been applied, and the
+current settings of the modules.`,
+		Run: wrap(daemonconfigcmd),
+	}
+
+	daemonEncryptCmd = &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt the persist files of the host and the renter",
+		Long: `Encrypt the persist files of the host and the renter with a key derived from
+a passphrase. Once encrypted, the host and the renter are only loaded after the
+persist files are unlocked with 'siac daemon unlock', or by unlocking the
+wallet if the passphrase is the wallet password. The passphrase cannot be
+recovered; without it the contracts and files of the renter are lost.`,
+		Run: wrap(daemonencryptcmd),
+	}
+
+	daemonLogLevelCmd = &cobra.Command{
+		Use:   "loglevel [module] [level]",
+		Short: "View or set the log levels",
+		Long: `View the log levels of the modules of the daemon, or set the log level of a
+module. The levels are debug, info, warn, error and critical. Entries below the
+level of a module are not written to its log file.`,
+		Run: daemonloglevelcmd,
+	}
+
+	daemonReloadCmd = &cobra.Command{
+		Use:   "reload",
+		Short: "Reload the config file",
+		Long: `Reload the config file of the daemon. The module settings and log levels
+are applied immediately; changes to the flags take effect when the daemon is
+restarted.`,
+		Run: wrap(daemonreloadcmd),
+	}
+
+	daemonTokensCmd = &cobra.Command{
+		Use:   "tokens",
+		Short: "View the API tokens",
+		Long:  "View the names and scopes of the API tokens of the daemon.",
+		Run:   wrap(daemontokenscmd),
+	}
+
+	daemonTokensAddCmd = &cobra.Command{
+		Use:   "add [name] [scopes]",
+		Short: "Create an API token",
+		Long: `Create an API token with a comma-separated list of scopes. The scopes are
+read-only, renter, host, wallet-spend and admin. The token is printed once and
+is used in place of the API password, e.g. with --apipassword.`,
+		Run: wrap(daemontokensaddcmd),
+	}
+
+	daemonTokensRemoveCmd = &cobra.Command{
+		Use:   "remove [name]",
+		Short: "Remove an API token",
+		Long:  "Remove an API token. Clients using the token can no longer call protected routes.",
+		Run:   wrap(daemontokensremovecmd),
+	}
+
+	daemonUnlockCmd = &cobra.Command{
+		Use:   "unlock",
+		Short: "Unlock the persist files of the host and the renter",
+		Long: `Unlock the encrypted persist files of the host and the renter and load the
+modules.`,
+		Run: wrap(daemonunlockcmd),
+	}
+
 	stopCmd = &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the Sia daemon",
@@ -99,3 +191,206 @@ func updatecheckcmd() {
 		fmt.Println("Up to date.")
 	}
 }
+
+// daemontokenscmd is the handler for the command `siac daemon tokens`. Lists
+// the API tokens of the daemon.
+func daemontokenscmd() {
+	dtg, err := httpClient.DaemonTokensGet()
+	if err != nil {
+		die("Could not get API tokens:", err)
+	}
+	if len(dtg.Tokens) == 0 {
+		fmt.Println("No API tokens.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tScopes\tCreated")
+	for _, t := range dtg.Tokens {
+		scopes := make([]string, len(t.Scopes))
+		for i, s := range t.Scopes {
+			scopes[i] = string(s)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\n", t.Name, strings.Join(scopes, ","), t.Created.Format(time.RFC822))
+	}
+	w.Flush()
+}
+
+// daemontokensaddcmd is the handler for the command `siac daemon tokens add
+// [name] [scopes]`. Creates an API token and prints it.
+func daemontokensaddcmd(name, scopes string) {
+	var apiScopes []api.APIScope
+	for _, s := range strings.Split(scopes, ",") {
+		apiScopes = append(apiScopes, api.APIScope(strings.TrimSpace(s)))
+	}
+	dtp, err := httpClient.DaemonTokensAddPost(name, apiScopes)
+	if err != nil {
+		die("Could not create API token:", err)
+	}
+	fmt.Println("Created API token", name+":")
+	fmt.Println(dtp.Token)
+	fmt.Println("Store the token now, it cannot be displayed again.")
+}
+
+// daemontokensremovecmd is the handler for the command `siac daemon tokens
+// remove [name]`. Removes an API token.
+func daemontokensremovecmd(name string) {
+	err := httpClient.DaemonTokensRemovePost(name)
+	if err != nil {
+		die("Could not remove API token:", err)
+	}
+	fmt.Println("Removed API token", name+".")
+}
+
+// daemonencryptcmd is the handler for the command `siac daemon encrypt`.
+// Encrypts the persist files of the host and the renter.
+func daemonencryptcmd() {
+	passphrase, err := passwordPrompt("Passphrase: ")
+	if err != nil {
+		die("Reading passphrase failed:", err)
+	}
+	if err := confirmPassword(passphrase); err != nil {
+		die(err)
+	}
+	if err := httpClient.DaemonEncryptionPost(passphrase); err != nil {
+		die("Could not encrypt the persist files:", err)
+	}
+	fmt.Println("Encrypted the persist files of the host and the renter.")
+}
+
+// daemonunlockcmd is the handler for the command `siac daemon unlock`.
+// Unlocks the persist files of the host and the renter.
+func daemonunlockcmd() {
+	passphrase, err := passwordPrompt("Passphrase: ")
+	if err != nil {
+		die("Reading passphrase failed:", err)
+	}
+	if err := httpClient.DaemonUnlockPost(passphrase); err != nil {
+		die("Could not unlock the persist files:", err)
+	}
+	fmt.Println("Unlocked the persist files of the host and the renter.")
+}
+
+// daemonloglevelcmd is the handler for the command `siac daemon loglevel
+// [module] [level]`. Lists the log levels of the modules, or sets the log
+// level of a module.
+func daemonloglevelcmd(cmd *cobra.Command, args []string) {
+	switch len(args) {
+	case 0:
+		dlg, err := httpClient.DaemonLogLevelGet()
+		if err != nil {
+			die("Could not get log levels:", err)
+		}
+		modules := make([]string, 0, len(dlg.Levels))
+		for module := range dlg.Levels {
+			modules = append(modules, module)
+		}
+		sort.Strings(modules)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Module\tLevel")
+		for _, module := range modules {
+			fmt.Fprintf(w, "%v\t%v\n", module, dlg.Levels[module])
+		}
+		w.Flush()
+	case 2:
+		level, err := persist.ParseLogLevel(args[1])
+		if err != nil {
+			die("Could not parse log level:", err)
+		}
+		if err := httpClient.DaemonLogLevelPost(args[0], level); err != nil {
+			die("Could not set log level:", err)
+		}
+		fmt.Printf("Set log level of %v to %v.\n", args[0], level)
+	default:
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+}
+
+// daemonconfigcmd is the handler for the command `siac daemon config`. Prints
+// the effective configuration of the daemon in the format of the config file.
+func daemonconfigcmd() {
+	dcg, err := httpClient.DaemonConfigGet()
+	if err != nil {
+		die("Could not get config:", err)
+	}
+	fmt.Println("# Config file:", dcg.File)
+	sections := []struct {
+		name     string
+		settings map[string]string
+	}{
+		{"siad", dcg.Siad},
+		{"gateway", dcg.Gateway},
+		{"host", dcg.Host},
+		{"renter", dcg.Renter},
+		{"loglevels", dcg.LogLevels},
+	}
+	for _, section := range sections {
+		if len(section.settings) == 0 {
+			continue
+		}
+		keys := make([]string, 0, len(section.settings))
+		for key := range section.settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Printf("\n[%v]\n", section.name)
+		for _, key := range keys {
+			fmt.Printf("%v = %q\n", key, section.settings[key])
+		}
+	}
+}
+
+// daemonreloadcmd is the handler for the command `siac daemon reload`.
+// Reloads the config file of the daemon.
+func daemonreloadcmd() {
+	drp, err := httpClient.DaemonReloadPost()
+	if err != nil {
+		die("Could not reload config file:", err)
+	}
+	if len(drp.Applied) == 0 {
+		fmt.Println("Reloaded config file, it contains no module settings.")
+	} else {
+		fmt.Println("Reloaded config file, applied", strings.Join(drp.Applied, ", ")+".")
+	}
+	if len(drp.RestartRequired) > 0 {
+		fmt.Println("Restart the daemon to apply", strings.Join(drp.RestartRequired, ", ")+".")
+	}
+}
+
+// daemonalertscmd is the handler for the command `siac daemon alerts`. Lists
+// the alerts of the daemon.
+func daemonalertscmd() {
+	dag, err := httpClient.DaemonAlertsGet()
+	if err != nil {
+		die("Could not get alerts:", err)
+	}
+	if len(dag.Alerts) == 0 {
+		fmt.Println("No alerts.")
+		return
+	}
+	for _, a := range dag.Alerts {
+		fmt.Printf("%v [%v] %v: %v\n", strings.ToUpper(a.Severity.String()), a.Module, a.ID, a.Msg)
+		if a.Cause != "" {
+			fmt.Println("  Cause:", a.Cause)
+		}
+		fmt.Println("  Since:", a.Time.Format(time.RFC822))
+	}
+}
+
+// printcriticalalerts prints the critical alerts of the daemon to stderr. It
+// runs before every command, so errors are ignored; the command itself
+// reports whether the daemon can be reached.
+func printcriticalalerts(cmd *cobra.Command, _ []string) {
+	if cmd == daemonAlertsCmd {
+		return
+	}
+	dag, err := httpClient.DaemonAlertsGet()
+	if err != nil {
+		return
+	}
+	for _, a := range dag.Alerts {
+		if a.Severity == modules.SeverityCritical {
+			fmt.Fprintf(os.Stderr, "CRITICAL ALERT [%v]: %v\n", a.Module, a.Msg)
+		}
+	}
+}
@@ -3,7 +3,10 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/acejam/Sia/modules"
@@ -17,6 +20,29 @@ var (
 		Run:   wrap(gatewayaddresscmd),
 	}
 
+	gatewayBlocklistCmd = &cobra.Command{
+		Use:   "blocklist",
+		Short: "View the gateway blocklist",
+		Long:  "View the IP addresses and subnets that the gateway refuses to connect to, and the misbehavior scores of peers.",
+		Run:   wrap(gatewayblocklistcmd),
+	}
+
+	gatewayBlocklistAddCmd = &cobra.Command{
+		Use:   "add [address]",
+		Short: "Add an address to the blocklist",
+		Long: `Add an IP address or a subnet in CIDR notation (e.g. 10.0.0.0/8) to the
+blocklist. Connected peers within the address range are disconnected. Without
+the --duration flag the address is blocked permanently.`,
+		Run: wrap(gatewayblocklistaddcmd),
+	}
+
+	gatewayBlocklistRemoveCmd = &cobra.Command{
+		Use:   "remove [address]",
+		Short: "Remove an address from the blocklist",
+		Long:  "Remove an IP address or a subnet in CIDR notation from the blocklist.",
+		Run:   wrap(gatewayblocklistremovecmd),
+	}
+
 	gatewayCmd = &cobra.Command{
 		Use:   "gateway",
 		Short: "Perform gateway actions",
@@ -38,6 +64,14 @@ var (
 		Run:   wrap(gatewaydisconnectcmd),
 	}
 
+	gatewayRatelimitCmd = &cobra.Command{
+		Use:   "ratelimit [maxdownloadspeed] [maxuploadspeed]",
+		Short: "Set the gateway's bandwidth limits",
+		Long: `Set the maximum download and upload speed of the combined traffic of all
+peers, e.g. 1MB or 500KiB per second. A speed of 0 disables the limit.`,
+		Run: wrap(gatewayratelimitcmd),
+	}
+
 	gatewayListCmd = &cobra.Command{
 		Use:   "list",
 		Short: "View a list of peers",
@@ -66,6 +100,103 @@ func gatewaydisconnectcmd(addr string) {
 	fmt.Println("Removed", addr, "from peer list.")
 }
 
+// gatewayblocklistcmd is the handler for the command `siac gateway blocklist`.
+// Prints the blocklist and the misbehavior scores of peers.
+func gatewayblocklistcmd() {
+	gbg, err := httpClient.GatewayBlocklistGet()
+	if err != nil {
+		die("Could not get blocklist:", err)
+	}
+	if len(gbg.Blocklist) == 0 {
+		fmt.Println("No blocked addresses.")
+	} else {
+		fmt.Println(len(gbg.Blocklist), "blocked addresses:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Address\tAutomatic\tExpiry\tReason")
+		for _, entry := range gbg.Blocklist {
+			expiry := "never"
+			if !entry.Expiry.IsZero() {
+				expiry = entry.Expiry.Format(time.RFC822)
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", entry.Address, yesNo(entry.Automatic), expiry, entry.Reason)
+		}
+		w.Flush()
+	}
+	if len(gbg.Misbehavior) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println(len(gbg.Misbehavior), "misbehaving addresses:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Address\tScore\tLast Reason")
+	for _, m := range gbg.Misbehavior {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", m.Address, m.Score, m.LastReason)
+	}
+	w.Flush()
+}
+
+// gatewayblocklistaddcmd is the handler for the command `siac gateway
+// blocklist add [address]`. Adds an address or subnet to the blocklist.
+func gatewayblocklistaddcmd(addr string) {
+	err := httpClient.GatewayBlocklistAddPost(addr, gatewayBlocklistDuration, gatewayBlocklistReason)
+	if err != nil {
+		die("Could not block address:", err)
+	}
+	fmt.Println("Added", addr, "to the blocklist.")
+}
+
+// gatewayblocklistremovecmd is the handler for the command `siac gateway
+// blocklist remove [address]`. Removes an address or subnet from the
+// blocklist.
+func gatewayblocklistremovecmd(addr string) {
+	err := httpClient.GatewayBlocklistRemovePost(addr)
+	if err != nil {
+		die("Could not unblock address:", err)
+	}
+	fmt.Println("Removed", addr, "from the blocklist.")
+}
+
+// parseSpeed converts a speed such as 1MB or 500KiB/s to bytes per second. A
+// speed of 0 does not require a unit.
+func parseSpeed(speed string) (int64, error) {
+	speed = strings.TrimSuffix(speed, "/s")
+	if speed == "0" {
+		return 0, nil
+	}
+	bytes, err := parseFilesize(speed)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(bytes, 10, 64)
+}
+
+// speedUnits returns a human readable representation of a speed in bytes per
+// second.
+func speedUnits(bps int64) string {
+	if bps == 0 {
+		return "unlimited"
+	}
+	return filesizeUnits(bps) + "/s"
+}
+
+// gatewayratelimitcmd is the handler for the command `siac gateway ratelimit
+// [maxdownloadspeed] [maxuploadspeed]`. Sets the gateway's bandwidth limits.
+func gatewayratelimitcmd(downloadSpeedStr, uploadSpeedStr string) {
+	downloadSpeed, err := parseSpeed(downloadSpeedStr)
+	if err != nil {
+		die("Could not parse download speed:", err)
+	}
+	uploadSpeed, err := parseSpeed(uploadSpeedStr)
+	if err != nil {
+		die("Could not parse upload speed:", err)
+	}
+	err = httpClient.GatewayRateLimitPost(downloadSpeed, uploadSpeed)
+	if err != nil {
+		die("Could not set bandwidth limits:", err)
+	}
+	fmt.Printf("Set gateway bandwidth limits to %v download and %v upload.\n", speedUnits(downloadSpeed), speedUnits(uploadSpeed))
+}
+
 // gatewayaddresscmd is the handler for the command `siac gateway address`.
 // Prints the gateway's network address.
 func gatewayaddresscmd() {
@@ -85,6 +216,8 @@ func gatewaycmd() {
 	}
 	fmt.Println("Address:", info.NetAddress)
 	fmt.Println("Active peers:", len(info.Peers))
+	fmt.Println("Max download speed:", speedUnits(info.MaxDownloadSpeed))
+	fmt.Println("Max upload speed:", speedUnits(info.MaxUploadSpeed))
 }
 
 // gatewaylistcmd is the handler for the command `siac gateway list`.
@@ -100,9 +233,10 @@ func gatewaylistcmd() {
 	}
 	fmt.Println(len(info.Peers), "active peers:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Version\tOutbound\tAddress")
+	fmt.Fprintln(w, "Version\tOutbound\tAddress\tDownloaded\tUploaded\tRPCs")
 	for _, peer := range info.Peers {
-		fmt.Fprintf(w, "%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), peer.NetAddress)
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), peer.NetAddress,
+			filesizeUnits(int64(peer.BytesIn)), filesizeUnits(int64(peer.BytesOut)), peer.RPCsCalled+peer.RPCsHandled)
 	}
 	w.Flush()
 }
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestParseSpeed probes the parseSpeed function.
+func TestParseSpeed(t *testing.T) {
+	tests := []struct {
+		in  string
+		out int64
+		err error
+	}{
+		{"0", 0, nil},
+		{"1MB", 1e6, nil},
+		{"1MB/s", 1e6, nil},
+		{"500KiB", 500 * 1024, nil},
+		{"12", 0, errUnableToParseSize},
+		{"foo", 0, errUnableToParseSize},
+	}
+	for _, test := range tests {
+		out, err := parseSpeed(test.in)
+		if err != test.err {
+			t.Errorf("parseSpeed(%q): expected error %v, got %v", test.in, test.err, err)
+		} else if out != test.out {
+			t.Errorf("parseSpeed(%q): expected %v, got %v", test.in, test.out, out)
+		}
+	}
+}
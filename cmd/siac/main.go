@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -13,14 +14,21 @@ import (
 
 var (
 	// Flags.
-	hostContractOutputType string // output type for host contracts
-	hostVerbose            bool   // display additional host info
-	initForce              bool   // destroy and re-encrypt the wallet on init if it already exists
-	initPassword           bool   // supply a custom password when creating a wallet
-	renterAllContracts     bool   // Show all active and expired contracts
-	renterDownloadAsync    bool   // Downloads files asynchronously
-	renterListVerbose      bool   // Show additional info about uploaded files.
-	renterShowHistory      bool   // Show download history in addition to download queue.
+	consensusSnapshotChecksum string        // expected checksum of an imported snapshot
+	consensusSnapshotHeight   uint64        // height of an exported snapshot
+	gatewayBlocklistDuration  time.Duration // how long an address remains on the blocklist
+	gatewayBlocklistReason    string        // reason for adding an address to the blocklist
+	hostContractOutputType    string        // output type for host contracts
+	hostVerbose               bool          // display additional host info
+	initForce                 bool          // destroy and re-encrypt the wallet on init if it already exists
+	initPassword              bool          // supply a custom password when creating a wallet
+	renterAllContracts        bool          // Show all active and expired contracts
+	renterDownloadAsync       bool          // Downloads files asynchronously
+	renterListVerbose         bool          // Show additional info about uploaded files.
+	renterShowHistory         bool          // Show download history in addition to download queue.
+	tpoolAddress              string        // only list unconfirmed transactions related to this address
+	tpoolLimit                int           // number of unconfirmed transactions to list
+	tpoolOffset               int           // number of unconfirmed transactions to skip
 )
 
 var (
@@ -78,6 +86,9 @@ func main() {
 		Short: "Sia Client v" + build.Version,
 		Long:  "Sia Client v" + build.Version,
 		Run:   wrap(consensuscmd),
+
+		// Print the critical alerts of the daemon before every command.
+		PersistentPreRun: printcriticalalerts,
 	}
 
 	rootCmd = root
@@ -89,6 +100,10 @@ func main() {
 	root.AddCommand(updateCmd)
 	updateCmd.AddCommand(updateCheckCmd)
 
+	root.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonAlertsCmd, daemonConfigCmd, daemonEncryptCmd, daemonLogLevelCmd, daemonReloadCmd, daemonTokensCmd, daemonUnlockCmd)
+	daemonTokensCmd.AddCommand(daemonTokensAddCmd, daemonTokensRemoveCmd)
+
 	root.AddCommand(hostCmd)
 	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostContractCmd, hostSectorCmd)
 	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
@@ -105,9 +120,10 @@ func main() {
 	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletChangepasswordCmd, walletInitCmd, walletInitSeedCmd,
+	walletCmd.AddCommand(walletAccountsCmd, walletAddressCmd, walletAddressesCmd, walletChangepasswordCmd, walletInitCmd, walletInitSeedCmd,
 		walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd, walletSweepCmd,
 		walletBalanceCmd, walletTransactionsCmd, walletUnlockCmd)
+	walletAccountsCmd.AddCommand(walletAccountsAddressCmd, walletAccountsCreateCmd, walletAccountsSendCmd)
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
@@ -133,9 +149,22 @@ func main() {
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 
 	root.AddCommand(gatewayCmd)
-	gatewayCmd.AddCommand(gatewayConnectCmd, gatewayDisconnectCmd, gatewayAddressCmd, gatewayListCmd)
+	gatewayCmd.AddCommand(gatewayConnectCmd, gatewayDisconnectCmd, gatewayAddressCmd, gatewayListCmd, gatewayBlocklistCmd, gatewayRatelimitCmd)
+	gatewayBlocklistCmd.AddCommand(gatewayBlocklistAddCmd, gatewayBlocklistRemoveCmd)
+	gatewayBlocklistAddCmd.Flags().DurationVarP(&gatewayBlocklistDuration, "duration", "d", 0, "How long the address remains blocked, e.g. 24h (default: permanently)")
+	gatewayBlocklistAddCmd.Flags().StringVarP(&gatewayBlocklistReason, "reason", "r", "", "Reason for blocking the address")
 
 	root.AddCommand(consensusCmd)
+	consensusCmd.AddCommand(consensusSnapshotCmd)
+	consensusSnapshotCmd.AddCommand(consensusSnapshotExportCmd, consensusSnapshotImportCmd)
+	consensusSnapshotExportCmd.Flags().Uint64VarP(&consensusSnapshotHeight, "height", "", 0, "Height of the snapshot (default: current height)")
+	consensusSnapshotImportCmd.Flags().StringVarP(&consensusSnapshotChecksum, "checksum", "", "", "Expected checksum of the snapshot")
+
+	root.AddCommand(tpoolCmd)
+	tpoolCmd.AddCommand(tpoolHistogramCmd, tpoolSetCmd, tpoolSetsCmd, tpoolTransactionsCmd)
+	tpoolTransactionsCmd.Flags().StringVarP(&tpoolAddress, "address", "", "", "Only list transactions related to this address")
+	tpoolTransactionsCmd.Flags().IntVarP(&tpoolLimit, "limit", "l", 100, "Number of transactions to list")
+	tpoolTransactionsCmd.Flags().IntVarP(&tpoolOffset, "offset", "o", 0, "Number of transactions to skip")
 
 	root.AddCommand(bashcomplCmd)
 	root.AddCommand(mangenCmd)
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+var (
+	tpoolCmd = &cobra.Command{
+		Use:   "tpool",
+		Short: "Print the current state of the transaction pool",
+		Long:  "Print the number and size of the unconfirmed transactions, the estimated fees and the rebroadcast status of the transaction pool.",
+		Run:   wrap(tpoolcmd),
+	}
+
+	tpoolHistogramCmd = &cobra.Command{
+		Use:   "histogram",
+		Short: "Print the fee-rate histogram of the transaction pool",
+		Long:  "Print how many unconfirmed sets, transactions and bytes fall into each fee-rate range.",
+		Run:   wrap(tpoolhistogramcmd),
+	}
+
+	tpoolSetCmd = &cobra.Command{
+		Use:   "set [id]",
+		Short: "View an unconfirmed set",
+		Long:  "View an unconfirmed set, its fee rate and its independent components.",
+		Run:   wrap(tpoolsetcmd),
+	}
+
+	tpoolSetsCmd = &cobra.Command{
+		Use:   "sets",
+		Short: "List the unconfirmed sets",
+		Long:  "List the unconfirmed sets of the transaction pool, ordered by fee rate from highest to lowest.",
+		Run:   wrap(tpoolsetscmd),
+	}
+
+	tpoolTransactionsCmd = &cobra.Command{
+		Use:   "transactions",
+		Short: "List the unconfirmed transactions",
+		Long:  "List the unconfirmed transactions of the transaction pool, ordered by the fee rate of their set. Use --address to only list the transactions related to an address.",
+		Run:   wrap(tpooltransactionscmd),
+	}
+)
+
+// feeRateUnits converts a fee rate in hastings per byte into a human-readable
+// fee rate per KB.
+func feeRateUnits(rate types.Currency) string {
+	return currencyUnits(rate.Mul64(1e3)) + " / KB"
+}
+
+// tpoolcmd is the handler for the command `siac tpool`.
+// Prints the current state of the transaction pool.
+func tpoolcmd() {
+	tg, err := httpClient.TransactionPoolGet()
+	if err != nil {
+		die("Could not get transaction pool stats:", err)
+	}
+	fee, err := httpClient.TransactionPoolFeeGet()
+	if err != nil {
+		die("Could not get fee estimation:", err)
+	}
+	lastRebroadcast := "never"
+	if !tg.LastRebroadcast.IsZero() {
+		lastRebroadcast = tg.LastRebroadcast.Format("2006-01-02 15:04:05")
+	}
+	fmt.Printf(`Transaction Sets:   %v
+Transactions:       %v
+Size:               %v
+Local Transactions: %v
+Restored Sets:      %v (%v dropped)
+Rebroadcasts:       %v (last: %v)
+Estimated Fee:      %v - %v
+`, tg.TransactionSets, tg.Transactions, filesizeUnits(int64(tg.Size)), tg.LocalTransactions,
+		tg.RestoredSets, tg.DroppedSets, tg.Rebroadcasts, lastRebroadcast,
+		feeRateUnits(fee.Minimum), feeRateUnits(fee.Maximum))
+}
+
+// tpoolhistogramcmd is the handler for the command `siac tpool histogram`.
+// Prints the fee-rate histogram of the transaction pool.
+func tpoolhistogramcmd() {
+	thg, err := httpClient.TransactionPoolHistogramGet()
+	if err != nil {
+		die("Could not get fee-rate histogram:", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Fee Rate\tSets\tTransactions\tSize")
+	for _, b := range thg.Buckets {
+		feeRange := ">= " + feeRateUnits(b.MinFeeRate)
+		if !b.MaxFeeRate.IsZero() {
+			feeRange = "< " + feeRateUnits(b.MaxFeeRate)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", feeRange, b.Sets, b.Transactions, filesizeUnits(int64(b.Size)))
+	}
+	w.Flush()
+}
+
+// tpoolsetcmd is the handler for the command `siac tpool set [id]`.
+// Prints an unconfirmed set and its components.
+func tpoolsetcmd(idStr string) {
+	var id crypto.Hash
+	if err := id.LoadString(idStr); err != nil {
+		die("Could not parse set id:", err)
+	}
+	tsg, err := httpClient.TransactionPoolSetGet(modules.TransactionSetID(id))
+	if err != nil {
+		die("Could not get unconfirmed set:", err)
+	}
+	fmt.Printf(`Set:      %v
+Size:     %v
+Fees:     %v
+Fee Rate: %v
+Local:    %v
+`, tsg.ID, filesizeUnits(int64(tsg.Size)), currencyUnits(tsg.Fees), feeRateUnits(tsg.FeeRate), yesNo(tsg.Local))
+	fmt.Printf("\n%v independent components:\n", len(tsg.Components))
+	for i, component := range tsg.Components {
+		fmt.Printf("  Component %v:\n", i+1)
+		for _, txid := range component {
+			fmt.Println("    ", txid)
+		}
+	}
+}
+
+// tpoolsetscmd is the handler for the command `siac tpool sets`.
+// Lists the unconfirmed sets of the transaction pool.
+func tpoolsetscmd() {
+	tsg, err := httpClient.TransactionPoolSetsGet()
+	if err != nil {
+		die("Could not get unconfirmed sets:", err)
+	}
+	if len(tsg.Sets) == 0 {
+		fmt.Println("No unconfirmed sets.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTransactions\tComponents\tSize\tFee Rate\tLocal")
+	for _, set := range tsg.Sets {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", set.ID, len(set.TransactionIDs), len(set.Components),
+			filesizeUnits(int64(set.Size)), feeRateUnits(set.FeeRate), yesNo(set.Local))
+	}
+	w.Flush()
+}
+
+// tpooltransactionscmd is the handler for the command `siac tpool
+// transactions`. Lists the unconfirmed transactions of the transaction pool.
+func tpooltransactionscmd() {
+	var addr types.UnlockHash
+	if tpoolAddress != "" {
+		if err := addr.LoadString(tpoolAddress); err != nil {
+			die("Could not parse address:", err)
+		}
+	}
+	ttg, err := httpClient.TransactionPoolTransactionsGet(addr, tpoolOffset, tpoolLimit)
+	if err != nil {
+		die("Could not get unconfirmed transactions:", err)
+	}
+	if len(ttg.Transactions) == 0 {
+		fmt.Println("No unconfirmed transactions.")
+		return
+	}
+	fmt.Printf("Showing %v-%v of %v transactions:\n", tpoolOffset+1, tpoolOffset+len(ttg.Transactions), ttg.Total)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Transaction ID\tSet ID\tFee Rate")
+	for _, txn := range ttg.Transactions {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", txn.ID, txn.SetID, feeRateUnits(txn.FeeRate))
+	}
+	w.Flush()
+}
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"os"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,6 +17,41 @@ import (
 )
 
 var (
+	walletAccountsCmd = &cobra.Command{
+		Use:   "accounts",
+		Short: "List the wallet accounts",
+		Long:  "List the named accounts of the wallet and their balances.",
+		Run:   wrap(walletaccountscmd),
+	}
+
+	walletAccountsAddressCmd = &cobra.Command{
+		Use:   "address [name]",
+		Short: "Get a new address of an account",
+		Long:  "Generate a new address that belongs to the named account.",
+		Run:   wrap(walletaccountsaddresscmd),
+	}
+
+	walletAccountsCreateCmd = &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a new wallet account",
+		Long: `Create a new named account. The keys of the account are derived from the
+primary seed. Accounts must be created in the same order to recover their
+funds when the wallet is restored from its seed.
+
+The "host" and "renter" accounts are used by the host and renter modules
+to fund their contracts.`,
+		Run: wrap(walletaccountscreatecmd),
+	}
+
+	walletAccountsSendCmd = &cobra.Command{
+		Use:   "send [name] [amount] [dest]",
+		Short: "Send siacoins from an account to an address",
+		Long: `Send siacoins from the named account to an address. Only the outputs of the
+account are used to fund the transaction. 'amount' can be specified in units,
+e.g. 1.23KS. Run 'wallet --help' for a list of units.`,
+		Run: wrap(walletaccountssendcmd),
+	}
+
 	walletAddressCmd = &cobra.Command{
 		Use:   "address",
 		Short: "Get a new wallet address",
@@ -213,6 +249,71 @@ func walletaddresscmd() {
 	fmt.Printf("Created new address: %s\n", addr.Address)
 }
 
+// walletaccountscmd lists the accounts of the wallet.
+func walletaccountscmd() {
+	accounts, err := httpClient.WalletAccountsGet()
+	if err != nil {
+		die("Could not get wallet accounts:", err)
+	}
+	if len(accounts.Accounts) == 0 {
+		fmt.Println("No accounts.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tConfirmed Balance\tUnconfirmed Delta\tSiafunds")
+	for _, acct := range accounts.Accounts {
+		unconfirmedBalance := acct.ConfirmedSiacoinBalance.Add(acct.UnconfirmedIncomingSiacoins).Sub(acct.UnconfirmedOutgoingSiacoins)
+		var delta string
+		if unconfirmedBalance.Cmp(acct.ConfirmedSiacoinBalance) >= 0 {
+			delta = "+" + currencyUnits(unconfirmedBalance.Sub(acct.ConfirmedSiacoinBalance))
+		} else {
+			delta = "-" + currencyUnits(acct.ConfirmedSiacoinBalance.Sub(unconfirmedBalance))
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v SF\n", acct.Name, currencyUnits(acct.ConfirmedSiacoinBalance), delta, acct.SiafundBalance)
+	}
+	w.Flush()
+}
+
+// walletaccountsaddresscmd fetches a new address of an account.
+func walletaccountsaddresscmd(name string) {
+	addr, err := httpClient.WalletAccountAddressGet(name)
+	if err != nil {
+		die("Could not generate new address:", err)
+	}
+	fmt.Printf("Created new address for account %v: %s\n", name, addr.Address)
+}
+
+// walletaccountscreatecmd creates a new account.
+func walletaccountscreatecmd(name string) {
+	_, err := httpClient.WalletAccountsPost(name)
+	if err != nil {
+		die("Could not create account:", err)
+	}
+	fmt.Printf("Created account %v\n", name)
+}
+
+// walletaccountssendcmd sends siacoins from an account to a destination
+// address.
+func walletaccountssendcmd(name, amount, dest string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	var hash types.UnlockHash
+	if _, err := fmt.Sscan(dest, &hash); err != nil {
+		die("Failed to parse destination address", err)
+	}
+	_, err = httpClient.WalletAccountSiacoinsPost(name, value, hash)
+	if err != nil {
+		die("Could not send siacoins:", err)
+	}
+	fmt.Printf("Sent %s hastings from account %v to %s\n", hastings, name, dest)
+}
+
 // walletaddressescmd fetches the list of addresses that the wallet knows.
 func walletaddressescmd() {
 	addrs, err := httpClient.WalletAddressesGet()
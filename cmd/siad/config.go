@@ -0,0 +1,391 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
+	"github.com/acejam/Sia/types"
+)
+
+// defaultConfigFile is the name of the config file that is loaded from the
+// sia directory if no config file is specified with --config.
+const defaultConfigFile = "siad.toml"
+
+var (
+	// errConfigFlag is returned if the config file tries to set the path of
+	// the config file.
+	errConfigFlag = errors.New("the config file cannot set the config flag")
+)
+
+// configFile contains the settings of the siad config file. The [siad]
+// section sets the flags of siad that were not set on the command line; the
+// values are parsed like the values of the flags. The module sections set the
+// settings of the modules with the same keys as the query string parameters
+// of the corresponding API calls, e.g. POST /renter. The [loglevels] section
+// sets the log level of modules by their log file name.
+type configFile struct {
+	Siad      map[string]interface{} `toml:"siad"`
+	Gateway   map[string]interface{} `toml:"gateway"`
+	Host      map[string]interface{} `toml:"host"`
+	Renter    map[string]interface{} `toml:"renter"`
+	LogLevels map[string]string      `toml:"loglevels"`
+}
+
+// loadConfigFile reads the config file at path. If the file does not exist
+// and the path was not given explicitly, an empty config is returned.
+func loadConfigFile(path string, explicit bool) (configFile, error) {
+	var cf configFile
+	if _, err := os.Stat(path); os.IsNotExist(err) && !explicit {
+		return cf, nil
+	}
+	md, err := toml.DecodeFile(path, &cf)
+	if err != nil {
+		return configFile{}, fmt.Errorf("unable to read config file %v: %v", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return configFile{}, fmt.Errorf("config file %v contains unknown setting %v", path, undecoded[0])
+	}
+	if err := cf.validate(); err != nil {
+		return configFile{}, fmt.Errorf("invalid config file %v: %v", path, err)
+	}
+	return cf, nil
+}
+
+// configFilePath returns the path of the config file and whether it was set
+// explicitly with the --config flag.
+func configFilePath(configFile, siaDir string) (string, bool) {
+	if configFile != "" {
+		return configFile, true
+	}
+	return filepath.Join(siaDir, defaultConfigFile), false
+}
+
+// validate checks that the module sections and the log levels of the config
+// file can be parsed. The [siad] section is checked when it is applied to the
+// flags.
+func (cf configFile) validate() error {
+	var down, up int64
+	if err := scanSection("gateway", cf.Gateway, gatewaySettingsTargets(&down, &up)); err != nil {
+		return err
+	}
+	if err := scanSection("host", cf.Host, hostSettingsTargets(&modules.HostInternalSettings{})); err != nil {
+		return err
+	}
+	if err := scanSection("renter", cf.Renter, renterSettingsTargets(&modules.RenterSettings{})); err != nil {
+		return err
+	}
+	for _, module := range sortedKeys(cf.LogLevels) {
+		if _, err := persist.ParseLogLevel(cf.LogLevels[module]); err != nil {
+			return fmt.Errorf("invalid value %q for loglevels.%v: %v", cf.LogLevels[module], module, err)
+		}
+	}
+	return nil
+}
+
+// applyFlags sets the flags of cmd that were not set on the command line to
+// the values of the [siad] section.
+func (cf configFile) applyFlags(cmd *cobra.Command) error {
+	for _, name := range sortedKeys(cf.Siad) {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("unknown setting siad.%v", name)
+		} else if name == "config" {
+			return errConfigFlag
+		} else if flag.Changed {
+			continue
+		}
+		value := fmt.Sprint(cf.Siad[name])
+		if err := cmd.Flags().Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q for siad.%v: %v", value, name, err)
+		}
+	}
+	return nil
+}
+
+// changedFlags returns the keys of the [siad] section that differ between cf
+// and old. Changes to the flags only take effect when siad is restarted.
+func (cf configFile) changedFlags(old configFile) []string {
+	var changed []string
+	for name, value := range cf.Siad {
+		if oldValue, ok := old.Siad[name]; !ok || fmt.Sprint(oldValue) != fmt.Sprint(value) {
+			changed = append(changed, "siad."+name)
+		}
+	}
+	for name := range old.Siad {
+		if _, ok := cf.Siad[name]; !ok {
+			changed = append(changed, "siad."+name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// gatewaySettingsTargets returns the values of the [gateway] section keyed by
+// their setting.
+func gatewaySettingsTargets(down, up *int64) map[string]interface{} {
+	return map[string]interface{}{
+		"maxdownloadspeed": down,
+		"maxuploadspeed":   up,
+	}
+}
+
+// hostSettingsTargets returns the values of the [host] section keyed by their
+// setting.
+func hostSettingsTargets(s *modules.HostInternalSettings) map[string]interface{} {
+	return map[string]interface{}{
+		"acceptingcontracts":        &s.AcceptingContracts,
+		"maxdownloadbatchsize":      &s.MaxDownloadBatchSize,
+		"maxduration":               &s.MaxDuration,
+		"maxrevisebatchsize":        &s.MaxReviseBatchSize,
+		"netaddress":                &s.NetAddress,
+		"windowsize":                &s.WindowSize,
+		"collateral":                &s.Collateral,
+		"collateralbudget":          &s.CollateralBudget,
+		"maxcollateral":             &s.MaxCollateral,
+		"mincontractprice":          &s.MinContractPrice,
+		"mindownloadbandwidthprice": &s.MinDownloadBandwidthPrice,
+		"minstorageprice":           &s.MinStoragePrice,
+		"minuploadbandwidthprice":   &s.MinUploadBandwidthPrice,
+	}
+}
+
+// renterSettingsTargets returns the values of the [renter] section keyed by
+// their setting.
+func renterSettingsTargets(s *modules.RenterSettings) map[string]interface{} {
+	return map[string]interface{}{
+		"funds":            &s.Allowance.Funds,
+		"hosts":            &s.Allowance.Hosts,
+		"period":           &s.Allowance.Period,
+		"renewwindow":      &s.Allowance.RenewWindow,
+		"maxdownloadspeed": &s.MaxDownloadSpeed,
+		"maxuploadspeed":   &s.MaxUploadSpeed,
+		"streamcachesize":  &s.StreamCacheSize,
+	}
+}
+
+// scanSection parses the values of a section of the config file into the
+// targets with the same keys.
+func scanSection(name string, section map[string]interface{}, targets map[string]interface{}) error {
+	for _, key := range sortedKeys(section) {
+		target, ok := targets[key]
+		if !ok {
+			return fmt.Errorf("unknown setting %v.%v", name, key)
+		}
+		value := fmt.Sprint(section[key])
+		if err := parseSetting(value, target); err != nil {
+			return fmt.Errorf("invalid value %q for %v.%v: %v", value, name, key, err)
+		}
+	}
+	return nil
+}
+
+// parseSetting parses value into target. The whole value must be a valid
+// value of the type of target.
+func parseSetting(value string, target interface{}) error {
+	switch t := target.(type) {
+	case *types.Currency:
+		i, ok := new(big.Int).SetString(value, 10)
+		if !ok || i.Sign() < 0 {
+			return errors.New("expected an amount of hastings")
+		}
+		*t = types.NewCurrency(i)
+		return nil
+	case *modules.NetAddress:
+		if value != "" {
+			if err := modules.NetAddress(value).IsValid(); err != nil {
+				return err
+			}
+		}
+		*t = modules.NetAddress(value)
+		return nil
+	}
+
+	v := reflect.ValueOf(target).Elem()
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("expected true or false")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("expected an integer")
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("expected a non-negative integer")
+		}
+		v.SetUint(u)
+	case reflect.String:
+		v.SetString(value)
+	default:
+		return fmt.Errorf("unsupported setting type %v", v.Type())
+	}
+	return nil
+}
+
+// formatSection returns the values of the targets as they would be written in
+// the config file.
+func formatSection(targets map[string]interface{}) map[string]string {
+	section := make(map[string]string, len(targets))
+	for key, target := range targets {
+		section[key] = fmt.Sprint(reflect.ValueOf(target).Elem().Interface())
+	}
+	return section
+}
+
+// sortedKeys returns the keys of a section in order, so that errors are
+// reported deterministically.
+func sortedKeys(section interface{}) []string {
+	var keys []string
+	for _, k := range reflect.ValueOf(section).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applyModuleConfig applies the module settings and the log levels of the
+// config file to the loaded modules. It returns the sections that were
+// applied. The [host] and [renter] sections are skipped while the persist
+// files of the modules are locked; they are applied once the modules are
+// unlocked.
+func (srv *Server) applyModuleConfig(cf configFile) ([]string, error) {
+	srv.mu.Lock()
+	g, h, r := srv.gateway, srv.host, srv.renter
+	hostPending, renterPending := srv.metadataModulePending("h"), srv.metadataModulePending("r")
+	srv.mu.Unlock()
+
+	var applied []string
+	if len(cf.Gateway) > 0 {
+		if g == nil {
+			return nil, errors.New("config file contains [gateway] settings, but the gateway is not loaded")
+		}
+		down, up := g.RateLimits()
+		if err := scanSection("gateway", cf.Gateway, gatewaySettingsTargets(&down, &up)); err != nil {
+			return nil, err
+		}
+		if err := g.SetRateLimits(down, up); err != nil {
+			return nil, fmt.Errorf("unable to apply [gateway] settings: %v", err)
+		}
+		applied = append(applied, "gateway")
+	}
+	if len(cf.Host) > 0 && !hostPending {
+		if h == nil {
+			return nil, errors.New("config file contains [host] settings, but the host is not loaded")
+		}
+		settings := h.InternalSettings()
+		if err := scanSection("host", cf.Host, hostSettingsTargets(&settings)); err != nil {
+			return nil, err
+		}
+		if err := h.SetInternalSettings(settings); err != nil {
+			return nil, fmt.Errorf("unable to apply [host] settings: %v", err)
+		}
+		applied = append(applied, "host")
+	}
+	if len(cf.Renter) > 0 && !renterPending {
+		if r == nil {
+			return nil, errors.New("config file contains [renter] settings, but the renter is not loaded")
+		}
+		settings := r.Settings()
+		if err := scanSection("renter", cf.Renter, renterSettingsTargets(&settings)); err != nil {
+			return nil, err
+		}
+		if err := r.SetSettings(settings); err != nil {
+			return nil, fmt.Errorf("unable to apply [renter] settings: %v", err)
+		}
+		applied = append(applied, "renter")
+	}
+	if len(cf.LogLevels) > 0 {
+		for _, module := range sortedKeys(cf.LogLevels) {
+			level, _ := persist.ParseLogLevel(cf.LogLevels[module])
+			if err := persist.SetLogLevel(module, level); err != nil {
+				return nil, fmt.Errorf("unable to apply loglevels.%v: %v", module, err)
+			}
+		}
+		applied = append(applied, "loglevels")
+	}
+	return applied, nil
+}
+
+// reloadConfig reads the config file again and applies the settings that can
+// be changed while siad is running. It returns the sections that were applied
+// and the flags that only take effect after a restart.
+func (srv *Server) reloadConfig() (applied, restartRequired []string, err error) {
+	srv.reloadMu.Lock()
+	defer srv.reloadMu.Unlock()
+
+	srv.mu.Lock()
+	old := srv.config.File
+	srv.mu.Unlock()
+
+	path, explicit := configFilePath(srv.config.Siad.ConfigFile, srv.config.Siad.SiaDir)
+	cf, err := loadConfigFile(path, explicit)
+	if err != nil {
+		return nil, nil, err
+	}
+	applied, err = srv.applyModuleConfig(cf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	srv.mu.Lock()
+	srv.config.File = cf
+	srv.mu.Unlock()
+	return applied, cf.changedFlags(old), nil
+}
+
+// effectiveConfig returns the flags of siad and the current settings of the
+// modules in the form of the config file.
+func (srv *Server) effectiveConfig() (flags, gateway, host, renter, logLevels map[string]string) {
+	srv.mu.Lock()
+	flags = make(map[string]string, len(srv.config.Flags))
+	for name, value := range srv.config.Flags {
+		flags[name] = value
+	}
+	g, h, r := srv.gateway, srv.host, srv.renter
+	srv.mu.Unlock()
+
+	if g != nil {
+		down, up := g.RateLimits()
+		gateway = formatSection(gatewaySettingsTargets(&down, &up))
+	}
+	if h != nil {
+		settings := h.InternalSettings()
+		host = formatSection(hostSettingsTargets(&settings))
+	}
+	if r != nil {
+		settings := r.Settings()
+		renter = formatSection(renterSettingsTargets(&settings))
+	}
+	logLevels = make(map[string]string)
+	for module, level := range persist.LogLevels() {
+		logLevels[module] = level.String()
+	}
+	return
+}
+
+// effectiveFlags returns the value of every flag of cmd.
+func effectiveFlags(cmd *cobra.Command) map[string]string {
+	flags := make(map[string]string)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	return flags
+}
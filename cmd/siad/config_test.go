@@ -0,0 +1,159 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+// writeConfigFile writes a config file to the test directory and returns its
+// path.
+func writeConfigFile(t *testing.T, contents string) string {
+	dir := build.TempDir("siad", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, defaultConfigFile)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadConfigFile checks that config files are parsed and that invalid
+// settings are reported with the name of the setting.
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `
+[siad]
+api-addr = "localhost:9990"
+no-bootstrap = true
+
+[host]
+acceptingcontracts = true
+minstorageprice = "1000"
+
+[renter]
+hosts = 30
+
+[loglevels]
+renter = "debug"
+`)
+	cf, err := loadConfigFile(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cf.Siad["api-addr"] != "localhost:9990" || cf.LogLevels["renter"] != "debug" {
+		t.Fatal("config file was not parsed:", cf)
+	}
+	var settings modules.HostInternalSettings
+	if err := scanSection("host", cf.Host, hostSettingsTargets(&settings)); err != nil {
+		t.Fatal(err)
+	}
+	if !settings.AcceptingContracts || !settings.MinStoragePrice.Equals(types.NewCurrency64(1000)) {
+		t.Fatal("host settings were not parsed:", settings)
+	}
+
+	// A missing config file is only an error if it was given explicitly.
+	missing := filepath.Join(filepath.Dir(path), "missing.toml")
+	if _, err := loadConfigFile(missing, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfigFile(missing, true); err == nil {
+		t.Fatal("expected an error for a missing config file")
+	}
+
+	tests := []struct {
+		contents string
+		err      string
+	}{
+		{"[foo]\nbar = 1", "unknown setting foo"},
+		{"[renter]\nfoo = 1", "unknown setting renter.foo"},
+		{"[renter]\nhosts = \"many\"", "invalid value \"many\" for renter.hosts"},
+		{"[host]\nacceptingcontracts = \"yes\"", "invalid value \"yes\" for host.acceptingcontracts"},
+		{"[renter]\nhosts = \"10abc\"", "invalid value \"10abc\" for renter.hosts"},
+		{"[renter]\nfunds = \"-5\"", "invalid value \"-5\" for renter.funds"},
+		{"[host]\nnetaddress = \"host.com :9982\"", "invalid value \"host.com :9982\" for host.netaddress"},
+		{"[loglevels]\nrenter = \"verbose\"", "invalid value \"verbose\" for loglevels.renter"},
+		{"[siad\n", "unable to read config file"},
+	}
+	for _, test := range tests {
+		if _, err := loadConfigFile(writeConfigFile(t, test.contents), true); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: expected error containing %q, got %v", test.contents, test.err, err)
+		}
+	}
+}
+
+// TestApplyFlags checks that the [siad] section only sets the flags that were
+// not set on the command line.
+func TestApplyFlags(t *testing.T) {
+	var config Config
+	cmd := &cobra.Command{Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().StringVarP(&config.Siad.APIaddr, "api-addr", "", "localhost:9980", "")
+	cmd.Flags().StringVarP(&config.Siad.RPCaddr, "rpc-addr", "", ":9981", "")
+	cmd.Flags().BoolVarP(&config.Siad.NoBootstrap, "no-bootstrap", "", false, "")
+	cmd.Flags().StringVarP(&config.Siad.ConfigFile, "config", "", "", "")
+	cmd.SetArgs([]string{"--rpc-addr", ":1234"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := []configFile{
+		{Siad: map[string]interface{}{"foo": 1}},
+		{Siad: map[string]interface{}{"config": "other.toml"}},
+		{Siad: map[string]interface{}{"no-bootstrap": "maybe"}},
+	}
+	for _, cf := range invalid {
+		if err := cf.applyFlags(cmd); err == nil {
+			t.Error("expected an error for", cf.Siad)
+		}
+	}
+
+	cf := configFile{Siad: map[string]interface{}{
+		"api-addr":     "localhost:9990",
+		"rpc-addr":     ":9991",
+		"no-bootstrap": true,
+	}}
+	if err := cf.applyFlags(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if config.Siad.APIaddr != "localhost:9990" || !config.Siad.NoBootstrap {
+		t.Error("config file was not applied:", config.Siad)
+	}
+	if config.Siad.RPCaddr != ":1234" {
+		t.Error("config file overwrote a command line flag:", config.Siad.RPCaddr)
+	}
+	if flags := effectiveFlags(cmd); flags["api-addr"] != "localhost:9990" || flags["rpc-addr"] != ":1234" {
+		t.Error("wrong effective flags:", flags)
+	}
+}
+
+// TestChangedFlags checks that reloads report the flags that changed.
+func TestChangedFlags(t *testing.T) {
+	old := configFile{Siad: map[string]interface{}{
+		"api-addr":     "localhost:9990",
+		"no-bootstrap": true,
+		"modules":      "gct",
+	}}
+	cf := configFile{Siad: map[string]interface{}{
+		"api-addr": "localhost:9990",
+		"modules":  "gctw",
+		"rpc-addr": ":9991",
+	}}
+	changed := cf.changedFlags(old)
+	expected := []string{"siad.modules", "siad.no-bootstrap", "siad.rpc-addr"}
+	if !reflect.DeepEqual(changed, expected) {
+		t.Fatalf("expected %v, got %v", expected, changed)
+	}
+	if changed := cf.changedFlags(cf); len(changed) != 0 {
+		t.Fatal("unchanged config has changes:", changed)
+	}
+}
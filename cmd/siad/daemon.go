@@ -13,6 +13,7 @@ import (
 	"github.com/acejam/Sia/build"
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/profile"
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
 
@@ -174,6 +175,13 @@ func startDaemon(config Config) (err error) {
 		os.Exit(1)
 	}()
 
+	// Apply the log rotation settings before any logger is opened.
+	persist.SetLogRotation(persist.LogRotation{
+		MaxSize:    config.Siad.LogMaxSize,
+		MaxAge:     config.Siad.LogMaxAge,
+		MaxBackups: config.Siad.LogMaxBackups,
+	})
+
 	// Print a startup message.
 	fmt.Println("Loading...")
 	loadStart := time.Now()
@@ -194,18 +202,37 @@ func startDaemon(config Config) (err error) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, os.Kill, syscall.SIGTERM)
 
+	// listen for reload signals
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+
 	// Print a 'startup complete' message.
 	startupTime := time.Since(loadStart)
 	fmt.Println("Finished loading in", startupTime.Seconds(), "seconds")
 
 	// wait for Serve to return or for kill signal to be caught
 	err = func() error {
-		select {
-		case err := <-errChan:
-			return err
-		case <-sigChan:
-			fmt.Println("\rCaught stop signal, quitting...")
-			return srv.Close()
+		for {
+			select {
+			case err := <-errChan:
+				return err
+			case <-sigChan:
+				fmt.Println("\rCaught stop signal, quitting...")
+				return srv.Close()
+			case <-hupChan:
+				fmt.Println("Caught reload signal, reloading the config file...")
+				applied, restartRequired, err := srv.reloadConfig()
+				if err != nil {
+					fmt.Println("Reload failed:", err)
+					continue
+				}
+				if len(applied) > 0 {
+					fmt.Println("Applied settings:", strings.Join(applied, ", "))
+				}
+				if len(restartRequired) > 0 {
+					fmt.Println("Restart siad to apply:", strings.Join(restartRequired, ", "))
+				}
+			}
 		}
 	}()
 	if err != nil {
@@ -217,6 +244,19 @@ func startDaemon(config Config) (err error) {
 
 // startDaemonCmd is a passthrough function for startDaemon.
 func startDaemonCmd(cmd *cobra.Command, _ []string) {
+	// Apply the config file to the flags that were not set on the command
+	// line.
+	path, explicit := configFilePath(globalConfig.Siad.ConfigFile, globalConfig.Siad.SiaDir)
+	cf, err := loadConfigFile(path, explicit)
+	if err != nil {
+		die(err)
+	}
+	if err := cf.applyFlags(cmd); err != nil {
+		die(fmt.Sprintf("invalid config file %v: %v", path, err))
+	}
+	globalConfig.File = cf
+	globalConfig.Flags = effectiveFlags(cmd)
+
 	var profileCPU, profileMem, profileTrace bool
 
 	profileCPU = strings.Contains(globalConfig.Siad.Profile, "c")
@@ -233,7 +273,7 @@ func startDaemonCmd(cmd *cobra.Command, _ []string) {
 	}
 
 	// Start siad. startDaemon will only return when it is shutting down.
-	err := startDaemon(globalConfig)
+	err = startDaemon(globalConfig)
 	if err != nil {
 		die(err)
 	}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/node/api"
+	"github.com/acejam/Sia/persist"
+)
+
+var (
+	// errMetadataEncrypted is returned when encrypting persist files that
+	// are encrypted already.
+	errMetadataEncrypted = errors.New("the persist files are encrypted already")
+
+	// errMetadataLocked is returned by the routes of the host and the renter
+	// while their persist files are locked.
+	errMetadataLocked = errors.New("the persist files of the host and the renter are encrypted; unlock them with /daemon/unlock or /wallet/unlock")
+
+	// errMetadataNotEncrypted is returned when unlocking persist files that
+	// are not encrypted.
+	errMetadataNotEncrypted = errors.New("the persist files are not encrypted")
+
+	// errMetadataUnlocked is returned when unlocking persist files that are
+	// unlocked already.
+	errMetadataUnlocked = errors.New("the persist files are unlocked already")
+
+	// lockedRoutePrefixes are the path prefixes of the API routes that are
+	// unavailable while the persist files are locked.
+	lockedRoutePrefixes = []string{"/host", "/renter"}
+)
+
+// statusWriter is a http.ResponseWriter that records the status of the
+// response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status and writes it to the underlying
+// ResponseWriter.
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// isLockedRoute returns true if the API route at path is unavailable while
+// the persist files are locked. The routes of the hostdb are included, since
+// the hostdb is loaded by the renter.
+func isLockedRoute(path string) bool {
+	for _, prefix := range lockedRoutePrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// metadataLocked returns true if the persist files of the host and the renter
+// are encrypted and have not been unlocked yet. The caller must hold srv.mu.
+func (srv *Server) metadataLocked() bool {
+	return srv.metadataKeyFile != nil && !srv.metadataUnlocked
+}
+
+// metadataModulePending returns true if the module with the given flag, 'h'
+// or 'r', is part of the server's config but is not loaded because the
+// persist files are locked. The caller must hold srv.mu.
+func (srv *Server) metadataModulePending(flag string) bool {
+	return srv.metadataLocked() && strings.Contains(srv.config.Siad.Modules, flag)
+}
+
+// removeMetadataModules removes the host and the renter from the loaded
+// modules and returns their closers, in the order they were loaded. The
+// caller must hold srv.mu.
+func (srv *Server) removeMetadataModules() []moduleCloser {
+	var removed, kept []moduleCloser
+	for _, m := range srv.moduleClosers {
+		if m.name == "host" || m.name == "renter" {
+			removed = append(removed, m)
+		} else {
+			kept = append(kept, m)
+		}
+	}
+	srv.moduleClosers = kept
+	srv.host, srv.renter = nil, nil
+	return removed
+}
+
+// loadEncryptedModules loads the host and the renter with their persist files
+// encrypted with key, and serves their API routes. The caller must hold
+// srv.unlockMu.
+func (srv *Server) loadEncryptedModules(key crypto.TwofishKey) error {
+	srv.mu.Lock()
+	loaded := len(srv.moduleClosers)
+	srv.mu.Unlock()
+	if err := srv.loadMetadataModules(loaded, &key); err != nil {
+		// Close the module that was loaded before the error, so that the
+		// persist files can be unlocked again.
+		srv.mu.Lock()
+		removed := srv.removeMetadataModules()
+		srv.mu.Unlock()
+		for i := len(removed) - 1; i >= 0; i-- {
+			removed[i].Close()
+		}
+		return err
+	}
+
+	srv.mu.Lock()
+	srv.metadataUnlocked = true
+	srv.api = srv.newAPI()
+	cf := srv.config.File
+	srv.mu.Unlock()
+	_, err := srv.applyModuleConfig(cf)
+	return err
+}
+
+// unlockMetadata unlocks the encrypted persist files of the host and the
+// renter with passphrase and loads the modules.
+func (srv *Server) unlockMetadata(passphrase string) error {
+	srv.unlockMu.Lock()
+	defer srv.unlockMu.Unlock()
+
+	srv.mu.Lock()
+	kf, unlocked := srv.metadataKeyFile, srv.metadataUnlocked
+	srv.mu.Unlock()
+	if kf == nil {
+		return errMetadataNotEncrypted
+	} else if unlocked {
+		return errMetadataUnlocked
+	}
+	key, err := kf.Key(passphrase)
+	if err != nil {
+		return err
+	}
+	return srv.loadEncryptedModules(key)
+}
+
+// encryptMetadata encrypts the plaintext persist files of the host and the
+// renter with a key derived from passphrase. The modules are closed and
+// loaded again, which encrypts their persist files.
+func (srv *Server) encryptMetadata(passphrase string) error {
+	srv.unlockMu.Lock()
+	defer srv.unlockMu.Unlock()
+
+	srv.mu.Lock()
+	encrypted := srv.metadataKeyFile != nil
+	srv.mu.Unlock()
+	if encrypted {
+		return errMetadataEncrypted
+	}
+	key, err := persist.CreateKeyFile(srv.config.Siad.SiaDir, passphrase)
+	if err != nil {
+		return err
+	}
+	kf, err := persist.LoadKeyFile(srv.config.Siad.SiaDir)
+	if err != nil {
+		return err
+	}
+
+	// The persist files are locked until the modules are loaded again.
+	srv.mu.Lock()
+	srv.metadataKeyFile = &kf
+	removed := srv.removeMetadataModules()
+	srv.api = srv.newAPI()
+	srv.mu.Unlock()
+	for i := len(removed) - 1; i >= 0; i-- {
+		fmt.Printf("Closing %v...\n", removed[i].name)
+		if err := removed[i].Close(); err != nil {
+			return fmt.Errorf("unable to close the %v: %v", removed[i].name, err)
+		}
+	}
+	return srv.loadEncryptedModules(key)
+}
+
+// managedUnlockMetadataWithWallet tries to unlock the persist files with the
+// password of the wallet. A different passphrase is not an error; the
+// persist files can still be unlocked with /daemon/unlock.
+func (srv *Server) managedUnlockMetadataWithWallet(password string) {
+	err := srv.unlockMetadata(password)
+	if err == persist.ErrBadPassphrase {
+		fmt.Println("The wallet password does not unlock the persist files of the host and the renter.")
+	} else if err != nil && err != errMetadataUnlocked {
+		fmt.Println("Unable to unlock the persist files of the host and the renter:", err)
+	}
+}
+
+// serveWalletUnlock serves /wallet/unlock while the persist files are locked.
+// Once the wallet is unlocked, the persist files are unlocked with the same
+// password in the background, since loading the renter can take a while.
+func (srv *Server) serveWalletUnlock(a http.Handler, w http.ResponseWriter, req *http.Request) {
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+	a.ServeHTTP(sw, req)
+	if sw.status < http.StatusMultipleChoices {
+		go srv.managedUnlockMetadataWithWallet(req.FormValue("encryptionpassword"))
+	}
+}
+
+// daemonEncryptionHandlerGET handles the API call that reports whether the
+// persist files are encrypted and unlocked.
+func (srv *Server) daemonEncryptionHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	api.WriteJSON(w, api.DaemonEncryptionGET{
+		Encrypted: srv.metadataKeyFile != nil,
+		Unlocked:  srv.metadataKeyFile == nil || srv.metadataUnlocked,
+	})
+}
+
+// daemonEncryptionHandlerPOST handles the API call that encrypts the
+// plaintext persist files of the host and the renter.
+func (srv *Server) daemonEncryptionHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	srv.mu.Lock()
+	loaded := srv.api != nil
+	srv.mu.Unlock()
+	if !loaded {
+		api.WriteError(w, api.Error{Message: "siad is not ready. please wait for siad to finish loading.", Code: api.ErrCodeUnavailable}, http.StatusServiceUnavailable)
+		return
+	}
+	passphrase := req.FormValue("passphrase")
+	if passphrase == "" {
+		api.WriteError(w, api.Error{Message: "a passphrase must be provided", Code: api.ErrCodeMissingParameter, Param: "passphrase"}, http.StatusBadRequest)
+		return
+	}
+	if err := srv.encryptMetadata(passphrase); err == errMetadataEncrypted {
+		api.WriteError(w, api.Error{Message: err.Error(), Code: api.ErrCodeInvalidParameter}, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		api.WriteError(w, api.Error{Message: "failed to encrypt the persist files: " + err.Error(), Code: api.ErrCodeModuleFailure}, http.StatusInternalServerError)
+		return
+	}
+	api.WriteSuccess(w)
+}
+
+// daemonUnlockHandlerPOST handles the API call that unlocks the encrypted
+// persist files of the host and the renter and loads the modules.
+func (srv *Server) daemonUnlockHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	srv.mu.Lock()
+	loaded := srv.api != nil
+	srv.mu.Unlock()
+	if !loaded {
+		api.WriteError(w, api.Error{Message: "siad is not ready. please wait for siad to finish loading.", Code: api.ErrCodeUnavailable}, http.StatusServiceUnavailable)
+		return
+	}
+	switch err := srv.unlockMetadata(req.FormValue("passphrase")); err {
+	case nil:
+		api.WriteSuccess(w)
+	case persist.ErrBadPassphrase:
+		api.WriteError(w, api.Error{Message: err.Error(), Code: api.ErrCodeInvalidParameter, Param: "passphrase"}, http.StatusBadRequest)
+	case errMetadataNotEncrypted, errMetadataUnlocked:
+		api.WriteError(w, api.Error{Message: err.Error(), Code: api.ErrCodeInvalidParameter}, http.StatusBadRequest)
+	default:
+		api.WriteError(w, api.Error{Message: "failed to load the host and the renter: " + err.Error(), Code: api.ErrCodeModuleFailure}, http.StatusInternalServerError)
+	}
+}
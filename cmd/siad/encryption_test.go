@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/node/api/client"
+)
+
+// TestIsLockedRoute checks which API routes are unavailable while the persist
+// files are locked.
+func TestIsLockedRoute(t *testing.T) {
+	tests := []struct {
+		path   string
+		locked bool
+	}{
+		{"/host", true},
+		{"/host/storage", true},
+		{"/hostdb/active", true},
+		{"/renter/files", true},
+		{"/wallet/unlock", false},
+		{"/consensus", false},
+		{"/daemon/unlock", false},
+	}
+	for _, test := range tests {
+		if locked := isLockedRoute(test.path); locked != test.locked {
+			t.Errorf("expected %v to be locked: %v, got %v", test.path, test.locked, locked)
+		}
+	}
+}
+
+// TestDaemonEncryption checks that the persist files of the host can be
+// encrypted, and that the host is only loaded after they are unlocked when
+// siad is restarted.
+func TestDaemonEncryption(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	config := Config{}
+	config.Siad.APIaddr = "localhost:0"
+	config.Siad.HostAddr = "localhost:0"
+	config.Siad.Modules = "cgtwh"
+	config.Siad.NoBootstrap = true
+	config.Siad.SiaDir = build.TempDir(t.Name())
+	defer os.RemoveAll(config.Siad.SiaDir)
+
+	// startServer creates a server and loads its modules.
+	startServer := func() (*Server, *client.Client) {
+		srv, err := NewServer(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go srv.Serve()
+		if err := srv.loadModules(); err != nil {
+			srv.Close()
+			t.Fatal(err)
+		}
+		return srv, client.New(srv.listener.Addr().String())
+	}
+	srv, c := startServer()
+
+	// The persist files are not encrypted by default.
+	deg, err := c.DaemonEncryptionGet()
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	} else if deg.Encrypted || !deg.Unlocked {
+		srv.Close()
+		t.Fatal("unexpected encryption state:", deg)
+	}
+	if err := c.DaemonUnlockPost("foo"); err == nil {
+		srv.Close()
+		t.Fatal("expected an error when unlocking plaintext persist files")
+	}
+	if err := c.DaemonEncryptionPost(""); err == nil {
+		srv.Close()
+		t.Fatal("expected an error for an empty passphrase")
+	}
+
+	// Encrypt the persist files. The host is loaded again right away.
+	if err := c.DaemonEncryptionPost("foo"); err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	if err := c.DaemonEncryptionPost("foo"); err == nil {
+		srv.Close()
+		t.Fatal("expected an error when encrypting twice")
+	}
+	if _, err := c.HostGet(); err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	if err := srv.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// After a restart, the host is not loaded until the persist files are
+	// unlocked.
+	srv, c = startServer()
+	defer srv.Close()
+	if deg, err := c.DaemonEncryptionGet(); err != nil {
+		t.Fatal(err)
+	} else if !deg.Encrypted || deg.Unlocked {
+		t.Fatal("unexpected encryption state:", deg)
+	}
+	if _, err := c.HostGet(); err == nil {
+		t.Fatal("expected an error while the persist files are locked")
+	}
+	if dhg, err := c.DaemonHealthGet(); err != nil {
+		t.Fatal(err)
+	} else if dhg.Status != "locked" {
+		t.Fatal("expected siad to be locked, got", dhg.Status)
+	}
+	if drg, err := c.DaemonReadyGet("host"); err != nil {
+		t.Fatal(err)
+	} else if drg.Modules["host"].Status != "locked" {
+		t.Fatal("expected the host to be locked, got", drg.Modules["host"])
+	}
+
+	if err := c.DaemonUnlockPost("bar"); err == nil {
+		t.Fatal("expected an error for the wrong passphrase")
+	}
+	if err := c.DaemonUnlockPost("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.HostGet(); err != nil {
+		t.Fatal(err)
+	}
+	if dhg, err := c.DaemonHealthGet(); err != nil {
+		t.Fatal(err)
+	} else if dhg.Status != "ok" {
+		t.Fatal("expected siad to be ok, got", dhg.Status)
+	}
+}
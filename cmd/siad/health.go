@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/node/api"
+)
+
+// readinessModules are the names of the modules whose readiness is checked by
+// /daemon/ready, in the order they are checked.
+var readinessModules = []string{"consensus", "wallet", "hostdb", "host", "renter"}
+
+// consensusReadiness returns the readiness of the consensus set. The consensus
+// set is ready once it is synced.
+func consensusReadiness(cs modules.ConsensusSet) api.ModuleReadiness {
+	if !cs.Synced() {
+		return api.ModuleReadiness{Status: fmt.Sprintf("syncing, at height %v", cs.Height())}
+	}
+	return api.ModuleReadiness{Ready: true, Status: "synced"}
+}
+
+// walletReadiness returns the readiness of the wallet. The wallet is ready
+// once it is unlocked.
+func walletReadiness(w modules.Wallet) api.ModuleReadiness {
+	unlocked, err := w.Unlocked()
+	if err != nil {
+		return api.ModuleReadiness{Status: "unable to check wallet: " + err.Error()}
+	} else if !unlocked {
+		return api.ModuleReadiness{Status: "locked"}
+	}
+	return api.ModuleReadiness{Ready: true, Status: "unlocked"}
+}
+
+// hostdbReadiness returns the readiness of the hostdb of the renter. The
+// hostdb is ready once its initial scan is complete.
+func hostdbReadiness(r modules.Renter) api.ModuleReadiness {
+	complete, err := r.InitialScanComplete()
+	if err != nil {
+		return api.ModuleReadiness{Status: "unable to check hostdb: " + err.Error()}
+	} else if !complete {
+		return api.ModuleReadiness{Status: "initial scan in progress"}
+	}
+	return api.ModuleReadiness{Ready: true, Status: "initial scan complete"}
+}
+
+// hostReadiness returns the readiness of a host with the given connectability
+// status. The host is ready once it is connectable at its net address.
+func hostReadiness(status modules.HostConnectabilityStatus) api.ModuleReadiness {
+	return api.ModuleReadiness{
+		Ready:  status == modules.HostConnectabilityStatusConnectable,
+		Status: string(status),
+	}
+}
+
+// renterReadiness returns the readiness of a renter with the given allowance
+// and contracts. The renter is ready once at least half of the hosts of its
+// allowance have contracts that are good for upload. Requiring every host
+// would make the renter flap between ready and not ready while the contractor
+// replaces hosts.
+func renterReadiness(allowance modules.Allowance, contracts []modules.RenterContract) api.ModuleReadiness {
+	if allowance.Funds.IsZero() || allowance.Hosts == 0 {
+		return api.ModuleReadiness{Status: "no allowance"}
+	}
+	var goodForUpload uint64
+	for _, c := range contracts {
+		if c.Utility.GoodForUpload {
+			goodForUpload++
+		}
+	}
+	required := (allowance.Hosts + 1) / 2
+	return api.ModuleReadiness{
+		Ready:  goodForUpload >= required,
+		Status: fmt.Sprintf("%v of %v contracts good for upload, %v required", goodForUpload, allowance.Hosts, required),
+	}
+}
+
+// moduleReadiness returns the readiness of the module with the given name.
+// Modules that are not loaded are not ready.
+func (srv *Server) moduleReadiness(name string) api.ModuleReadiness {
+	srv.mu.Lock()
+	loaded := srv.api != nil
+	cs, w, h, r := srv.consensus, srv.wallet, srv.host, srv.renter
+	hostLocked, renterLocked := srv.metadataModulePending("h"), srv.metadataModulePending("r")
+	srv.mu.Unlock()
+
+	notLoaded := api.ModuleReadiness{Status: "not loaded"}
+	if !loaded {
+		return api.ModuleReadiness{Status: "loading"}
+	}
+	if (name == "host" && hostLocked) || ((name == "hostdb" || name == "renter") && renterLocked) {
+		return api.ModuleReadiness{Status: "locked"}
+	}
+	switch name {
+	case "consensus":
+		if cs == nil {
+			return notLoaded
+		}
+		return consensusReadiness(cs)
+	case "wallet":
+		if w == nil {
+			return notLoaded
+		}
+		return walletReadiness(w)
+	case "hostdb":
+		if r == nil {
+			return notLoaded
+		}
+		return hostdbReadiness(r)
+	case "host":
+		if h == nil {
+			return notLoaded
+		}
+		return hostReadiness(h.ConnectabilityStatus())
+	case "renter":
+		if r == nil {
+			return notLoaded
+		}
+		return renterReadiness(r.Settings().Allowance, r.Contracts())
+	}
+	return notLoaded
+}
+
+// loadedReadinessModules returns the names of the checkable modules that are
+// loaded, or that will be loaded once their persist files are unlocked.
+func (srv *Server) loadedReadinessModules() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	hostLoaded := srv.host != nil || srv.metadataModulePending("h")
+	renterLoaded := srv.renter != nil || srv.metadataModulePending("r")
+	loaded := map[string]bool{
+		"consensus": srv.consensus != nil,
+		"wallet":    srv.wallet != nil,
+		"hostdb":    renterLoaded,
+		"host":      hostLoaded,
+		"renter":    renterLoaded,
+	}
+	var names []string
+	for _, name := range readinessModules {
+		if loaded[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseReadinessModules parses the comma-separated modules parameter of
+// /daemon/ready.
+func parseReadinessModules(param string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(param, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		known := false
+		for _, m := range readinessModules {
+			known = known || m == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown module %q, must be one of %v", name, strings.Join(readinessModules, ", "))
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// writeStatusJSON writes obj with the status 200 OK if ok is true and 503
+// Service Unavailable otherwise.
+func writeStatusJSON(w http.ResponseWriter, ok bool, obj interface{}) {
+	if !ok {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	api.WriteJSON(w, obj)
+}
+
+// daemonHealthHandler handles the API call that reports whether siad is
+// running and has loaded its modules. While the persist files of the host and
+// the renter are locked, the status is "locked"; siad itself is healthy.
+func (srv *Server) daemonHealthHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	srv.mu.Lock()
+	loaded := srv.api != nil
+	locked := srv.metadataLocked()
+	names := make([]string, 0, len(srv.moduleClosers))
+	if loaded {
+		for _, m := range srv.moduleClosers {
+			names = append(names, m.name)
+		}
+	}
+	srv.mu.Unlock()
+
+	status := "ok"
+	if !loaded {
+		status = "loading"
+	} else if locked {
+		status = "locked"
+	}
+	writeStatusJSON(w, loaded, api.DaemonHealthGET{
+		Status:  status,
+		Modules: names,
+	})
+}
+
+// daemonReadyHandler handles the API call that reports whether the modules
+// of siad are ready to be used. The status is 503 Service Unavailable unless
+// every checked module is ready.
+func (srv *Server) daemonReadyHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	names, err := parseReadinessModules(req.FormValue("modules"))
+	if err != nil {
+		api.WriteError(w, api.Error{Message: "unable to parse modules: " + err.Error(), Code: api.ErrCodeInvalidParameter, Param: "modules"}, http.StatusBadRequest)
+		return
+	}
+	if len(names) == 0 {
+		names = srv.loadedReadinessModules()
+	}
+
+	srv.mu.Lock()
+	ready := srv.api != nil
+	srv.mu.Unlock()
+	readiness := make(map[string]api.ModuleReadiness, len(names))
+	for _, name := range names {
+		readiness[name] = srv.moduleReadiness(name)
+		ready = ready && readiness[name].Ready
+	}
+	writeStatusJSON(w, ready, api.DaemonReadyGET{
+		Ready:   ready,
+		Modules: readiness,
+	})
+}
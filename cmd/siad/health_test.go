@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/node/api/client"
+	"github.com/acejam/Sia/types"
+)
+
+// TestRenterReadiness checks that the renter is ready once half of the hosts
+// of its allowance have contracts that are good for upload.
+func TestRenterReadiness(t *testing.T) {
+	allowance := modules.Allowance{Funds: types.SiacoinPrecision, Hosts: 5}
+	contracts := func(good, bad int) []modules.RenterContract {
+		var cs []modules.RenterContract
+		for i := 0; i < good+bad; i++ {
+			cs = append(cs, modules.RenterContract{Utility: modules.ContractUtility{GoodForUpload: i < good}})
+		}
+		return cs
+	}
+
+	tests := []struct {
+		allowance modules.Allowance
+		contracts []modules.RenterContract
+		ready     bool
+	}{
+		{modules.Allowance{}, nil, false},
+		{allowance, nil, false},
+		{allowance, contracts(2, 3), false},
+		{allowance, contracts(3, 0), true},
+		{allowance, contracts(5, 0), true},
+	}
+	for _, test := range tests {
+		if r := renterReadiness(test.allowance, test.contracts); r.Ready != test.ready {
+			t.Errorf("expected ready to be %v for %v contracts, got %v (%v)", test.ready, len(test.contracts), r.Ready, r.Status)
+		}
+	}
+
+	if r := hostReadiness(modules.HostConnectabilityStatusChecking); r.Ready {
+		t.Error("host is ready while checking connectability")
+	}
+	if r := hostReadiness(modules.HostConnectabilityStatusConnectable); !r.Ready {
+		t.Error("connectable host is not ready")
+	}
+}
+
+// TestParseReadinessModules checks the parsing of the modules parameter of
+// /daemon/ready.
+func TestParseReadinessModules(t *testing.T) {
+	names, err := parseReadinessModules("consensus, wallet,,renter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"consensus", "wallet", "renter"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+	if names, err := parseReadinessModules(""); err != nil || len(names) != 0 {
+		t.Fatal("expected no modules, got", names, err)
+	}
+	if _, err := parseReadinessModules("consensus,miner"); err == nil {
+		t.Fatal("expected an error for a module whose readiness is not checked")
+	}
+}
+
+// TestDaemonHealthReady checks the health and readiness of a server before
+// and after its modules are loaded, and the alerts of the loaded modules.
+func TestDaemonHealthReady(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	config := Config{}
+	config.Siad.APIaddr = "localhost:0"
+	config.Siad.Modules = "cg"
+	config.Siad.NoBootstrap = true
+	config.Siad.SiaDir = build.TempDir(t.Name())
+	defer os.RemoveAll(config.Siad.SiaDir)
+	srv, err := NewServer(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve()
+	defer srv.Close()
+	c := client.New(srv.listener.Addr().String())
+
+	// Before the modules are loaded, siad is neither healthy nor ready.
+	dhg, err := c.DaemonHealthGet()
+	if err != nil {
+		t.Fatal(err)
+	} else if dhg.Status != "loading" {
+		t.Fatal("expected siad to be loading, got", dhg.Status)
+	}
+	drg, err := c.DaemonReadyGet("consensus")
+	if err != nil {
+		t.Fatal(err)
+	} else if drg.Ready || drg.Modules["consensus"].Status != "loading" {
+		t.Fatal("siad is ready before loading its modules:", drg)
+	}
+
+	if err := srv.loadModules(); err != nil {
+		t.Fatal(err)
+	}
+	dhg, err = c.DaemonHealthGet()
+	if err != nil {
+		t.Fatal(err)
+	} else if dhg.Status != "ok" || !reflect.DeepEqual(dhg.Modules, []string{"gateway", "consensus"}) {
+		t.Fatal("unexpected health:", dhg)
+	}
+
+	// Only the loaded modules are checked by default.
+	drg, err = c.DaemonReadyGet()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(drg.Modules) != 1 || drg.Ready != drg.Modules["consensus"].Ready {
+		t.Fatal("unexpected readiness:", drg)
+	}
+	// Modules that are not loaded are never ready.
+	drg, err = c.DaemonReadyGet("consensus", "wallet")
+	if err != nil {
+		t.Fatal(err)
+	} else if drg.Ready || drg.Modules["wallet"].Status != "not loaded" {
+		t.Fatal("siad is ready without a wallet:", drg)
+	}
+	if _, err := c.DaemonReadyGet("miner"); err == nil {
+		t.Fatal("expected an error for an unknown module")
+	}
+
+	// Neither the gateway nor the consensus set raise alerts.
+	dag, err := c.DaemonAlertsGet()
+	if err != nil {
+		t.Fatal(err)
+	} else if dag.Alerts == nil || len(dag.Alerts) != 0 {
+		t.Fatal("expected an empty list of alerts, got", dag.Alerts)
+	}
+}
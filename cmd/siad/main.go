@@ -3,10 +3,12 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/persist"
 )
 
 var (
@@ -29,6 +31,11 @@ type Config struct {
 	// --authenticate-api flag is set.
 	APIPassword string
 
+	// File contains the settings of the config file, and Flags the effective
+	// value of every flag after the config file has been applied.
+	File  configFile
+	Flags map[string]string
+
 	// The Siad variables are referenced directly by cobra, and are set
 	// according to the flags.
 	Siad struct {
@@ -42,6 +49,17 @@ type Config struct {
 		RequiredUserAgent string
 		AuthenticateAPI   bool
 
+		ConsensusSnapshot         string
+		ConsensusSnapshotID       string
+		ConsensusSnapshotChecksum string
+		ConsensusPruneDepth       uint64
+
+		ConfigFile string
+
+		LogMaxSize    int64
+		LogMaxAge     time.Duration
+		LogMaxBackups int
+
 		Profile    string
 		ProfileDir string
 		SiaDir     string
@@ -162,15 +180,24 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.ProfileDir, "profile-directory", "", "profiles", "location of the profiling directory")
 	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", "localhost:9980", "which host:port the API server listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
+	root.Flags().StringVarP(&globalConfig.Siad.ConfigFile, "config", "", "", "location of the config file (default is siad.toml in the sia directory)")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
+	root.Flags().StringVarP(&globalConfig.Siad.ConsensusSnapshot, "consensus-snapshot", "", "", "bootstrap the consensus set of a new node from this snapshot file")
+	root.Flags().StringVarP(&globalConfig.Siad.ConsensusSnapshotID, "consensus-snapshot-id", "", "", "block id that the consensus snapshot must end in")
+	root.Flags().StringVarP(&globalConfig.Siad.ConsensusSnapshotChecksum, "consensus-snapshot-checksum", "", "", "expected checksum of the consensus snapshot")
+	root.Flags().Uint64VarP(&globalConfig.Siad.ConsensusPruneDepth, "consensus-prune-depth", "", 0, "only keep the most recent blocks of the consensus set in full, pruning older blocks (0 disables pruning)")
+	root.Flags().Int64VarP(&globalConfig.Siad.LogMaxSize, "log-max-size", "", persist.CurrentLogRotation().MaxSize, "size in bytes after which a log file is rotated (0 disables rotation by size)")
+	root.Flags().DurationVarP(&globalConfig.Siad.LogMaxAge, "log-max-age", "", persist.CurrentLogRotation().MaxAge, "age after which a log file is rotated and rotated files are removed (0 disables it)")
+	root.Flags().IntVarP(&globalConfig.Siad.LogMaxBackups, "log-max-backups", "", persist.CurrentLogRotation().MaxBackups, "number of rotated log files that are kept (0 keeps all)")
 	root.Flags().StringVarP(&globalConfig.Siad.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
 	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "cghrtw", "enabled modules, see 'siad modules' for more info")
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", false, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow siad to listen on a non-localhost address (DANGEROUS)")
 
-	// Parse cmdline flags, overwriting both the default values and the config
-	// file values.
+	// Parse cmdline flags, overwriting the default values. The values of the
+	// config file are applied by startDaemonCmd to the flags that were not
+	// set.
 	if err := root.Execute(); err != nil {
 		// Since no commands return errors (all commands set Command.Run instead of
 		// Command.RunE), Command.Execute() should only return an error on an
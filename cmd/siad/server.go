@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/modules/consensus"
 	"github.com/acejam/Sia/modules/explorer"
@@ -32,6 +33,7 @@ import (
 	"github.com/acejam/Sia/modules/transactionpool"
 	"github.com/acejam/Sia/modules/wallet"
 	"github.com/acejam/Sia/node/api"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 
 	"github.com/inconshreveable/go-update"
@@ -50,7 +52,31 @@ type (
 		config        Config
 		moduleClosers []moduleCloser
 		api           http.Handler
+		tokens        *api.TokenStore
 		mu            sync.Mutex
+
+		// The loaded modules. The API is created from them, and they are
+		// used by the config file and /daemon/ready.
+		consensus modules.ConsensusSet
+		explorer  modules.Explorer
+		gateway   modules.Gateway
+		host      modules.Host
+		miner     modules.Miner
+		renter    modules.Renter
+		tpool     modules.TransactionPool
+		wallet    modules.Wallet
+
+		// metadataKeyFile is the key file of the encrypted persist files of
+		// the host and the renter, or nil if they are not encrypted. Until
+		// metadataUnlocked is set, the host and the renter are not loaded.
+		metadataKeyFile  *persist.KeyFile
+		metadataUnlocked bool
+
+		// reloadMu serializes reloads of the config file.
+		reloadMu sync.Mutex
+
+		// unlockMu serializes unlocking and encrypting the persist files.
+		unlockMu sync.Mutex
 	}
 
 	// moduleCloser defines a struct that closes modules, defined by a name and
@@ -143,6 +169,28 @@ bwIDAQAB
 -----END PUBLIC KEY-----`
 )
 
+// daemonRoutes describes the /daemon routes served by siad for the OpenAPI
+// specification. The routes of the modules are described by the api package.
+var daemonRoutes = []api.APIRoute{
+	{Method: "GET", Path: "/daemon/constants", Summary: "Get the constants of the network.", Response: SiaConstants{}},
+	{Method: "GET", Path: "/daemon/version", Summary: "Get the version of siad.", Response: DaemonVersion{}},
+	{Method: "GET", Path: "/daemon/update", Summary: "Check for an update.", Response: UpdateInfo{}},
+	{Method: "POST", Path: "/daemon/update", Summary: "Update siad and siac to the latest release."},
+	{Method: "GET", Path: "/daemon/stop", Summary: "Stop siad."},
+	{Method: "GET", Path: "/daemon/tokens", Summary: "Get the API tokens.", Response: api.DaemonTokensGET{}},
+	{Method: "POST", Path: "/daemon/tokens", Summary: "Add or remove an API token.", Params: []string{"action", "name", "scopes"}, Response: api.DaemonTokensPOST{}},
+	{Method: "GET", Path: "/daemon/loglevel", Summary: "Get the log levels of the modules.", Response: api.DaemonLogLevelGET{}},
+	{Method: "POST", Path: "/daemon/loglevel", Summary: "Set the log level of a module.", Params: []string{"module", "level"}},
+	{Method: "GET", Path: "/daemon/config", Summary: "Get the effective configuration of siad.", Response: api.DaemonConfigGET{}},
+	{Method: "POST", Path: "/daemon/reload", Summary: "Reload the config file.", Response: api.DaemonReloadPOST{}},
+	{Method: "GET", Path: "/daemon/alerts", Summary: "Get the alerts of the modules.", Response: api.DaemonAlertsGET{}},
+	{Method: "GET", Path: "/daemon/encryption", Summary: "Check whether the persist files of the host and the renter are encrypted.", Response: api.DaemonEncryptionGET{}},
+	{Method: "POST", Path: "/daemon/encryption", Summary: "Encrypt the persist files of the host and the renter.", Params: []string{"passphrase"}},
+	{Method: "POST", Path: "/daemon/unlock", Summary: "Unlock the encrypted persist files of the host and the renter.", Params: []string{"passphrase"}},
+	{Method: "GET", Path: "/daemon/health", Summary: "Check whether siad has loaded its modules.", Response: api.DaemonHealthGET{}, ResponseStatuses: []int{http.StatusServiceUnavailable}},
+	{Method: "GET", Path: "/daemon/ready", Summary: "Check whether the modules are ready to be used.", Params: []string{"modules"}, Response: api.DaemonReadyGET{}, ResponseStatuses: []int{http.StatusServiceUnavailable}},
+}
+
 // version returns the version number of a non-LTS release. This assumes that
 // tag names will always be of the form "vX.Y.Z".
 func (r *githubRelease) version() string {
@@ -302,7 +350,7 @@ func updateToRelease(release githubRelease) error {
 func (srv *Server) daemonUpdateHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	release, err := fetchLatestRelease()
 	if err != nil {
-		api.WriteError(w, api.Error{Message: "Failed to fetch latest release: " + err.Error()}, http.StatusInternalServerError)
+		api.WriteError(w, api.Error{Message: "Failed to fetch latest release: " + err.Error(), Code: api.ErrCodeInternal}, http.StatusInternalServerError)
 		return
 	}
 	latestVersion := release.TagName[1:] // delete leading 'v'
@@ -319,15 +367,15 @@ func (srv *Server) daemonUpdateHandlerGET(w http.ResponseWriter, _ *http.Request
 func (srv *Server) daemonUpdateHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	release, err := fetchLatestRelease()
 	if err != nil {
-		api.WriteError(w, api.Error{Message: "Failed to fetch latest release: " + err.Error()}, http.StatusInternalServerError)
+		api.WriteError(w, api.Error{Message: "Failed to fetch latest release: " + err.Error(), Code: api.ErrCodeInternal}, http.StatusInternalServerError)
 		return
 	}
 	err = updateToRelease(release)
 	if err != nil {
 		if rerr := update.RollbackError(err); rerr != nil {
-			api.WriteError(w, api.Error{Message: "Serious error: Failed to rollback from bad update: " + rerr.Error()}, http.StatusInternalServerError)
+			api.WriteError(w, api.Error{Message: "Serious error: Failed to rollback from bad update: " + rerr.Error(), Code: api.ErrCodeInternal}, http.StatusInternalServerError)
 		} else {
-			api.WriteError(w, api.Error{Message: "Failed to apply update: " + err.Error()}, http.StatusInternalServerError)
+			api.WriteError(w, api.Error{Message: "Failed to apply update: " + err.Error(), Code: api.ErrCodeInternal}, http.StatusInternalServerError)
 		}
 		return
 	}
@@ -390,6 +438,132 @@ func (srv *Server) daemonStopHandler(w http.ResponseWriter, _ *http.Request, _ h
 	}
 }
 
+// daemonTokensHandlerGET handles the API call that lists the API tokens.
+func (srv *Server) daemonTokensHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	api.WriteJSON(w, api.DaemonTokensGET{
+		Tokens: srv.tokens.Tokens(),
+	})
+}
+
+// daemonTokensHandlerPOST handles the API call that adds or removes an API
+// token.
+func (srv *Server) daemonTokensHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	name := req.FormValue("name")
+	switch req.FormValue("action") {
+	case "add":
+		var scopes []api.APIScope
+		for _, s := range strings.Split(req.FormValue("scopes"), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				scopes = append(scopes, api.APIScope(s))
+			}
+		}
+		token, err := srv.tokens.AddToken(name, scopes)
+		if err != nil {
+			api.WriteError(w, api.Error{Message: "failed to add token: " + err.Error(), Code: api.ErrCodeInvalidParameter}, http.StatusBadRequest)
+			return
+		}
+		api.WriteJSON(w, api.DaemonTokensPOST{
+			Token: token,
+		})
+	case "remove":
+		if err := srv.tokens.RemoveToken(name); err != nil {
+			api.WriteError(w, api.Error{Message: "failed to remove token: " + err.Error(), Code: api.ErrCodeInvalidParameter, Param: "name"}, http.StatusBadRequest)
+			return
+		}
+		api.WriteSuccess(w)
+	default:
+		api.WriteError(w, api.Error{Message: "action must be 'add' or 'remove'", Code: api.ErrCodeInvalidParameter, Param: "action"}, http.StatusBadRequest)
+	}
+}
+
+// daemonLogLevelHandlerGET handles the API call that lists the log levels of
+// the modules.
+func (srv *Server) daemonLogLevelHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	api.WriteJSON(w, api.DaemonLogLevelGET{
+		Levels: persist.LogLevels(),
+	})
+}
+
+// daemonLogLevelHandlerPOST handles the API call that sets the log level of a
+// module.
+func (srv *Server) daemonLogLevelHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	level, err := persist.ParseLogLevel(req.FormValue("level"))
+	if err != nil {
+		api.WriteError(w, api.Error{Message: "unable to parse level: " + err.Error(), Code: api.ErrCodeInvalidParameter, Param: "level"}, http.StatusBadRequest)
+		return
+	}
+	if err := persist.SetLogLevel(req.FormValue("module"), level); err != nil {
+		api.WriteError(w, api.Error{Message: "failed to set log level: " + err.Error(), Code: api.ErrCodeInvalidParameter, Param: "module"}, http.StatusBadRequest)
+		return
+	}
+	api.WriteSuccess(w)
+}
+
+// daemonConfigHandlerGET handles the API call that returns the effective
+// configuration of siad.
+func (srv *Server) daemonConfigHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	path, _ := configFilePath(srv.config.Siad.ConfigFile, srv.config.Siad.SiaDir)
+	flags, gateway, host, renter, logLevels := srv.effectiveConfig()
+	api.WriteJSON(w, api.DaemonConfigGET{
+		File:      path,
+		Siad:      flags,
+		Gateway:   gateway,
+		Host:      host,
+		Renter:    renter,
+		LogLevels: logLevels,
+	})
+}
+
+// daemonReloadHandlerPOST handles the API call that reloads the config file.
+func (srv *Server) daemonReloadHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	srv.mu.Lock()
+	loaded := srv.api != nil
+	srv.mu.Unlock()
+	if !loaded {
+		api.WriteError(w, api.Error{Message: "siad is not ready. please wait for siad to finish loading.", Code: api.ErrCodeUnavailable}, http.StatusServiceUnavailable)
+		return
+	}
+	applied, restartRequired, err := srv.reloadConfig()
+	if err != nil {
+		api.WriteError(w, api.Error{Message: "failed to reload the config file: " + err.Error(), Code: api.ErrCodeInvalidParameter}, http.StatusBadRequest)
+		return
+	}
+	api.WriteJSON(w, api.DaemonReloadPOST{
+		Applied:         applied,
+		RestartRequired: restartRequired,
+	})
+}
+
+// daemonAlertsHandler handles the API call that returns the alerts of the
+// loaded modules.
+func (srv *Server) daemonAlertsHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	srv.mu.Lock()
+	var alerters []modules.Alerter
+	if srv.api != nil {
+		for _, m := range srv.moduleClosers {
+			if a, ok := m.Closer.(modules.Alerter); ok {
+				alerters = append(alerters, a)
+			}
+		}
+	}
+	srv.mu.Unlock()
+
+	alerts := []modules.Alert{}
+	for _, a := range alerters {
+		alerts = append(alerts, a.Alerts()...)
+	}
+	modules.SortAlerts(alerts)
+	api.WriteJSON(w, api.DaemonAlertsGET{
+		Alerts: alerts,
+	})
+}
+
+// daemonOpenAPIHandler handles the API call that returns the OpenAPI
+// specification of the API, including the /daemon routes.
+func (srv *Server) daemonOpenAPIHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	api.WriteJSON(w, api.OpenAPI(daemonRoutes...))
+}
+
 func (srv *Server) daemonHandler(password string) http.Handler {
 	router := httprouter.New()
 
@@ -397,7 +571,20 @@ func (srv *Server) daemonHandler(password string) http.Handler {
 	router.GET("/daemon/version", srv.daemonVersionHandler)
 	router.GET("/daemon/update", srv.daemonUpdateHandlerGET)
 	router.POST("/daemon/update", srv.daemonUpdateHandlerPOST)
-	router.GET("/daemon/stop", api.RequirePassword(srv.daemonStopHandler, password))
+	router.GET("/daemon/stop", api.RequireScope(srv.daemonStopHandler, password, srv.tokens, api.ScopeAdmin))
+	router.GET("/daemon/tokens", api.RequireScope(srv.daemonTokensHandlerGET, password, srv.tokens, api.ScopeAdmin))
+	router.POST("/daemon/tokens", api.RequireScope(srv.daemonTokensHandlerPOST, password, srv.tokens, api.ScopeAdmin))
+	router.GET("/daemon/loglevel", api.RequireScope(srv.daemonLogLevelHandlerGET, password, srv.tokens, api.ScopeReadOnly))
+	router.POST("/daemon/loglevel", api.RequireScope(srv.daemonLogLevelHandlerPOST, password, srv.tokens, api.ScopeAdmin))
+	router.GET("/daemon/openapi", srv.daemonOpenAPIHandler)
+	router.GET("/daemon/config", api.RequireScope(srv.daemonConfigHandlerGET, password, srv.tokens, api.ScopeReadOnly))
+	router.POST("/daemon/reload", api.RequireScope(srv.daemonReloadHandlerPOST, password, srv.tokens, api.ScopeAdmin))
+	router.GET("/daemon/alerts", api.RequireScope(srv.daemonAlertsHandler, password, srv.tokens, api.ScopeReadOnly))
+	router.GET("/daemon/encryption", api.RequireScope(srv.daemonEncryptionHandlerGET, password, srv.tokens, api.ScopeReadOnly))
+	router.POST("/daemon/encryption", api.RequireScope(srv.daemonEncryptionHandlerPOST, password, srv.tokens, api.ScopeAdmin))
+	router.POST("/daemon/unlock", api.RequireScope(srv.daemonUnlockHandlerPOST, password, srv.tokens, api.ScopeAdmin))
+	router.GET("/daemon/health", srv.daemonHealthHandler)
+	router.GET("/daemon/ready", srv.daemonReadyHandler)
 
 	return router
 }
@@ -406,13 +593,22 @@ func (srv *Server) daemonHandler(password string) http.Handler {
 // will return an error. Otherwise it will serve the api.
 func (srv *Server) apiHandler(w http.ResponseWriter, r *http.Request) {
 	srv.mu.Lock()
-	isReady := srv.api != nil
+	a := srv.api
+	locked := srv.metadataLocked()
 	srv.mu.Unlock()
-	if !isReady {
-		api.WriteError(w, api.Error{Message: "siad is not ready. please wait for siad to finish loading."}, http.StatusServiceUnavailable)
+	if a == nil {
+		api.WriteError(w, api.Error{Message: "siad is not ready. please wait for siad to finish loading.", Code: api.ErrCodeUnavailable}, http.StatusServiceUnavailable)
 		return
 	}
-	srv.api.ServeHTTP(w, r)
+	if locked && isLockedRoute(r.URL.Path) {
+		api.WriteError(w, api.Error{Message: errMetadataLocked.Error(), Code: api.ErrCodeUnavailable}, http.StatusServiceUnavailable)
+		return
+	}
+	if locked && r.Method == http.MethodPost && r.URL.Path == "/wallet/unlock" {
+		srv.serveWalletUnlock(a, w, r)
+		return
+	}
+	a.ServeHTTP(w, r)
 }
 
 // NewServer creates a new net.http server listening on bindAddr.  Only the
@@ -434,12 +630,19 @@ func NewServer(config Config) (*Server, error) {
 		return nil, err
 	}
 
+	// Load the API tokens.
+	tokens, err := api.NewTokenStore(config.Siad.SiaDir)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
 	// Create the Server
 	mux := http.NewServeMux()
 	srv := &Server{
 		listener: l,
 		httpServer: &http.Server{
-			Handler: mux,
+			Handler: api.APIVersionHandler(mux),
 
 			// set reasonable timeout windows for requests, to prevent the Sia API
 			// server from leaking file descriptors due to slow, disappearing, or
@@ -459,6 +662,7 @@ func NewServer(config Config) (*Server, error) {
 			IdleTimeout: time.Minute * 5,
 		},
 		config: config,
+		tokens: tokens,
 	}
 
 	// Register siad routes
@@ -478,15 +682,51 @@ func isAddrInUseErr(err error) bool {
 	return false
 }
 
+// importConsensusSnapshot bootstraps the consensus database from the snapshot
+// given in the server's config.
+func (srv *Server) importConsensusSnapshot() error {
+	var id types.BlockID
+	if err := id.LoadString(srv.config.Siad.ConsensusSnapshotID); err != nil {
+		return errors.New("invalid consensus snapshot block id: " + err.Error())
+	}
+	var checksum crypto.Hash
+	if srv.config.Siad.ConsensusSnapshotChecksum != "" {
+		if err := checksum.LoadString(srv.config.Siad.ConsensusSnapshotChecksum); err != nil {
+			return errors.New("invalid consensus snapshot checksum: " + err.Error())
+		}
+	}
+	f, err := os.Open(srv.config.Siad.ConsensusSnapshot)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	snap, err := consensus.BootstrapFromSnapshot(filepath.Join(srv.config.Siad.SiaDir, modules.ConsensusDir), f, id, checksum)
+	if err != nil {
+		return errors.New("unable to import consensus snapshot: " + err.Error())
+	}
+	fmt.Printf("Imported consensus snapshot at height %v\n", snap.Height)
+	return nil
+}
+
 // loadModules loads the modules defined by the server's config and makes their
 // API routes available.
 func (srv *Server) loadModules() error {
 	// Create the server and start serving daemon routes immediately.
 	fmt.Printf("(0/%d) Loading siad...\n", len(srv.config.Siad.Modules))
 
+	// Check whether the persist files of the host and the renter are
+	// encrypted.
+	kf, err := persist.LoadKeyFile(srv.config.Siad.SiaDir)
+	if err == nil {
+		srv.mu.Lock()
+		srv.metadataKeyFile = &kf
+		srv.mu.Unlock()
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("unable to load the metadata key: %v", err)
+	}
+
 	// Initialize the Sia modules
 	i := 0
-	var err error
 	var g modules.Gateway
 	if strings.Contains(srv.config.Siad.Modules, "g") {
 		i++
@@ -501,10 +741,22 @@ func (srv *Server) loadModules() error {
 	if strings.Contains(srv.config.Siad.Modules, "c") {
 		i++
 		fmt.Printf("(%d/%d) Loading consensus...\n", i, len(srv.config.Siad.Modules))
-		cs, err = consensus.New(g, !srv.config.Siad.NoBootstrap, filepath.Join(srv.config.Siad.SiaDir, modules.ConsensusDir))
+		if srv.config.Siad.ConsensusSnapshot != "" {
+			if err := srv.importConsensusSnapshot(); err != nil {
+				return err
+			}
+		}
+		c, err := consensus.New(g, !srv.config.Siad.NoBootstrap, filepath.Join(srv.config.Siad.SiaDir, modules.ConsensusDir))
 		if err != nil {
 			return err
 		}
+		if srv.config.Siad.ConsensusPruneDepth != 0 {
+			if err := c.SetPruneDepth(types.BlockHeight(srv.config.Siad.ConsensusPruneDepth)); err != nil {
+				c.Close()
+				return err
+			}
+		}
+		cs = c
 		srv.moduleClosers = append(srv.moduleClosers, moduleCloser{name: "consensus", Closer: cs})
 	}
 	var e modules.Explorer
@@ -547,57 +799,110 @@ func (srv *Server) loadModules() error {
 		}
 		srv.moduleClosers = append(srv.moduleClosers, moduleCloser{name: "miner", Closer: m})
 	}
+	srv.mu.Lock()
+	srv.consensus, srv.explorer, srv.gateway, srv.miner, srv.tpool, srv.wallet = cs, e, g, m, tpool, w
+	locked := srv.metadataKeyFile != nil
+	srv.mu.Unlock()
+
+	// Load the host and the renter, unless their persist files are encrypted.
+	// Encrypted modules are loaded once the persist files are unlocked.
+	if !locked {
+		if err := srv.loadMetadataModules(i, nil); err != nil {
+			return err
+		}
+	} else if strings.ContainsAny(srv.config.Siad.Modules, "hr") {
+		fmt.Println("The persist files of the host and the renter are encrypted. Unlock them with 'siac daemon unlock' or by unlocking the wallet.")
+	}
+
+	// Apply the module settings of the config file before the API is
+	// served.
+	if _, err := srv.applyModuleConfig(srv.config.File); err != nil {
+		return err
+	}
+
+	// connect the API to the server
+	srv.mu.Lock()
+	srv.api = srv.newAPI()
+	srv.mu.Unlock()
+
+	// Attempt to auto-unlock the wallet using the SIA_WALLET_PASSWORD env variable
+	if password := os.Getenv("SIA_WALLET_PASSWORD"); password != "" {
+		fmt.Println("Sia Wallet Password found, attempting to auto-unlock wallet")
+		if err := unlockWallet(w, password); err != nil {
+			fmt.Println("Auto-unlock failed.")
+		} else {
+			fmt.Println("Auto-unlock successful.")
+		}
+		if locked {
+			srv.managedUnlockMetadataWithWallet(password)
+		}
+	}
+
+	return nil
+}
+
+// loadMetadataModules loads the host and the renter, if they are part of the
+// server's config. Their persist files are encrypted with key unless key is
+// nil. i is the number of modules that were loaded before.
+func (srv *Server) loadMetadataModules(i int, key *crypto.TwofishKey) error {
+	srv.mu.Lock()
+	cs, g, tpool, w := srv.consensus, srv.gateway, srv.tpool, srv.wallet
+	srv.mu.Unlock()
+
 	var h modules.Host
+	var err error
 	if strings.Contains(srv.config.Siad.Modules, "h") {
 		i++
 		fmt.Printf("(%d/%d) Loading host...\n", i, len(srv.config.Siad.Modules))
-		h, err = host.New(cs, g, tpool, w, srv.config.Siad.HostAddr, filepath.Join(srv.config.Siad.SiaDir, modules.HostDir))
+		if key == nil {
+			h, err = host.New(cs, g, tpool, w, srv.config.Siad.HostAddr, filepath.Join(srv.config.Siad.SiaDir, modules.HostDir))
+		} else {
+			h, err = host.NewEncrypted(cs, g, tpool, w, srv.config.Siad.HostAddr, filepath.Join(srv.config.Siad.SiaDir, modules.HostDir), *key)
+		}
 		if err != nil {
 			return err
 		}
+		srv.mu.Lock()
 		srv.moduleClosers = append(srv.moduleClosers, moduleCloser{name: "host", Closer: h})
+		srv.host = h
+		srv.mu.Unlock()
 	}
 	var r modules.Renter
 	if strings.Contains(srv.config.Siad.Modules, "r") {
 		i++
 		fmt.Printf("(%d/%d) Loading renter...\n", i, len(srv.config.Siad.Modules))
-		r, err = renter.New(g, cs, w, tpool, filepath.Join(srv.config.Siad.SiaDir, modules.RenterDir))
+		if key == nil {
+			r, err = renter.New(g, cs, w, tpool, filepath.Join(srv.config.Siad.SiaDir, modules.RenterDir))
+		} else {
+			r, err = renter.NewEncrypted(g, cs, w, tpool, filepath.Join(srv.config.Siad.SiaDir, modules.RenterDir), *key)
+		}
 		if err != nil {
 			return err
 		}
+		srv.mu.Lock()
 		srv.moduleClosers = append(srv.moduleClosers, moduleCloser{name: "renter", Closer: r})
+		srv.renter = r
+		srv.mu.Unlock()
 	}
+	return nil
+}
 
-	// Create the Sia API
-	a := api.New(
+// newAPI creates the Sia API from the loaded modules. The caller must hold
+// srv.mu.
+func (srv *Server) newAPI() *api.API {
+	return api.New(
 		srv.config.Siad.RequiredUserAgent,
 		srv.config.APIPassword,
-		cs,
-		e,
-		g,
-		h,
-		m,
-		r,
-		tpool,
-		w,
+		srv.tokens,
+		srv.consensus,
+		srv.explorer,
+		srv.gateway,
+		srv.host,
+		srv.miner,
+		srv.renter,
+		srv.tpool,
+		srv.wallet,
 	)
-
-	// connect the API to the server
-	srv.mu.Lock()
-	srv.api = a
-	srv.mu.Unlock()
-
-	// Attempt to auto-unlock the wallet using the SIA_WALLET_PASSWORD env variable
-	if password := os.Getenv("SIA_WALLET_PASSWORD"); password != "" {
-		fmt.Println("Sia Wallet Password found, attempting to auto-unlock wallet")
-		if err := unlockWallet(w, password); err != nil {
-			fmt.Println("Auto-unlock failed.")
-		} else {
-			fmt.Println("Auto-unlock successful.")
-		}
-	}
-
-	return nil
 }
 
 // Serve starts the HTTP server
@@ -619,14 +924,21 @@ func (srv *Server) Close() error {
 	if err := srv.listener.Close(); err != nil {
 		errs = append(errs, err)
 	}
-	// Close all of the modules in reverse order
-	for i := len(srv.moduleClosers) - 1; i >= 0; i-- {
-		m := srv.moduleClosers[i]
+	// Close all of the modules in reverse order. The closers are copied,
+	// since unlocking the persist files can load modules concurrently.
+	srv.mu.Lock()
+	closers := append([]moduleCloser(nil), srv.moduleClosers...)
+	srv.mu.Unlock()
+	for i := len(closers) - 1; i >= 0; i-- {
+		m := closers[i]
 		fmt.Printf("Closing %v...\n", m.name)
 		if err := m.Close(); err != nil {
 			errs = append(errs, err)
 		}
 	}
+	if err := srv.tokens.Close(); err != nil {
+		errs = append(errs, err)
+	}
 
 	return build.JoinErrors(errs, "\n")
 }
@@ -0,0 +1,189 @@
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SeverityUnknown is the severity of an alert that was not set.
+	SeverityUnknown AlertSeverity = iota
+	// SeverityWarning warns of a condition that might require the attention
+	// of the user.
+	SeverityWarning
+	// SeverityError is a condition that prevents a module from working
+	// correctly, e.g. contracts that fail to renew.
+	SeverityError
+	// SeverityCritical is a condition that can lead to the loss of data or
+	// money, e.g. a host that cannot submit its storage proofs.
+	SeverityCritical
+)
+
+var (
+	// alertSeverityNames are the names of the alert severities, indexed by
+	// severity.
+	alertSeverityNames = []string{"unknown", "warning", "error", "critical"}
+
+	// errUnknownAlertSeverity is returned when parsing the name of an unknown
+	// alert severity.
+	errUnknownAlertSeverity = errors.New("unknown alert severity")
+)
+
+type (
+	// AlertSeverity is the severity of an alert.
+	AlertSeverity int
+
+	// AlertID identifies an alert within its module. Registering an alert
+	// with the ID of an existing alert replaces the existing alert.
+	AlertID string
+
+	// An Alert is a condition of a module that requires the attention of the
+	// user. Msg describes the condition and Cause the error that led to it,
+	// if any. Time is the time at which the alert was first registered.
+	Alert struct {
+		ID       AlertID       `json:"id"`
+		Module   string        `json:"module"`
+		Msg      string        `json:"msg"`
+		Cause    string        `json:"cause"`
+		Severity AlertSeverity `json:"severity"`
+		Time     time.Time     `json:"time"`
+	}
+
+	// An Alerter is a module that raises alerts. The daemon collects the
+	// alerts of every loaded module that implements Alerter.
+	Alerter interface {
+		// Alerts returns the current alerts of the module.
+		Alerts() []Alert
+	}
+
+	// GenericAlerter keeps track of the alerts of a module. Modules persist
+	// their alerts by saving the result of Alerts with the rest of their
+	// persist data and restoring it with LoadAlerts, so that an alert remains
+	// until the module unregisters it.
+	GenericAlerter struct {
+		alerts map[AlertID]Alert
+		module string
+		mu     sync.Mutex
+	}
+)
+
+// String returns the name of the alert severity.
+func (s AlertSeverity) String() string {
+	if s < 0 || int(s) >= len(alertSeverityNames) {
+		return fmt.Sprintf("AlertSeverity(%d)", int(s))
+	}
+	return alertSeverityNames[s]
+}
+
+// MarshalJSON marshals the alert severity as its name.
+func (s AlertSeverity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON unmarshals the name of an alert severity.
+func (s *AlertSeverity) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+	severity, err := ParseAlertSeverity(name)
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+// ParseAlertSeverity returns the alert severity with the given name.
+func ParseAlertSeverity(name string) (AlertSeverity, error) {
+	for i, n := range alertSeverityNames {
+		if strings.EqualFold(name, n) {
+			return AlertSeverity(i), nil
+		}
+	}
+	return 0, errUnknownAlertSeverity
+}
+
+// NewAlerter creates a GenericAlerter for the module with the given name.
+func NewAlerter(module string) *GenericAlerter {
+	return &GenericAlerter{
+		alerts: make(map[AlertID]Alert),
+		module: module,
+	}
+}
+
+// Alerts returns the alerts of the module, most severe first.
+func (a *GenericAlerter) Alerts() []Alert {
+	a.mu.Lock()
+	alerts := make([]Alert, 0, len(a.alerts))
+	for _, alert := range a.alerts {
+		alerts = append(alerts, alert)
+	}
+	a.mu.Unlock()
+	SortAlerts(alerts)
+	return alerts
+}
+
+// LoadAlerts restores alerts that were persisted by the module. Alerts of
+// other modules are ignored.
+func (a *GenericAlerter) LoadAlerts(alerts []Alert) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, alert := range alerts {
+		if alert.Module == a.module {
+			a.alerts[alert.ID] = alert
+		}
+	}
+}
+
+// RegisterAlert registers an alert of the module. If an alert with the same
+// ID is registered already, its message, cause and severity are updated, but
+// it keeps the time at which it was first registered. RegisterAlert returns
+// true if the alerts of the module changed.
+func (a *GenericAlerter) RegisterAlert(id AlertID, msg, cause string, severity AlertSeverity) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	alert, exists := a.alerts[id]
+	if exists && alert.Msg == msg && alert.Cause == cause && alert.Severity == severity {
+		return false
+	}
+	if !exists {
+		alert = Alert{
+			ID:     id,
+			Module: a.module,
+			Time:   time.Now(),
+		}
+	}
+	alert.Msg, alert.Cause, alert.Severity = msg, cause, severity
+	a.alerts[id] = alert
+	return true
+}
+
+// UnregisterAlert removes the alert with the given ID, if it exists. It
+// returns true if the alert existed.
+func (a *GenericAlerter) UnregisterAlert(id AlertID) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, exists := a.alerts[id]
+	delete(a.alerts, id)
+	return exists
+}
+
+// SortAlerts sorts alerts by severity, most severe first, and then by module
+// and ID.
+func SortAlerts(alerts []Alert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Severity != alerts[j].Severity {
+			return alerts[i].Severity > alerts[j].Severity
+		}
+		if alerts[i].Module != alerts[j].Module {
+			return alerts[i].Module < alerts[j].Module
+		}
+		return alerts[i].ID < alerts[j].ID
+	})
+}
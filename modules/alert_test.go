@@ -0,0 +1,66 @@
+package modules
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestAlertSeverityJSON tests that alert severities are marshaled as their
+// names.
+func TestAlertSeverityJSON(t *testing.T) {
+	for _, s := range []AlertSeverity{SeverityUnknown, SeverityWarning, SeverityError, SeverityCritical} {
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Fatal(err)
+		} else if string(b) != `"`+s.String()+`"` {
+			t.Fatalf("expected %v to be marshaled as its name, got %s", s, b)
+		}
+		var s2 AlertSeverity
+		if err := json.Unmarshal(b, &s2); err != nil {
+			t.Fatal(err)
+		} else if s2 != s {
+			t.Fatalf("expected %v, got %v", s, s2)
+		}
+	}
+	var s AlertSeverity
+	if err := json.Unmarshal([]byte(`"fatal"`), &s); err == nil {
+		t.Fatal("expected an error for an unknown severity")
+	}
+}
+
+// TestGenericAlerter tests registering, unregistering and loading alerts.
+func TestGenericAlerter(t *testing.T) {
+	a := NewAlerter("foo")
+	if !a.RegisterAlert("warn", "msg", "", SeverityWarning) {
+		t.Fatal("registering a new alert did not change the alerts")
+	}
+	if a.RegisterAlert("warn", "msg", "", SeverityWarning) {
+		t.Fatal("registering an identical alert changed the alerts")
+	}
+	first := a.Alerts()[0].Time
+	if !a.RegisterAlert("warn", "msg2", "", SeverityWarning) {
+		t.Fatal("updating an alert did not change the alerts")
+	}
+	if alerts := a.Alerts(); len(alerts) != 1 || alerts[0].Msg != "msg2" || !alerts[0].Time.Equal(first) {
+		t.Fatal("alert was not updated in place:", alerts)
+	}
+	a.RegisterAlert("crit", "msg", "cause", SeverityCritical)
+	alerts := a.Alerts()
+	if len(alerts) != 2 || alerts[0].ID != "crit" || alerts[0].Module != "foo" {
+		t.Fatal("expected the critical alert first, got", alerts)
+	}
+
+	// Only the alerts of the module are loaded.
+	b := NewAlerter("foo")
+	b.LoadAlerts(append(alerts, Alert{ID: "other", Module: "bar"}))
+	if loaded := b.Alerts(); len(loaded) != 2 {
+		t.Fatal("expected 2 alerts to be loaded, got", loaded)
+	}
+
+	if !b.UnregisterAlert("crit") || b.UnregisterAlert("crit") {
+		t.Fatal("unexpected result of unregistering an alert")
+	}
+	if remaining := b.Alerts(); len(remaining) != 1 || remaining[0].ID != "warn" {
+		t.Fatal("unexpected alerts after unregistering:", remaining)
+	}
+}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"errors"
+	"io"
 
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/types"
@@ -46,6 +47,12 @@ var (
 	// should be handled by the module, and not reported to the user.
 	ErrInvalidConsensusChangeID = errors.New("consensus subscription has invalid id - files are inconsistent")
 
+	// ErrConsensusChangePruned indicates that a consensus change could not be
+	// computed because it references blocks that were pruned from the
+	// consensus set. Subscribers receiving this error cannot resume from their
+	// most recent change and need a consensus set that is not pruned.
+	ErrConsensusChangePruned = errors.New("consensus change references blocks that were pruned from the consensus set")
+
 	// ErrNonExtendingBlock indicates that a block is valid but does not result
 	// in a fork that is the heaviest known fork - the consensus set has not
 	// changed as a result of seeing the block.
@@ -134,35 +141,91 @@ type (
 	// A SiacoinOutputDiff indicates the addition or removal of a SiacoinOutput in
 	// the consensus set.
 	SiacoinOutputDiff struct {
-		Direction     DiffDirection
-		ID            types.SiacoinOutputID
-		SiacoinOutput types.SiacoinOutput
+		Direction     DiffDirection         `json:"direction"`
+		ID            types.SiacoinOutputID `json:"id"`
+		SiacoinOutput types.SiacoinOutput   `json:"siacoinoutput"`
 	}
 
 	// A FileContractDiff indicates the addition or removal of a FileContract in
 	// the consensus set.
 	FileContractDiff struct {
-		Direction    DiffDirection
-		ID           types.FileContractID
-		FileContract types.FileContract
+		Direction    DiffDirection        `json:"direction"`
+		ID           types.FileContractID `json:"id"`
+		FileContract types.FileContract   `json:"filecontract"`
 	}
 
 	// A SiafundOutputDiff indicates the addition or removal of a SiafundOutput in
 	// the consensus set.
 	SiafundOutputDiff struct {
-		Direction     DiffDirection
-		ID            types.SiafundOutputID
-		SiafundOutput types.SiafundOutput
+		Direction     DiffDirection         `json:"direction"`
+		ID            types.SiafundOutputID `json:"id"`
+		SiafundOutput types.SiafundOutput   `json:"siafundoutput"`
 	}
 
 	// A DelayedSiacoinOutputDiff indicates the introduction of a siacoin output
 	// that cannot be spent until after maturing for 144 blocks. When the output
 	// has matured, a SiacoinOutputDiff will be provided.
 	DelayedSiacoinOutputDiff struct {
-		Direction      DiffDirection
-		ID             types.SiacoinOutputID
-		SiacoinOutput  types.SiacoinOutput
-		MaturityHeight types.BlockHeight
+		Direction      DiffDirection         `json:"direction"`
+		ID             types.SiacoinOutputID `json:"id"`
+		SiacoinOutput  types.SiacoinOutput   `json:"siacoinoutput"`
+		MaturityHeight types.BlockHeight     `json:"maturityheight"`
+	}
+
+	// A ConsensusSnapshot describes a snapshot of the consensus set that was
+	// exported from or imported into the consensus database. Checksum covers
+	// the consensus checksum of the state after the block with ID BlockID was
+	// applied, and the targets and totals of the blocks preceding it, which
+	// are not covered by the block ids.
+	ConsensusSnapshot struct {
+		Height   types.BlockHeight `json:"height"`
+		BlockID  types.BlockID     `json:"blockid"`
+		Checksum crypto.Hash       `json:"checksum"`
+	}
+
+	// ConsensusPruneStatus describes the pruning of the consensus set.
+	// PruneDepth is the number of recent blocks that are kept in full, or 0 if
+	// pruning is disabled, and the blocks below PrunedHeight have been pruned.
+	// DiskUsage is the number of bytes of the consensus database that are in
+	// use. Pruning frees the pages of old blocks for reuse, but doesn't shrink
+	// the database file.
+	ConsensusPruneStatus struct {
+		PruneDepth   types.BlockHeight `json:"prunedepth"`
+		PrunedHeight types.BlockHeight `json:"prunedheight"`
+		DiskUsage    uint64            `json:"diskusage"`
+	}
+
+	// ConsensusSyncProgress describes the progress of header-first
+	// synchronization. HeaderHeight is the height of the heaviest known valid
+	// header and BlockHeight the height of the current block. BodiesInFlight
+	// is the number of blocks that are currently being downloaded.
+	ConsensusSyncProgress struct {
+		HeaderHeight   types.BlockHeight `json:"headerheight"`
+		BlockHeight    types.BlockHeight `json:"blockheight"`
+		BodiesInFlight int               `json:"bodiesinflight"`
+	}
+
+	// A ReorgEvent describes a reorganization of the blockchain. Depth is the
+	// number of blocks that were reverted and ForkHeight the height of the
+	// common parent of the old and the new tip. RevertedTransactions are the
+	// transactions of the reverted blocks that the new blocks don't contain.
+	ReorgEvent struct {
+		ID                   uint64                `json:"id"`
+		Timestamp            types.Timestamp       `json:"timestamp"`
+		Depth                types.BlockHeight     `json:"depth"`
+		ForkHeight           types.BlockHeight     `json:"forkheight"`
+		OldTip               types.BlockID         `json:"oldtip"`
+		NewTip               types.BlockID         `json:"newtip"`
+		RevertedTransactions []types.TransactionID `json:"revertedtransactions"`
+	}
+
+	// A ReorgedTransaction is a transaction that was confirmed in a block
+	// that a reorg reverted, and that the new blocks of the reorg don't
+	// contain. BlockID is the ID of the reverted block.
+	ReorgedTransaction struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+		BlockID       types.BlockID       `json:"blockid"`
+		Timestamp     types.Timestamp     `json:"timestamp"`
 	}
 
 	// A SiafundPoolDiff contains the value of the siafundPool before the block
@@ -170,9 +233,9 @@ type (
 	// siafundPool to 'Adjusted'. When reverting the diff, set siafundPool to
 	// 'Previous'.
 	SiafundPoolDiff struct {
-		Direction DiffDirection
-		Previous  types.Currency
-		Adjusted  types.Currency
+		Direction DiffDirection  `json:"direction"`
+		Previous  types.Currency `json:"previous"`
+		Adjusted  types.Currency `json:"adjusted"`
 	}
 
 	// A ConsensusSet accepts blocks and builds an understanding of network
@@ -214,16 +277,40 @@ type (
 		// blockchain.
 		CurrentBlock() types.Block
 
+		// ExportSnapshot writes a snapshot of the consensus set at the given
+		// height to w. The snapshot contains the headers of all blocks up to
+		// that height, the most recent blocks in full, and the complete set of
+		// unspent outputs, file contracts and delayed outputs.
+		ExportSnapshot(w io.Writer, height types.BlockHeight) (ConsensusSnapshot, error)
+
+		// DelayedSiacoinOutputs returns the delayed siacoin outputs that
+		// mature at the given height, with a bool to indicate whether the
+		// consensus set tracks delayed outputs at that height.
+		DelayedSiacoinOutputs(types.BlockHeight) (map[types.SiacoinOutputID]types.SiacoinOutput, bool)
+
 		// Flush will cause the consensus set to finish all in-progress
 		// routines.
 		Flush() error
 
+		// FileContract returns the most recent revision of an open file
+		// contract, with a bool to indicate whether the contract exists.
+		FileContract(types.FileContractID) (types.FileContract, bool)
+
 		// Height returns the current height of consensus.
 		Height() types.BlockHeight
 
 		// Synced returns true if the consensus set is synced with the network.
 		Synced() bool
 
+		// SyncProgress returns the progress of header-first synchronization.
+		SyncProgress() ConsensusSyncProgress
+
+		// ImportSnapshot replaces the state of a consensus set that only
+		// contains the genesis block with the snapshot read from r. The
+		// snapshot is rejected unless it ends in the block with the given id
+		// and, if the checksum is not empty, has the given checksum.
+		ImportSnapshot(r io.Reader, id types.BlockID, checksum crypto.Hash) (ConsensusSnapshot, error)
+
 		// InCurrentPath returns true if the block id presented is found in the
 		// current path, false otherwise.
 		InCurrentPath(types.BlockID) bool
@@ -234,6 +321,25 @@ type (
 		// risk of mining invalid blocks.
 		MinimumValidChildTimestamp(types.BlockID) (types.Timestamp, bool)
 
+		// PruneStatus returns the prune depth, the height of the first block
+		// that was not pruned and the disk usage of the consensus set.
+		PruneStatus() ConsensusPruneStatus
+
+		// Reorgs returns the most recent reorganizations of the blockchain,
+		// oldest first.
+		Reorgs() []ReorgEvent
+
+		// SiacoinOutput returns an unspent siacoin output, with a bool to
+		// indicate whether the output exists.
+		SiacoinOutput(types.SiacoinOutputID) (types.SiacoinOutput, bool)
+
+		// SiafundOutput returns an unspent siafund output, with a bool to
+		// indicate whether the output exists.
+		SiafundOutput(types.SiafundOutputID) (types.SiafundOutput, bool)
+
+		// SiafundPool returns the current value of the siafund pool.
+		SiafundPool() types.Currency
+
 		// StorageProofSegment returns the segment to be used in the storage proof for
 		// a given file contract.
 		StorageProofSegment(types.FileContractID) (uint64, error)
@@ -265,3 +371,33 @@ func (cc ConsensusChange) Append(cc2 ConsensusChange) ConsensusChange {
 		DelayedSiacoinOutputDiffs: append(cc.DelayedSiacoinOutputDiffs, cc2.DelayedSiacoinOutputDiffs...),
 	}
 }
+
+// ReorgedTransactions returns the transactions of the reverted blocks that
+// the applied blocks of the consensus change don't contain and for which
+// relevant returns true.
+func (cc ConsensusChange) ReorgedTransactions(relevant func(types.Transaction) bool) []ReorgedTransaction {
+	if len(cc.RevertedBlocks) == 0 {
+		return nil
+	}
+	reapplied := make(map[types.TransactionID]struct{})
+	for _, block := range cc.AppliedBlocks {
+		for _, txn := range block.Transactions {
+			reapplied[txn.ID()] = struct{}{}
+		}
+	}
+	var rts []ReorgedTransaction
+	for _, block := range cc.RevertedBlocks {
+		for _, txn := range block.Transactions {
+			txid := txn.ID()
+			if _, exists := reapplied[txid]; exists || !relevant(txn) {
+				continue
+			}
+			rts = append(rts, ReorgedTransaction{
+				TransactionID: txid,
+				BlockID:       block.ID(),
+				Timestamp:     types.CurrentTimestamp(),
+			})
+		}
+	}
+	return rts
+}
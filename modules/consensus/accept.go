@@ -59,6 +59,11 @@ func (cs *ConsensusSet) validateHeaderAndBlock(tx dbTx, b types.Block, id types.
 	if err != nil {
 		return nil, err
 	}
+	// Blocks below an imported snapshot are not fully known, so the chain
+	// cannot be reorganized below it.
+	if parent.Height < cs.reorgFloor() {
+		return nil, errPrunedParent
+	}
 	// Check that the timestamp is not too far in the past to be acceptable.
 	minTimestamp := cs.blockRuleHelper.minimumValidChildTimestamp(blockMap, parent)
 
@@ -107,6 +112,9 @@ func (cs *ConsensusSet) validateHeader(tx dbTx, h types.BlockHeader) error {
 	if err != nil {
 		return err
 	}
+	if parent.Height < cs.reorgFloor() {
+		return errPrunedParent
+	}
 
 	// Check that the target of the new block is sufficient.
 	if !checkHeaderTarget(h, parent.ChildTarget) {
@@ -172,6 +180,11 @@ func (cs *ConsensusSet) addBlockToTree(tx *bolt.Tx, b types.Block, parent *proce
 	for _, an := range appliedBlocks {
 		ce.AppliedBlocks = append(ce.AppliedBlocks, an.Block.ID())
 	}
+	if len(revertedBlocks) > 0 {
+		if err := cs.recordReorg(tx, revertedBlocks, appliedBlocks); err != nil {
+			return changeEntry{}, err
+		}
+	}
 	err = appendChangeLog(tx, ce)
 	if err != nil {
 		return changeEntry{}, err
@@ -312,6 +325,18 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	for i := 0; i < len(changes); i++ {
 		cs.updateSubscribers(changes[i])
 	}
+	// Prune the blocks that are now too deep once a full batch can be
+	// pruned.
+	if cs.pruneDepth != 0 {
+		var height types.BlockHeight
+		_ = cs.db.View(func(tx *bolt.Tx) error {
+			height = blockHeight(tx)
+			return nil
+		})
+		if height >= cs.prunedHeight+cs.pruneDepth+pruneBatchSize {
+			go cs.threadedPrune()
+		}
+	}
 	return chainExtended, nil
 }
 
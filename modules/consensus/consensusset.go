@@ -81,6 +81,24 @@ type ConsensusSet struct {
 	// whether the consensus set is synced with the network.
 	synced bool
 
+	// snapshotHeight is the height of the snapshot that the consensus set was
+	// bootstrapped from, or 0 if it was synced from the genesis block. Blocks
+	// below the snapshot height are only known by their headers, so the chain
+	// cannot be reorganized below it.
+	snapshotHeight types.BlockHeight
+
+	// headerHeight is the height of the heaviest header that was received
+	// during header-first synchronization, and bodiesInFlight is the number
+	// of blocks that are currently being downloaded.
+	headerHeight   types.BlockHeight
+	bodiesInFlight int
+
+	// pruneDepth is the number of recent blocks that are kept in full if the
+	// consensus set is pruned, or 0 if pruning is disabled. The blocks below
+	// prunedHeight have been pruned.
+	pruneDepth   types.BlockHeight
+	prunedHeight types.BlockHeight
+
 	// Interfaces to abstract the dependencies of the ConsensusSet.
 	marshaler       marshaler
 	blockRuleHelper blockRuleHelper
@@ -112,40 +130,7 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 	}
 
 	// Create the ConsensusSet object.
-	cs := &ConsensusSet{
-		gateway: gateway,
-
-		blockRoot: processedBlock{
-			Block:       types.GenesisBlock,
-			ChildTarget: types.RootTarget,
-			Depth:       types.RootDepth,
-
-			DiffsGenerated: true,
-		},
-
-		dosBlocks: make(map[types.BlockID]struct{}),
-
-		marshaler:       stdMarshaler{},
-		blockRuleHelper: stdBlockRuleHelper{},
-		blockValidator:  NewBlockValidator(),
-
-		staticDeps: deps,
-		persistDir: persistDir,
-	}
-
-	// Create the diffs for the genesis siafund outputs.
-	for i, siafundOutput := range types.GenesisBlock.Transactions[0].SiafundOutputs {
-		sfid := types.GenesisBlock.Transactions[0].SiafundOutputID(uint64(i))
-		sfod := modules.SiafundOutputDiff{
-			Direction:     modules.DiffApply,
-			ID:            sfid,
-			SiafundOutput: siafundOutput,
-		}
-		cs.blockRoot.SiafundOutputDiffs = append(cs.blockRoot.SiafundOutputDiffs, sfod)
-	}
-
-	// Initialize the consensus persistence structures.
-	err := cs.initPersist()
+	cs, err := newConsensusSet(gateway, persistDir, deps)
 	if err != nil {
 		return nil, err
 	}
@@ -176,11 +161,15 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 		gateway.RegisterRPC("SendBlocks", cs.rpcSendBlocks)
 		gateway.RegisterRPC("RelayHeader", cs.threadedRPCRelayHeader)
 		gateway.RegisterRPC("SendBlk", cs.rpcSendBlk)
+		gateway.RegisterRPC("SendHeaders", cs.rpcSendHeaders)
+		gateway.RegisterRPC("SendBodies", cs.rpcSendBodies)
 		gateway.RegisterConnectCall("SendBlocks", cs.threadedReceiveBlocks)
 		cs.tg.OnStop(func() {
 			cs.gateway.UnregisterRPC("SendBlocks")
 			cs.gateway.UnregisterRPC("RelayHeader")
 			cs.gateway.UnregisterRPC("SendBlk")
+			cs.gateway.UnregisterRPC("SendHeaders")
+			cs.gateway.UnregisterRPC("SendBodies")
 			cs.gateway.UnregisterConnectCall("SendBlocks")
 		})
 
@@ -193,6 +182,50 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 	return cs, nil
 }
 
+// newConsensusSet creates a ConsensusSet and loads its database, without
+// connecting it to the network.
+func newConsensusSet(gateway modules.Gateway, persistDir string, deps modules.Dependencies) (*ConsensusSet, error) {
+	cs := &ConsensusSet{
+		gateway: gateway,
+
+		blockRoot: processedBlock{
+			Block:       types.GenesisBlock,
+			ChildTarget: types.RootTarget,
+			Depth:       types.RootDepth,
+
+			DiffsGenerated: true,
+		},
+
+		dosBlocks: make(map[types.BlockID]struct{}),
+
+		marshaler:       stdMarshaler{},
+		blockRuleHelper: stdBlockRuleHelper{},
+		blockValidator:  NewBlockValidator(),
+
+		staticDeps: deps,
+		persistDir: persistDir,
+	}
+
+	// Create the diffs for the genesis siafund outputs.
+	for i, siafundOutput := range types.GenesisBlock.Transactions[0].SiafundOutputs {
+		sfid := types.GenesisBlock.Transactions[0].SiafundOutputID(uint64(i))
+		sfod := modules.SiafundOutputDiff{
+			Direction:     modules.DiffApply,
+			ID:            sfid,
+			SiafundOutput: siafundOutput,
+		}
+		cs.blockRoot.SiafundOutputDiffs = append(cs.blockRoot.SiafundOutputDiffs, sfod)
+	}
+
+	// Initialize the consensus persistence structures.
+	err := cs.initPersist()
+	if err != nil {
+		return nil, err
+	}
+
+	return cs, nil
+}
+
 // BlockAtHeight returns the block at a given height.
 func (cs *ConsensusSet) BlockAtHeight(height types.BlockHeight) (block types.Block, exists bool) {
 	_ = cs.db.View(func(tx *bolt.Tx) error {
@@ -359,6 +392,9 @@ func (cs *ConsensusSet) MinimumValidChildTimestamp(id types.BlockID) (timestamp
 		if err != nil {
 			return err
 		}
+		if !timestampWindowKnown(tx, id) {
+			return errPrunedParent
+		}
 		timestamp = cs.blockRuleHelper.minimumValidChildTimestamp(tx.Bucket(BlockMap), pb)
 		exists = true
 		return nil
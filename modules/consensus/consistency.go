@@ -286,6 +286,10 @@ func (cs *ConsensusSet) checkRevertApply(tx *bolt.Tx) {
 	if current.Block.ID() == cs.blockRoot.Block.ID() {
 		return
 	}
+	// The snapshot block and the blocks below it cannot be reverted.
+	if current.Height <= snapshotHeight(tx) {
+		return
+	}
 
 	parent, err := getBlockMap(tx, current.Block.ParentID)
 	if err != nil {
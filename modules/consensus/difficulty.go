@@ -155,6 +155,20 @@ func (cs *ConsensusSet) getBlockTotals(tx *bolt.Tx, id types.BlockID) (totalTime
 // block and stores that new time in the database. It also returns the new
 // totals.
 func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockHeight, currentBlockID types.BlockID, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target, err error) {
+	newTotalTime, newTotalTarget = blockTotals(currentHeight, prevTotalTime, parentTimestamp, currentTimestamp, prevTotalTarget, targetOfCurrentBlock)
+
+	// Store the new total time and total target in the database at the
+	// appropriate id.
+	err = putBlockTotals(tx, currentBlockID, newTotalTime, newTotalTarget)
+	if err != nil {
+		return 0, types.Target{}, err
+	}
+	return newTotalTime, newTotalTarget, nil
+}
+
+// blockTotals computes the new total time and total target for the block at
+// currentHeight from the totals of its parent.
+func blockTotals(currentHeight types.BlockHeight, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target) {
 	// Reset the prevTotalTime to a delta of zero just before the hardfork.
 	//
 	// NOTICE: This code is broken, an incorrectly executed hardfork. The
@@ -179,17 +193,20 @@ func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockH
 	// delta.
 	newTotalTime = (prevTotalTime * types.OakDecayNum / types.OakDecayDenom) + (int64(currentTimestamp) - int64(parentTimestamp))
 	newTotalTarget = prevTotalTarget.MulDifficulty(big.NewRat(types.OakDecayNum, types.OakDecayDenom)).AddDifficulties(targetOfCurrentBlock)
+	return newTotalTime, newTotalTarget
+}
 
-	// Store the new total time and total target in the database at the
-	// appropriate id.
+// putBlockTotals stores the total time and total target of a block in the
+// database.
+func putBlockTotals(tx *bolt.Tx, id types.BlockID, totalTime int64, totalTarget types.Target) error {
 	bytes := make([]byte, 40)
-	binary.LittleEndian.PutUint64(bytes[:8], uint64(newTotalTime))
-	copy(bytes[8:], newTotalTarget[:])
-	err = tx.Bucket(BucketOak).Put(currentBlockID[:], bytes)
+	binary.LittleEndian.PutUint64(bytes[:8], uint64(totalTime))
+	copy(bytes[8:], totalTarget[:])
+	err := tx.Bucket(BucketOak).Put(id[:], bytes)
 	if err != nil {
-		return 0, types.Target{}, errors.Extend(errors.New("unable to store total time values"), err)
+		return errors.Extend(errors.New("unable to store total time values"), err)
 	}
-	return newTotalTime, newTotalTarget, nil
+	return nil
 }
 
 // initOak will initialize all of the oak difficulty adjustment related fields.
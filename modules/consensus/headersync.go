@@ -0,0 +1,694 @@
+package consensus
+
+// headersync.go implements header-first synchronization. During IBD, the
+// headers that extend the current path are requested from several peers at
+// once with the SendHeaders RPC and validated without downloading any blocks.
+// The blocks leading to the heaviest valid header are then downloaded in
+// batches with the SendBodies RPC, spread across all peers that announced
+// them, with a bounded number of batches in flight at any time. Peers that do
+// not support these RPCs are synchronized using SendBlocks.
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	errMissingBodies      = errors.New("peer did not send all requested blocks")
+	errNoBodyPeers        = errors.New("no peers left to download the blocks from")
+	errTooManyBodies      = errors.New("too many blocks requested")
+	errTooManyHeaders     = errors.New("peer sent too many headers")
+	errUnrequestedBlock   = errors.New("peer sent a block that was not requested")
+	errUnconnectedHeaders = errors.New("peer sent headers that do not connect to each other")
+
+	// MaxCatchUpHeaders is the maximum number of headers that are sent in a
+	// single batch of the SendHeaders RPC.
+	MaxCatchUpHeaders = build.Select(build.Var{
+		Standard: types.BlockHeight(2000),
+		Dev:      types.BlockHeight(500),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
+	// headerSyncPeers is the maximum number of outbound peers that headers
+	// are requested from at the same time during IBD.
+	headerSyncPeers = build.Select(build.Var{
+		Standard: 8,
+		Dev:      4,
+		Testing:  4,
+	}).(int)
+
+	// headerSyncWindow is the maximum number of batches of MaxCatchUpBlocks
+	// blocks that are downloaded at the same time during header-first
+	// synchronization.
+	headerSyncWindow = build.Select(build.Var{
+		Standard: 16,
+		Dev:      8,
+		Testing:  4,
+	}).(int)
+
+	// sendHeadersTimeout is the timeout for the SendHeaders RPC.
+	sendHeadersTimeout = build.Select(build.Var{
+		Standard: 180 * time.Second,
+		Dev:      40 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// sendBodiesTimeout is the timeout for the SendBodies RPC.
+	sendBodiesTimeout = build.Select(build.Var{
+		Standard: 120 * time.Second,
+		Dev:      30 * time.Second,
+		Testing:  4 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// headerNode is a header that was validated during header-first
+	// synchronization, together with the values that are needed to validate
+	// its children.
+	headerNode struct {
+		Header      types.BlockHeader
+		Height      types.BlockHeight
+		Depth       types.Target
+		ChildTarget types.Target
+		TotalTime   int64
+		TotalTarget types.Target
+	}
+
+	// headerTree holds the headers that were received during header-first
+	// synchronization but whose blocks are not part of the consensus set yet.
+	headerTree struct {
+		nodes map[types.BlockID]*headerNode
+		// tips contains the most recent header announced by each peer. The
+		// peer is expected to have the blocks of all ancestors of its tip.
+		tips map[modules.NetAddress]types.BlockID
+		best *headerNode
+		mu   sync.Mutex
+	}
+
+	// headerBucket overlays the headers of a headerTree on top of the block
+	// map, so that the block rule helpers can walk the ancestors of a header
+	// that is not in the database yet. Only the parent id and the timestamp
+	// of the returned values are meaningful.
+	headerBucket struct {
+		blockMap dbBucket
+		tree     *headerTree
+	}
+
+	// bodyBatch is a set of consecutive blocks that is requested from a
+	// single peer with the SendBodies RPC.
+	bodyBatch struct {
+		ids    []types.BlockID
+		height types.BlockHeight // height of the last block in ids
+	}
+)
+
+// newHeaderTree returns an empty headerTree.
+func newHeaderTree() *headerTree {
+	return &headerTree{
+		nodes: make(map[types.BlockID]*headerNode),
+		tips:  make(map[modules.NetAddress]types.BlockID),
+	}
+}
+
+// Get returns the encoded header of a block in the header tree, which shares
+// its prefix with the encoded processed block, or the processed block from
+// the block map.
+func (hb headerBucket) Get(key []byte) []byte {
+	var id types.BlockID
+	copy(id[:], key)
+	if node, exists := hb.tree.nodes[id]; exists {
+		return encoding.Marshal(node.Header)
+	}
+	return hb.blockMap.Get(key)
+}
+
+// headerParent returns the header node of the parent of h, from either the
+// header tree or the consensus database.
+func (cs *ConsensusSet) headerParent(tx *bolt.Tx, ht *headerTree, h types.BlockHeader) (*headerNode, error) {
+	if parent, exists := ht.nodes[h.ParentID]; exists {
+		return parent, nil
+	}
+	pb, err := getBlockMap(tx, h.ParentID)
+	if err != nil {
+		return nil, errOrphan
+	}
+	if pb.Height < cs.reorgFloor() {
+		return nil, errPrunedParent
+	}
+	totalTime, totalTarget := cs.getBlockTotals(tx, h.ParentID)
+	return &headerNode{
+		Header:      pb.Block.Header(),
+		Height:      pb.Height,
+		Depth:       pb.Depth,
+		ChildTarget: pb.ChildTarget,
+		TotalTime:   totalTime,
+		TotalTarget: totalTarget,
+	}, nil
+}
+
+// addHeader validates h and adds it to the header tree. The same rules as in
+// validateHeader are applied, and the target of the header's children is
+// computed the same way as in newChild, so that a header is only accepted if
+// its block could extend its parent. modules.ErrBlockKnown is returned if the
+// block of the header is already in the consensus set.
+func (cs *ConsensusSet) addHeader(tx *bolt.Tx, ht *headerTree, h types.BlockHeader) (*headerNode, error) {
+	id := h.ID()
+	if node, exists := ht.nodes[id]; exists {
+		return node, nil
+	}
+	if _, exists := cs.dosBlocks[id]; exists {
+		return nil, errDoSBlock
+	}
+	blockMap := tx.Bucket(BlockMap)
+	if blockMap.Get(id[:]) != nil {
+		return nil, modules.ErrBlockKnown
+	}
+	parent, err := cs.headerParent(tx, ht, h)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the target and timestamp of the header.
+	if !checkHeaderTarget(h, parent.ChildTarget) {
+		return nil, modules.ErrBlockUnsolved
+	}
+	overlay := headerBucket{blockMap: blockMap, tree: ht}
+	parentStub := &processedBlock{Block: types.Block{ParentID: parent.Header.ParentID, Timestamp: parent.Header.Timestamp}}
+	if cs.blockRuleHelper.minimumValidChildTimestamp(overlay, parentStub) > h.Timestamp {
+		return nil, errEarlyTimestamp
+	}
+	if h.Timestamp > types.CurrentTimestamp()+types.ExtremeFutureThreshold {
+		return nil, errExtremeFutureTimestamp
+	}
+
+	// Compute the values that are required to validate the children of the
+	// header.
+	node := &headerNode{
+		Header: h,
+		Height: parent.Height + 1,
+		Depth:  parent.Depth.AddDifficulties(parent.ChildTarget),
+	}
+	node.TotalTime, node.TotalTarget = blockTotals(node.Height, parent.TotalTime, parent.Header.Timestamp, h.Timestamp, parent.TotalTarget, parent.ChildTarget)
+	if parent.Height < types.OakHardforkBlock {
+		node.ChildTarget = parent.ChildTarget
+		if node.Height%(types.TargetWindow/2) == 0 {
+			stub := &processedBlock{Block: types.Block{ParentID: h.ParentID, Timestamp: h.Timestamp}}
+			adjustment := clampTargetAdjustment(cs.targetAdjustmentBase(overlay, stub))
+			node.ChildTarget = types.RatToTarget(new(big.Rat).Mul(parent.ChildTarget.Rat(), adjustment))
+		}
+	} else {
+		node.ChildTarget = cs.childTargetOak(parent.TotalTime, parent.TotalTarget, parent.ChildTarget, parent.Height, parent.Header.Timestamp)
+	}
+
+	ht.nodes[id] = node
+	if ht.best == nil || node.Depth.Cmp(ht.best.Depth) < 0 {
+		ht.best = node
+	}
+	return node, nil
+}
+
+// managedReceiveHeaders returns the calling end of the SendHeaders RPC. The
+// received headers are validated and added to ht. responded is set once the
+// peer has sent its first batch of headers, which indicates that the peer
+// supports the RPC.
+func (cs *ConsensusSet) managedReceiveHeaders(ht *headerTree, responded *bool) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		err := conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+		if err != nil {
+			return err
+		}
+		finishedChan := make(chan struct{})
+		defer close(finishedChan)
+		go func() {
+			select {
+			case <-cs.tg.StopChan():
+			case <-finishedChan:
+			}
+			conn.Close()
+		}()
+
+		// Send the block ids of the current path.
+		var history [32]types.BlockID
+		cs.mu.RLock()
+		err = cs.db.View(func(tx *bolt.Tx) error {
+			history = blockHistory(tx)
+			return nil
+		})
+		cs.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, history); err != nil {
+			return err
+		}
+
+		moreAvailable := true
+		for moreAvailable {
+			var headers []types.BlockHeader
+			if err := encoding.ReadObject(conn, &headers, uint64(MaxCatchUpHeaders)*types.BlockHeaderSize+8); err != nil {
+				return err
+			}
+			if err := encoding.ReadObject(conn, &moreAvailable, 1); err != nil {
+				return err
+			}
+			*responded = true
+			if uint64(len(headers)) > uint64(MaxCatchUpHeaders) {
+				return errTooManyHeaders
+			}
+
+			// Validate the headers and add them to the tree.
+			cs.mu.RLock()
+			ht.mu.Lock()
+			err = cs.db.View(func(tx *bolt.Tx) error {
+				tip, hasTip := ht.tips[conn.RPCAddr()]
+				for _, h := range headers {
+					if hasTip && h.ParentID != tip {
+						return errUnconnectedHeaders
+					}
+					_, err := cs.addHeader(tx, ht, h)
+					if err != nil && err != modules.ErrBlockKnown {
+						return err
+					}
+					tip, hasTip = h.ID(), err == nil
+				}
+				if hasTip {
+					ht.tips[conn.RPCAddr()] = tip
+				}
+				return nil
+			})
+			ht.mu.Unlock()
+			cs.mu.RUnlock()
+			if err != nil {
+				cs.reportMisbehavior(conn.RPCAddr(), modules.MisbehaviorInvalidHeader, err)
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// rpcSendHeaders is the receiving end of the SendHeaders RPC. Like
+// SendBlocks, it finds the most recent common block from the 32 input block
+// IDs and sends the headers of all following blocks in the current path, in
+// batches of up to 'MaxCatchUpHeaders', each followed by a boolean indicating
+// whether more headers are available.
+func (cs *ConsensusSet) rpcSendHeaders(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	var knownBlocks [32]types.BlockID
+	err = encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize)
+	if err != nil {
+		return err
+	}
+	var start types.BlockHeight
+	found := false
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		start, found = cs.syncStartHeight(tx, knownBlocks)
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if !found {
+		if err := encoding.WriteObject(conn, []types.BlockHeader{}); err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, false)
+	}
+
+	moreAvailable := true
+	for moreAvailable {
+		var headers []types.BlockHeader
+		cs.mu.RLock()
+		err = cs.db.View(func(tx *bolt.Tx) error {
+			height := blockHeight(tx)
+			for i := start; i <= height && i < start+MaxCatchUpHeaders; i++ {
+				id, err := getPath(tx, i)
+				if err != nil {
+					return err
+				}
+				pb, err := getBlockMap(tx, id)
+				if err != nil {
+					return err
+				}
+				headers = append(headers, pb.Block.Header())
+			}
+			moreAvailable = start+MaxCatchUpHeaders <= height
+			start += MaxCatchUpHeaders
+			return nil
+		})
+		cs.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, headers); err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, moreAvailable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// managedReceiveBodies returns the calling end of the SendBodies RPC,
+// which requests the blocks of a batch and stores them in blocks once all of
+// them were received.
+func (cs *ConsensusSet) managedReceiveBodies(batch bodyBatch, blocks *[]types.Block) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		err := conn.SetDeadline(time.Now().Add(sendBodiesTimeout))
+		if err != nil {
+			return err
+		}
+		finishedChan := make(chan struct{})
+		defer close(finishedChan)
+		go func() {
+			select {
+			case <-cs.tg.StopChan():
+			case <-finishedChan:
+			}
+			conn.Close()
+		}()
+
+		if err := encoding.WriteObject(conn, batch.ids); err != nil {
+			return err
+		}
+		var received []types.Block
+		if err := encoding.ReadObject(conn, &received, uint64(MaxCatchUpBlocks)*types.BlockSizeLimit); err != nil {
+			return err
+		}
+		if len(received) != len(batch.ids) {
+			return errMissingBodies
+		}
+		for i, b := range received {
+			if b.ID() != batch.ids[i] {
+				cs.reportMisbehavior(conn.RPCAddr(), modules.MisbehaviorInvalidBlock, errUnrequestedBlock)
+				return errUnrequestedBlock
+			}
+		}
+		*blocks = received
+		return nil
+	}
+}
+
+// rpcSendBodies is the receiving end of the SendBodies RPC. It
+// reads up to 'MaxCatchUpBlocks' block ids and sends the corresponding
+// blocks, stopping at the first block that is not known.
+func (cs *ConsensusSet) rpcSendBodies(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendBodiesTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	var ids []types.BlockID
+	err = encoding.ReadObject(conn, &ids, uint64(MaxCatchUpBlocks)*crypto.HashSize+8)
+	if err != nil {
+		return err
+	}
+	if uint64(len(ids)) > uint64(MaxCatchUpBlocks) {
+		return errTooManyBodies
+	}
+	var blocks []types.Block
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				break
+			}
+			blocks = append(blocks, pb.Block)
+		}
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, blocks)
+}
+
+// managedHeaderSync synchronizes the consensus set with the outbound peers
+// that support header-first synchronization. Peers that do not support it
+// are added to legacy, so that they are only synchronized with SendBlocks.
+func (cs *ConsensusSet) managedHeaderSync(peers []modules.Peer, legacy map[modules.NetAddress]struct{}) error {
+	ht := newHeaderTree()
+	var wg sync.WaitGroup
+	var legacyMu sync.Mutex
+	numPeers := 0
+	for _, p := range peers {
+		if _, isLegacy := legacy[p.NetAddress]; p.Inbound || isLegacy {
+			continue
+		}
+		if numPeers >= headerSyncPeers {
+			break
+		}
+		numPeers++
+		wg.Add(1)
+		go func(addr modules.NetAddress) {
+			defer wg.Done()
+			var responded bool
+			err := cs.gateway.RPC(addr, "SendHeaders", cs.managedReceiveHeaders(ht, &responded))
+			if err != nil && !responded && !isTimeoutErr(err) {
+				// The peer closed the stream without sending any headers,
+				// which is what peers that don't know the RPC do.
+				legacyMu.Lock()
+				legacy[addr] = struct{}{}
+				legacyMu.Unlock()
+			}
+			if err != nil {
+				cs.log.Debugf("WARN: failed to receive headers from peer %v: %v", addr, err)
+			}
+		}(p.NetAddress)
+	}
+	wg.Wait()
+	if ht.best == nil {
+		return nil
+	}
+
+	// Only download the blocks if the heaviest header is heavier than the
+	// current block.
+	var current *processedBlock
+	cs.mu.RLock()
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		current = currentProcessedBlock(tx)
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if ht.best.Depth.Cmp(current.Depth) >= 0 {
+		return nil
+	}
+	cs.mu.Lock()
+	if ht.best.Height > cs.headerHeight {
+		cs.headerHeight = ht.best.Height
+	}
+	cs.mu.Unlock()
+	return cs.managedDownloadBodies(ht)
+}
+
+// managedDownloadBodies downloads the blocks leading to the heaviest header in
+// ht and adds them to the consensus set. The blocks are downloaded in rounds
+// of up to 'headerSyncWindow' batches, and the batches of a round are
+// requested from different peers in parallel.
+func (cs *ConsensusSet) managedDownloadBodies(ht *headerTree) error {
+	ht.mu.Lock()
+	// Collect the path from the heaviest header back to the consensus set.
+	var path []*headerNode
+	for node := ht.best; node != nil; node = ht.nodes[node.Header.ParentID] {
+		path = append(path, node)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	inPath := make(map[types.BlockID]*headerNode, len(path))
+	for _, node := range path {
+		inPath[node.Header.ID()] = node
+	}
+	// Determine up to which height each peer can serve the blocks of the
+	// path. The tip of a peer on a different fork is walked back to the block
+	// where its fork leaves the path.
+	peerHeights := make(map[modules.NetAddress]types.BlockHeight)
+	for addr, tip := range ht.tips {
+		for node := ht.nodes[tip]; node != nil; node = ht.nodes[node.Header.ParentID] {
+			if _, exists := inPath[node.Header.ID()]; exists {
+				peerHeights[addr] = node.Height
+				break
+			}
+		}
+	}
+	ht.mu.Unlock()
+
+	// Split the path into batches.
+	var batches []bodyBatch
+	for i := 0; i < len(path); i += int(MaxCatchUpBlocks) {
+		var batch bodyBatch
+		for j := i; j < len(path) && j < i+int(MaxCatchUpBlocks); j++ {
+			batch.ids = append(batch.ids, path[j].Header.ID())
+			batch.height = path[j].Height
+		}
+		batches = append(batches, batch)
+	}
+
+	defer func() {
+		cs.mu.Lock()
+		cs.bodiesInFlight = 0
+		cs.mu.Unlock()
+	}()
+	for len(batches) > 0 {
+		round := batches
+		if len(round) > headerSyncWindow {
+			round = round[:headerSyncWindow]
+		}
+		batches = batches[len(round):]
+
+		blocks, senders, err := cs.managedDownloadRound(round, peerHeights)
+		if err != nil {
+			return err
+		}
+		// Accept the blocks in order.
+		for i := range blocks {
+			_, err := cs.managedAcceptBlocks(blocks[i])
+			if err != nil && err != modules.ErrNonExtendingBlock && err != modules.ErrBlockKnown {
+				cs.reportMisbehavior(senders[i], modules.MisbehaviorInvalidBlock, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// managedDownloadRound downloads the batches of a round in parallel. Each
+// batch is requested from a peer that can serve it, and batches that fail are
+// requested again from other peers until all batches are downloaded or no
+// peers are left. The blocks of each batch are returned along with the peer
+// that sent them.
+func (cs *ConsensusSet) managedDownloadRound(round []bodyBatch, peerHeights map[modules.NetAddress]types.BlockHeight) ([][]types.Block, []modules.NetAddress, error) {
+	blocks := make([][]types.Block, len(round))
+	senders := make([]modules.NetAddress, len(round))
+	for {
+		var missing []int
+		numBlocks := 0
+		for i := range round {
+			if blocks[i] == nil {
+				missing = append(missing, i)
+				numBlocks += len(round[i].ids)
+			}
+		}
+		if len(missing) == 0 {
+			return blocks, senders, nil
+		}
+		cs.mu.Lock()
+		cs.bodiesInFlight = numBlocks
+		cs.mu.Unlock()
+
+		var wg sync.WaitGroup
+		var failedMu sync.Mutex
+		var failed []modules.NetAddress
+		for n, i := range missing {
+			// Spread the batches across all peers that can serve them.
+			var candidates []modules.NetAddress
+			for addr, height := range peerHeights {
+				if height >= round[i].height {
+					candidates = append(candidates, addr)
+				}
+			}
+			if len(candidates) == 0 {
+				wg.Wait()
+				return nil, nil, errNoBodyPeers
+			}
+			sort.Slice(candidates, func(a, b int) bool { return candidates[a] < candidates[b] })
+			addr := candidates[n%len(candidates)]
+			senders[i] = addr
+
+			wg.Add(1)
+			go func(i int, addr modules.NetAddress) {
+				defer wg.Done()
+				err := cs.gateway.RPC(addr, "SendBodies", cs.managedReceiveBodies(round[i], &blocks[i]))
+				if err != nil {
+					cs.log.Debugf("WARN: failed to download blocks from peer %v: %v", addr, err)
+					failedMu.Lock()
+					failed = append(failed, addr)
+					failedMu.Unlock()
+				}
+			}(i, addr)
+		}
+		wg.Wait()
+
+		// Don't request any more blocks from peers that failed to send them.
+		for _, addr := range failed {
+			delete(peerHeights, addr)
+		}
+	}
+}
+
+// SyncProgress returns the progress of the header-first synchronization.
+func (cs *ConsensusSet) SyncProgress() modules.ConsensusSyncProgress {
+	err := cs.tg.Add()
+	if err != nil {
+		return modules.ConsensusSyncProgress{}
+	}
+	defer cs.tg.Done()
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	var height types.BlockHeight
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		height = blockHeight(tx)
+		return nil
+	})
+	headerHeight := cs.headerHeight
+	if headerHeight < height {
+		headerHeight = height
+	}
+	return modules.ConsensusSyncProgress{
+		HeaderHeight:   headerHeight,
+		BlockHeight:    height,
+		BodiesInFlight: cs.bodiesInFlight,
+	}
+}
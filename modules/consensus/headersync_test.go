@@ -0,0 +1,151 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// TestAddHeader checks that the headers of a chain can be validated without
+// their blocks, and that the child targets computed from the headers match the
+// child targets of the consensus set.
+func TestAddHeader(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	// Mine past the oak hardfork, so that both difficulty adjustment
+	// algorithms are used.
+	for cst.cs.Height() <= types.OakHardforkBlock+5 {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fresh, err := blankConsensusSetTester(t.Name()+"-fresh", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+
+	ht := newHeaderTree()
+	err = fresh.cs.db.View(func(tx *bolt.Tx) error {
+		for height := types.BlockHeight(1); height <= cst.cs.Height(); height++ {
+			b, _ := cst.cs.BlockAtHeight(height)
+			node, err := fresh.cs.addHeader(tx, ht, b.Header())
+			if err != nil {
+				t.Fatalf("header at height %v rejected: %v", height, err)
+			}
+			target, _ := cst.cs.ChildTarget(b.ID())
+			if node.Height != height || node.ChildTarget != target {
+				t.Fatalf("header at height %v has the wrong height or child target", height)
+			}
+		}
+
+		// Headers of known blocks, orphans and headers that don't meet the
+		// target are rejected.
+		if _, err := fresh.cs.addHeader(tx, ht, types.GenesisBlock.Header()); err != modules.ErrBlockKnown {
+			t.Fatalf("expected %v, got %v", modules.ErrBlockKnown, err)
+		}
+		if _, err := fresh.cs.addHeader(tx, ht, types.BlockHeader{ParentID: types.BlockID{1}}); err != errOrphan {
+			t.Fatalf("expected %v, got %v", errOrphan, err)
+		}
+		unsolved := types.BlockHeader{ParentID: cst.cs.CurrentBlock().ID(), Timestamp: types.CurrentTimestamp()}
+		target, _ := cst.cs.ChildTarget(unsolved.ParentID)
+		for checkHeaderTarget(unsolved, target) {
+			unsolved.Nonce[0]++
+		}
+		if _, err := fresh.cs.addHeader(tx, ht, unsolved); err != modules.ErrBlockUnsolved {
+			t.Fatalf("expected %v, got %v", modules.ErrBlockUnsolved, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.best == nil || ht.best.Header.ID() != cst.cs.CurrentBlock().ID() {
+		t.Fatal("the heaviest header is not the current block")
+	}
+	if fresh.cs.Height() != 0 {
+		t.Fatal("adding headers modified the consensus set")
+	}
+}
+
+// TestHeaderSync checks that the consensus set can synchronize with several
+// peers using header-first synchronization, and that it falls back to
+// SendBlocks for peers that don't support it.
+func TestHeaderSync(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	remote1, err := createConsensusSetTester(t.Name() + "-remote1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote1.Close()
+	remote2, err := blankConsensusSetTester(t.Name()+"-remote2", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote2.Close()
+	local, err := blankConsensusSetTester(t.Name()+"-local", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+
+	// Give remote2 the chain of remote1, so that the blocks can be
+	// downloaded from both peers.
+	for height := types.BlockHeight(1); height <= remote1.cs.Height(); height++ {
+		b, _ := remote1.cs.BlockAtHeight(height)
+		if err := remote2.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Connect without triggering SendBlocks.
+	local.gateway.UnregisterConnectCall("SendBlocks")
+	if err := local.gateway.Connect(remote1.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.gateway.Connect(remote2.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	local.gateway.RegisterConnectCall("SendBlocks", local.cs.threadedReceiveBlocks)
+
+	legacy := make(map[modules.NetAddress]struct{})
+	if err := local.cs.managedHeaderSync(local.gateway.Peers(), legacy); err != nil {
+		t.Fatal(err)
+	}
+	if len(legacy) != 0 {
+		t.Fatal("peers were marked as legacy:", legacy)
+	}
+	if local.cs.dbCurrentBlockID() != remote1.cs.dbCurrentBlockID() {
+		t.Fatal("header-first synchronization failed")
+	}
+	progress := local.cs.SyncProgress()
+	if progress.HeaderHeight != remote1.cs.Height() || progress.BlockHeight != remote1.cs.Height() || progress.BodiesInFlight != 0 {
+		t.Fatalf("unexpected sync progress: %+v", progress)
+	}
+
+	// Peers that don't support the RPCs are marked as legacy.
+	remote1.gateway.UnregisterRPC("SendHeaders")
+	if _, err := remote1.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.cs.managedHeaderSync(local.gateway.Peers(), legacy); err != nil {
+		t.Fatal(err)
+	}
+	if _, isLegacy := legacy[remote1.gateway.Address()]; !isLegacy || len(legacy) != 1 {
+		t.Fatal("peer without SendHeaders was not marked as legacy:", legacy)
+	}
+	remote1.gateway.RegisterRPC("SendHeaders", remote1.cs.rpcSendHeaders)
+}
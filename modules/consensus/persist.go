@@ -56,6 +56,8 @@ func (cs *ConsensusSet) loadDB() error {
 		if genesisID != cs.blockRoot.Block.ID() {
 			return errors.New("Blockchain has wrong genesis block, exiting.")
 		}
+		cs.snapshotHeight = snapshotHeight(tx)
+		cs.prunedHeight = prunedHeight(tx)
 		return nil
 	})
 }
@@ -58,7 +58,7 @@ func (pb *processedBlock) childDepth() types.Target {
 
 // targetAdjustmentBase returns the magnitude that the target should be
 // adjusted by before a clamp is applied.
-func (cs *ConsensusSet) targetAdjustmentBase(blockMap *bolt.Bucket, pb *processedBlock) *big.Rat {
+func (cs *ConsensusSet) targetAdjustmentBase(blockMap dbBucket, pb *processedBlock) *big.Rat {
 	// Grab the block that was generated 'TargetWindow' blocks prior to the
 	// parent. If there are not 'TargetWindow' blocks yet, stop at the genesis
 	// block.
@@ -0,0 +1,262 @@
+package consensus
+
+// prune.go implements the pruned mode of the consensus set. A pruned consensus
+// set only keeps the processed blocks, including their diffs, of the most recent
+// 'pruneDepth' blocks. Older blocks are only kept by their headers, in the same
+// bucket that holds the headers below an imported snapshot.
+//
+// The change log is not pruned, so subscribers that resume from a change that
+// only references recent blocks continue to work. Subscribers that need the
+// diffs of a pruned block receive modules.ErrConsensusChangePruned, and the
+// consensus set refuses to reorganize the chain below the pruned blocks.
+
+import (
+	"errors"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// Pruning is a database bucket that contains information about the
+	// blocks that were pruned from the consensus set. It only exists if the
+	// consensus set was ever pruned.
+	Pruning = []byte("Pruning")
+
+	// FieldPrunedHeight is a field in the Pruning bucket that contains the
+	// height of the first block that was not pruned.
+	FieldPrunedHeight = []byte("PrunedHeight")
+)
+
+var (
+	errPruneDepth = errors.New("prune depth is below the minimum prune depth")
+
+	// MinPruneDepth is the minimum number of recent blocks that a pruned
+	// consensus set keeps.
+	MinPruneDepth = build.Select(build.Var{
+		Standard: types.BlockHeight(1008),
+		Dev:      types.BlockHeight(144),
+		Testing:  types.BlockHeight(20),
+	}).(types.BlockHeight)
+
+	// pruneBatchSize is the maximum number of blocks that are pruned in a
+	// single database transaction.
+	pruneBatchSize = build.Select(build.Var{
+		Standard: types.BlockHeight(1000),
+		Dev:      types.BlockHeight(100),
+		Testing:  types.BlockHeight(5),
+	}).(types.BlockHeight)
+)
+
+// prunedHeight returns the height of the first block that was not pruned from
+// the consensus set, or 0 if no blocks were pruned.
+func prunedHeight(tx *bolt.Tx) (height types.BlockHeight) {
+	b := tx.Bucket(Pruning)
+	if b == nil {
+		return 0
+	}
+	err := encoding.Unmarshal(b.Get(FieldPrunedHeight), &height)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return height
+}
+
+// reorgFloor returns the minimum height of the parent of a new block. The
+// blocks below the floor were either imported as part of a snapshot or
+// pruned, and the timestamps of MedianTimestampWindow ancestors are required
+// to validate a child.
+func (cs *ConsensusSet) reorgFloor() types.BlockHeight {
+	floor := cs.snapshotHeight
+	if cs.prunedHeight != 0 {
+		if prunedFloor := cs.prunedHeight + types.BlockHeight(types.MedianTimestampWindow) - 1; prunedFloor > floor {
+			floor = prunedFloor
+		}
+	}
+	return floor
+}
+
+// changeEntryPruned returns true if the change entry references a block that
+// was pruned from the consensus set, or if its most recent block is too close
+// to the pruned blocks to compute its minimum valid child timestamp.
+func changeEntryPruned(tx *bolt.Tx, ce changeEntry) bool {
+	blockMap := tx.Bucket(BlockMap)
+	for _, id := range ce.RevertedBlocks {
+		if blockMap.Get(id[:]) == nil {
+			return true
+		}
+	}
+	for _, id := range ce.AppliedBlocks {
+		if blockMap.Get(id[:]) == nil {
+			return true
+		}
+	}
+	recentBlock := ce.AppliedBlocks[len(ce.AppliedBlocks)-1]
+	return !timestampWindowKnown(tx, recentBlock)
+}
+
+// timestampWindowKnown returns true if the timestamps of the
+// MedianTimestampWindow most recent ancestors of a block, including the block
+// itself, have not been pruned.
+func timestampWindowKnown(tx *bolt.Tx, id types.BlockID) bool {
+	pb, err := getBlockMap(tx, id)
+	if err != nil {
+		return false
+	}
+	pruned := prunedHeight(tx)
+	return pruned == 0 || pb.Height+1 >= pruned+types.BlockHeight(types.MedianTimestampWindow)
+}
+
+// pruneBlocks removes the processed blocks that are more than 'pruneDepth'
+// blocks below the current block from the database, keeping their headers. At
+// most 'pruneBatchSize' blocks are pruned. The new pruned height is returned,
+// along with a bool indicating whether all blocks that should be pruned have
+// been pruned.
+func (cs *ConsensusSet) pruneBlocks(tx *bolt.Tx) (types.BlockHeight, bool, error) {
+	start := prunedHeight(tx)
+	if start == 0 {
+		// The genesis block is never pruned.
+		start = 1
+	}
+	// Blocks are only pruned above the oak hardfork, so that the children of
+	// the remaining blocks never need to look back 'TargetWindow' blocks to
+	// compute their target.
+	height := blockHeight(tx)
+	if cs.pruneDepth == 0 || height < cs.pruneDepth+types.OakHardforkBlock {
+		return start, true, nil
+	}
+	target := height - cs.pruneDepth
+	if start >= target {
+		return start, true, nil
+	}
+	end := target
+	if end > start+pruneBatchSize {
+		end = start + pruneBatchSize
+	}
+
+	headers, err := tx.CreateBucketIfNotExists(SnapshotHeaders)
+	if err != nil {
+		return 0, false, err
+	}
+	for i := start; i < end; i++ {
+		id, err := getPath(tx, i)
+		if err != nil {
+			return 0, false, err
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			// The block is only known by its header already, e.g. because
+			// it is below an imported snapshot.
+			continue
+		}
+		if err := headers.Put(encoding.Marshal(i), encoding.Marshal(pb.Block.Header())); err != nil {
+			return 0, false, err
+		}
+		if err := tx.Bucket(BlockMap).Delete(id[:]); err != nil {
+			return 0, false, err
+		}
+		if err := tx.Bucket(BucketOak).Delete(id[:]); err != nil {
+			return 0, false, err
+		}
+	}
+
+	b, err := tx.CreateBucketIfNotExists(Pruning)
+	if err != nil {
+		return 0, false, err
+	}
+	if err := b.Put(FieldPrunedHeight, encoding.Marshal(end)); err != nil {
+		return 0, false, err
+	}
+	return end, end == target, nil
+}
+
+// managedPrune prunes the blocks that are more than 'pruneDepth' blocks below
+// the current block, in batches of 'pruneBatchSize' blocks.
+func (cs *ConsensusSet) managedPrune() error {
+	for {
+		cs.mu.Lock()
+		var height types.BlockHeight
+		var done bool
+		err := cs.db.Update(func(tx *bolt.Tx) error {
+			var err error
+			height, done, err = cs.pruneBlocks(tx)
+			return err
+		})
+		if err == nil && height > cs.prunedHeight {
+			cs.prunedHeight = height
+		}
+		cs.mu.Unlock()
+		if err != nil || done {
+			return err
+		}
+
+		select {
+		case <-cs.tg.StopChan():
+			return nil
+		default:
+		}
+	}
+}
+
+// threadedPrune prunes the consensus set in the background.
+func (cs *ConsensusSet) threadedPrune() {
+	if err := cs.tg.Add(); err != nil {
+		return
+	}
+	defer cs.tg.Done()
+	if err := cs.managedPrune(); err != nil {
+		cs.log.Println("WARN: unable to prune the consensus set:", err)
+	}
+}
+
+// SetPruneDepth enables the pruned mode of the consensus set. Only the most
+// recent 'depth' blocks are kept in full, older blocks are pruned in the
+// background. Pruned blocks cannot be restored, so disabling pruning by setting
+// a depth of 0 only stops the consensus set from pruning more blocks.
+func (cs *ConsensusSet) SetPruneDepth(depth types.BlockHeight) error {
+	if err := cs.tg.Add(); err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+	if depth != 0 && depth < MinPruneDepth {
+		return errPruneDepth
+	}
+	cs.mu.Lock()
+	cs.pruneDepth = depth
+	cs.mu.Unlock()
+	if depth != 0 {
+		go cs.threadedPrune()
+	}
+	return nil
+}
+
+// PruneStatus returns the prune depth, the height of the first block that was
+// not pruned, and the number of bytes of the consensus database that are in
+// use. bolt never shrinks the database file; the pages of pruned blocks are
+// only reused for new blocks. Pruning therefore caps the growth of the file,
+// and the in-use size is reported instead of the size of the file.
+func (cs *ConsensusSet) PruneStatus() modules.ConsensusPruneStatus {
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusPruneStatus{}
+	}
+	defer cs.tg.Done()
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	status := modules.ConsensusPruneStatus{
+		PruneDepth:   cs.pruneDepth,
+		PrunedHeight: cs.prunedHeight,
+	}
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		size, free := tx.Size(), int64(cs.db.Stats().FreeAlloc)
+		if free < size {
+			status.DiskUsage = uint64(size - free)
+		}
+		return nil
+	})
+	return status
+}
@@ -0,0 +1,130 @@
+package consensus
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/modules/gateway"
+	"github.com/acejam/Sia/types"
+)
+
+// TestPruneBlocks checks that a pruned consensus set discards old blocks,
+// keeps working, and rejects subscriptions that need pruned blocks.
+func TestPruneBlocks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	for cst.cs.Height() < types.OakHardforkBlock+MinPruneDepth+2*pruneBatchSize {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Remember a recent consensus change.
+	ms := newMockSubscriber()
+	if err := cst.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, nil); err != nil {
+		t.Fatal(err)
+	}
+	cst.cs.Unsubscribe(&ms)
+	recentChange := ms.updates[len(ms.updates)-3].ID
+
+	if err := cst.cs.SetPruneDepth(MinPruneDepth - 1); err != errPruneDepth {
+		t.Fatalf("expected %v, got %v", errPruneDepth, err)
+	}
+	checksum := cst.cs.dbConsensusChecksum()
+	cst.cs.mu.Lock()
+	cst.cs.pruneDepth = MinPruneDepth
+	cst.cs.mu.Unlock()
+	if err := cst.cs.managedPrune(); err != nil {
+		t.Fatal(err)
+	}
+	height := cst.cs.Height()
+	status := cst.cs.PruneStatus()
+	if status.PruneDepth != MinPruneDepth || status.PrunedHeight != height-MinPruneDepth || status.DiskUsage == 0 {
+		t.Fatalf("unexpected prune status: %+v", status)
+	}
+	if fi, err := os.Stat(cst.cs.db.Path()); err != nil {
+		t.Fatal(err)
+	} else if status.DiskUsage > uint64(fi.Size()) {
+		t.Fatalf("disk usage %v exceeds the size of the database file %v", status.DiskUsage, fi.Size())
+	}
+	if _, exists := cst.cs.BlockAtHeight(status.PrunedHeight - 1); exists {
+		t.Fatal("pruned block is still available")
+	}
+	if _, exists := cst.cs.BlockAtHeight(status.PrunedHeight); !exists {
+		t.Fatal("block above the pruned height is not available")
+	}
+	if cst.cs.dbConsensusChecksum() != checksum {
+		t.Fatal("pruning changed the consensus checksum")
+	}
+
+	// Subscriptions that need pruned blocks are rejected, recent ones are
+	// not.
+	ms = newMockSubscriber()
+	if err := cst.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, nil); err != modules.ErrConsensusChangePruned {
+		t.Fatalf("expected %v, got %v", modules.ErrConsensusChangePruned, err)
+	}
+	if len(ms.updates) != 0 {
+		t.Fatal("subscriber received changes before being rejected")
+	}
+	if err := cst.cs.ConsensusSetSubscribe(&ms, recentChange, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(ms.updates) != 2 {
+		t.Fatalf("expected 2 changes, got %v", len(ms.updates))
+	}
+	cst.cs.Unsubscribe(&ms)
+
+	// Blocks on pruned parents are rejected.
+	parent, _ := cst.cs.BlockAtHeight(status.PrunedHeight)
+	orphan := types.Block{ParentID: parent.ID(), Timestamp: types.CurrentTimestamp()}
+	if err := cst.cs.AcceptBlock(orphan); err != errPrunedParent {
+		t.Fatalf("expected %v, got %v", errPrunedParent, err)
+	}
+
+	// New blocks are accepted and pruned in the background.
+	for i := types.BlockHeight(0); i < pruneBatchSize; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 50 && cst.cs.PruneStatus().PrunedHeight != status.PrunedHeight+pruneBatchSize; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if prunedHeight := cst.cs.PruneStatus().PrunedHeight; prunedHeight != status.PrunedHeight+pruneBatchSize {
+		t.Fatal("blocks were not pruned in the background:", prunedHeight)
+	}
+
+	// A snapshot exported from the pruned consensus set can be imported into
+	// a consensus set without subscribers.
+	var buf bytes.Buffer
+	snap, err := cst.cs.ExportSnapshot(&buf, cst.cs.Height())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-fresh")
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	fresh, err := New(g, false, filepath.Join(testdir, modules.ConsensusDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+	if _, err := fresh.ImportSnapshot(&buf, snap.BlockID, snap.Checksum); err != nil {
+		t.Fatal(err)
+	}
+}
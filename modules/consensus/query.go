@@ -0,0 +1,112 @@
+package consensus
+
+import (
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// SiacoinOutput returns the unspent siacoin output with the given id, with a
+// bool to indicate whether the output exists.
+func (cs *ConsensusSet) SiacoinOutput(id types.SiacoinOutputID) (sco types.SiacoinOutput, exists bool) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.SiacoinOutput{}, false
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		sco, err = getSiacoinOutput(tx, id)
+		exists = err == nil
+		return nil
+	})
+	return sco, exists
+}
+
+// SiafundOutput returns the unspent siafund output with the given id, with a
+// bool to indicate whether the output exists.
+func (cs *ConsensusSet) SiafundOutput(id types.SiafundOutputID) (sfo types.SiafundOutput, exists bool) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.SiafundOutput{}, false
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		sfo, err = getSiafundOutput(tx, id)
+		exists = err == nil
+		return nil
+	})
+	return sfo, exists
+}
+
+// FileContract returns the most recent revision of the open file contract with
+// the given id, with a bool to indicate whether the contract exists.
+func (cs *ConsensusSet) FileContract(id types.FileContractID) (fc types.FileContract, exists bool) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.FileContract{}, false
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		fc, err = getFileContract(tx, id)
+		exists = err == nil
+		return nil
+	})
+	return fc, exists
+}
+
+// SiafundPool returns the current value of the siafund pool.
+func (cs *ConsensusSet) SiafundPool() (pool types.Currency) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return types.ZeroCurrency
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		pool = getSiafundPool(tx)
+		return nil
+	})
+	return pool
+}
+
+// DelayedSiacoinOutputs returns the delayed siacoin outputs that mature at the
+// given height, with a bool to indicate whether the consensus set tracks
+// delayed outputs at that height. Only the heights following the current
+// height, up to the maturity delay, are tracked.
+func (cs *ConsensusSet) DelayedSiacoinOutputs(height types.BlockHeight) (dscos map[types.SiacoinOutputID]types.SiacoinOutput, exists bool) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return nil, false
+	}
+	defer cs.tg.Done()
+
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(append(prefixDSCO, encoding.Marshal(height)...))
+		if bucket == nil {
+			return nil
+		}
+		exists = true
+		dscos = make(map[types.SiacoinOutputID]types.SiacoinOutput)
+		return bucket.ForEach(func(k, v []byte) error {
+			var id types.SiacoinOutputID
+			var sco types.SiacoinOutput
+			copy(id[:], k)
+			if err := encoding.Unmarshal(v, &sco); err != nil {
+				return err
+			}
+			dscos[id] = sco
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, false
+	}
+	return dscos, exists
+}
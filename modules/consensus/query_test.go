@@ -0,0 +1,109 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// TestQueryConsensusState probes the methods that query the outputs, file
+// contracts and siafund pool of the consensus set.
+func TestQueryConsensusState(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// The miner payout of the current block is delayed until it matures.
+	cb := cst.cs.CurrentBlock()
+	payoutID := cb.MinerPayoutID(0)
+	maturityHeight := cst.cs.Height() + types.MaturityDelay
+	dscos, exists := cst.cs.DelayedSiacoinOutputs(maturityHeight)
+	if !exists {
+		t.Fatal("delayed outputs at the maturity height are not tracked")
+	}
+	if sco, ok := dscos[payoutID]; !ok || !sco.Value.Equals(cb.MinerPayouts[0].Value) {
+		t.Fatal("miner payout is not a delayed output")
+	}
+	if _, exists := cst.cs.DelayedSiacoinOutputs(maturityHeight + 1); exists {
+		t.Fatal("delayed outputs above the maturity delay are tracked")
+	}
+	if _, exists := cst.cs.SiacoinOutput(payoutID); exists {
+		t.Fatal("delayed output is already a siacoin output")
+	}
+	for cst.cs.Height() < maturityHeight {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sco, exists := cst.cs.SiacoinOutput(payoutID); !exists || sco.UnlockHash != cb.MinerPayouts[0].UnlockHash {
+		t.Fatal("matured miner payout is not a siacoin output")
+	}
+
+	// Query an unspent siafund output and the siafund pool.
+	var sfoid types.SiafundOutputID
+	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(SiafundOutputs).Cursor().First()
+		copy(sfoid[:], k)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sfo, exists := cst.cs.SiafundOutput(sfoid); !exists || sfo.Value.IsZero() {
+		t.Fatal("unspent siafund output was not found")
+	}
+	if _, exists := cst.cs.SiafundOutput(types.SiafundOutputID{1}); exists {
+		t.Fatal("unknown siafund output was found")
+	}
+	if !cst.cs.SiafundPool().Equals(cst.cs.dbGetSiafundPool()) {
+		t.Fatal("wrong siafund pool")
+	}
+
+	// Create a file contract and query it.
+	payout := types.NewCurrency64(400e6)
+	fc := types.FileContract{
+		FileSize:    4e3,
+		WindowStart: cst.cs.Height() + 3,
+		WindowEnd:   cst.cs.Height() + 4,
+		Payout:      payout,
+		ValidProofOutputs: []types.SiacoinOutput{{
+			Value: types.PostTax(cst.cs.Height(), payout),
+		}},
+		MissedProofOutputs: []types.SiacoinOutput{{
+			Value: types.PostTax(cst.cs.Height(), payout),
+		}},
+	}
+	txnBuilder, err := cst.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txnBuilder.FundSiacoins(payout); err != nil {
+		t.Fatal(err)
+	}
+	fcIndex := txnBuilder.AddFileContract(fc)
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cst.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	fcid := txnSet[len(txnSet)-1].FileContractID(fcIndex)
+	if stored, exists := cst.cs.FileContract(fcid); !exists || stored.WindowStart != fc.WindowStart {
+		t.Fatal("file contract was not found")
+	}
+	if _, exists := cst.cs.FileContract(types.FileContractID{1}); exists {
+		t.Fatal("unknown file contract was found")
+	}
+}
@@ -0,0 +1,124 @@
+package consensus
+
+// reorg.go records the reorganizations of the blockchain in a bounded log in
+// the consensus database. Every time that addBlockToTree reverts blocks, an
+// event with the depth of the reorg, the old and the new tip and the reverted
+// transactions is appended, and the oldest event is dropped once the log holds
+// more than maxReorgEvents events.
+
+import (
+	"encoding/binary"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// ReorgLog is a database bucket that contains the most recent reorg
+	// events, keyed by their big-endian ID. It is created by the first reorg.
+	ReorgLog = []byte("ReorgLog")
+
+	// maxReorgEvents is the number of reorg events that are kept in the
+	// reorg log.
+	maxReorgEvents = build.Select(build.Var{
+		Standard: uint64(1000),
+		Dev:      uint64(100),
+		Testing:  uint64(5),
+	}).(uint64)
+)
+
+// reorgEvent creates the reorg event of a fork that reverted and applied the
+// given blocks.
+func reorgEvent(revertedBlocks, appliedBlocks []*processedBlock) modules.ReorgEvent {
+	reapplied := make(map[types.TransactionID]struct{})
+	for _, pb := range appliedBlocks {
+		for _, txn := range pb.Block.Transactions {
+			reapplied[txn.ID()] = struct{}{}
+		}
+	}
+	ev := modules.ReorgEvent{
+		Timestamp:  types.CurrentTimestamp(),
+		Depth:      types.BlockHeight(len(revertedBlocks)),
+		ForkHeight: revertedBlocks[len(revertedBlocks)-1].Height - 1,
+		OldTip:     revertedBlocks[0].Block.ID(),
+		NewTip:     appliedBlocks[len(appliedBlocks)-1].Block.ID(),
+	}
+	for _, pb := range revertedBlocks {
+		for _, txn := range pb.Block.Transactions {
+			if _, exists := reapplied[txn.ID()]; !exists {
+				ev.RevertedTransactions = append(ev.RevertedTransactions, txn.ID())
+			}
+		}
+	}
+	return ev
+}
+
+// appendReorgLog adds a reorg event to the reorg log, dropping the oldest
+// event if the log is full. The ID of the event is set by appendReorgLog.
+func appendReorgLog(tx *bolt.Tx, ev modules.ReorgEvent) (modules.ReorgEvent, error) {
+	b, err := tx.CreateBucketIfNotExists(ReorgLog)
+	if err != nil {
+		return modules.ReorgEvent{}, err
+	}
+	ev.ID, err = b.NextSequence()
+	if err != nil {
+		return modules.ReorgEvent{}, err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, ev.ID)
+	if err := b.Put(key, encoding.Marshal(ev)); err != nil {
+		return modules.ReorgEvent{}, err
+	}
+	if ev.ID > maxReorgEvents {
+		binary.BigEndian.PutUint64(key, ev.ID-maxReorgEvents)
+		if err := b.Delete(key); err != nil {
+			return modules.ReorgEvent{}, err
+		}
+	}
+	return ev, nil
+}
+
+// recordReorg adds the reorg event of a fork to the reorg log and warns about
+// it in the log file.
+func (cs *ConsensusSet) recordReorg(tx *bolt.Tx, revertedBlocks, appliedBlocks []*processedBlock) error {
+	ev, err := appendReorgLog(tx, reorgEvent(revertedBlocks, appliedBlocks))
+	if err != nil {
+		return err
+	}
+	cs.log.Printf("WARN: reorg of depth %v at height %v from %v to %v reverted %v transactions", ev.Depth, ev.ForkHeight, ev.OldTip, ev.NewTip, len(ev.RevertedTransactions))
+	return nil
+}
+
+// Reorgs returns the most recent reorganizations of the blockchain, oldest
+// first.
+func (cs *ConsensusSet) Reorgs() (events []modules.ReorgEvent) {
+	// A call to a closed database can cause undefined behavior.
+	if err := cs.tg.Add(); err != nil {
+		return nil
+	}
+	defer cs.tg.Done()
+
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(ReorgLog)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var ev modules.ReorgEvent
+			if err := encoding.Unmarshal(v, &ev); err != nil {
+				return err
+			}
+			events = append(events, ev)
+			return nil
+		})
+	})
+	if err != nil {
+		cs.log.Println("ERROR: failed to read the reorg log:", err)
+		return nil
+	}
+	return events
+}
@@ -0,0 +1,97 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// onPath returns true if the block with the given id is on the current path
+// of the consensus set.
+func (cst *consensusSetTester) onPath(id types.BlockID) bool {
+	pb, err := cst.cs.dbGetBlockMap(id)
+	if err != nil {
+		return false
+	}
+	pathID, err := cst.cs.dbGetPath(pb.Height)
+	return err == nil && pathID == id
+}
+
+// TestIntegrationReorgLog checks that the reorgs of a consensus set are
+// recorded in the reorg log.
+func TestIntegrationReorgLog(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rs := createReorgSets(t.Name())
+	defer rs.Close()
+
+	if len(rs.cstMain.cs.Reorgs()) != 0 {
+		t.Fatal("reorg log is not empty before the first reorg")
+	}
+
+	// Give a siacoin block to cstMain, reorg it out of the consensus set and
+	// back in again.
+	rs.cstMain.testSpendSiacoinsBlock()
+	oldTip := rs.cstMain.cs.CurrentBlock().ID()
+	rs.save()
+	rs.extend()
+
+	reorgs := rs.cstMain.cs.Reorgs()
+	if len(reorgs) != 1 {
+		t.Fatal("wrong number of reorgs after extending:", len(reorgs))
+	}
+	ev := reorgs[0]
+	if ev.ID != 1 || ev.OldTip != oldTip || !rs.cstMain.onPath(ev.NewTip) {
+		t.Fatal("reorg event has the wrong id or tips:", ev)
+	}
+	if ev.ForkHeight != 0 || ev.Depth != rs.cstBackup.cs.Height() {
+		t.Fatal("reorg event has the wrong height or depth:", ev)
+	}
+	if len(ev.RevertedTransactions) == 0 {
+		t.Fatal("reorg event has no reverted transactions")
+	}
+
+	rs.restore()
+	reorgs = rs.cstMain.cs.Reorgs()
+	if len(reorgs) != 2 || reorgs[1].ID != 2 || !rs.cstMain.onPath(reorgs[1].NewTip) {
+		t.Fatal("second reorg was not recorded correctly:", reorgs)
+	}
+}
+
+// TestAppendReorgLog checks that appendReorgLog drops the oldest events once
+// the reorg log is full.
+func TestAppendReorgLog(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		for i := uint64(0); i < maxReorgEvents+2; i++ {
+			if _, err := appendReorgLog(tx, modules.ReorgEvent{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reorgs := cst.cs.Reorgs()
+	if uint64(len(reorgs)) != maxReorgEvents {
+		t.Fatal("wrong number of events in the reorg log:", len(reorgs))
+	}
+	if reorgs[0].ID != 3 || reorgs[len(reorgs)-1].ID != maxReorgEvents+2 {
+		t.Fatal("reorg log dropped the wrong events:", reorgs[0].ID, reorgs[len(reorgs)-1].ID)
+	}
+}
@@ -0,0 +1,688 @@
+package consensus
+
+// snapshot.go implements the export and import of consensus snapshots, which
+// allow a new node to skip downloading and validating the blockchain up to the
+// height of the snapshot.
+//
+// A snapshot is a stream of length-prefixed objects: a snapshotHeader, the
+// headers of all blocks between the genesis block and the tail of the
+// snapshot, the blocks of the tail in full, and finally the contents of all
+// buckets that make up the consensus state, terminated by an empty
+// snapshotEntry. The tail contains the last MedianTimestampWindow blocks, which
+// are required to validate the children of the snapshot block.
+//
+// An imported snapshot is presented to subscribers as a single consensus
+// change. The diffs of the change transform the genesis state into the state
+// of the snapshot. Blocks below the tail are only known by their headers, and
+// are presented to subscribers as blocks without payouts or transactions.
+// Because the full blocks are not known, the consensus set refuses to
+// reorganize the chain below the snapshot height.
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+const (
+	// snapshotVersion is the version of the snapshot format.
+	snapshotVersion = "1.1"
+
+	// snapshotHeaderMaxLen is the maximum length of an encoded
+	// snapshotHeader.
+	snapshotHeaderMaxLen = 1 << 10
+
+	// snapshotEntryMaxLen is the maximum length of an encoded snapshotEntry.
+	snapshotEntryMaxLen = 1 << 20
+)
+
+var (
+	// Snapshot is a database bucket that contains information about the
+	// snapshot that the consensus set was bootstrapped from. It only exists
+	// if a snapshot was imported.
+	Snapshot = []byte("Snapshot")
+
+	// SnapshotHeaders is a database bucket that maps the heights of the
+	// blocks below the tail of an imported snapshot to their headers.
+	SnapshotHeaders = []byte("SnapshotHeaders")
+
+	// FieldSnapshotHeight is a field in the Snapshot bucket that contains the
+	// height of the imported snapshot.
+	FieldSnapshotHeight = []byte("SnapshotHeight")
+)
+
+var (
+	errPrunedParent     = errors.New("block builds on a block below the consensus snapshot or on a pruned block")
+	errSnapshotBlockID  = errors.New("snapshot does not end in the expected block")
+	errSnapshotChecksum = errors.New("snapshot checksum does not match the expected checksum")
+	errSnapshotHeight   = errors.New("snapshot height must be above the height of the imported snapshot and the pruned blocks, and at most the current height")
+	errSnapshotInvalid  = errors.New("snapshot is malformed")
+	errSnapshotNotFresh = errors.New("a snapshot can only be imported into a consensus set that only contains the genesis block")
+	errSnapshotRollback = errors.New("snapshot written, rolling back the database transaction")
+	errSnapshotVersion  = errors.New("snapshot has an unsupported version")
+)
+
+type (
+	// snapshotHeader is the first object of a snapshot.
+	snapshotHeader struct {
+		Version  string
+		Height   types.BlockHeight
+		BlockID  types.BlockID
+		Checksum crypto.Hash
+	}
+
+	// snapshotBlock is a block of the tail of a snapshot, together with the
+	// fields of its processedBlock and its oak totals.
+	snapshotBlock struct {
+		Block       types.Block
+		Depth       types.Target
+		ChildTarget types.Target
+		TotalTime   int64
+		TotalTarget types.Target
+	}
+
+	// snapshotBlockFields are the fields of a snapshotBlock that are not
+	// covered by the id of its block. They are covered by the checksum of
+	// the snapshot instead.
+	snapshotBlockFields struct {
+		ID          types.BlockID
+		Depth       types.Target
+		ChildTarget types.Target
+		TotalTime   int64
+		TotalTarget types.Target
+	}
+
+	// snapshotEntry is a key/value pair of one of the consensus buckets. An
+	// entry with an empty key marks the start of a bucket, an entry with an
+	// empty bucket marks the end of the snapshot.
+	snapshotEntry struct {
+		Bucket []byte
+		Key    []byte
+		Value  []byte
+	}
+)
+
+// isSnapshotBucket returns true if the bucket with the given name is part of
+// the consensus state that is contained in a snapshot.
+func isSnapshotBucket(name []byte) bool {
+	return bytes.Equal(name, SiacoinOutputs) || bytes.Equal(name, FileContracts) ||
+		bytes.Equal(name, SiafundOutputs) || bytes.Equal(name, SiafundPool) ||
+		bytes.HasPrefix(name, prefixDSCO) || bytes.HasPrefix(name, prefixFCEX)
+}
+
+// snapshotHeight returns the height of the snapshot that the consensus set was
+// bootstrapped from, or 0 if no snapshot was imported.
+func snapshotHeight(tx *bolt.Tx) (height types.BlockHeight) {
+	b := tx.Bucket(Snapshot)
+	if b == nil {
+		return 0
+	}
+	err := encoding.Unmarshal(b.Get(FieldSnapshotHeight), &height)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return height
+}
+
+// fields returns the fields of sb that are not covered by the id of its block.
+func (sb snapshotBlock) fields() snapshotBlockFields {
+	return snapshotBlockFields{
+		ID:          sb.Block.ID(),
+		Depth:       sb.Depth,
+		ChildTarget: sb.ChildTarget,
+		TotalTime:   sb.TotalTime,
+		TotalTarget: sb.TotalTarget,
+	}
+}
+
+// snapshotChecksum returns the checksum of a snapshot with the given consensus
+// checksum and tail. The targets and totals of the tail are not covered by the
+// block ids, so they are hashed together with the state.
+func snapshotChecksum(stateChecksum crypto.Hash, tail []snapshotBlockFields) crypto.Hash {
+	return crypto.HashAll(stateChecksum, tail)
+}
+
+// snapshotTailStart returns the height of the first block of the tail of a
+// snapshot at the given height.
+func snapshotTailStart(height types.BlockHeight) types.BlockHeight {
+	if height < types.BlockHeight(types.MedianTimestampWindow) {
+		return 1
+	}
+	return height - types.BlockHeight(types.MedianTimestampWindow) + 1
+}
+
+// snapshotBlockHeader returns the header of the block at the given height in
+// the current path, which may be a block below an imported snapshot.
+func snapshotBlockHeader(tx *bolt.Tx, height types.BlockHeight) (types.BlockHeader, error) {
+	id, err := getPath(tx, height)
+	if err != nil {
+		return types.BlockHeader{}, err
+	}
+	if pb, err := getBlockMap(tx, id); err == nil {
+		return pb.Block.Header(), nil
+	}
+	var h types.BlockHeader
+	b := tx.Bucket(SnapshotHeaders)
+	if b == nil {
+		return types.BlockHeader{}, errNilBucket
+	}
+	headerBytes := b.Get(encoding.Marshal(height))
+	if headerBytes == nil {
+		return types.BlockHeader{}, errNilItem
+	}
+	err = encoding.Unmarshal(headerBytes, &h)
+	return h, err
+}
+
+// snapshotPrecedingBlocks returns the blocks that precede the block of an
+// imported snapshot. Blocks that are only known by their headers are returned
+// without payouts and transactions.
+func snapshotPrecedingBlocks(tx *bolt.Tx, height types.BlockHeight) ([]types.Block, error) {
+	blocks := make([]types.Block, 0, height-1)
+	for i := types.BlockHeight(1); i < height; i++ {
+		id, err := getPath(tx, i)
+		if err != nil {
+			return nil, err
+		}
+		if pb, err := getBlockMap(tx, id); err == nil {
+			blocks = append(blocks, pb.Block)
+			continue
+		}
+		h, err := snapshotBlockHeader(tx, i)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, types.Block{
+			ParentID:  h.ParentID,
+			Nonce:     h.Nonce,
+			Timestamp: h.Timestamp,
+		})
+	}
+	return blocks, nil
+}
+
+// bucketContents returns a copy of all key/value pairs of all consensus
+// buckets that are part of a snapshot.
+func bucketContents(tx *bolt.Tx) (map[string]map[string][]byte, error) {
+	contents := make(map[string]map[string][]byte)
+	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !isSnapshotBucket(name) {
+			return nil
+		}
+		kvs := make(map[string][]byte)
+		contents[string(name)] = kvs
+		return b.ForEach(func(k, v []byte) error {
+			kvs[string(k)] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	return contents, err
+}
+
+// bucketDiffs calls fn for every key/value pair that was removed from a
+// bucket, and then for every key/value pair that was added to a bucket, in
+// byte order.
+func bucketDiffs(old, new map[string][]byte, fn func(dir modules.DiffDirection, k string, v []byte) error) error {
+	var removed, added []string
+	for k, v := range old {
+		if nv, exists := new[k]; !exists || !bytes.Equal(nv, v) {
+			removed = append(removed, k)
+		}
+	}
+	for k, v := range new {
+		if ov, exists := old[k]; !exists || !bytes.Equal(ov, v) {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	for _, k := range removed {
+		if err := fn(modules.DiffRevert, k, old[k]); err != nil {
+			return err
+		}
+	}
+	for _, k := range added {
+		if err := fn(modules.DiffApply, k, new[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// snapshotDiffs sets the diffs of the snapshot block to the diffs that
+// transform the state described by old into the state described by new.
+func snapshotDiffs(pb *processedBlock, old, new map[string]map[string][]byte) error {
+	err := bucketDiffs(old[string(SiacoinOutputs)], new[string(SiacoinOutputs)], func(dir modules.DiffDirection, k string, v []byte) error {
+		scod := modules.SiacoinOutputDiff{Direction: dir}
+		copy(scod.ID[:], k)
+		pb.SiacoinOutputDiffs = append(pb.SiacoinOutputDiffs, scod)
+		return encoding.Unmarshal(v, &pb.SiacoinOutputDiffs[len(pb.SiacoinOutputDiffs)-1].SiacoinOutput)
+	})
+	if err != nil {
+		return err
+	}
+	err = bucketDiffs(old[string(FileContracts)], new[string(FileContracts)], func(dir modules.DiffDirection, k string, v []byte) error {
+		fcd := modules.FileContractDiff{Direction: dir}
+		copy(fcd.ID[:], k)
+		pb.FileContractDiffs = append(pb.FileContractDiffs, fcd)
+		return encoding.Unmarshal(v, &pb.FileContractDiffs[len(pb.FileContractDiffs)-1].FileContract)
+	})
+	if err != nil {
+		return err
+	}
+	err = bucketDiffs(old[string(SiafundOutputs)], new[string(SiafundOutputs)], func(dir modules.DiffDirection, k string, v []byte) error {
+		sfod := modules.SiafundOutputDiff{Direction: dir}
+		copy(sfod.ID[:], k)
+		pb.SiafundOutputDiffs = append(pb.SiafundOutputDiffs, sfod)
+		return encoding.Unmarshal(v, &pb.SiafundOutputDiffs[len(pb.SiafundOutputDiffs)-1].SiafundOutput)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Delayed siacoin outputs are spread across one bucket per maturity
+	// height.
+	var dscoBuckets []string
+	for name := range old {
+		if bytes.HasPrefix([]byte(name), prefixDSCO) {
+			dscoBuckets = append(dscoBuckets, name)
+		}
+	}
+	for name := range new {
+		if _, exists := old[name]; !exists && bytes.HasPrefix([]byte(name), prefixDSCO) {
+			dscoBuckets = append(dscoBuckets, name)
+		}
+	}
+	sort.Strings(dscoBuckets)
+	for _, name := range dscoBuckets {
+		var maturityHeight types.BlockHeight
+		if err := encoding.Unmarshal([]byte(name[len(prefixDSCO):]), &maturityHeight); err != nil {
+			return err
+		}
+		err = bucketDiffs(old[name], new[name], func(dir modules.DiffDirection, k string, v []byte) error {
+			dscod := modules.DelayedSiacoinOutputDiff{Direction: dir, MaturityHeight: maturityHeight}
+			copy(dscod.ID[:], k)
+			pb.DelayedSiacoinOutputDiffs = append(pb.DelayedSiacoinOutputDiffs, dscod)
+			return encoding.Unmarshal(v, &pb.DelayedSiacoinOutputDiffs[len(pb.DelayedSiacoinOutputDiffs)-1].SiacoinOutput)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// The siafund pool only ever grows, so a single diff suffices.
+	sfpd := modules.SiafundPoolDiff{Direction: modules.DiffApply}
+	if err := encoding.Unmarshal(old[string(SiafundPool)][string(SiafundPool)], &sfpd.Previous); err != nil {
+		return err
+	}
+	if err := encoding.Unmarshal(new[string(SiafundPool)][string(SiafundPool)], &sfpd.Adjusted); err != nil {
+		return err
+	}
+	if sfpd.Adjusted.Cmp(sfpd.Previous) < 0 {
+		return errSnapshotInvalid
+	}
+	pb.SiafundPoolDiffs = []modules.SiafundPoolDiff{sfpd}
+	return nil
+}
+
+// snapshotTail returns the blocks of the tail of a snapshot at the given
+// height.
+func (cs *ConsensusSet) snapshotTail(tx *bolt.Tx, height types.BlockHeight) ([]snapshotBlock, error) {
+	var tail []snapshotBlock
+	for h := snapshotTailStart(height); h <= height; h++ {
+		id, err := getPath(tx, h)
+		if err != nil {
+			return nil, err
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		totalTime, totalTarget := cs.getBlockTotals(tx, id)
+		tail = append(tail, snapshotBlock{
+			Block:       pb.Block,
+			Depth:       pb.Depth,
+			ChildTarget: pb.ChildTarget,
+			TotalTime:   totalTime,
+			TotalTarget: totalTarget,
+		})
+	}
+	return tail, nil
+}
+
+// writeSnapshot writes a snapshot of the current state of the consensus set
+// with the given tail to w.
+func (cs *ConsensusSet) writeSnapshot(tx *bolt.Tx, w io.Writer, snap modules.ConsensusSnapshot, tail []snapshotBlock) error {
+	err := encoding.WriteObject(w, snapshotHeader{
+		Version:  snapshotVersion,
+		Height:   snap.Height,
+		BlockID:  snap.BlockID,
+		Checksum: snap.Checksum,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write the headers of the blocks below the tail.
+	start := snapshotTailStart(snap.Height)
+	for height := types.BlockHeight(1); height < start; height++ {
+		h, err := snapshotBlockHeader(tx, height)
+		if err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(w, h); err != nil {
+			return err
+		}
+	}
+
+	// Write the blocks of the tail.
+	for _, sb := range tail {
+		if err := encoding.WriteObject(w, sb); err != nil {
+			return err
+		}
+	}
+
+	// Write the consensus state.
+	err = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !isSnapshotBucket(name) {
+			return nil
+		}
+		if err := encoding.WriteObject(w, snapshotEntry{Bucket: name}); err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			return encoding.WriteObject(w, snapshotEntry{Bucket: name, Key: k, Value: v})
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return encoding.WriteObject(w, snapshotEntry{})
+}
+
+// importSnapshot reads a snapshot from r and replaces the state of the
+// consensus set, which must only contain the genesis block, with it. The
+// change entry of the snapshot is added to the change log and returned.
+func (cs *ConsensusSet) importSnapshot(tx *bolt.Tx, r io.Reader, id types.BlockID, checksum crypto.Hash) (modules.ConsensusSnapshot, changeEntry, error) {
+	if blockHeight(tx) != 0 {
+		return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotNotFresh
+	}
+
+	var sh snapshotHeader
+	if err := encoding.ReadObject(r, &sh, snapshotHeaderMaxLen); err != nil {
+		return modules.ConsensusSnapshot{}, changeEntry{}, errors.New("unable to read snapshot header: " + err.Error())
+	}
+	if sh.Version != snapshotVersion {
+		return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotVersion
+	} else if sh.BlockID != id {
+		return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotBlockID
+	} else if checksum != (crypto.Hash{}) && sh.Checksum != checksum {
+		return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotChecksum
+	} else if sh.Height == 0 {
+		return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotInvalid
+	}
+	snap := modules.ConsensusSnapshot{
+		Height:   sh.Height,
+		BlockID:  sh.BlockID,
+		Checksum: sh.Checksum,
+	}
+
+	// Read the headers below the tail and check that they form a chain that
+	// starts at the genesis block.
+	headers, err := tx.CreateBucket(SnapshotHeaders)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, changeEntry{}, err
+	}
+	parentID := cs.blockRoot.Block.ID()
+	start := snapshotTailStart(sh.Height)
+	for height := types.BlockHeight(1); height < start; height++ {
+		var h types.BlockHeader
+		if err := encoding.ReadObject(r, &h, types.BlockHeaderSize+8); err != nil {
+			return modules.ConsensusSnapshot{}, changeEntry{}, errors.New("unable to read snapshot header: " + err.Error())
+		}
+		if h.ParentID != parentID {
+			return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotInvalid
+		}
+		parentID = h.ID()
+		pushPath(tx, parentID)
+		if err := headers.Put(encoding.Marshal(height), encoding.Marshal(h)); err != nil {
+			return modules.ConsensusSnapshot{}, changeEntry{}, err
+		}
+	}
+
+	// Read the blocks of the tail. The proof of work of each block is checked
+	// against the target of its parent, and its depth against the depth of
+	// its parent. The targets and totals are covered by the checksum, which
+	// is verified after the state has been read.
+	var pb *processedBlock
+	var tail []snapshotBlockFields
+	for height := start; height <= sh.Height; height++ {
+		var sb snapshotBlock
+		if err := encoding.ReadObject(r, &sb, types.BlockSizeLimit+snapshotHeaderMaxLen); err != nil {
+			return modules.ConsensusSnapshot{}, changeEntry{}, errors.New("unable to read snapshot block: " + err.Error())
+		}
+		if sb.Block.ParentID != parentID {
+			return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotInvalid
+		} else if pb != nil && !checkHeaderTarget(sb.Block.Header(), pb.ChildTarget) {
+			return modules.ConsensusSnapshot{}, changeEntry{}, modules.ErrBlockUnsolved
+		} else if pb != nil && sb.Depth != pb.childDepth() {
+			return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotInvalid
+		}
+		tail = append(tail, sb.fields())
+		pb = &processedBlock{
+			Block:          sb.Block,
+			Height:         height,
+			Depth:          sb.Depth,
+			ChildTarget:    sb.ChildTarget,
+			DiffsGenerated: true,
+		}
+		parentID = sb.Block.ID()
+		pushPath(tx, parentID)
+		addBlockMap(tx, pb)
+		if err := putBlockTotals(tx, parentID, sb.TotalTime, sb.TotalTarget); err != nil {
+			return modules.ConsensusSnapshot{}, changeEntry{}, err
+		}
+	}
+	if parentID != sh.BlockID {
+		return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotBlockID
+	}
+
+	// Replace the genesis state with the state of the snapshot. The genesis
+	// state is remembered so that the snapshot block can carry the diffs
+	// between the two.
+	genesisState, err := bucketContents(tx)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, changeEntry{}, err
+	}
+	for name := range genesisState {
+		if err := tx.DeleteBucket([]byte(name)); err != nil {
+			return modules.ConsensusSnapshot{}, changeEntry{}, err
+		}
+	}
+	for {
+		var e snapshotEntry
+		if err := encoding.ReadObject(r, &e, snapshotEntryMaxLen); err != nil {
+			return modules.ConsensusSnapshot{}, changeEntry{}, errors.New("unable to read snapshot entry: " + err.Error())
+		}
+		if len(e.Bucket) == 0 {
+			break
+		} else if !isSnapshotBucket(e.Bucket) {
+			return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotInvalid
+		}
+		if len(e.Key) == 0 {
+			if _, err := tx.CreateBucket(e.Bucket); err != nil {
+				return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotInvalid
+			}
+			continue
+		}
+		b := tx.Bucket(e.Bucket)
+		if b == nil {
+			return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotInvalid
+		}
+		if err := b.Put(e.Key, e.Value); err != nil {
+			return modules.ConsensusSnapshot{}, changeEntry{}, err
+		}
+	}
+	for _, bucket := range [][]byte{SiacoinOutputs, FileContracts, SiafundOutputs, SiafundPool} {
+		if tx.Bucket(bucket) == nil {
+			return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotInvalid
+		}
+	}
+	snapshotState, err := bucketContents(tx)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, changeEntry{}, err
+	}
+	if err := snapshotDiffs(pb, genesisState, snapshotState); err != nil {
+		return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotInvalid
+	}
+
+	// Verify the checksum of the imported state and tail.
+	stateChecksum := consensusChecksum(tx)
+	if snapshotChecksum(stateChecksum, tail) != sh.Checksum {
+		return modules.ConsensusSnapshot{}, changeEntry{}, errSnapshotChecksum
+	}
+	pb.ConsensusChecksum = stateChecksum
+	addBlockMap(tx, pb)
+
+	// Record the snapshot height and add the snapshot to the change log.
+	b, err := tx.CreateBucket(Snapshot)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, changeEntry{}, err
+	}
+	if err := b.Put(FieldSnapshotHeight, encoding.Marshal(sh.Height)); err != nil {
+		return modules.ConsensusSnapshot{}, changeEntry{}, err
+	}
+	ce := changeEntry{AppliedBlocks: []types.BlockID{sh.BlockID}}
+	if err := appendChangeLog(tx, ce); err != nil {
+		return modules.ConsensusSnapshot{}, changeEntry{}, err
+	}
+	return snap, ce, nil
+}
+
+// ExportSnapshot writes a snapshot of the consensus set at the given height to
+// w. Exporting a snapshot below the current height temporarily reverts the
+// blocks above it, which blocks the consensus set until the snapshot has been
+// written.
+func (cs *ConsensusSet) ExportSnapshot(w io.Writer, height types.BlockHeight) (modules.ConsensusSnapshot, error) {
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	defer cs.tg.Done()
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	var snap modules.ConsensusSnapshot
+	bw := bufio.NewWriter(w)
+	err := cs.db.Update(func(tx *bolt.Tx) error {
+		if height == 0 || height < cs.reorgFloor() || height > blockHeight(tx) {
+			return errSnapshotHeight
+		}
+		id, err := getPath(tx, height)
+		if err != nil {
+			return err
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		cs.revertToBlock(tx, pb)
+		tail, err := cs.snapshotTail(tx, height)
+		if err != nil {
+			return err
+		}
+		fields := make([]snapshotBlockFields, len(tail))
+		for i, sb := range tail {
+			fields[i] = sb.fields()
+		}
+		snap = modules.ConsensusSnapshot{
+			Height:   height,
+			BlockID:  id,
+			Checksum: snapshotChecksum(consensusChecksum(tx), fields),
+		}
+		if err := cs.writeSnapshot(tx, bw, snap, tail); err != nil {
+			return err
+		}
+		// Roll back the transaction, undoing the reverted blocks.
+		return errSnapshotRollback
+	})
+	if err != errSnapshotRollback {
+		return modules.ConsensusSnapshot{}, err
+	}
+	if err := bw.Flush(); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	return snap, nil
+}
+
+// ImportSnapshot replaces the state of a consensus set that only contains the
+// genesis block with the snapshot read from r. The snapshot must end in the
+// block with the given id. If checksum is not empty, the checksum of the
+// snapshot must match it as well. Since the blocks below the snapshot are not
+// validated, the block id and checksum should be obtained from a trusted
+// source. After the import, the consensus set continues to sync from the
+// height of the snapshot.
+func (cs *ConsensusSet) ImportSnapshot(r io.Reader, id types.BlockID, checksum crypto.Hash) (modules.ConsensusSnapshot, error) {
+	if err := cs.tg.Add(); err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	defer cs.tg.Done()
+
+	cs.mu.Lock()
+	var snap modules.ConsensusSnapshot
+	var ce changeEntry
+	err := cs.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		snap, ce, err = cs.importSnapshot(tx, bufio.NewReader(r), id, checksum)
+		return err
+	})
+	if err != nil {
+		cs.mu.Unlock()
+		return modules.ConsensusSnapshot{}, err
+	}
+	cs.snapshotHeight = snap.Height
+	cs.log.Printf("INFO: imported consensus snapshot at height %v (block %v)\n", snap.Height, snap.BlockID)
+	cs.updateSubscribers(ce)
+	cs.mu.Unlock()
+
+	// Continue syncing from the snapshot.
+	if cs.gateway != nil {
+		for _, p := range cs.gateway.Peers() {
+			go func(addr modules.NetAddress) {
+				if cs.tg.Add() != nil {
+					return
+				}
+				defer cs.tg.Done()
+				err := cs.gateway.RPC(addr, "SendBlocks", cs.managedReceiveBlocks)
+				if err != nil {
+					cs.log.Debugln("WARN: unable to sync after importing snapshot:", err)
+				}
+			}(p.NetAddress)
+		}
+	}
+	return snap, nil
+}
+
+// BootstrapFromSnapshot imports a snapshot into the consensus database in
+// persistDir before the consensus set is started. It has the same
+// requirements as ConsensusSet.ImportSnapshot.
+func BootstrapFromSnapshot(persistDir string, r io.Reader, id types.BlockID, checksum crypto.Hash) (modules.ConsensusSnapshot, error) {
+	cs, err := newConsensusSet(nil, persistDir, modules.ProdDependencies)
+	if err != nil {
+		return modules.ConsensusSnapshot{}, err
+	}
+	snap, err := cs.ImportSnapshot(r, id, checksum)
+	if stopErr := cs.tg.Stop(); err == nil {
+		err = stopErr
+	}
+	return snap, err
+}
@@ -0,0 +1,279 @@
+package consensus
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/modules/gateway"
+	"github.com/acejam/Sia/types"
+)
+
+// TestSnapshotTailStart probes the snapshotTailStart function.
+func TestSnapshotTailStart(t *testing.T) {
+	window := types.BlockHeight(types.MedianTimestampWindow)
+	tests := []struct {
+		height, start types.BlockHeight
+	}{
+		{1, 1},
+		{window - 1, 1},
+		{window, 1},
+		{window + 1, 2},
+		{1000, 1000 - window + 1},
+	}
+	for _, test := range tests {
+		if start := snapshotTailStart(test.height); start != test.start {
+			t.Errorf("height %v: expected tail start %v, got %v", test.height, test.start, start)
+		}
+	}
+}
+
+// TestExportSnapshot checks that exporting a snapshot below the current height
+// does not modify the consensus set.
+func TestExportSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	height := cst.cs.Height()
+	current := cst.cs.CurrentBlock().ID()
+	checksum := cst.cs.dbConsensusChecksum()
+
+	var buf bytes.Buffer
+	snap, err := cst.cs.ExportSnapshot(&buf, height-3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Height != height-3 {
+		t.Fatal("wrong snapshot height:", snap.Height)
+	}
+	if b, _ := cst.cs.BlockAtHeight(height - 3); snap.BlockID != b.ID() {
+		t.Fatal("wrong snapshot block")
+	}
+	if snap.Checksum == checksum {
+		t.Fatal("snapshot has the checksum of the current state")
+	}
+	if cst.cs.Height() != height || cst.cs.CurrentBlock().ID() != current || cst.cs.dbConsensusChecksum() != checksum {
+		t.Fatal("exporting a snapshot modified the consensus set")
+	}
+
+	// Heights outside of the current path cannot be exported.
+	if _, err := cst.cs.ExportSnapshot(&buf, 0); err != errSnapshotHeight {
+		t.Fatalf("expected %v, got %v", errSnapshotHeight, err)
+	}
+	if _, err := cst.cs.ExportSnapshot(&buf, height+1); err != errSnapshotHeight {
+		t.Fatalf("expected %v, got %v", errSnapshotHeight, err)
+	}
+}
+
+// TestImportSnapshot exports a snapshot from one consensus set and imports it
+// into a fresh one, which should then be able to continue the chain.
+func TestImportSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	fresh, err := blankConsensusSetTester(t.Name()+"-fresh", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+
+	var buf bytes.Buffer
+	snap, err := cst.cs.ExportSnapshot(&buf, cst.cs.Height())
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := append([]byte(nil), buf.Bytes()...)
+
+	// A snapshot with the wrong block id or checksum is rejected.
+	if _, err := fresh.cs.ImportSnapshot(bytes.NewReader(snapshot), types.BlockID{}, crypto.Hash{}); err != errSnapshotBlockID {
+		t.Fatalf("expected %v, got %v", errSnapshotBlockID, err)
+	}
+	if _, err := fresh.cs.ImportSnapshot(bytes.NewReader(snapshot), snap.BlockID, crypto.Hash{1}); err != errSnapshotChecksum {
+		t.Fatalf("expected %v, got %v", errSnapshotChecksum, err)
+	}
+	if fresh.cs.Height() != 0 {
+		t.Fatal("failed import modified the consensus set")
+	}
+
+	imported, err := fresh.cs.ImportSnapshot(bytes.NewReader(snapshot), snap.BlockID, snap.Checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != snap {
+		t.Fatal("imported snapshot does not match exported snapshot")
+	}
+	if fresh.cs.Height() != cst.cs.Height() || fresh.cs.CurrentBlock().ID() != cst.cs.CurrentBlock().ID() {
+		t.Fatal("imported consensus set is not at the snapshot block")
+	}
+	if fresh.cs.dbConsensusChecksum() != cst.cs.dbConsensusChecksum() {
+		t.Fatal("imported consensus set has a different checksum")
+	}
+
+	// A second import is rejected.
+	if _, err := fresh.cs.ImportSnapshot(bytes.NewReader(snapshot), snap.BlockID, snap.Checksum); err != errSnapshotNotFresh {
+		t.Fatalf("expected %v, got %v", errSnapshotNotFresh, err)
+	}
+
+	// New subscribers receive one applied block per height.
+	ms := newMockSubscriber()
+	if err := fresh.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, nil); err != nil {
+		t.Fatal(err)
+	}
+	var applied int
+	for _, cc := range ms.updates {
+		applied += len(cc.AppliedBlocks)
+	}
+	if applied != int(snap.Height)+1 {
+		t.Fatalf("expected %v applied blocks, got %v", snap.Height+1, applied)
+	}
+
+	// The imported consensus set accepts new blocks, but no blocks below the
+	// snapshot.
+	for i := 0; i < 3; i++ {
+		b, err := cst.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fresh.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fresh.cs.dbConsensusChecksum() != cst.cs.dbConsensusChecksum() {
+		t.Fatal("consensus sets diverged after the snapshot")
+	}
+	parent, _ := cst.cs.BlockAtHeight(snap.Height - 2)
+	orphan := types.Block{ParentID: parent.ID(), Timestamp: types.CurrentTimestamp()}
+	if err := fresh.cs.AcceptBlock(orphan); err != errPrunedParent {
+		t.Fatalf("expected %v, got %v", errPrunedParent, err)
+	}
+
+	// Snapshots can be exported again from the imported consensus set.
+	buf.Reset()
+	reexported, err := fresh.cs.ExportSnapshot(&buf, snap.Height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reexported != snap || !bytes.Equal(buf.Bytes(), snapshot) {
+		t.Fatal("re-exported snapshot does not match the original")
+	}
+	if _, err := fresh.cs.ExportSnapshot(&buf, snap.Height-1); err != errSnapshotHeight {
+		t.Fatalf("expected %v, got %v", errSnapshotHeight, err)
+	}
+}
+
+// TestImportSnapshotTamperedTail checks that a snapshot whose tail targets or
+// totals were modified is rejected, even though the block ids still match.
+func TestImportSnapshotTamperedTail(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	fresh, err := blankConsensusSetTester(t.Name()+"-fresh", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fresh.Close()
+
+	var buf bytes.Buffer
+	snap, err := cst.cs.ExportSnapshot(&buf, cst.cs.Height())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Copy the snapshot, increasing the total time of the last tail block.
+	var tampered bytes.Buffer
+	var sh snapshotHeader
+	if err := encoding.ReadObject(&buf, &sh, snapshotHeaderMaxLen); err != nil {
+		t.Fatal(err)
+	}
+	encoding.WriteObject(&tampered, sh)
+	start := snapshotTailStart(sh.Height)
+	for height := types.BlockHeight(1); height < start; height++ {
+		var h types.BlockHeader
+		if err := encoding.ReadObject(&buf, &h, types.BlockHeaderSize+8); err != nil {
+			t.Fatal(err)
+		}
+		encoding.WriteObject(&tampered, h)
+	}
+	for height := start; height <= sh.Height; height++ {
+		var sb snapshotBlock
+		if err := encoding.ReadObject(&buf, &sb, types.BlockSizeLimit+snapshotHeaderMaxLen); err != nil {
+			t.Fatal(err)
+		}
+		if height == sh.Height {
+			sb.TotalTime++
+		}
+		encoding.WriteObject(&tampered, sb)
+	}
+	tampered.Write(buf.Bytes())
+
+	if _, err := fresh.cs.ImportSnapshot(&tampered, snap.BlockID, snap.Checksum); err != errSnapshotChecksum {
+		t.Fatalf("expected %v, got %v", errSnapshotChecksum, err)
+	}
+	if fresh.cs.Height() != 0 {
+		t.Fatal("failed import modified the consensus set")
+	}
+}
+
+// TestBootstrapFromSnapshot checks that a snapshot can be imported before the
+// consensus set is started.
+func TestBootstrapFromSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	var buf bytes.Buffer
+	snap, err := cst.cs.ExportSnapshot(&buf, cst.cs.Height())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-bootstrap")
+	if _, err := BootstrapFromSnapshot(filepath.Join(testdir, modules.ConsensusDir), &buf, snap.BlockID, snap.Checksum); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	cs, err := New(g, false, filepath.Join(testdir, modules.ConsensusDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	if cs.Height() != snap.Height || cs.CurrentBlock().ID() != snap.BlockID {
+		t.Fatal("consensus set was not bootstrapped from the snapshot")
+	}
+	if cs.snapshotHeight != snap.Height {
+		t.Fatal("snapshot height was not loaded:", cs.snapshotHeight)
+	}
+}
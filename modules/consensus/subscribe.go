@@ -14,6 +14,9 @@ import (
 // computeConsensusChange computes the consensus change from the change entry
 // at index 'i' in the change log. If i is out of bounds, an error is returned.
 func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (modules.ConsensusChange, error) {
+	if cs.prunedHeight != 0 && changeEntryPruned(tx, ce) {
+		return modules.ConsensusChange{}, modules.ErrConsensusChangePruned
+	}
 	cc := modules.ConsensusChange{
 		ID: ce.ID(),
 	}
@@ -60,6 +63,16 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 			return modules.ConsensusChange{}, err
 		}
 
+		// The blocks below an imported snapshot are applied together with
+		// the snapshot block.
+		if height := snapshotHeight(tx); height != 0 && appliedBlock.Height == height {
+			precedingBlocks, err := snapshotPrecedingBlocks(tx, height)
+			if err != nil {
+				cs.log.Critical("snapshotPrecedingBlocks failed in computeConsensusChange:", err)
+				return modules.ConsensusChange{}, err
+			}
+			cc.AppliedBlocks = append(cc.AppliedBlocks, precedingBlocks...)
+		}
 		cc.AppliedBlocks = append(cc.AppliedBlocks, appliedBlock.Block)
 		for _, scod := range appliedBlock.SiacoinOutputDiffs {
 			cc.SiacoinOutputDiffs = append(cc.SiacoinOutputDiffs, scod)
@@ -165,6 +178,18 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 			}
 			entry, exists = entry.NextEntry(tx)
 		}
+
+		// Refuse the subscription before sending any changes if the first
+		// changes reference pruned blocks. The genesis block is never pruned,
+		// so the change following it is checked as well.
+		if exists && cs.prunedHeight != 0 {
+			if changeEntryPruned(tx, entry) {
+				return modules.ErrConsensusChangePruned
+			}
+			if next, nextExists := entry.NextEntry(tx); start == modules.ConsensusChangeBeginning && nextExists && changeEntryPruned(tx, next) {
+				return modules.ErrConsensusChangePruned
+			}
+		}
 		return nil
 	})
 	cs.mu.RUnlock()
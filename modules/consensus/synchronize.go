@@ -3,6 +3,7 @@ package consensus
 import (
 	"errors"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/encoding"
 	"github.com/acejam/Sia/modules"
+	siasync "github.com/acejam/Sia/sync"
 	"github.com/acejam/Sia/types"
 
 	"github.com/coreos/bbolt"
@@ -230,12 +232,26 @@ func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr e
 		// sharing is implemented, block already in database should also be
 		// ignored.
 		if acceptErr != nil && acceptErr != modules.ErrNonExtendingBlock && acceptErr != modules.ErrBlockKnown {
+			cs.reportMisbehavior(conn.RPCAddr(), modules.MisbehaviorInvalidBlock, acceptErr)
 			return acceptErr
 		}
 	}
 	return nil
 }
 
+// reportMisbehavior reports to the gateway that the peer at addr supplied a
+// block or header that was rejected with err. Errors that honest peers can
+// cause, such as relaying orphans, known blocks or blocks with a timestamp
+// slightly ahead of the local clock, are ignored.
+func (cs *ConsensusSet) reportMisbehavior(addr modules.NetAddress, score uint64, err error) {
+	switch err {
+	case nil, errOrphan, errPrunedParent, errFutureTimestamp, errExtremeFutureTimestamp, errInconsistentSet,
+		modules.ErrBlockKnown, modules.ErrNonExtendingBlock, siasync.ErrStopped:
+		return
+	}
+	cs.gateway.AddMisbehavior(addr, score, err.Error())
+}
+
 // threadedReceiveBlocks is the calling end of the SendBlocks RPC.
 func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) error {
 	err := conn.SetDeadline(time.Now().Add(sendBlocksTimeout))
@@ -259,6 +275,39 @@ func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) error {
 	return cs.managedReceiveBlocks(conn)
 }
 
+// syncStartHeight finds the most recent block from knownBlocks in the current
+// path and returns the height of its child, which is the first block that
+// needs to be sent to the peer. false is returned if no block needs to be
+// sent, either because the peer already has all blocks or because no common
+// block was found.
+func (cs *ConsensusSet) syncStartHeight(tx *bolt.Tx, knownBlocks [32]types.BlockID) (types.BlockHeight, bool) {
+	csHeight := blockHeight(tx)
+	for _, id := range knownBlocks {
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			continue
+		}
+		pathID, err := getPath(tx, pb.Height)
+		if err != nil {
+			continue
+		}
+		if pathID != pb.Block.ID() {
+			continue
+		}
+		if pb.Height == csHeight {
+			return 0, false
+		}
+		// The blocks below an imported snapshot or pruned blocks cannot be
+		// sent.
+		if pb.Height < cs.reorgFloor() {
+			return 0, false
+		}
+		// Start from the child of the common block.
+		return pb.Height + 1, true
+	}
+	return 0, false
+}
+
 // rpcSendBlocks is the receiving end of the SendBlocks RPC. It returns a
 // sequential set of blocks based on the 32 input block IDs. The most recent
 // known ID is used as the starting point, and up to 'MaxCatchUpBlocks' from
@@ -295,30 +344,9 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 	// Find the most recent block from knownBlocks in the current path.
 	found := false
 	var start types.BlockHeight
-	var csHeight types.BlockHeight
 	cs.mu.RLock()
 	err = cs.db.View(func(tx *bolt.Tx) error {
-		csHeight = blockHeight(tx)
-		for _, id := range knownBlocks {
-			pb, err := getBlockMap(tx, id)
-			if err != nil {
-				continue
-			}
-			pathID, err := getPath(tx, pb.Height)
-			if err != nil {
-				continue
-			}
-			if pathID != pb.Block.ID() {
-				continue
-			}
-			if pb.Height == csHeight {
-				break
-			}
-			found = true
-			// Start from the child of the common block.
-			start = pb.Height + 1
-			break
-		}
+		start, found = cs.syncStartHeight(tx, knownBlocks)
 		return nil
 	})
 	cs.mu.RUnlock()
@@ -449,6 +477,7 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 		}()
 		return nil
 	} else if err != nil {
+		cs.reportMisbehavior(conn.RPCAddr(), modules.MisbehaviorInvalidHeader, err)
 		return err
 	}
 
@@ -531,15 +560,18 @@ func (cs *ConsensusSet) managedReceiveBlock(id types.BlockID) modules.RPCFunc {
 			cs.managedBroadcastBlock(block)
 		}
 		if err != nil {
+			cs.reportMisbehavior(conn.RPCAddr(), modules.MisbehaviorInvalidBlock, err)
 			return err
 		}
 		return nil
 	}
 }
 
-// threadedInitialBlockchainDownload performs the IBD on outbound peers. Blocks
-// are downloaded from one peer at a time in 5 minute intervals, so as to
-// prevent any one peer from significantly slowing down IBD.
+// threadedInitialBlockchainDownload performs the IBD on outbound peers. Peers
+// that support header-first synchronization are synchronized with first, see
+// headersync.go. Afterwards, blocks are downloaded from one peer at a time in 5
+// minute intervals, so as to prevent any one peer from significantly slowing
+// down IBD.
 //
 // NOTE: IBD will succeed right now when each peer has a different blockchain.
 // The height and the block id of the remote peers' current blocks are not
@@ -556,10 +588,39 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() error {
 	deadline := time.Now().Add(minIBDWaitTime)
 	numOutboundSynced := 0
 	numOutboundNotSynced := 0
+	// throughput tracks the download rate of each peer during IBD, so that
+	// blocks are requested from the fastest peers first.
+	throughput := make(map[modules.NetAddress]float64)
+	// legacy contains the peers that do not support header-first
+	// synchronization.
+	legacy := make(map[modules.NetAddress]struct{})
 	for {
 		numOutboundSynced = 0
 		numOutboundNotSynced = 0
-		for _, p := range cs.gateway.Peers() {
+		peers := cs.gateway.Peers()
+
+		// Download the headers and blocks from the peers that support
+		// header-first synchronization. Afterwards, SendBlocks is used to
+		// confirm that each peer considers us synced, and to synchronize with
+		// the peers that don't support header-first synchronization.
+		err := func() error {
+			err := cs.tg.Add()
+			if err != nil {
+				return err
+			}
+			defer cs.tg.Done()
+			err = cs.managedHeaderSync(peers, legacy)
+			if err != nil {
+				cs.log.Printf("WARN: header-first synchronization failed: %v", err)
+			}
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+
+		sortPeersByThroughput(peers, throughput)
+		for _, p := range peers {
 			// We only sync on outbound peers at first to make IBD less susceptible to
 			// fast-mining and other attacks, as outbound peers are more difficult to
 			// manipulate.
@@ -577,7 +638,13 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() error {
 
 				// Request blocks from the peer. The error returned will only be
 				// 'nil' if there are no more blocks to receive.
+				bytesBefore, _ := cs.managedPeerBytesIn(p.NetAddress)
+				start := time.Now()
 				err = cs.gateway.RPC(p.NetAddress, "SendBlocks", cs.managedReceiveBlocks)
+				bytesAfter, connected := cs.managedPeerBytesIn(p.NetAddress)
+				if connected && bytesAfter > bytesBefore {
+					throughput[p.NetAddress] = float64(bytesAfter-bytesBefore) / time.Since(start).Seconds()
+				}
 				if err == nil {
 					numOutboundSynced++
 					// In this case, 'return nil' is equivalent to skipping to
@@ -628,6 +695,31 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() error {
 	return nil
 }
 
+// managedPeerBytesIn returns the number of bytes that the gateway received
+// from the peer at addr. false is returned if the peer is not connected.
+func (cs *ConsensusSet) managedPeerBytesIn(addr modules.NetAddress) (uint64, bool) {
+	for _, p := range cs.gateway.Peers() {
+		if p.NetAddress == addr {
+			return p.BytesIn, true
+		}
+	}
+	return 0, false
+}
+
+// sortPeersByThroughput sorts peers by their measured download throughput in
+// descending order. Peers without a measurement are sorted first, so that
+// their throughput is measured during the next SendBlocks RPC.
+func sortPeersByThroughput(peers []modules.Peer, throughput map[modules.NetAddress]float64) {
+	sort.SliceStable(peers, func(i, j int) bool {
+		ti, measuredI := throughput[peers[i].NetAddress]
+		tj, measuredJ := throughput[peers[j].NetAddress]
+		if !measuredI || !measuredJ {
+			return !measuredI && measuredJ
+		}
+		return ti > tj
+	})
+}
+
 // Synced returns true if the consensus set is synced with the network.
 func (cs *ConsensusSet) Synced() bool {
 	err := cs.tg.Add()
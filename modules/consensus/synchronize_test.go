@@ -1505,3 +1505,25 @@ func TestIntegrationSendBlocksStalls(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestSortPeersByThroughput probes the sortPeersByThroughput function.
+func TestSortPeersByThroughput(t *testing.T) {
+	peers := []modules.Peer{
+		{NetAddress: "1.1.1.1:1"},
+		{NetAddress: "2.2.2.2:2"},
+		{NetAddress: "3.3.3.3:3"},
+		{NetAddress: "4.4.4.4:4"},
+	}
+	throughput := map[modules.NetAddress]float64{
+		"1.1.1.1:1": 10,
+		"2.2.2.2:2": 1000,
+		"4.4.4.4:4": 100,
+	}
+	sortPeersByThroughput(peers, throughput)
+	expected := []modules.NetAddress{"3.3.3.3:3", "2.2.2.2:2", "4.4.4.4:4", "1.1.1.1:1"}
+	for i, p := range peers {
+		if p.NetAddress != expected[i] {
+			t.Fatalf("expected %v at position %v, got %v", expected[i], i, p.NetAddress)
+		}
+	}
+}
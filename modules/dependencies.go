@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/persist"
 	"gitlab.com/NebulousLabs/fastrand"
 )
@@ -53,6 +54,10 @@ type (
 		// Listen gives the host the ability to receive incoming connections.
 		Listen(string, string) (net.Listener, error)
 
+		// JSONFileUpdate returns a journal update that saves JSON encoded
+		// data to a file that can be loaded with LoadFile.
+		JSONFileUpdate(persist.Metadata, interface{}, string) (persist.Update, error)
+
 		// LoadFile allows the host to load a persistence structure form disk.
 		LoadFile(persist.Metadata, interface{}, string) error
 
@@ -127,6 +132,27 @@ type (
 		pd *ProductionDependencies
 		*os.File
 	}
+
+	// EncryptedDependencies are the production dependencies of a module whose
+	// persist files are encrypted at rest. LoadFile and SaveFileSync encrypt
+	// JSON files, and modules that persist binary files encrypt them through
+	// the PersistEncrypter interface.
+	EncryptedDependencies struct {
+		ProductionDependencies
+		key crypto.TwofishKey
+	}
+
+	// A PersistEncrypter encrypts the persist files of a module. Modules check
+	// whether their dependencies implement PersistEncrypter to decide whether
+	// to encrypt the binary files they persist.
+	PersistEncrypter interface {
+		// DecryptPersist decrypts data that was encrypted with
+		// EncryptPersist.
+		DecryptPersist(crypto.Ciphertext) ([]byte, error)
+
+		// EncryptPersist encrypts data before it is persisted.
+		EncryptPersist([]byte) crypto.Ciphertext
+	}
 )
 
 // Close will close a file, checking whether the file handle is open somewhere
@@ -228,6 +254,12 @@ func (*ProductionDependencies) Listen(s1, s2 string) (net.Listener, error) {
 	return net.Listen(s1, s2)
 }
 
+// JSONFileUpdate returns a journal update that saves JSON encoded data to a
+// file.
+func (*ProductionDependencies) JSONFileUpdate(meta persist.Metadata, data interface{}, filename string) (persist.Update, error) {
+	return persist.JSONFileUpdate(meta, data, filename)
+}
+
 // LoadFile loads JSON encoded data from a file.
 func (*ProductionDependencies) LoadFile(meta persist.Metadata, data interface{}, filename string) error {
 	return persist.LoadJSON(meta, data, filename)
@@ -351,3 +383,36 @@ func (*ProductionDependencies) Symlink(s1, s2 string) error {
 func (*ProductionDependencies) WriteFile(s string, b []byte, fm os.FileMode) error {
 	return ioutil.WriteFile(s, b, fm)
 }
+
+// NewEncryptedDependencies returns production dependencies that encrypt the
+// persist files of a module with key.
+func NewEncryptedDependencies(key crypto.TwofishKey) *EncryptedDependencies {
+	return &EncryptedDependencies{key: key}
+}
+
+// DecryptPersist decrypts data that was encrypted with EncryptPersist.
+func (ed *EncryptedDependencies) DecryptPersist(ct crypto.Ciphertext) ([]byte, error) {
+	return ed.key.DecryptBytes(ct)
+}
+
+// EncryptPersist encrypts data before it is persisted.
+func (ed *EncryptedDependencies) EncryptPersist(plaintext []byte) crypto.Ciphertext {
+	return ed.key.EncryptBytes(plaintext)
+}
+
+// JSONFileUpdate returns a journal update that saves encrypted JSON encoded
+// data to a file.
+func (ed *EncryptedDependencies) JSONFileUpdate(meta persist.Metadata, data interface{}, filename string) (persist.Update, error) {
+	return persist.EncryptedJSONFileUpdate(meta, data, filename, ed.key)
+}
+
+// LoadFile loads an encrypted JSON file. Plaintext files are loaded as well,
+// so that they are encrypted the next time they are saved.
+func (ed *EncryptedDependencies) LoadFile(meta persist.Metadata, data interface{}, filename string) error {
+	return persist.LoadEncryptedJSON(meta, data, filename, ed.key)
+}
+
+// SaveFileSync encrypts JSON encoded data and writes it to a file.
+func (ed *EncryptedDependencies) SaveFileSync(meta persist.Metadata, data interface{}, filename string) error {
+	return persist.SaveEncryptedJSON(meta, data, filename, ed.key)
+}
@@ -45,6 +45,54 @@ type (
 		TotalRevisionVolume types.Currency `json:"totalrevisionvolume"`
 	}
 
+	// An ExplorerAddress contains the balances and the unspent outputs of an
+	// unlock hash. FirstSeen and LastSeen are the heights of the first and the
+	// last block that reference the unlock hash.
+	ExplorerAddress struct {
+		UnlockHash     types.UnlockHash        `json:"unlockhash"`
+		SiacoinBalance types.Currency          `json:"siacoinbalance"`
+		SiafundBalance types.Currency          `json:"siafundbalance"`
+		FirstSeen      types.BlockHeight       `json:"firstseen"`
+		LastSeen       types.BlockHeight       `json:"lastseen"`
+		SiacoinOutputs []types.SiacoinOutputID `json:"siacoinoutputs"`
+		SiafundOutputs []types.SiafundOutputID `json:"siafundoutputs"`
+	}
+
+	// An ExplorerRichListEntry is an unlock hash in the rich list together
+	// with its siacoin or siafund balance.
+	ExplorerRichListEntry struct {
+		UnlockHash types.UnlockHash `json:"unlockhash"`
+		Balance    types.Currency   `json:"balance"`
+	}
+
+	// An ExplorerHistoryQuery selects a page of the history of an object.
+	// Only transactions with a height in [StartHeight, EndHeight] are
+	// returned, starting after the transaction identified by Cursor, which is
+	// either empty or the NextCursor of the previous page. At most Limit
+	// transactions are returned.
+	ExplorerHistoryQuery struct {
+		StartHeight types.BlockHeight
+		EndHeight   types.BlockHeight
+		Cursor      string
+		Limit       uint64
+	}
+
+	// An ExplorerHistoryEntry is a transaction in the history of an object,
+	// together with the height of its block.
+	ExplorerHistoryEntry struct {
+		Height        types.BlockHeight   `json:"height"`
+		TransactionID types.TransactionID `json:"transactionid"`
+	}
+
+	// An ExplorerHistoryPage is a page of the history of an object, ordered
+	// by height and transaction ID. NextCursor is empty if the page is the
+	// last page. A reorg only changes the entries at heights above the fork
+	// height, so entries below it keep their order across pages.
+	ExplorerHistoryPage struct {
+		Entries    []ExplorerHistoryEntry `json:"entries"`
+		NextCursor string                 `json:"nextcursor"`
+	}
+
 	// Explorer tracks the blockchain and provides tools for gathering
 	// statistics and finding objects or patterns within the blockchain.
 	Explorer interface {
@@ -56,6 +104,10 @@ type (
 		// appeared at a given block.
 		BlockFacts(types.BlockHeight) (BlockFacts, bool)
 
+		// BlockFactsRange returns the block facts of the blocks with a
+		// height in [start, end], ending early at the current height.
+		BlockFactsRange(start, end types.BlockHeight) ([]BlockFacts, error)
+
 		// LatestBlockFacts returns the block facts of the last block
 		// in the explorer's database.
 		LatestBlockFacts() BlockFacts
@@ -71,6 +123,21 @@ type (
 		// provided unlock hash.
 		UnlockHash(types.UnlockHash) []types.TransactionID
 
+		// UnlockHashTransactions returns a page of the transactions
+		// associated with the provided unlock hash.
+		UnlockHashTransactions(types.UnlockHash, ExplorerHistoryQuery) (ExplorerHistoryPage, error)
+
+		// Address returns the balances and unspent outputs of the provided
+		// unlock hash. The bool indicates whether the unlock hash appears in
+		// the blockchain.
+		Address(types.UnlockHash) (ExplorerAddress, bool)
+
+		// RichList returns the unlock hashes with the largest balances of the
+		// given fund type, which is either types.SpecifierSiacoinOutput or
+		// types.SpecifierSiafundOutput. The first offset entries are skipped
+		// and at most limit entries are returned.
+		RichList(fundType types.Specifier, offset, limit uint64) ([]ExplorerRichListEntry, error)
+
 		// SiacoinOutput will return the siacoin output associated with the
 		// input id.
 		SiacoinOutput(types.SiacoinOutputID) (types.SiacoinOutput, bool)
@@ -79,6 +146,10 @@ type (
 		// the provided siacoin output id.
 		SiacoinOutputID(types.SiacoinOutputID) []types.TransactionID
 
+		// SiacoinOutputTransactions returns a page of the transactions
+		// associated with the provided siacoin output id.
+		SiacoinOutputTransactions(types.SiacoinOutputID, ExplorerHistoryQuery) (ExplorerHistoryPage, error)
+
 		// FileContractHistory returns the history associated with a file
 		// contract, which includes the file contract itself and all of the
 		// revisions that have been submitted to the blockchain. The first bool
@@ -91,6 +162,10 @@ type (
 		// the provided file contract id.
 		FileContractID(types.FileContractID) []types.TransactionID
 
+		// FileContractTransactions returns a page of the transactions
+		// associated with the provided file contract id.
+		FileContractTransactions(types.FileContractID, ExplorerHistoryQuery) (ExplorerHistoryPage, error)
+
 		// SiafundOutput will return the siafund output associated with the
 		// input id.
 		SiafundOutput(types.SiafundOutputID) (types.SiafundOutput, bool)
@@ -99,6 +174,10 @@ type (
 		// the provided siafund output id.
 		SiafundOutputID(types.SiafundOutputID) []types.TransactionID
 
+		// SiafundOutputTransactions returns a page of the transactions
+		// associated with the provided siafund output id.
+		SiafundOutputTransactions(types.SiafundOutputID, ExplorerHistoryQuery) (ExplorerHistoryPage, error)
+
 		Close() error
 	}
 )
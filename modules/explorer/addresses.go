@@ -0,0 +1,238 @@
+package explorer
+
+// addresses.go maintains the address indexes of the explorer: the siacoin and
+// siafund balance of every unlock hash, its unspent outputs, the heights of
+// the blocks that reference it and the rich lists. The balances and unspent
+// outputs follow the output diffs of the consensus changes, so reverted
+// blocks are undone by the revert diffs of the change.
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// richListKeyBalanceLen is the number of bytes of a rich list key that hold
+// the balance.
+const richListKeyBalanceLen = 32
+
+// addressBalance is the balance of an unlock hash as it is stored in
+// bucketAddresses.
+type addressBalance struct {
+	SiacoinBalance types.Currency
+	SiafundBalance types.Currency
+}
+
+// richListKey returns the key of an unlock hash in a rich list. The key
+// starts with the big-endian balance, so that the keys of a rich list are
+// sorted by balance.
+func richListKey(balance types.Currency, uh types.UnlockHash) []byte {
+	key := make([]byte, richListKeyBalanceLen+len(uh))
+	b := balance.Big().Bytes()
+	copy(key[richListKeyBalanceLen-len(b):], b)
+	copy(key[richListKeyBalanceLen:], uh[:])
+	return key
+}
+
+// heightKey returns the key of a height in bucketAddressHeights. Heights are
+// stored big-endian so that the first and last key are the lowest and
+// highest height.
+func heightKey(height types.BlockHeight) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	return key
+}
+
+// dbUpdateAddressBalance applies update to the balance of an unlock hash and
+// moves the unlock hash within the rich lists.
+func dbUpdateAddressBalance(tx *bolt.Tx, uh types.UnlockHash, update func(*addressBalance)) {
+	var ab addressBalance
+	if err := dbGetAndDecode(bucketAddresses, uh, &ab)(tx); err != nil && err != errNotExist {
+		panic(err)
+	}
+	old := ab
+	update(&ab)
+
+	richLists := []struct {
+		bucket   []byte
+		old, new types.Currency
+	}{
+		{bucketSiacoinRichList, old.SiacoinBalance, ab.SiacoinBalance},
+		{bucketSiafundRichList, old.SiafundBalance, ab.SiafundBalance},
+	}
+	for _, rl := range richLists {
+		b := tx.Bucket(rl.bucket)
+		if !rl.old.IsZero() {
+			assertNil(b.Delete(richListKey(rl.old, uh)))
+		}
+		if !rl.new.IsZero() {
+			assertNil(b.Put(richListKey(rl.new, uh), nil))
+		}
+	}
+
+	if ab.SiacoinBalance.IsZero() && ab.SiafundBalance.IsZero() {
+		mustDelete(tx.Bucket(bucketAddresses), uh)
+		return
+	}
+	mustPut(tx.Bucket(bucketAddresses), uh, ab)
+}
+
+// Add/Remove unspent siacoin output of an address
+func dbAddAddressSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, sco types.SiacoinOutput) {
+	b, err := tx.Bucket(bucketAddressSiacoinOutputs).CreateBucketIfNotExists(encoding.Marshal(sco.UnlockHash))
+	assertNil(err)
+	mustPutSet(b, id)
+	dbUpdateAddressBalance(tx, sco.UnlockHash, func(ab *addressBalance) {
+		ab.SiacoinBalance = ab.SiacoinBalance.Add(sco.Value)
+	})
+}
+func dbRemoveAddressSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, sco types.SiacoinOutput) {
+	bucket := tx.Bucket(bucketAddressSiacoinOutputs).Bucket(encoding.Marshal(sco.UnlockHash))
+	mustDelete(bucket, id)
+	if bucketIsEmpty(bucket) {
+		tx.Bucket(bucketAddressSiacoinOutputs).DeleteBucket(encoding.Marshal(sco.UnlockHash))
+	}
+	dbUpdateAddressBalance(tx, sco.UnlockHash, func(ab *addressBalance) {
+		ab.SiacoinBalance = ab.SiacoinBalance.Sub(sco.Value)
+	})
+}
+
+// Add/Remove unspent siafund output of an address
+func dbAddAddressSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, sfo types.SiafundOutput) {
+	b, err := tx.Bucket(bucketAddressSiafundOutputs).CreateBucketIfNotExists(encoding.Marshal(sfo.UnlockHash))
+	assertNil(err)
+	mustPutSet(b, id)
+	dbUpdateAddressBalance(tx, sfo.UnlockHash, func(ab *addressBalance) {
+		ab.SiafundBalance = ab.SiafundBalance.Add(sfo.Value)
+	})
+}
+func dbRemoveAddressSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, sfo types.SiafundOutput) {
+	bucket := tx.Bucket(bucketAddressSiafundOutputs).Bucket(encoding.Marshal(sfo.UnlockHash))
+	mustDelete(bucket, id)
+	if bucketIsEmpty(bucket) {
+		tx.Bucket(bucketAddressSiafundOutputs).DeleteBucket(encoding.Marshal(sfo.UnlockHash))
+	}
+	dbUpdateAddressBalance(tx, sfo.UnlockHash, func(ab *addressBalance) {
+		ab.SiafundBalance = ab.SiafundBalance.Sub(sfo.Value)
+	})
+}
+
+// Add/Remove a reference to an address at a height. The number of references
+// is counted so that a height is only removed once every reference to the
+// address in the block was removed.
+func dbAddAddressHeight(tx *bolt.Tx, uh types.UnlockHash, height types.BlockHeight) {
+	b, err := tx.Bucket(bucketAddressHeights).CreateBucketIfNotExists(encoding.Marshal(uh))
+	assertNil(err)
+	var count uint64
+	if v := b.Get(heightKey(height)); v != nil {
+		assertNil(encoding.Unmarshal(v, &count))
+	}
+	assertNil(b.Put(heightKey(height), encoding.Marshal(count+1)))
+}
+func dbRemoveAddressHeight(tx *bolt.Tx, uh types.UnlockHash, height types.BlockHeight) {
+	bucket := tx.Bucket(bucketAddressHeights).Bucket(encoding.Marshal(uh))
+	var count uint64
+	assertNil(encoding.Unmarshal(bucket.Get(heightKey(height)), &count))
+	if count > 1 {
+		assertNil(bucket.Put(heightKey(height), encoding.Marshal(count-1)))
+		return
+	}
+	assertNil(bucket.Delete(heightKey(height)))
+	if bucketIsEmpty(bucket) {
+		tx.Bucket(bucketAddressHeights).DeleteBucket(encoding.Marshal(uh))
+	}
+}
+
+// Address returns the balances and the unspent outputs of an unlock hash, and
+// a bool indicating whether the unlock hash appears in the blockchain.
+func (e *Explorer) Address(uh types.UnlockHash) (modules.ExplorerAddress, bool) {
+	var addr modules.ExplorerAddress
+	err := e.db.View(func(tx *bolt.Tx) error {
+		heights := tx.Bucket(bucketAddressHeights).Bucket(encoding.Marshal(uh))
+		if heights == nil {
+			return errNotExist
+		}
+		first, _ := heights.Cursor().First()
+		last, _ := heights.Cursor().Last()
+		addr = modules.ExplorerAddress{
+			UnlockHash: uh,
+			FirstSeen:  types.BlockHeight(binary.BigEndian.Uint64(first)),
+			LastSeen:   types.BlockHeight(binary.BigEndian.Uint64(last)),
+		}
+
+		var ab addressBalance
+		if err := dbGetAndDecode(bucketAddresses, uh, &ab)(tx); err != nil && err != errNotExist {
+			return err
+		}
+		addr.SiacoinBalance = ab.SiacoinBalance
+		addr.SiafundBalance = ab.SiafundBalance
+
+		if b := tx.Bucket(bucketAddressSiacoinOutputs).Bucket(encoding.Marshal(uh)); b != nil {
+			err := b.ForEach(func(k, _ []byte) error {
+				var id types.SiacoinOutputID
+				if err := encoding.Unmarshal(k, &id); err != nil {
+					return err
+				}
+				addr.SiacoinOutputs = append(addr.SiacoinOutputs, id)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if b := tx.Bucket(bucketAddressSiafundOutputs).Bucket(encoding.Marshal(uh)); b != nil {
+			err := b.ForEach(func(k, _ []byte) error {
+				var id types.SiafundOutputID
+				if err := encoding.Unmarshal(k, &id); err != nil {
+					return err
+				}
+				addr.SiafundOutputs = append(addr.SiafundOutputs, id)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return modules.ExplorerAddress{}, false
+	}
+	return addr, true
+}
+
+// RichList returns the unlock hashes with the largest siacoin or siafund
+// balances, largest balance first. The first offset entries are skipped and
+// at most limit entries are returned.
+func (e *Explorer) RichList(fundType types.Specifier, offset, limit uint64) (entries []modules.ExplorerRichListEntry, err error) {
+	var bucket []byte
+	switch fundType {
+	case types.SpecifierSiacoinOutput:
+		bucket = bucketSiacoinRichList
+	case types.SpecifierSiafundOutput:
+		bucket = bucketSiafundRichList
+	default:
+		return nil, errUnknownFundType
+	}
+
+	err = e.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		k, _ := c.Last()
+		for i := uint64(0); i < offset && k != nil; i++ {
+			k, _ = c.Prev()
+		}
+		for ; k != nil && uint64(len(entries)) < limit; k, _ = c.Prev() {
+			var entry modules.ExplorerRichListEntry
+			copy(entry.UnlockHash[:], k[richListKeyBalanceLen:])
+			entry.Balance = types.NewCurrency(new(big.Int).SetBytes(k[:richListKeyBalanceLen]))
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
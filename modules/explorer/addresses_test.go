@@ -0,0 +1,133 @@
+package explorer
+
+import (
+	"testing"
+
+	"github.com/acejam/Sia/types"
+
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestExplorerRichList checks that the siafund rich list contains all
+// siafunds, largest balance first, and that it can be paginated.
+func TestExplorerRichList(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := et.explorer.RichList(types.SpecifierSiafundOutput, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("siafund rich list is empty")
+	}
+	total := types.ZeroCurrency
+	for i, entry := range entries {
+		if i > 0 && entry.Balance.Cmp(entries[i-1].Balance) > 0 {
+			t.Fatal("rich list is not sorted by balance")
+		}
+		addr, exists := et.explorer.Address(entry.UnlockHash)
+		if !exists || !addr.SiafundBalance.Equals(entry.Balance) {
+			t.Fatal("rich list entry does not match the address balance")
+		}
+		total = total.Add(entry.Balance)
+	}
+	if !total.Equals(types.SiafundCount) {
+		t.Fatal("rich list does not contain all siafunds:", total)
+	}
+
+	// Check the pagination of the rich list.
+	if len(entries) > 1 {
+		page, err := et.explorer.RichList(types.SpecifierSiafundOutput, 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) != 1 || page[0].UnlockHash != entries[1].UnlockHash || !page[0].Balance.Equals(entries[1].Balance) {
+			t.Fatal("rich list returned the wrong page:", page)
+		}
+	}
+	if _, err := et.explorer.RichList(types.SpecifierMinerFee, 0, 1); err != errUnknownFundType {
+		t.Fatal("expected errUnknownFundType, got", err)
+	}
+
+	// The siacoin rich list contains the matured miner payouts.
+	entries, err = et.explorer.RichList(types.SpecifierSiacoinOutput, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("siacoin rich list is empty")
+	}
+}
+
+// TestExplorerAddress checks that the balance, the unspent outputs and the
+// heights of an address are updated when coins are sent to the address and
+// when the block is reverted.
+func TestExplorerAddress(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var uh types.UnlockHash
+	fastrand.Read(uh[:])
+	if _, exists := et.explorer.Address(uh); exists {
+		t.Fatal("unknown address exists")
+	}
+
+	// Send coins to the address in two blocks.
+	amount := types.NewCurrency64(1e9)
+	for i := 0; i < 2; i++ {
+		_, err = et.wallet.SendSiacoins(amount, uh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = et.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	addr, exists := et.explorer.Address(uh)
+	if !exists {
+		t.Fatal("address does not exist after receiving coins")
+	}
+	if !addr.SiacoinBalance.Equals(amount.Mul64(2)) || !addr.SiafundBalance.IsZero() {
+		t.Fatal("address has the wrong balance:", addr.SiacoinBalance, addr.SiafundBalance)
+	}
+	if len(addr.SiacoinOutputs) != 2 || len(addr.SiafundOutputs) != 0 {
+		t.Fatal("address has the wrong unspent outputs:", addr.SiacoinOutputs, addr.SiafundOutputs)
+	}
+	if addr.FirstSeen != et.cs.Height()-1 || addr.LastSeen != et.cs.Height() {
+		t.Fatal("address has the wrong first and last seen heights:", addr.FirstSeen, addr.LastSeen)
+	}
+
+	// Reverting the blocks removes the address.
+	height := et.cs.Height()
+	err = et.reorgToBlank()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if et.cs.Height() <= height {
+		t.Fatal("the alternate chain did not reorg the explorer:", et.cs.Height())
+	}
+	if _, exists := et.explorer.Address(uh); exists {
+		t.Fatal("address exists after its blocks were reverted")
+	}
+	entries, err := et.explorer.RichList(types.SpecifierSiacoinOutput, 0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.UnlockHash == uh {
+			t.Fatal("address is in the rich list after its blocks were reverted")
+		}
+	}
+}
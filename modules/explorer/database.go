@@ -11,22 +11,33 @@ import (
 
 var (
 	// database buckets
-	bucketBlockFacts            = []byte("BlockFacts")
-	bucketBlockIDs              = []byte("BlockIDs")
-	bucketBlocksDifficulty      = []byte("BlocksDifficulty")
-	bucketBlockTargets          = []byte("BlockTargets")
-	bucketFileContractHistories = []byte("FileContractHistories")
-	bucketFileContractIDs       = []byte("FileContractIDs")
+	bucketAddresses                = []byte("Addresses")
+	bucketAddressHeights           = []byte("AddressHeights")
+	bucketAddressSiacoinOutputs    = []byte("AddressSiacoinOutputs")
+	bucketAddressSiafundOutputs    = []byte("AddressSiafundOutputs")
+	bucketBlockFacts               = []byte("BlockFacts")
+	bucketBlockIDs                 = []byte("BlockIDs")
+	bucketBlocksDifficulty         = []byte("BlocksDifficulty")
+	bucketBlockTargets             = []byte("BlockTargets")
+	bucketFileContractHistories    = []byte("FileContractHistories")
+	bucketFileContractIDs          = []byte("FileContractIDs")
+	bucketFileContractTransactions = []byte("FileContractTransactions")
 	// bucketInternal is used to store values internal to the explorer
-	bucketInternal         = []byte("Internal")
-	bucketSiacoinOutputIDs = []byte("SiacoinOutputIDs")
-	bucketSiacoinOutputs   = []byte("SiacoinOutputs")
-	bucketSiafundOutputIDs = []byte("SiafundOutputIDs")
-	bucketSiafundOutputs   = []byte("SiafundOutputs")
-	bucketTransactionIDs   = []byte("TransactionIDs")
-	bucketUnlockHashes     = []byte("UnlockHashes")
+	bucketInternal                  = []byte("Internal")
+	bucketSiacoinOutputIDs          = []byte("SiacoinOutputIDs")
+	bucketSiacoinOutputs            = []byte("SiacoinOutputs")
+	bucketSiacoinOutputTransactions = []byte("SiacoinOutputTransactions")
+	bucketSiacoinRichList           = []byte("SiacoinRichList")
+	bucketSiafundOutputIDs          = []byte("SiafundOutputIDs")
+	bucketSiafundOutputs            = []byte("SiafundOutputs")
+	bucketSiafundOutputTransactions = []byte("SiafundOutputTransactions")
+	bucketSiafundRichList           = []byte("SiafundRichList")
+	bucketTransactionIDs            = []byte("TransactionIDs")
+	bucketUnlockHashes              = []byte("UnlockHashes")
+	bucketUnlockHashTransactions    = []byte("UnlockHashTransactions")
 
-	errNotExist = errors.New("entry does not exist")
+	errNotExist        = errors.New("entry does not exist")
+	errUnknownFundType = errors.New("unknown fund type")
 
 	// keys for bucketInternal
 	internalBlockHeight  = []byte("BlockHeight")
@@ -0,0 +1,159 @@
+package explorer
+
+// history.go maintains the history indexes of the explorer. For every unlock
+// hash, siacoin output, file contract and siafund output, the transactions
+// that reference it are stored under keys that start with the big-endian
+// height of the transaction, so that the history can be read in order of
+// height and resumed from any key. Reverted blocks only remove the keys at
+// their heights, which keeps the order of the remaining keys stable.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// historyKeyLen is the length of the keys of the history indexes.
+const historyKeyLen = 8 + len(types.TransactionID{})
+
+var (
+	errInvalidCursor = errors.New("invalid history cursor")
+	errInvalidRange  = errors.New("end height is below start height")
+)
+
+// historyKey returns the key of a transaction at a height in a history index.
+func historyKey(height types.BlockHeight, txid types.TransactionID) []byte {
+	key := make([]byte, historyKeyLen)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	copy(key[8:], txid[:])
+	return key
+}
+
+// Add/Remove txid at a height from a history index
+func dbAddHistory(tx *bolt.Tx, bucket []byte, id interface{}, txid types.TransactionID, height types.BlockHeight) {
+	b, err := tx.Bucket(bucket).CreateBucketIfNotExists(encoding.Marshal(id))
+	assertNil(err)
+	assertNil(b.Put(historyKey(height, txid), nil))
+}
+func dbRemoveHistory(tx *bolt.Tx, bucket []byte, id interface{}, txid types.TransactionID, height types.BlockHeight) {
+	b := tx.Bucket(bucket).Bucket(encoding.Marshal(id))
+	if b == nil {
+		// The transaction referenced the id more than once.
+		return
+	}
+	assertNil(b.Delete(historyKey(height, txid)))
+	if bucketIsEmpty(b) {
+		tx.Bucket(bucket).DeleteBucket(encoding.Marshal(id))
+	}
+}
+
+// history returns a page of the history index of id. Only transactions with a
+// height in the range of the query are returned, starting after the cursor of
+// the query.
+func (e *Explorer) history(bucket []byte, id interface{}, q modules.ExplorerHistoryQuery) (page modules.ExplorerHistoryPage, err error) {
+	if q.EndHeight < q.StartHeight {
+		return modules.ExplorerHistoryPage{}, errInvalidRange
+	} else if q.Limit == 0 {
+		return modules.ExplorerHistoryPage{}, nil
+	}
+	start := historyKey(q.StartHeight, types.TransactionID{})
+	var cursor []byte
+	if q.Cursor != "" {
+		cursor, err = hex.DecodeString(q.Cursor)
+		if err != nil || len(cursor) != historyKeyLen {
+			return modules.ExplorerHistoryPage{}, errInvalidCursor
+		}
+		if bytes.Compare(cursor, start) >= 0 {
+			start = cursor
+		}
+	}
+
+	err = e.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket).Bucket(encoding.Marshal(id))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		k, _ := c.Seek(start)
+		if k != nil && bytes.Equal(k, cursor) {
+			k, _ = c.Next()
+		}
+		var last []byte
+		for ; k != nil; k, _ = c.Next() {
+			height := types.BlockHeight(binary.BigEndian.Uint64(k))
+			if height > q.EndHeight {
+				break
+			}
+			if uint64(len(page.Entries)) == q.Limit {
+				// There are more entries; the next page starts after the
+				// last entry of this page.
+				page.NextCursor = hex.EncodeToString(last)
+				break
+			}
+			entry := modules.ExplorerHistoryEntry{Height: height}
+			copy(entry.TransactionID[:], k[8:])
+			page.Entries = append(page.Entries, entry)
+			last = k
+		}
+		return nil
+	})
+	return page, err
+}
+
+// UnlockHashTransactions returns a page of the transactions that contain the
+// unlock hash, in order of height.
+func (e *Explorer) UnlockHashTransactions(uh types.UnlockHash, q modules.ExplorerHistoryQuery) (modules.ExplorerHistoryPage, error) {
+	return e.history(bucketUnlockHashTransactions, uh, q)
+}
+
+// SiacoinOutputTransactions returns a page of the transactions that contain
+// the siacoin output ID, in order of height.
+func (e *Explorer) SiacoinOutputTransactions(id types.SiacoinOutputID, q modules.ExplorerHistoryQuery) (modules.ExplorerHistoryPage, error) {
+	return e.history(bucketSiacoinOutputTransactions, id, q)
+}
+
+// FileContractTransactions returns a page of the transactions that contain
+// the file contract ID, in order of height.
+func (e *Explorer) FileContractTransactions(id types.FileContractID, q modules.ExplorerHistoryQuery) (modules.ExplorerHistoryPage, error) {
+	return e.history(bucketFileContractTransactions, id, q)
+}
+
+// SiafundOutputTransactions returns a page of the transactions that contain
+// the siafund output ID, in order of height.
+func (e *Explorer) SiafundOutputTransactions(id types.SiafundOutputID, q modules.ExplorerHistoryQuery) (modules.ExplorerHistoryPage, error) {
+	return e.history(bucketSiafundOutputTransactions, id, q)
+}
+
+// BlockFactsRange returns the block facts of the blocks in the range
+// [start, end]. The range ends early at the current height of the explorer.
+func (e *Explorer) BlockFactsRange(start, end types.BlockHeight) ([]modules.BlockFacts, error) {
+	if end < start {
+		return nil, errInvalidRange
+	}
+	var facts []modules.BlockFacts
+	err := e.db.View(func(tx *bolt.Tx) error {
+		var height types.BlockHeight
+		if err := dbGetInternal(internalBlockHeight, &height)(tx); err != nil {
+			return err
+		}
+		if end > height {
+			end = height
+		}
+		for h := start; h <= end; h++ {
+			var bf blockFacts
+			if err := e.dbGetBlockFacts(h, &bf)(tx); err != nil {
+				return err
+			}
+			facts = append(facts, bf.BlockFacts)
+		}
+		return nil
+	})
+	return facts, err
+}
@@ -0,0 +1,161 @@
+package explorer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestExplorerUnlockHashTransactions checks the pagination and the height
+// filters of the history of an unlock hash.
+func TestExplorerUnlockHashTransactions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send coins to the address in three blocks.
+	var uh types.UnlockHash
+	fastrand.Read(uh[:])
+	var txids []types.TransactionID
+	for i := 0; i < 3; i++ {
+		txns, err := et.wallet.SendSiacoins(types.NewCurrency64(1e9), uh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txids = append(txids, txns[len(txns)-1].ID())
+		_, err = et.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	height := et.cs.Height()
+
+	// Page through the history.
+	q := modules.ExplorerHistoryQuery{
+		EndHeight: types.BlockHeight(math.MaxUint64),
+		Limit:     2,
+	}
+	page, err := et.explorer.UnlockHashTransactions(uh, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 2 || page.NextCursor == "" {
+		t.Fatal("first page is wrong:", page)
+	}
+	q.Cursor = page.NextCursor
+	page2, err := et.explorer.UnlockHashTransactions(uh, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page2.Entries) != 1 || page2.NextCursor != "" {
+		t.Fatal("second page is wrong:", page2)
+	}
+	entries := append(page.Entries, page2.Entries...)
+	for i, entry := range entries {
+		if entry.TransactionID != txids[i] || entry.Height != height-2+types.BlockHeight(i) {
+			t.Fatal("history entry is wrong:", i, entry)
+		}
+	}
+
+	// Filter the history by height.
+	q = modules.ExplorerHistoryQuery{
+		StartHeight: height - 1,
+		EndHeight:   height - 1,
+		Limit:       10,
+	}
+	page, err = et.explorer.UnlockHashTransactions(uh, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].TransactionID != txids[1] {
+		t.Fatal("height filter returned the wrong entries:", page)
+	}
+
+	// Invalid queries are rejected.
+	q.Cursor = "foo"
+	if _, err := et.explorer.UnlockHashTransactions(uh, q); err != errInvalidCursor {
+		t.Fatal("expected errInvalidCursor, got", err)
+	}
+	q = modules.ExplorerHistoryQuery{StartHeight: 2, EndHeight: 1, Limit: 10}
+	if _, err := et.explorer.UnlockHashTransactions(uh, q); err != errInvalidRange {
+		t.Fatal("expected errInvalidRange, got", err)
+	}
+
+	// The history of the output is indexed as well.
+	var scoid types.SiacoinOutputID
+	for _, txn := range mustBlockTransactions(t, et, height) {
+		for i, sco := range txn.SiacoinOutputs {
+			if sco.UnlockHash == uh {
+				scoid = txn.SiacoinOutputID(uint64(i))
+			}
+		}
+	}
+	page, err = et.explorer.SiacoinOutputTransactions(scoid, modules.ExplorerHistoryQuery{EndHeight: height, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Entries) != 1 || page.Entries[0].TransactionID != txids[2] {
+		t.Fatal("siacoin output history is wrong:", page)
+	}
+}
+
+// mustBlockTransactions returns the transactions of the block at the given
+// height.
+func mustBlockTransactions(t *testing.T, et *explorerTester, height types.BlockHeight) []types.Transaction {
+	block, exists := et.cs.BlockAtHeight(height)
+	if !exists {
+		t.Fatal("no block at height", height)
+	}
+	return block.Transactions
+}
+
+// TestExplorerBlockFactsRange checks that BlockFactsRange returns the block
+// facts of a range of heights.
+func TestExplorerBlockFactsRange(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for et.cs.Height() < 5 {
+		if _, err := et.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	facts, err := et.explorer.BlockFactsRange(2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(facts) != 4 {
+		t.Fatal("wrong number of block facts:", len(facts))
+	}
+	for i, bf := range facts {
+		if bf.Height != types.BlockHeight(i)+2 {
+			t.Fatal("block facts have the wrong height:", bf.Height)
+		}
+	}
+
+	// The range ends at the current height.
+	height := et.cs.Height()
+	facts, err = et.explorer.BlockFactsRange(height-1, height+10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(facts) != 2 || facts[1].Height != height {
+		t.Fatal("range was not cut off at the current height:", len(facts))
+	}
+	if _, err := et.explorer.BlockFactsRange(5, 2); err != errInvalidRange {
+		t.Fatal("expected errInvalidRange, got", err)
+	}
+}
@@ -35,19 +35,42 @@ func (e *Explorer) initPersist() error {
 	// Initialize the database
 	err = e.db.Update(func(tx *bolt.Tx) error {
 		buckets := [][]byte{
+			bucketAddresses,
+			bucketAddressHeights,
+			bucketAddressSiacoinOutputs,
+			bucketAddressSiafundOutputs,
 			bucketBlockFacts,
 			bucketBlockIDs,
 			bucketBlocksDifficulty,
 			bucketBlockTargets,
 			bucketFileContractHistories,
 			bucketFileContractIDs,
+			bucketFileContractTransactions,
 			bucketInternal,
 			bucketSiacoinOutputIDs,
 			bucketSiacoinOutputs,
+			bucketSiacoinOutputTransactions,
+			bucketSiacoinRichList,
 			bucketSiafundOutputIDs,
 			bucketSiafundOutputs,
+			bucketSiafundOutputTransactions,
+			bucketSiafundRichList,
 			bucketTransactionIDs,
 			bucketUnlockHashes,
+			bucketUnlockHashTransactions,
+		}
+		// Databases of older explorers don't contain the address and history
+		// indexes. The existing buckets are dropped so that the explorer
+		// rebuilds its database from the genesis block.
+		if tx.Bucket(bucketInternal) != nil && (tx.Bucket(bucketAddresses) == nil || tx.Bucket(bucketUnlockHashTransactions) == nil) {
+			for _, b := range buckets {
+				if tx.Bucket(b) == nil {
+					continue
+				}
+				if err := tx.DeleteBucket(b); err != nil {
+					return err
+				}
+			}
 		}
 		for _, b := range buckets {
 			_, err := tx.CreateBucketIfNotExists(b)
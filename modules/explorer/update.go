@@ -38,7 +38,6 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			bid := block.ID()
 			tbid := types.TransactionID(bid)
 
-			blockheight--
 			dbRemoveBlockID(tx, bid)
 			dbRemoveTransactionID(tx, tbid) // Miner payouts are a transaction
 
@@ -51,8 +50,8 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			// Remove miner payouts
 			for j, payout := range block.MinerPayouts {
 				scoid := block.MinerPayoutID(uint64(j))
-				dbRemoveSiacoinOutputID(tx, scoid, tbid)
-				dbRemoveUnlockHash(tx, payout.UnlockHash, tbid)
+				dbRemoveSiacoinOutputID(tx, scoid, tbid, blockheight)
+				dbRemoveUnlockHash(tx, payout.UnlockHash, tbid, blockheight)
 			}
 
 			// Remove transactions
@@ -61,44 +60,44 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 				dbRemoveTransactionID(tx, txid)
 
 				for _, sci := range txn.SiacoinInputs {
-					dbRemoveSiacoinOutputID(tx, sci.ParentID, txid)
-					dbRemoveUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid)
+					dbRemoveSiacoinOutputID(tx, sci.ParentID, txid, blockheight)
+					dbRemoveUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid, blockheight)
 				}
 				for k, sco := range txn.SiacoinOutputs {
 					scoid := txn.SiacoinOutputID(uint64(k))
-					dbRemoveSiacoinOutputID(tx, scoid, txid)
-					dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+					dbRemoveSiacoinOutputID(tx, scoid, txid, blockheight)
+					dbRemoveUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					dbRemoveSiacoinOutput(tx, scoid)
 				}
 				for k, fc := range txn.FileContracts {
 					fcid := txn.FileContractID(uint64(k))
-					dbRemoveFileContractID(tx, fcid, txid)
-					dbRemoveUnlockHash(tx, fc.UnlockHash, txid)
+					dbRemoveFileContractID(tx, fcid, txid, blockheight)
+					dbRemoveUnlockHash(tx, fc.UnlockHash, txid, blockheight)
 					for l, sco := range fc.ValidProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofValid, uint64(l))
-						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fc.MissedProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofMissed, uint64(l))
-						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					dbRemoveFileContract(tx, fcid)
 				}
 				for _, fcr := range txn.FileContractRevisions {
-					dbRemoveFileContractID(tx, fcr.ParentID, txid)
-					dbRemoveUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid)
-					dbRemoveUnlockHash(tx, fcr.NewUnlockHash, txid)
+					dbRemoveFileContractID(tx, fcr.ParentID, txid, blockheight)
+					dbRemoveUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbRemoveUnlockHash(tx, fcr.NewUnlockHash, txid, blockheight)
 					for l, sco := range fcr.NewValidProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofValid, uint64(l))
-						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fcr.NewMissedProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofMissed, uint64(l))
-						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					// Remove the file contract revision from the revision chain.
 					dbRemoveFileContractRevision(tx, fcr.ParentID)
@@ -107,19 +106,20 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 					dbRemoveStorageProof(tx, sp.ParentID)
 				}
 				for _, sfi := range txn.SiafundInputs {
-					dbRemoveSiafundOutputID(tx, sfi.ParentID, txid)
-					dbRemoveUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid)
-					dbRemoveUnlockHash(tx, sfi.ClaimUnlockHash, txid)
+					dbRemoveSiafundOutputID(tx, sfi.ParentID, txid, blockheight)
+					dbRemoveUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbRemoveUnlockHash(tx, sfi.ClaimUnlockHash, txid, blockheight)
 				}
 				for k, sfo := range txn.SiafundOutputs {
 					sfoid := txn.SiafundOutputID(uint64(k))
-					dbRemoveSiafundOutputID(tx, sfoid, txid)
-					dbRemoveUnlockHash(tx, sfo.UnlockHash, txid)
+					dbRemoveSiafundOutputID(tx, sfoid, txid, blockheight)
+					dbRemoveUnlockHash(tx, sfo.UnlockHash, txid, blockheight)
 				}
 			}
 
 			// remove the associated block facts
 			dbRemoveBlockFacts(tx, bid)
+			blockheight--
 		}
 
 		// Update cumulative stats for applied blocks.
@@ -146,8 +146,8 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			// Catalog the new miner payouts.
 			for j, payout := range block.MinerPayouts {
 				scoid := block.MinerPayoutID(uint64(j))
-				dbAddSiacoinOutputID(tx, scoid, tbid)
-				dbAddUnlockHash(tx, payout.UnlockHash, tbid)
+				dbAddSiacoinOutputID(tx, scoid, tbid, blockheight)
+				dbAddUnlockHash(tx, payout.UnlockHash, tbid, blockheight)
 			}
 
 			// Update cumulative stats for applied transactions.
@@ -157,59 +157,59 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 				dbAddTransactionID(tx, txid, blockheight)
 
 				for _, sci := range txn.SiacoinInputs {
-					dbAddSiacoinOutputID(tx, sci.ParentID, txid)
-					dbAddUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid)
+					dbAddSiacoinOutputID(tx, sci.ParentID, txid, blockheight)
+					dbAddUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid, blockheight)
 				}
 				for j, sco := range txn.SiacoinOutputs {
 					scoid := txn.SiacoinOutputID(uint64(j))
-					dbAddSiacoinOutputID(tx, scoid, txid)
-					dbAddUnlockHash(tx, sco.UnlockHash, txid)
+					dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+					dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 				}
 				for k, fc := range txn.FileContracts {
 					fcid := txn.FileContractID(uint64(k))
-					dbAddFileContractID(tx, fcid, txid)
-					dbAddUnlockHash(tx, fc.UnlockHash, txid)
+					dbAddFileContractID(tx, fcid, txid, blockheight)
+					dbAddUnlockHash(tx, fc.UnlockHash, txid, blockheight)
 					dbAddFileContract(tx, fcid, fc)
 					for l, sco := range fc.ValidProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofValid, uint64(l))
-						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fc.MissedProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofMissed, uint64(l))
-						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 				}
 				for _, fcr := range txn.FileContractRevisions {
-					dbAddFileContractID(tx, fcr.ParentID, txid)
-					dbAddUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid)
-					dbAddUnlockHash(tx, fcr.NewUnlockHash, txid)
+					dbAddFileContractID(tx, fcr.ParentID, txid, blockheight)
+					dbAddUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbAddUnlockHash(tx, fcr.NewUnlockHash, txid, blockheight)
 					for l, sco := range fcr.NewValidProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofValid, uint64(l))
-						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fcr.NewMissedProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofMissed, uint64(l))
-						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddSiacoinOutputID(tx, scoid, txid, blockheight)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					dbAddFileContractRevision(tx, fcr.ParentID, fcr)
 				}
 				for _, sp := range txn.StorageProofs {
-					dbAddFileContractID(tx, sp.ParentID, txid)
+					dbAddFileContractID(tx, sp.ParentID, txid, blockheight)
 					dbAddStorageProof(tx, sp.ParentID, sp)
 				}
 				for _, sfi := range txn.SiafundInputs {
-					dbAddSiafundOutputID(tx, sfi.ParentID, txid)
-					dbAddUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid)
-					dbAddUnlockHash(tx, sfi.ClaimUnlockHash, txid)
+					dbAddSiafundOutputID(tx, sfi.ParentID, txid, blockheight)
+					dbAddUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbAddUnlockHash(tx, sfi.ClaimUnlockHash, txid, blockheight)
 				}
 				for k, sfo := range txn.SiafundOutputs {
 					sfoid := txn.SiafundOutputID(uint64(k))
-					dbAddSiafundOutputID(tx, sfoid, txid)
-					dbAddUnlockHash(tx, sfo.UnlockHash, txid)
+					dbAddSiafundOutputID(tx, sfoid, txid, blockheight)
+					dbAddUnlockHash(tx, sfo.UnlockHash, txid, blockheight)
 				}
 			}
 
@@ -220,17 +220,23 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			}
 		}
 
-		// Update stats according to SiacoinOutputDiffs
+		// Update stats and address balances according to SiacoinOutputDiffs
 		for _, scod := range cc.SiacoinOutputDiffs {
 			if scod.Direction == modules.DiffApply {
 				dbAddSiacoinOutput(tx, scod.ID, scod.SiacoinOutput)
+				dbAddAddressSiacoinOutput(tx, scod.ID, scod.SiacoinOutput)
+			} else {
+				dbRemoveAddressSiacoinOutput(tx, scod.ID, scod.SiacoinOutput)
 			}
 		}
 
-		// Update stats according to SiafundOutputDiffs
+		// Update stats and address balances according to SiafundOutputDiffs
 		for _, sfod := range cc.SiafundOutputDiffs {
 			if sfod.Direction == modules.DiffApply {
 				dbAddSiafundOutput(tx, sfod.ID, sfod.SiafundOutput)
+				dbAddAddressSiafundOutput(tx, sfod.ID, sfod.SiafundOutput)
+			} else {
+				dbRemoveAddressSiafundOutput(tx, sfod.ID, sfod.SiafundOutput)
 			}
 		}
 
@@ -340,17 +346,19 @@ func dbRemoveFileContract(tx *bolt.Tx, id types.FileContractID) {
 }
 
 // Add/Remove txid from file contract ID bucket
-func dbAddFileContractID(tx *bolt.Tx, id types.FileContractID, txid types.TransactionID) {
+func dbAddFileContractID(tx *bolt.Tx, id types.FileContractID, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketFileContractIDs).CreateBucketIfNotExists(encoding.Marshal(id))
 	assertNil(err)
 	mustPutSet(b, txid)
+	dbAddHistory(tx, bucketFileContractTransactions, id, txid, height)
 }
-func dbRemoveFileContractID(tx *bolt.Tx, id types.FileContractID, txid types.TransactionID) {
+func dbRemoveFileContractID(tx *bolt.Tx, id types.FileContractID, txid types.TransactionID, height types.BlockHeight) {
 	bucket := tx.Bucket(bucketFileContractIDs).Bucket(encoding.Marshal(id))
 	mustDelete(bucket, txid)
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketFileContractIDs).DeleteBucket(encoding.Marshal(id))
 	}
+	dbRemoveHistory(tx, bucketFileContractTransactions, id, txid, height)
 }
 
 func dbAddFileContractRevision(tx *bolt.Tx, fcid types.FileContractID, fcr types.FileContractRevision) {
@@ -376,17 +384,19 @@ func dbRemoveSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) {
 }
 
 // Add/Remove txid from siacoin output ID bucket
-func dbAddSiacoinOutputID(tx *bolt.Tx, id types.SiacoinOutputID, txid types.TransactionID) {
+func dbAddSiacoinOutputID(tx *bolt.Tx, id types.SiacoinOutputID, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketSiacoinOutputIDs).CreateBucketIfNotExists(encoding.Marshal(id))
 	assertNil(err)
 	mustPutSet(b, txid)
+	dbAddHistory(tx, bucketSiacoinOutputTransactions, id, txid, height)
 }
-func dbRemoveSiacoinOutputID(tx *bolt.Tx, id types.SiacoinOutputID, txid types.TransactionID) {
+func dbRemoveSiacoinOutputID(tx *bolt.Tx, id types.SiacoinOutputID, txid types.TransactionID, height types.BlockHeight) {
 	bucket := tx.Bucket(bucketSiacoinOutputIDs).Bucket(encoding.Marshal(id))
 	mustDelete(bucket, txid)
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketSiacoinOutputIDs).DeleteBucket(encoding.Marshal(id))
 	}
+	dbRemoveHistory(tx, bucketSiacoinOutputTransactions, id, txid, height)
 }
 
 // Add/Remove siafund output
@@ -398,17 +408,19 @@ func dbRemoveSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) {
 }
 
 // Add/Remove txid from siafund output ID bucket
-func dbAddSiafundOutputID(tx *bolt.Tx, id types.SiafundOutputID, txid types.TransactionID) {
+func dbAddSiafundOutputID(tx *bolt.Tx, id types.SiafundOutputID, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketSiafundOutputIDs).CreateBucketIfNotExists(encoding.Marshal(id))
 	assertNil(err)
 	mustPutSet(b, txid)
+	dbAddHistory(tx, bucketSiafundOutputTransactions, id, txid, height)
 }
-func dbRemoveSiafundOutputID(tx *bolt.Tx, id types.SiafundOutputID, txid types.TransactionID) {
+func dbRemoveSiafundOutputID(tx *bolt.Tx, id types.SiafundOutputID, txid types.TransactionID, height types.BlockHeight) {
 	bucket := tx.Bucket(bucketSiafundOutputIDs).Bucket(encoding.Marshal(id))
 	mustDelete(bucket, txid)
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketSiafundOutputIDs).DeleteBucket(encoding.Marshal(id))
 	}
+	dbRemoveHistory(tx, bucketSiafundOutputTransactions, id, txid, height)
 }
 
 // Add/Remove storage proof
@@ -430,18 +442,24 @@ func dbRemoveTransactionID(tx *bolt.Tx, id types.TransactionID) {
 	mustDelete(tx.Bucket(bucketTransactionIDs), id)
 }
 
-// Add/Remove txid from unlock hash bucket
-func dbAddUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID) {
+// Add/Remove txid from unlock hash bucket. The height of the block containing
+// the transaction is added to/removed from the address heights and the
+// history of the unlock hash.
+func dbAddUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketUnlockHashes).CreateBucketIfNotExists(encoding.Marshal(uh))
 	assertNil(err)
 	mustPutSet(b, txid)
+	dbAddAddressHeight(tx, uh, height)
+	dbAddHistory(tx, bucketUnlockHashTransactions, uh, txid, height)
 }
-func dbRemoveUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID) {
+func dbRemoveUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
 	bucket := tx.Bucket(bucketUnlockHashes).Bucket(encoding.Marshal(uh))
 	mustDelete(bucket, txid)
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketUnlockHashes).DeleteBucket(encoding.Marshal(uh))
 	}
+	dbRemoveAddressHeight(tx, uh, height)
+	dbRemoveHistory(tx, bucketUnlockHashTransactions, uh, txid, height)
 }
 
 func dbCalculateBlockFacts(tx *bolt.Tx, cs modules.ConsensusSet, block types.Block) blockFacts {
@@ -532,8 +550,8 @@ func dbAddGenesisBlock(tx *bolt.Tx) {
 	dbAddTransactionID(tx, txid, 0)
 	for i, sfo := range types.GenesisSiafundAllocation {
 		sfoid := types.GenesisBlock.Transactions[0].SiafundOutputID(uint64(i))
-		dbAddSiafundOutputID(tx, sfoid, txid)
-		dbAddUnlockHash(tx, sfo.UnlockHash, txid)
+		dbAddSiafundOutputID(tx, sfoid, txid, 0)
+		dbAddUnlockHash(tx, sfo.UnlockHash, txid, 0)
 		dbAddSiafundOutput(tx, sfoid, sfo)
 	}
 	dbAddBlockFacts(tx, blockFacts{
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"net"
+	"time"
 
 	"github.com/acejam/Sia/build"
 )
@@ -12,6 +13,29 @@ const (
 	GatewayDir = "gateway"
 )
 
+// Misbehavior scores that modules report to the gateway when a peer supplies
+// data that they reject. A peer whose accumulated score reaches the gateway's
+// ban threshold is automatically added to the blocklist.
+const (
+	// MisbehaviorInvalidBlock is reported when a peer relays a block that
+	// fails validation.
+	MisbehaviorInvalidBlock = 100
+
+	// MisbehaviorInvalidHeader is reported when a peer relays a block header
+	// that fails validation.
+	MisbehaviorInvalidHeader = 20
+
+	// MisbehaviorInvalidTransactionSet is reported when a peer relays a
+	// transaction set that is malformed or non-standard.
+	MisbehaviorInvalidTransactionSet = 10
+
+	// MisbehaviorConflictingTransactionSet is reported when a peer relays a
+	// transaction set that is invalid given the current consensus set. Honest
+	// peers occasionally relay such sets during reorgs and double-spend races,
+	// so the score is low.
+	MisbehaviorConflictingTransactionSet = 1
+)
+
 var (
 	// BootstrapPeers is a list of peers that can be used to find other peers -
 	// when a client first connects to the network, the only options for
@@ -135,6 +159,39 @@ type (
 		Local      bool       `json:"local"`
 		NetAddress NetAddress `json:"netaddress"`
 		Version    string     `json:"version"`
+
+		// Traffic statistics of the connection to the peer. BytesIn and
+		// BytesOut count the bytes received from and sent to the peer since
+		// ConnectedSince. RPCsCalled is the number of RPCs the gateway called
+		// on the peer, RPCsHandled the number of RPCs the peer called on the
+		// gateway.
+		BytesIn        uint64    `json:"bytesin"`
+		BytesOut       uint64    `json:"bytesout"`
+		ConnectedSince time.Time `json:"connectedsince"`
+		RPCsCalled     uint64    `json:"rpcscalled"`
+		RPCsHandled    uint64    `json:"rpcshandled"`
+	}
+
+	// GatewayBlocklistEntry is an IP address or subnet that the gateway
+	// refuses to connect to or accept connections from.
+	GatewayBlocklistEntry struct {
+		// Address is either a single IP address or a subnet in CIDR notation.
+		Address string `json:"address"`
+		Reason  string `json:"reason"`
+		// Automatic is true if the entry was added because the peer's
+		// misbehavior score reached the ban threshold.
+		Automatic bool `json:"automatic"`
+		// Expiry is the time at which the entry is removed. The zero value
+		// indicates that the entry never expires.
+		Expiry time.Time `json:"expiry"`
+	}
+
+	// GatewayMisbehavior is the current misbehavior score of an IP address.
+	GatewayMisbehavior struct {
+		Address    string    `json:"address"`
+		Score      uint64    `json:"score"`
+		LastReason string    `json:"lastreason"`
+		LastUpdate time.Time `json:"lastupdate"`
 	}
 
 	// A PeerConn is the connection type used when communicating with peers during
@@ -208,6 +265,37 @@ type (
 		// Online returns true if the gateway is connected to remote hosts
 		Online() bool
 
+		// AddMisbehavior increases the misbehavior score of the peer at the
+		// given address. If the score reaches the ban threshold, the peer's
+		// IP address is added to the blocklist and the peer is disconnected.
+		AddMisbehavior(addr NetAddress, score uint64, reason string)
+
+		// Block adds an IP address or CIDR subnet to the blocklist and
+		// disconnects all matching peers. A zero duration blocks the address
+		// permanently.
+		Block(addr string, duration time.Duration, reason string) error
+
+		// Blocklist returns the addresses and subnets that are currently
+		// blocked.
+		Blocklist() []GatewayBlocklistEntry
+
+		// Misbehavior returns the current misbehavior scores of all
+		// addresses that have misbehaved recently.
+		Misbehavior() []GatewayMisbehavior
+
+		// RateLimits returns the bandwidth limits in bytes per second that
+		// apply to the combined traffic of all peers. A value of 0 means
+		// that the bandwidth is not limited.
+		RateLimits() (downloadBPS, uploadBPS int64)
+
+		// SetRateLimits changes the bandwidth limits in bytes per second that
+		// apply to the combined traffic of all peers. A value of 0 disables
+		// the limit.
+		SetRateLimits(downloadBPS, uploadBPS int64) error
+
+		// Unblock removes an IP address or CIDR subnet from the blocklist.
+		Unblock(addr string) error
+
 		// Close safely stops the Gateway's listener process.
 		Close() error
 	}
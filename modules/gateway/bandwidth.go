@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+
+	"gitlab.com/NebulousLabs/ratelimit"
+)
+
+// rateLimitPacketSize is the packet size used by the gateway's rate limiter.
+const rateLimitPacketSize = 4 * 4096
+
+// errNegativeRateLimit is returned if a bandwidth limit below 0 is supplied.
+var errNegativeRateLimit = errors.New("download/upload rate limit can't be below 0")
+
+// peerTraffic contains the traffic counters of a peer connection. All fields
+// are accessed atomically.
+type peerTraffic struct {
+	bytesIn     uint64
+	bytesOut    uint64
+	rpcsCalled  uint64
+	rpcsHandled uint64
+}
+
+// countingConn wraps a net.Conn and counts the bytes read from and written to
+// it.
+type countingConn struct {
+	net.Conn
+	traffic *peerTraffic
+}
+
+// Read implements the io.Reader interface.
+func (cc countingConn) Read(b []byte) (int, error) {
+	n, err := cc.Conn.Read(b)
+	atomic.AddUint64(&cc.traffic.bytesIn, uint64(n))
+	return n, err
+}
+
+// Write implements the io.Writer interface.
+func (cc countingConn) Write(b []byte) (int, error) {
+	n, err := cc.Conn.Write(b)
+	atomic.AddUint64(&cc.traffic.bytesOut, uint64(n))
+	return n, err
+}
+
+// staticWrapPeerConn wraps the connection of a peer, so that its traffic is
+// counted and subject to the gateway's bandwidth limits. It must be called
+// after the handshake is complete and before the stream session is created.
+func (g *Gateway) staticWrapPeerConn(conn net.Conn, traffic *peerTraffic) net.Conn {
+	return countingConn{
+		Conn:    ratelimit.NewRLConn(conn, g.staticRL, g.threads.StopChan()),
+		traffic: traffic,
+	}
+}
+
+// setRateLimits applies the bandwidth limits to the gateway's rate limiter.
+func (g *Gateway) setRateLimits(downloadBPS, uploadBPS int64) {
+	if downloadBPS == 0 && uploadBPS == 0 {
+		g.staticRL.SetLimits(0, 0, 0)
+		return
+	}
+	g.staticRL.SetLimits(downloadBPS, uploadBPS, rateLimitPacketSize)
+}
+
+// RateLimits returns the bandwidth limits in bytes per second that apply to
+// the combined traffic of all peers. A value of 0 means that the bandwidth is
+// not limited.
+func (g *Gateway) RateLimits() (downloadBPS, uploadBPS int64) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.settings.MaxDownloadSpeed, g.settings.MaxUploadSpeed
+}
+
+// SetRateLimits changes the bandwidth limits in bytes per second that apply to
+// the combined traffic of all peers. A value of 0 disables the limit. The
+// limits are persisted and apply to existing connections as well.
+func (g *Gateway) SetRateLimits(downloadBPS, uploadBPS int64) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	if downloadBPS < 0 || uploadBPS < 0 {
+		return errNegativeRateLimit
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.settings.MaxDownloadSpeed = downloadBPS
+	g.settings.MaxUploadSpeed = uploadBPS
+	g.setRateLimits(downloadBPS, uploadBPS)
+	return g.saveSettings()
+}
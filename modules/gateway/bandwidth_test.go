@@ -0,0 +1,130 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
+)
+
+// peerInfo returns the modules.Peer of the peer at addr.
+func peerInfo(g *Gateway, addr modules.NetAddress) (modules.Peer, bool) {
+	for _, p := range g.Peers() {
+		if p.NetAddress == addr {
+			return p, true
+		}
+	}
+	return modules.Peer{}, false
+}
+
+// TestPeerTraffic tests that the bytes and RPCs exchanged with a peer are
+// counted.
+func TestPeerTraffic(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	g2.RegisterRPC("Foo", func(conn modules.PeerConn) error {
+		var b []byte
+		if err := encoding.ReadObject(conn, &b, 1<<20); err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, make([]byte, 2000))
+	})
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for g2 to register g1 as a peer.
+	var g1Addr modules.NetAddress
+	err := build.Retry(50, 100e6, func() error {
+		for _, p := range g2.Peers() {
+			g1Addr = p.NetAddress
+			return nil
+		}
+		return errNoPeers
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, _ := peerInfo(g1, g2.Address())
+	if before.ConnectedSince.IsZero() {
+		t.Fatal("ConnectedSince was not set")
+	}
+
+	err = g1.RPC(g2.Address(), "Foo", func(conn modules.PeerConn) error {
+		if err := encoding.WriteObject(conn, make([]byte, 1000)); err != nil {
+			return err
+		}
+		var b []byte
+		return encoding.ReadObject(conn, &b, 1<<20)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, ok := peerInfo(g1, g2.Address())
+	if !ok {
+		t.Fatal("peer disconnected")
+	}
+	// Other RPCs, such as ShareNodes, may be called concurrently.
+	if after.RPCsCalled <= before.RPCsCalled {
+		t.Errorf("expected more than %v RPCs called, got %v", before.RPCsCalled, after.RPCsCalled)
+	}
+	if after.BytesOut-before.BytesOut < 1000 {
+		t.Errorf("expected at least 1000 bytes out, got %v", after.BytesOut-before.BytesOut)
+	}
+	if after.BytesIn-before.BytesIn < 2000 {
+		t.Errorf("expected at least 2000 bytes in, got %v", after.BytesIn-before.BytesIn)
+	}
+
+	// g2 should have counted the handled RPC and the received bytes.
+	err = build.Retry(50, 100e6, func() error {
+		info, ok := peerInfo(g2, g1Addr)
+		if !ok || info.RPCsHandled == 0 || info.BytesIn < 1000 {
+			return errNoPeers
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("remote traffic was not counted:", err)
+	}
+}
+
+// TestSetRateLimits tests that the bandwidth limits are validated and
+// persisted.
+func TestSetRateLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+
+	if down, up := g.RateLimits(); down != 0 || up != 0 {
+		t.Fatal("expected no default limits, got", down, up)
+	}
+	if err := g.SetRateLimits(-1, 0); err != errNegativeRateLimit {
+		t.Fatalf("expected %v, got %v", errNegativeRateLimit, err)
+	}
+	if err := g.SetRateLimits(1e6, 5e5); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := New("localhost:0", false, g.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if down, up := g.RateLimits(); down != 1e6 || up != 5e5 {
+		t.Fatal("limits were not persisted:", down, up)
+	}
+}
@@ -0,0 +1,272 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/acejam/Sia/modules"
+)
+
+var (
+	errInvalidBlocklistAddress = errors.New("blocklist address must be an IP address or a CIDR subnet")
+	errNotBlocked              = errors.New("address is not on the blocklist")
+	errPeerBlocked             = errors.New("peer is on the blocklist")
+)
+
+type (
+	// blocklistEntry is an entry of the gateway's blocklist together with the
+	// parsed subnet that it covers. Single IP addresses are stored as subnets
+	// containing only that address.
+	blocklistEntry struct {
+		modules.GatewayBlocklistEntry
+		subnet *net.IPNet
+	}
+
+	// misbehavior tracks the misbehavior score of a single IP address.
+	misbehavior struct {
+		score      uint64
+		lastReason string
+		lastUpdate time.Time
+	}
+)
+
+// parseBlocklistAddress parses an IP address, an IP address with a port, or a
+// CIDR subnet and returns the normalized form of the address together with the
+// subnet that it covers.
+func parseBlocklistAddress(addr string) (string, *net.IPNet, error) {
+	if strings.Contains(addr, "/") {
+		_, subnet, err := net.ParseCIDR(addr)
+		if err != nil {
+			return "", nil, errInvalidBlocklistAddress
+		}
+		return subnet.String(), subnet, nil
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return "", nil, errInvalidBlocklistAddress
+		}
+		ip = net.ParseIP(host)
+	}
+	if ip == nil {
+		return "", nil, errInvalidBlocklistAddress
+	}
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 8 * net.IPv4len
+	}
+	return ip.String(), &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// decayedScore returns a misbehavior score after halving it once for every
+// misbehaviorDecayInterval that has passed since the last update.
+func decayedScore(score uint64, lastUpdate, now time.Time) uint64 {
+	halvings := uint64(now.Sub(lastUpdate) / misbehaviorDecayInterval)
+	if halvings >= 64 {
+		return 0
+	}
+	return score >> halvings
+}
+
+// blockEntry adds an entry to the blocklist and disconnects all peers and
+// removes all nodes covered by it.
+func (g *Gateway) blockEntry(key string, entry *blocklistEntry) {
+	g.blocklist[key] = entry
+	for addr, p := range g.peers {
+		if ip := net.ParseIP(addr.Host()); ip != nil && entry.subnet.Contains(ip) {
+			p.sess.Close()
+			delete(g.peers, addr)
+			g.log.Printf("INFO: disconnected from blocked peer %v\n", addr)
+		}
+	}
+	for addr := range g.nodes {
+		if ip := net.ParseIP(addr.Host()); ip != nil && entry.subnet.Contains(ip) {
+			delete(g.nodes, addr)
+		}
+	}
+}
+
+// isBlocked returns true if the given host is covered by an entry of the
+// blocklist that has not yet expired.
+func (g *Gateway) isBlocked(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	now := time.Now()
+	for _, entry := range g.blocklist {
+		if !entry.Expiry.IsZero() && now.After(entry.Expiry) {
+			continue
+		}
+		if entry.subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneBlocklist removes all expired entries from the blocklist.
+func (g *Gateway) pruneBlocklist() {
+	now := time.Now()
+	for key, entry := range g.blocklist {
+		if !entry.Expiry.IsZero() && now.After(entry.Expiry) {
+			delete(g.blocklist, key)
+		}
+	}
+}
+
+// AddMisbehavior increases the misbehavior score of the peer at the given
+// address. If the score reaches the ban threshold, the peer's IP address is
+// added to the blocklist and the peer is disconnected. Local peers are never
+// banned automatically, since they are assumed to be under the control of the
+// operator.
+func (g *Gateway) AddMisbehavior(addr modules.NetAddress, score uint64, reason string) {
+	if g.threads.Add() != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	key, subnet, err := parseBlocklistAddress(addr.Host())
+	if err != nil || addr.IsLocal() {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	m, exists := g.misbehavior[key]
+	if !exists {
+		m = &misbehavior{}
+		g.misbehavior[key] = m
+	}
+	m.score = decayedScore(m.score, m.lastUpdate, now) + score
+	m.lastReason = reason
+	m.lastUpdate = now
+	g.log.Debugf("INFO: misbehavior score of %v increased by %v to %v: %v", key, score, m.score, reason)
+	if m.score < misbehaviorBanThreshold {
+		return
+	}
+
+	// The ban threshold was reached, block the address.
+	delete(g.misbehavior, key)
+	if g.isBlocked(key) {
+		return
+	}
+	g.blockEntry(key, &blocklistEntry{
+		GatewayBlocklistEntry: modules.GatewayBlocklistEntry{
+			Address:   key,
+			Reason:    fmt.Sprintf("misbehavior score reached %v: %v", m.score, reason),
+			Automatic: true,
+			Expiry:    now.Add(automaticBanDuration),
+		},
+		subnet: subnet,
+	})
+	g.log.Printf("INFO: banned %v until %v: %v\n", key, now.Add(automaticBanDuration), reason)
+	if err := g.saveBlocklist(); err != nil {
+		g.log.Println("ERROR: Unable to save gateway blocklist:", err)
+	}
+}
+
+// Block adds an IP address or CIDR subnet to the blocklist and disconnects all
+// matching peers. A zero duration blocks the address permanently.
+func (g *Gateway) Block(addr string, duration time.Duration, reason string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	key, subnet, err := parseBlocklistAddress(addr)
+	if err != nil {
+		return err
+	}
+	if duration < 0 {
+		return errors.New("blocklist duration cannot be negative")
+	}
+	var expiry time.Time
+	if duration > 0 {
+		expiry = time.Now().Add(duration)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.blockEntry(key, &blocklistEntry{
+		GatewayBlocklistEntry: modules.GatewayBlocklistEntry{
+			Address: key,
+			Reason:  reason,
+			Expiry:  expiry,
+		},
+		subnet: subnet,
+	})
+	g.log.Printf("INFO: added %v to the blocklist: %v\n", key, reason)
+	return g.saveBlocklist()
+}
+
+// Blocklist returns the addresses and subnets that are currently blocked.
+func (g *Gateway) Blocklist() []modules.GatewayBlocklistEntry {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pruneBlocklist()
+	entries := make([]modules.GatewayBlocklistEntry, 0, len(g.blocklist))
+	for _, entry := range g.blocklist {
+		entries = append(entries, entry.GatewayBlocklistEntry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Address < entries[j].Address
+	})
+	return entries
+}
+
+// Misbehavior returns the current misbehavior scores of all addresses that
+// have misbehaved recently.
+func (g *Gateway) Misbehavior() []modules.GatewayMisbehavior {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	scores := make([]modules.GatewayMisbehavior, 0, len(g.misbehavior))
+	for key, m := range g.misbehavior {
+		score := decayedScore(m.score, m.lastUpdate, now)
+		if score == 0 {
+			delete(g.misbehavior, key)
+			continue
+		}
+		scores = append(scores, modules.GatewayMisbehavior{
+			Address:    key,
+			Score:      score,
+			LastReason: m.lastReason,
+			LastUpdate: m.lastUpdate,
+		})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].Address < scores[j].Address
+	})
+	return scores
+}
+
+// Unblock removes an IP address or CIDR subnet from the blocklist.
+func (g *Gateway) Unblock(addr string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	key, _, err := parseBlocklistAddress(addr)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.blocklist[key]; !exists {
+		return errNotBlocked
+	}
+	delete(g.blocklist, key)
+	delete(g.misbehavior, key)
+	g.log.Printf("INFO: removed %v from the blocklist\n", key)
+	return g.saveBlocklist()
+}
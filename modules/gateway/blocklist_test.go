@@ -0,0 +1,193 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/acejam/Sia/modules"
+)
+
+// TestParseBlocklistAddress probes the parseBlocklistAddress function.
+func TestParseBlocklistAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		key  string
+		err  error
+	}{
+		{"1.2.3.4", "1.2.3.4", nil},
+		{"1.2.3.4:9981", "1.2.3.4", nil},
+		{"1.2.3.0/24", "1.2.3.0/24", nil},
+		{"1.2.3.4/24", "1.2.3.0/24", nil},
+		{"2001:db8::1", "2001:db8::1", nil},
+		{"[2001:db8::1]:9981", "2001:db8::1", nil},
+		{"2001:db8::/32", "2001:db8::/32", nil},
+		{"", "", errInvalidBlocklistAddress},
+		{"foo.com", "", errInvalidBlocklistAddress},
+		{"foo.com:9981", "", errInvalidBlocklistAddress},
+		{"1.2.3.4/33", "", errInvalidBlocklistAddress},
+	}
+	for _, test := range tests {
+		key, subnet, err := parseBlocklistAddress(test.addr)
+		if err != test.err {
+			t.Errorf("%q: expected error %v, got %v", test.addr, test.err, err)
+			continue
+		} else if key != test.key {
+			t.Errorf("%q: expected key %q, got %q", test.addr, test.key, key)
+		} else if err == nil && subnet == nil {
+			t.Errorf("%q: expected a subnet", test.addr)
+		}
+	}
+}
+
+// TestBlocklist tests that blocked peers are disconnected, cannot connect and
+// cannot be connected to, and that the blocklist is persisted.
+func TestBlocklist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Block the subnet that contains g2. g2 should be disconnected and
+	// removed from the node list.
+	if err := g1.Block("127.0.0.0/8", 0, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("blocked peer was not disconnected")
+	}
+	g1.mu.RLock()
+	_, exists := g1.nodes[g2.Address()]
+	g1.mu.RUnlock()
+	if exists {
+		t.Fatal("blocked peer was not removed from the node list")
+	}
+	entries := g1.Blocklist()
+	if len(entries) != 1 || entries[0].Address != "127.0.0.0/8" || entries[0].Reason != "test" || !entries[0].Expiry.IsZero() {
+		t.Fatal("unexpected blocklist:", entries)
+	}
+
+	// Neither gateway should be able to connect to the other.
+	if err := g1.Connect(g2.Address()); err != errPeerBlocked {
+		t.Fatalf("expected %v, got %v", errPeerBlocked, err)
+	}
+	g2.Disconnect(g1.Address())
+	if err := g2.Connect(g1.Address()); err == nil {
+		t.Fatal("blocked peer was able to connect")
+	}
+
+	// The blocklist should survive a restart.
+	if err := g1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g1, err := New("localhost:0", false, g1.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g1.Close()
+	if entries := g1.Blocklist(); len(entries) != 1 || entries[0].Address != "127.0.0.0/8" {
+		t.Fatal("blocklist was not persisted:", entries)
+	}
+
+	// After unblocking, the gateways can connect again.
+	if err := g1.Unblock("127.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.Unblock("127.0.0.0/8"); err != errNotBlocked {
+		t.Fatalf("expected %v, got %v", errNotBlocked, err)
+	}
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBlocklistExpiry tests that expired entries are removed from the
+// blocklist.
+func TestBlocklistExpiry(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer g.Close()
+
+	if err := g.Block("1.2.3.4", time.Millisecond, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Block("1.2.3.5", -time.Second, "test"); err == nil {
+		t.Fatal("expected negative duration to be rejected")
+	}
+	time.Sleep(10 * time.Millisecond)
+	g.mu.RLock()
+	blocked := g.isBlocked("1.2.3.4")
+	g.mu.RUnlock()
+	if blocked {
+		t.Fatal("expired entry is still blocking")
+	}
+	if entries := g.Blocklist(); len(entries) != 0 {
+		t.Fatal("expired entry was not pruned:", entries)
+	}
+}
+
+// TestAddMisbehavior tests that peers are banned automatically once their
+// misbehavior score reaches the ban threshold.
+func TestAddMisbehavior(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer g.Close()
+
+	addr := modules.NetAddress("1.2.3.4:9981")
+	g.AddMisbehavior(addr, misbehaviorBanThreshold/2, "first")
+	scores := g.Misbehavior()
+	if len(scores) != 1 || scores[0].Address != "1.2.3.4" || scores[0].Score != misbehaviorBanThreshold/2 || scores[0].LastReason != "first" {
+		t.Fatal("unexpected misbehavior scores:", scores)
+	}
+	if len(g.Blocklist()) != 0 {
+		t.Fatal("peer was banned before reaching the threshold")
+	}
+
+	// Reaching the threshold bans the peer and resets its score.
+	g.AddMisbehavior(addr, misbehaviorBanThreshold/2, "second")
+	entries := g.Blocklist()
+	if len(entries) != 1 || entries[0].Address != "1.2.3.4" || !entries[0].Automatic || entries[0].Expiry.IsZero() {
+		t.Fatal("peer was not banned:", entries)
+	}
+	if scores := g.Misbehavior(); len(scores) != 0 {
+		t.Fatal("score was not reset after the ban:", scores)
+	}
+	if err := g.Connect(addr); err != errPeerBlocked {
+		t.Fatalf("expected %v, got %v", errPeerBlocked, err)
+	}
+
+	// Local peers are never banned automatically.
+	g.AddMisbehavior("127.0.0.1:9981", misbehaviorBanThreshold, "local")
+	if len(g.Blocklist()) != 1 || len(g.Misbehavior()) != 0 {
+		t.Fatal("local peer was penalized")
+	}
+}
+
+// TestDecayedScore probes the decayedScore function.
+func TestDecayedScore(t *testing.T) {
+	now := time.Now()
+	if s := decayedScore(100, now, now); s != 100 {
+		t.Fatal("expected 100, got", s)
+	}
+	if s := decayedScore(100, now.Add(-misbehaviorDecayInterval), now); s != 50 {
+		t.Fatal("expected 50, got", s)
+	}
+	if s := decayedScore(100, now.Add(-3*misbehaviorDecayInterval), now); s != 12 {
+		t.Fatal("expected 12, got", s)
+	}
+	if s := decayedScore(100, now.Add(-100*misbehaviorDecayInterval), now); s != 0 {
+		t.Fatal("expected 0, got", s)
+	}
+}
@@ -33,6 +33,10 @@ const (
 	// codebase were made that weren't backwards compatible. This might include
 	// changes to the protocol or hardforks.
 	minimumAcceptablePeerVersion = "1.3.1"
+
+	// misbehaviorBanThreshold is the misbehavior score at which a peer's IP
+	// address is automatically added to the blocklist.
+	misbehaviorBanThreshold = 100
 )
 
 var (
@@ -103,6 +107,25 @@ var (
 	}).(int)
 )
 
+var (
+	// automaticBanDuration is the amount of time that an IP address remains on
+	// the blocklist after its misbehavior score reached the ban threshold.
+	automaticBanDuration = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Dev:      10 * time.Minute,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// misbehaviorDecayInterval is the amount of time after which a peer's
+	// misbehavior score is halved, so that occasional rejections of honest
+	// peers do not accumulate into a ban.
+	misbehaviorDecayInterval = build.Select(build.Var{
+		Standard: time.Hour,
+		Dev:      5 * time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+)
+
 var (
 	// The gateway will sleep this long between incoming connections. For
 	// attack reasons, the acceptInterval should be longer than the
@@ -107,6 +107,7 @@ import (
 	"github.com/acejam/Sia/persist"
 	siasync "github.com/acejam/Sia/sync"
 	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/ratelimit"
 )
 
 var (
@@ -145,6 +146,20 @@ type Gateway struct {
 	peers  map[modules.NetAddress]*peer
 	peerTG siasync.ThreadGroup
 
+	// blocklist contains the IP addresses and subnets that the gateway
+	// refuses to connect to or accept connections from, keyed by their
+	// normalized address.
+	//
+	// misbehavior tracks the misbehavior scores of IP addresses whose peers
+	// supplied data that was rejected by other modules.
+	blocklist   map[string]*blocklistEntry
+	misbehavior map[string]*misbehavior
+
+	// settings contains the persisted bandwidth limits, which are enforced by
+	// staticRL on the connections of all peers.
+	settings gatewaySettings
+	staticRL *ratelimit.RateLimit
+
 	// Utilities.
 	log        *persist.Logger
 	mu         sync.RWMutex
@@ -210,6 +225,11 @@ func New(addr string, bootstrap bool, persistDir string) (*Gateway, error) {
 		nodes: make(map[modules.NetAddress]*node),
 		peers: make(map[modules.NetAddress]*peer),
 
+		blocklist:   make(map[string]*blocklistEntry),
+		misbehavior: make(map[string]*misbehavior),
+
+		staticRL: ratelimit.NewRateLimit(0, 0, 0),
+
 		persistDir: persistDir,
 	}
 
@@ -256,6 +276,15 @@ func New(addr string, bootstrap bool, persistDir string) (*Gateway, error) {
 	if loadErr := g.load(); loadErr != nil && !os.IsNotExist(loadErr) {
 		return nil, loadErr
 	}
+	// Load the blocklist.
+	if loadErr := g.loadBlocklist(); loadErr != nil && !os.IsNotExist(loadErr) {
+		return nil, loadErr
+	}
+	// Load the settings and apply the bandwidth limits.
+	if loadErr := g.loadSettings(); loadErr != nil && !os.IsNotExist(loadErr) {
+		return nil, loadErr
+	}
+	g.setRateLimits(g.settings.MaxDownloadSpeed, g.settings.MaxUploadSpeed)
 	// Spawn the thread to periodically save the gateway.
 	go g.threadedSaveLoop()
 	// Make sure that the gateway saves after shutdown.
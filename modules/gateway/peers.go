@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/acejam/Sia/build"
@@ -35,10 +36,26 @@ func (s invalidVersionError) Error() string {
 }
 
 type peer struct {
+	// traffic counts the bytes and RPCs exchanged with the peer. It is the
+	// first field to guarantee the 64-bit alignment required by the atomic
+	// operations on 32-bit platforms.
+	traffic peerTraffic
+
 	modules.Peer
 	sess streamSession
 }
 
+// info returns the modules.Peer of the peer, including its traffic
+// statistics.
+func (p *peer) info() modules.Peer {
+	info := p.Peer
+	info.BytesIn = atomic.LoadUint64(&p.traffic.bytesIn)
+	info.BytesOut = atomic.LoadUint64(&p.traffic.bytesOut)
+	info.RPCsCalled = atomic.LoadUint64(&p.traffic.rpcsCalled)
+	info.RPCsHandled = atomic.LoadUint64(&p.traffic.rpcsHandled)
+	return info
+}
+
 // sessionHeader is sent after the initial version exchange. It prevents peers
 // on different blockchains from connecting to each other, and prevents the
 // gateway from connecting to itself.
@@ -146,6 +163,15 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 	addr := modules.NetAddress(conn.RemoteAddr().String())
 	g.log.Debugf("INFO: %v wants to connect", addr)
 
+	g.mu.RLock()
+	blocked := g.isBlocked(addr.Host())
+	g.mu.RUnlock()
+	if blocked {
+		g.log.Debugf("INFO: %v wanted to connect but is on the blocklist", addr)
+		conn.Close()
+		return
+	}
+
 	remoteVersion, err := acceptVersionHandshake(conn, build.Version)
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect but version handshake failed: %v", addr, err)
@@ -221,11 +247,12 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 			Local: remoteAddr.IsLocal(),
 			// Ignoring claimed IP address (which should be == to the socket address)
 			// by the host but keeping note of the port number so we can call back
-			NetAddress: remoteAddr,
-			Version:    remoteVersion,
+			NetAddress:     remoteAddr,
+			Version:        remoteVersion,
+			ConnectedSince: time.Now(),
 		},
-		sess: newServerStream(conn, remoteVersion),
 	}
+	peer.sess = newServerStream(g.staticWrapPeerConn(conn, &peer.traffic), remoteVersion)
 	g.mu.Lock()
 	g.acceptPeer(peer)
 	g.mu.Unlock()
@@ -419,10 +446,14 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	}
 	g.mu.RLock()
 	_, exists := g.peers[addr]
+	blocked := g.isBlocked(addr.Host())
 	g.mu.RUnlock()
 	if exists {
 		return errPeerExists
 	}
+	if blocked {
+		return errPeerBlocked
+	}
 
 	// Dial the peer and perform peer initialization.
 	conn, err := g.staticDial(addr)
@@ -455,15 +486,23 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	g.addPeer(&peer{
+	// The peer may have been blocked while the connection was being
+	// established.
+	if g.isBlocked(addr.Host()) {
+		conn.Close()
+		return errPeerBlocked
+	}
+	p := &peer{
 		Peer: modules.Peer{
-			Inbound:    false,
-			Local:      addr.IsLocal(),
-			NetAddress: addr,
-			Version:    remoteVersion,
+			Inbound:        false,
+			Local:          addr.IsLocal(),
+			NetAddress:     addr,
+			Version:        remoteVersion,
+			ConnectedSince: time.Now(),
 		},
-		sess: newClientStream(conn, remoteVersion),
-	})
+	}
+	p.sess = newClientStream(g.staticWrapPeerConn(conn, &p.traffic), remoteVersion)
+	g.addPeer(p)
 	g.addNode(addr)
 	g.nodes[addr].WasOutboundPeer = true
 
@@ -522,7 +561,7 @@ func (g *Gateway) Peers() []modules.Peer {
 	defer g.mu.RUnlock()
 	var peers []modules.Peer
 	for _, p := range g.peers {
-		peers = append(peers, p.Peer)
+		peers = append(peers, p.info())
 	}
 	return peers
 }
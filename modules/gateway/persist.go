@@ -6,14 +6,22 @@ import (
 
 	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/persist"
+	"gitlab.com/NebulousLabs/errors"
 )
 
 const (
+	// blocklistFile is the name of the file that contains the blocklist.
+	blocklistFile = "blocklist.json"
+
 	// logFile is the name of the log file.
 	logFile = modules.GatewayDir + ".log"
 
 	// nodesFile is the name of the file that contains all seen nodes.
 	nodesFile = "nodes.json"
+
+	// settingsFile is the name of the file that contains the gateway's
+	// settings.
+	settingsFile = "settings.json"
 )
 
 // persistMetadata contains the header and version strings that identify the
@@ -23,6 +31,26 @@ var persistMetadata = persist.Metadata{
 	Version: "1.3.0",
 }
 
+// blocklistMetadata contains the header and version strings that identify the
+// gateway blocklist file.
+var blocklistMetadata = persist.Metadata{
+	Header:  "Sia Gateway Blocklist",
+	Version: "1.3.4",
+}
+
+// settingsMetadata contains the header and version strings that identify the
+// gateway settings file.
+var settingsMetadata = persist.Metadata{
+	Header:  "Sia Gateway Settings",
+	Version: "1.3.4",
+}
+
+// gatewaySettings contains the user-configurable settings of the gateway.
+type gatewaySettings struct {
+	MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
+	MaxUploadSpeed   int64 `json:"maxuploadspeed"`
+}
+
 // persistData returns the data in the Gateway that will be saved to disk.
 func (g *Gateway) persistData() (nodes []*node) {
 	for _, node := range g.nodes {
@@ -45,10 +73,56 @@ func (g *Gateway) load() error {
 	return nil
 }
 
+// loadBlocklist loads the Gateway's blocklist from disk. Expired entries are
+// discarded.
+func (g *Gateway) loadBlocklist() error {
+	var entries []modules.GatewayBlocklistEntry
+	err := persist.LoadJSON(blocklistMetadata, &entries, filepath.Join(g.persistDir, blocklistFile))
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		key, subnet, err := parseBlocklistAddress(entry.Address)
+		if err != nil {
+			g.log.Printf("WARN: error loading blocklist entry '%v' from persist: %v", entry.Address, err)
+			continue
+		}
+		g.blocklist[key] = &blocklistEntry{
+			GatewayBlocklistEntry: entry,
+			subnet:                subnet,
+		}
+	}
+	g.pruneBlocklist()
+	return nil
+}
+
+// saveBlocklist stores the Gateway's blocklist on disk.
+func (g *Gateway) saveBlocklist() error {
+	g.pruneBlocklist()
+	entries := make([]modules.GatewayBlocklistEntry, 0, len(g.blocklist))
+	for _, entry := range g.blocklist {
+		entries = append(entries, entry.GatewayBlocklistEntry)
+	}
+	return persist.SaveJSON(blocklistMetadata, entries, filepath.Join(g.persistDir, blocklistFile))
+}
+
+// loadSettings loads the Gateway's settings from disk.
+func (g *Gateway) loadSettings() error {
+	return persist.LoadJSON(settingsMetadata, &g.settings, filepath.Join(g.persistDir, settingsFile))
+}
+
+// saveSettings stores the Gateway's settings on disk.
+func (g *Gateway) saveSettings() error {
+	return persist.SaveJSON(settingsMetadata, g.settings, filepath.Join(g.persistDir, settingsFile))
+}
+
 // saveSync stores the Gateway's persistent data on disk, and then syncs to
 // disk to minimize the possibility of data loss.
 func (g *Gateway) saveSync() error {
-	return persist.SaveJSON(persistMetadata, g.persistData(), filepath.Join(g.persistDir, nodesFile))
+	return errors.Compose(
+		persist.SaveJSON(persistMetadata, g.persistData(), filepath.Join(g.persistDir, nodesFile)),
+		g.saveBlocklist(),
+	)
 }
 
 // threadedSaveLoop periodically saves the gateway.
@@ -3,6 +3,7 @@ package gateway
 import (
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/acejam/Sia/build"
@@ -55,6 +56,7 @@ func (g *Gateway) managedRPC(addr modules.NetAddress, name string, fn modules.RP
 		return err
 	}
 	defer conn.Close()
+	atomic.AddUint64(&peer.traffic.rpcsCalled, 1)
 
 	// write header
 	conn.SetDeadline(time.Now().Add(rpcStdDeadline))
@@ -177,6 +179,7 @@ func (g *Gateway) threadedListenPeer(p *peer) {
 
 		// The handler is responsible for closing the connection, though a
 		// default deadline has been set.
+		atomic.AddUint64(&p.traffic.rpcsHandled, 1)
 		go g.threadedHandleConn(conn)
 		if !g.managedSleep(peerRPCDelay) {
 			break
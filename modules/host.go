@@ -176,6 +176,11 @@ type (
 		// PublicKey returns the public key of the host.
 		PublicKey() types.SiaPublicKey
 
+		// ReorgedTransactions returns the most recent transactions of storage
+		// obligations that were reverted by a reorg and not confirmed again
+		// by the same reorg, oldest first.
+		ReorgedTransactions() []ReorgedTransaction
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
@@ -0,0 +1,36 @@
+package host
+
+import (
+	"github.com/acejam/Sia/modules"
+)
+
+// alertIDWalletLocked is the ID of the alert that is registered while the
+// wallet is locked and the host has unresolved storage obligations.
+const alertIDWalletLocked = modules.AlertID("wallet-locked")
+
+// Alerts returns the alerts of the host and of its storage manager.
+func (h *Host) Alerts() []modules.Alert {
+	alerts := h.staticAlerter.Alerts()
+	if sm, ok := h.StorageManager.(modules.Alerter); ok {
+		alerts = append(alerts, sm.Alerts()...)
+	}
+	modules.SortAlerts(alerts)
+	return alerts
+}
+
+// updateWalletAlert registers an alert if the wallet is locked while the host
+// has unresolved storage obligations, since the host needs the wallet to
+// submit the revisions and storage proofs of its contracts. The alert is
+// unregistered once the wallet is unlocked or the obligations are resolved.
+func (h *Host) updateWalletAlert() {
+	unlocked, err := h.wallet.Unlocked()
+	if err != nil {
+		h.log.Println("Unable to check whether the wallet is unlocked:", err)
+		return
+	}
+	if unlocked || h.financialMetrics.ContractCount == 0 {
+		h.staticAlerter.UnregisterAlert(alertIDWalletLocked)
+		return
+	}
+	h.staticAlerter.RegisterAlert(alertIDWalletLocked, "the wallet is locked, but the host has storage obligations whose revisions and storage proofs require an unlocked wallet; unlock the wallet to avoid losing collateral", "", modules.SeverityCritical)
+}
@@ -87,7 +87,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 	}
 
 	// Create a transaction, with a fee, that contains the full announcement.
-	txnBuilder, err := h.wallet.StartTransaction()
+	txnBuilder, err := h.startTransaction()
 	if err != nil {
 		return err
 	}
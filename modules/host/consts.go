@@ -21,6 +21,10 @@ const (
 	// necessary to limit the impact of DoS attacks.
 	fileContractNegotiationTimeout = 120 * time.Second
 
+	// maxReorgedTransactions is the number of storage obligation transactions
+	// reverted by reorgs that the host keeps in memory.
+	maxReorgedTransactions = 1000
+
 	// iteratedConnectionTime is the amount of time that is allowed to pass
 	// before the host will stop accepting new iterations on an iterated
 	// connection.
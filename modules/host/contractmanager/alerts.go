@@ -0,0 +1,39 @@
+package contractmanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/acejam/Sia/modules"
+)
+
+// alertIDUnavailableFolders is the ID of the alert that is registered while
+// storage folders are unavailable.
+const alertIDUnavailableFolders = modules.AlertID("unavailable-storage-folders")
+
+// Alerts returns the alerts of the contract manager.
+func (cm *ContractManager) Alerts() []modules.Alert {
+	return cm.staticAlerter.Alerts()
+}
+
+// updateFolderAlert registers an alert listing the unavailable storage
+// folders, or unregisters it if every storage folder is available. The alert
+// is not persisted; it is registered again when the contract manager fails to
+// open the folders on startup. The caller must hold the WAL lock.
+func (cm *ContractManager) updateFolderAlert() {
+	var unavailable []string
+	for _, sf := range cm.storageFolders {
+		if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+			unavailable = append(unavailable, sf.path)
+		}
+	}
+	if len(unavailable) == 0 {
+		cm.staticAlerter.UnregisterAlert(alertIDUnavailableFolders)
+		return
+	}
+	sort.Strings(unavailable)
+	msg := fmt.Sprintf("%v storage folders are unavailable; the host cannot serve or prove the sectors stored in them until they are restored: %v", len(unavailable), strings.Join(unavailable, ", "))
+	cm.staticAlerter.RegisterAlert(alertIDUnavailableFolders, msg, "", modules.SeverityCritical)
+}
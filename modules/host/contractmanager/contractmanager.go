@@ -94,11 +94,12 @@ type ContractManager struct {
 	lockedSectors map[sectorID]*sectorLock
 
 	// Utilities.
-	dependencies modules.Dependencies
-	log          *persist.Logger
-	persistDir   string
-	tg           siasync.ThreadGroup
-	wal          writeAheadLog
+	dependencies  modules.Dependencies
+	log           *persist.Logger
+	persistDir    string
+	staticAlerter *modules.GenericAlerter
+	tg            siasync.ThreadGroup
+	wal           writeAheadLog
 }
 
 // Close will cleanly shutdown the contract manager.
@@ -115,8 +116,9 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (*
 
 		lockedSectors: make(map[sectorID]*sectorLock),
 
-		dependencies: dependencies,
-		persistDir:   persistDir,
+		dependencies:  dependencies,
+		persistDir:    persistDir,
+		staticAlerter: modules.NewAlerter("contractmanager"),
 	}
 	cm.wal.cm = cm
 	cm.tg.AfterStop(func() {
@@ -199,6 +201,7 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (*
 		}
 		cm.loadSectorLocations(sf)
 	}
+	cm.updateFolderAlert()
 
 	// Launch the sync loop that periodically flushes changes from the WAL to
 	// disk.
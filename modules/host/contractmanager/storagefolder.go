@@ -322,6 +322,7 @@ func (cm *ContractManager) threadedFolderRecheck() {
 				}
 			}
 		}
+		cm.updateFolderAlert()
 		cm.wal.mu.Unlock()
 
 		// Increase the sleep time.
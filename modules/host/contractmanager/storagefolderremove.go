@@ -20,6 +20,7 @@ func (wal *writeAheadLog) commitStorageFolderRemoval(sfr storageFolderRemoval) {
 	sf, exists := wal.cm.storageFolders[sfr.Index]
 	if exists {
 		delete(wal.cm.storageFolders, sfr.Index)
+		wal.cm.updateFolderAlert()
 	}
 	if exists && sf.metadataFile != nil {
 		err := sf.metadataFile.Close()
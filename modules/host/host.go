@@ -161,6 +161,7 @@ type Host struct {
 	revisionNumber       uint64
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
+	reorgedTransactions  []modules.ReorgedTransaction
 
 	// A map of storage obligations that are currently being modified. Locks on
 	// storage obligations can be long-running, and each storage obligation can
@@ -168,13 +169,14 @@ type Host struct {
 	lockedStorageObligations map[types.FileContractID]*siasync.TryMutex
 
 	// Utilities.
-	db         *persist.BoltDatabase
-	listener   net.Listener
-	log        *persist.Logger
-	mu         sync.RWMutex
-	persistDir string
-	port       string
-	tg         siasync.ThreadGroup
+	db            *persist.BoltDatabase
+	listener      net.Listener
+	log           *persist.Logger
+	mu            sync.RWMutex
+	persistDir    string
+	port          string
+	staticAlerter *modules.GenericAlerter
+	tg            siasync.ThreadGroup
 }
 
 // checkUnlockHash will check that the host has an unlock hash. If the host
@@ -194,7 +196,7 @@ func (h *Host) checkUnlockHash() error {
 		}
 	}
 	if !hasAddr || h.unlockHash == (types.UnlockHash{}) {
-		uc, err := h.wallet.NextAddress()
+		uc, err := h.nextAddress()
 		if err != nil {
 			return err
 		}
@@ -211,6 +213,32 @@ func (h *Host) checkUnlockHash() error {
 	return nil
 }
 
+// nextAddress returns a new address of the wallet. If the wallet has a host
+// account, the address belongs to that account.
+func (h *Host) nextAddress() (types.UnlockConditions, error) {
+	uc, err := h.wallet.AccountNextAddress(modules.WalletAccountHost)
+	if err == modules.ErrUnknownWalletAccount {
+		return h.wallet.NextAddress()
+	}
+	return uc, err
+}
+
+// registerTransaction registers a transaction with the wallet. If the wallet
+// has a host account, the transaction is funded by that account.
+func (h *Host) registerTransaction(txn types.Transaction, parents []types.Transaction) (modules.TransactionBuilder, error) {
+	builder, err := h.wallet.RegisterAccountTransaction(modules.WalletAccountHost, txn, parents)
+	if err == modules.ErrUnknownWalletAccount {
+		return h.wallet.RegisterTransaction(txn, parents)
+	}
+	return builder, err
+}
+
+// startTransaction is a convenience function that calls
+// registerTransaction(types.Transaction{}, nil).
+func (h *Host) startTransaction() (modules.TransactionBuilder, error) {
+	return h.registerTransaction(types.Transaction{}, nil)
+}
+
 // newHost returns an initialized Host, taking a set of dependencies as input.
 // By making the dependencies an argument of the 'new' call, the host can be
 // mocked such that the dependencies can return unexpected errors or unique
@@ -241,7 +269,8 @@ func newHost(dependencies modules.Dependencies, cs modules.ConsensusSet, g modul
 
 		lockedStorageObligations: make(map[types.FileContractID]*siasync.TryMutex),
 
-		persistDir: persistDir,
+		persistDir:    persistDir,
+		staticAlerter: modules.NewAlerter("host"),
 	}
 
 	// Call stop in the event of a partial startup.
@@ -317,6 +346,14 @@ func New(cs modules.ConsensusSet, g modules.Gateway, tpool modules.TransactionPo
 	return newHost(modules.ProdDependencies, cs, g, tpool, wallet, address, persistDir)
 }
 
+// NewEncrypted returns an initialized Host whose persist file, which contains
+// the secret key of the host, is encrypted with key. A plaintext persist file
+// is encrypted when the Host loads it. The storage obligations and sectors of
+// the host contain no secrets and are not encrypted.
+func NewEncrypted(cs modules.ConsensusSet, g modules.Gateway, tpool modules.TransactionPool, wallet modules.Wallet, address string, persistDir string, key crypto.TwofishKey) (*Host, error) {
+	return newHost(modules.NewEncryptedDependencies(key), cs, g, tpool, wallet, address, persistDir)
+}
+
 // Close shuts down the host.
 func (h *Host) Close() error {
 	return h.tg.Stop()
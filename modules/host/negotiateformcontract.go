@@ -38,7 +38,7 @@ func (h *Host) managedAddCollateral(settings modules.HostExternalSettings, txnSe
 	parents := txnSet[:len(txnSet)-1]
 	fc := txn.FileContracts[0]
 	hostPortion := contractCollateral(settings, fc)
-	builder, err = h.wallet.RegisterTransaction(txn, parents)
+	builder, err = h.registerTransaction(txn, parents)
 	if err != nil {
 		return
 	}
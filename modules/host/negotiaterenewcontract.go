@@ -51,7 +51,7 @@ func (h *Host) managedAddRenewCollateral(so storageObligation, settings modules.
 	parents := txnSet[:len(txnSet)-1]
 	fc := txn.FileContracts[0]
 	hostPortion := renewContractCollateral(so, settings, fc)
-	builder, err = h.wallet.RegisterTransaction(txn, parents)
+	builder, err = h.registerTransaction(txn, parents)
 	if err != nil {
 		return
 	}
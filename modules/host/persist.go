@@ -16,6 +16,9 @@ import (
 
 // persistence is the data that is kept when the host is restarted.
 type persistence struct {
+	// Alerts that have not been cleared.
+	Alerts []modules.Alert `json:"alerts"`
+
 	// Consensus Tracking.
 	BlockHeight  types.BlockHeight         `json:"blockheight"`
 	RecentChange modules.ConsensusChangeID `json:"recentchange"`
@@ -34,6 +37,8 @@ type persistence struct {
 // persistData returns the data in the Host that will be saved to disk.
 func (h *Host) persistData() persistence {
 	return persistence{
+		Alerts: h.staticAlerter.Alerts(),
+
 		// Consensus Tracking.
 		BlockHeight:  h.blockHeight,
 		RecentChange: h.recentChange,
@@ -86,6 +91,8 @@ func (h *Host) establishDefaults() error {
 // loadPersistObject will take a persist object and copy the data into the
 // host.
 func (h *Host) loadPersistObject(p *persistence) {
+	h.staticAlerter.LoadAlerts(p.Alerts)
+
 	// Copy over consensus tracking.
 	h.blockHeight = p.BlockHeight
 	h.recentChange = p.RecentChange
@@ -159,6 +166,14 @@ func (h *Host) load() error {
 	if err == nil {
 		// Copy in the persistence.
 		h.loadPersistObject(p)
+
+		// Encrypt a plaintext persist file right away if the persist files
+		// of the host are encrypted.
+		if _, ok := h.dependencies.(modules.PersistEncrypter); ok {
+			if err := h.saveSync(); err != nil {
+				return err
+			}
+		}
 	} else if os.IsNotExist(err) {
 		// There is no host.json file, set up sane defaults.
 		return h.establishDefaults()
@@ -202,5 +217,5 @@ func (h *Host) load() error {
 
 // saveSync stores all of the persist data to disk and then syncs to disk.
 func (h *Host) saveSync() error {
-	return persist.SaveJSON(persistMetadata, h.persistData(), filepath.Join(h.persistDir, settingsFile))
+	return h.dependencies.SaveFileSync(persistMetadata, h.persistData(), filepath.Join(h.persistDir, settingsFile))
 }
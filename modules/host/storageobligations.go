@@ -40,6 +40,7 @@ import (
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/encoding"
 	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 
 	"github.com/coreos/bbolt"
@@ -431,7 +432,7 @@ func (h *Host) managedAddStorageObligation(so storageObligation) error {
 	err6 := h.queueActionItem(so.expiration()+resubmissionTimeout*2, soid) // Paranoia
 	err = composeErrors(err1, err2, err3, err4, err5, err6)
 	if err != nil {
-		h.log.Println("Error with transaction set, redacting obligation, id", so.id())
+		h.log.With(persist.LogFieldContractID, so.id()).Println("Error with transaction set, redacting obligation")
 		return composeErrors(err, h.removeStorageObligation(so, obligationRejected))
 	}
 	return nil
@@ -551,20 +552,21 @@ func (h *Host) modifyStorageObligation(so storageObligation, sectorsRemoved []cr
 // removeStorageObligation will remove a storage obligation from the host,
 // either due to failure or success.
 func (h *Host) removeStorageObligation(so storageObligation, sos storageObligationStatus) error {
+	log := h.log.With(persist.LogFieldContractID, so.id())
 	// Error is not checked, we want to call remove on every sector even if
 	// there are problems - disk health information will be updated.
 	_ = h.RemoveSectorBatch(so.SectorRoots)
 
 	// Update the host revenue metrics based on the status of the obligation.
 	if sos == obligationUnresolved {
-		h.log.Critical("storage obligation 'unresolved' during call to removeStorageObligation, id", so.id())
+		log.Critical("storage obligation 'unresolved' during call to removeStorageObligation")
 	}
 	if sos == obligationRejected {
 		if h.financialMetrics.TransactionFeeExpenses.Cmp(so.TransactionFeesAdded) >= 0 {
 			h.financialMetrics.TransactionFeeExpenses = h.financialMetrics.TransactionFeeExpenses.Sub(so.TransactionFeesAdded)
 
 			// Remove the obligation statistics as potential risk and income.
-			h.log.Printf("Rejecting storage obligation expiring at block %v, current height is %v. Potential revenue is %v.\n", so.expiration(), h.blockHeight, h.financialMetrics.PotentialContractCompensation.Add(h.financialMetrics.PotentialStorageRevenue).Add(h.financialMetrics.PotentialDownloadBandwidthRevenue).Add(h.financialMetrics.PotentialUploadBandwidthRevenue))
+			log.Printf("Rejecting storage obligation expiring at block %v, current height is %v. Potential revenue is %v.\n", so.expiration(), h.blockHeight, h.financialMetrics.PotentialContractCompensation.Add(h.financialMetrics.PotentialStorageRevenue).Add(h.financialMetrics.PotentialDownloadBandwidthRevenue).Add(h.financialMetrics.PotentialUploadBandwidthRevenue))
 			h.financialMetrics.PotentialContractCompensation = h.financialMetrics.PotentialContractCompensation.Sub(so.ContractCost)
 			h.financialMetrics.LockedStorageCollateral = h.financialMetrics.LockedStorageCollateral.Sub(so.LockedCollateral)
 			h.financialMetrics.PotentialStorageRevenue = h.financialMetrics.PotentialStorageRevenue.Sub(so.PotentialStorageRevenue)
@@ -578,9 +580,9 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 		// storage obligation should equal the contract cost of the obligation
 		revenue := so.ContractCost.Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue)
 		if len(so.SectorRoots) == 0 {
-			h.log.Printf("No need to submit a storage proof for empty contract. Revenue is %v.\n", revenue)
+			log.Printf("No need to submit a storage proof for empty contract. Revenue is %v.\n", revenue)
 		} else {
-			h.log.Printf("Successfully submitted a storage proof. Revenue is %v.\n", revenue)
+			log.Printf("Successfully submitted a storage proof. Revenue is %v.\n", revenue)
 		}
 
 		// Remove the obligation statistics as potential risk and income.
@@ -599,7 +601,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	}
 	if sos == obligationFailed {
 		// Remove the obligation statistics as potential risk and income.
-		h.log.Printf("Missed storage proof. Revenue would have been %v.\n", so.ContractCost.Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue))
+		log.Printf("Missed storage proof. Revenue would have been %v.\n", so.ContractCost.Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue))
 		h.financialMetrics.PotentialContractCompensation = h.financialMetrics.PotentialContractCompensation.Sub(so.ContractCost)
 		h.financialMetrics.LockedStorageCollateral = h.financialMetrics.LockedStorageCollateral.Sub(so.LockedCollateral)
 		h.financialMetrics.PotentialStorageRevenue = h.financialMetrics.PotentialStorageRevenue.Sub(so.PotentialStorageRevenue)
@@ -632,6 +634,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		return
 	}
 	defer h.tg.Done()
+	log := h.log.With(persist.LogFieldContractID, soid)
 
 	// Lock the storage obligation in question.
 	h.managedLockStorageObligation(soid)
@@ -649,7 +652,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 	})
 	h.mu.RUnlock()
 	if err != nil {
-		h.log.Println("Could not get storage obligation:", err)
+		log.Println("Could not get storage obligation:", err)
 		return
 	}
 
@@ -667,7 +670,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		// confirmed.
 		err := h.tpool.AcceptTransactionSet(so.OriginTransactionSet)
 		if err != nil {
-			h.log.Debugln("Could not get origin transaction set accepted", err)
+			log.Debugln("Could not get origin transaction set accepted", err)
 
 			// Check if the transaction is invalid with the current consensus set.
 			// If so, the transaction is highly unlikely to ever be confirmed, and
@@ -679,12 +682,12 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			// parents are confirmed, might be some difficulty.
 			_, t := err.(modules.ConsensusConflict)
 			if t {
-				h.log.Println("Consensus conflict on the origin transaction set, id", so.id())
+				log.Println("Consensus conflict on the origin transaction set")
 				h.mu.Lock()
 				err = h.removeStorageObligation(so, obligationRejected)
 				h.mu.Unlock()
 				if err != nil {
-					h.log.Println("Error removing storage obligation:", err)
+					log.Println("Error removing storage obligation:", err)
 				}
 				return
 			}
@@ -695,7 +698,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		err = h.queueActionItem(h.blockHeight+resubmissionTimeout, so.id())
 		h.mu.Unlock()
 		if err != nil {
-			h.log.Println("Error queuing action item:", err)
+			log.Println("Error queuing action item:", err)
 		}
 	}
 
@@ -704,7 +707,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		// Sanity check - there should be a file contract revision.
 		rtsLen := len(so.RevisionTransactionSet)
 		if rtsLen < 1 || len(so.RevisionTransactionSet[rtsLen-1].FileContractRevisions) != 1 {
-			h.log.Critical("transaction revision marked as unconfirmed, yet there is no transaction revision")
+			log.Critical("transaction revision marked as unconfirmed, yet there is no transaction revision")
 			return
 		}
 
@@ -716,7 +719,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			// be confirmed, and the origin transaction may be confirmed, which
 			// would confuse the revenue stuff a bit. Might happen frequently
 			// due to the dynamic fee pool.
-			h.log.Println("Full time has elapsed, but the revision transaction could not be submitted to consensus, id", so.id())
+			log.Println("Full time has elapsed, but the revision transaction could not be submitted to consensus")
 			h.mu.Lock()
 			h.removeStorageObligation(so, obligationRejected)
 			h.mu.Unlock()
@@ -728,16 +731,16 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		err := h.queueActionItem(blockHeight+resubmissionTimeout, so.id())
 		h.mu.Unlock()
 		if err != nil {
-			h.log.Println("Error queuing action item:", err)
+			log.Println("Error queuing action item:", err)
 		}
 
 		// Add a miner fee to the transaction and submit it to the blockchain.
 		revisionTxnIndex := len(so.RevisionTransactionSet) - 1
 		revisionParents := so.RevisionTransactionSet[:revisionTxnIndex]
 		revisionTxn := so.RevisionTransactionSet[revisionTxnIndex]
-		builder, err := h.wallet.RegisterTransaction(revisionTxn, revisionParents)
+		builder, err := h.registerTransaction(revisionTxn, revisionParents)
 		if err != nil {
-			h.log.Println("Error registering transaction:", err)
+			log.Println("Error registering transaction:", err)
 			return
 		}
 		_, feeRecommendation := h.tpool.FeeEstimation()
@@ -753,22 +756,22 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		requiredFee := feeRecommendation.Mul64(txnSize)
 		err = builder.FundSiacoins(requiredFee)
 		if err != nil {
-			h.log.Println("Error funding transaction fees", err)
+			log.Println("Error funding transaction fees", err)
 			builder.Drop()
 		}
 		builder.AddMinerFee(requiredFee)
 		if err != nil {
-			h.log.Println("Error adding miner fees", err)
+			log.Println("Error adding miner fees", err)
 			builder.Drop()
 		}
 		feeAddedRevisionTransactionSet, err := builder.Sign(true)
 		if err != nil {
-			h.log.Println("Error signing transaction", err)
+			log.Println("Error signing transaction", err)
 			builder.Drop()
 		}
 		err = h.tpool.AcceptTransactionSet(feeAddedRevisionTransactionSet)
 		if err != nil {
-			h.log.Println("Error submitting transaction to transaction pool", err)
+			log.Println("Error submitting transaction to transaction pool", err)
 			builder.Drop()
 		}
 		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
@@ -778,30 +781,30 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 	// Check whether a storage proof is ready to be provided, and whether it
 	// has been accepted. Check for death.
 	if !so.ProofConfirmed && blockHeight >= so.expiration()+resubmissionTimeout {
-		h.log.Debugln("Host is attempting a storage proof for", so.id())
+		log.Debugln("Host is attempting a storage proof")
 
 		// If the obligation has no sector roots, we can remove the obligation and not
 		// submit a storage proof. The host payout for a failed empty contract
 		// includes the contract cost and locked collateral.
 		if len(so.SectorRoots) == 0 {
-			h.log.Debugln("storage proof not submitted for empty contract, id", so.id())
+			log.Debugln("storage proof not submitted for empty contract")
 			h.mu.Lock()
 			err := h.removeStorageObligation(so, obligationSucceeded)
 			h.mu.Unlock()
 			if err != nil {
-				h.log.Println("Error removing storage obligation:", err)
+				log.Println("Error removing storage obligation:", err)
 			}
 			return
 		}
 		// If the window has closed, the host has failed and the obligation can
 		// be removed.
 		if so.proofDeadline() < blockHeight {
-			h.log.Debugln("storage proof not confirmed by deadline, id", so.id())
+			log.Debugln("storage proof not confirmed by deadline")
 			h.mu.Lock()
 			err := h.removeStorageObligation(so, obligationFailed)
 			h.mu.Unlock()
 			if err != nil {
-				h.log.Println("Error removing storage obligation:", err)
+				log.Println("Error removing storage obligation:", err)
 			}
 			return
 		}
@@ -809,7 +812,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		// the segment.
 		segmentIndex, err := h.cs.StorageProofSegment(so.id())
 		if err != nil {
-			h.log.Debugln("Host got an error when fetching a storage proof segment:", err)
+			log.Debugln("Host got an error when fetching a storage proof segment:", err)
 			return
 		}
 		sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
@@ -817,7 +820,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		sectorRoot := so.SectorRoots[sectorIndex]
 		sectorBytes, err := h.ReadSector(sectorRoot)
 		if err != nil {
-			h.log.Debugln(err)
+			log.Debugln(err)
 			return
 		}
 
@@ -843,16 +846,22 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		copy(sp.Segment[:], base)
 
 		// Create and build the transaction with the storage proof.
-		builder, err := h.wallet.StartTransaction()
+		builder, err := h.startTransaction()
 		if err != nil {
-			h.log.Println("Failed to start transaction:", err)
+			log.Println("Failed to start transaction:", err)
 			return
 		}
-		_, feeRecommendation := h.tpool.FeeEstimation()
+		// Target confirmation within half of the remaining proof window, so
+		// that there is time to resubmit the proof.
+		feeTarget := (so.proofDeadline() - blockHeight) / 2
+		if feeTarget < 1 {
+			feeTarget = 1
+		}
+		_, feeRecommendation := h.tpool.FeeEstimationTarget(feeTarget)
 		if so.value().Cmp(feeRecommendation) < 0 {
 			// There's no sense submitting the storage proof if the fee is more
 			// than the anticipated revenue.
-			h.log.Debugln("Host not submitting storage proof due to a value that does not sufficiently exceed the fee cost")
+			log.Debugln("Host not submitting storage proof due to a value that does not sufficiently exceed the fee cost")
 			builder.Drop()
 			return
 		}
@@ -860,7 +869,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		requiredFee := feeRecommendation.Mul64(txnSize)
 		err = builder.FundSiacoins(requiredFee)
 		if err != nil {
-			h.log.Println("Host error when funding a storage proof transaction fee:", err)
+			log.Println("Host error when funding a storage proof transaction fee:", err)
 			builder.Drop()
 			return
 		}
@@ -868,13 +877,13 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		builder.AddStorageProof(sp)
 		storageProofSet, err := builder.Sign(true)
 		if err != nil {
-			h.log.Println("Host error when signing the storage proof transaction:", err)
+			log.Println("Host error when signing the storage proof transaction:", err)
 			builder.Drop()
 			return
 		}
 		err = h.tpool.AcceptTransactionSet(storageProofSet)
 		if err != nil {
-			h.log.Println("Host unable to submit storage proof transaction to transaction pool:", err)
+			log.Println("Host unable to submit storage proof transaction to transaction pool:", err)
 			builder.Drop()
 			return
 		}
@@ -886,7 +895,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		err = h.queueActionItem(so.proofDeadline(), so.id())
 		h.mu.Unlock()
 		if err != nil {
-			h.log.Println("Error queuing action item:", err)
+			log.Println("Error queuing action item:", err)
 		}
 	}
 
@@ -899,13 +908,13 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		return tx.Bucket(bucketStorageObligations).Put(soid[:], soBytes)
 	})
 	if err != nil {
-		h.log.Println("Error updating the storage obligations", err)
+		log.Println("Error updating the storage obligations", err)
 	}
 
 	// Check if all items have succeeded with the required confirmations. Report
 	// success, delete the obligation.
 	if so.ProofConfirmed && blockHeight >= so.proofDeadline() {
-		h.log.Println("file contract complete, id", so.id())
+		log.Println("file contract complete")
 		h.mu.Lock()
 		h.removeStorageObligation(so, obligationSucceeded)
 		h.mu.Unlock()
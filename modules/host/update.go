@@ -9,6 +9,7 @@ import (
 
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 
 	"github.com/coreos/bbolt"
@@ -77,7 +78,7 @@ func (h *Host) initRescan() error {
 		err3 := h.queueActionItem(so.expiration()+resubmissionTimeout, soid)
 		err = composeErrors(err1, err2, err3)
 		if err != nil {
-			h.log.Println("dropping storage obligation during rescan, id", so.id())
+			h.log.With(persist.LogFieldContractID, soid).Println("dropping storage obligation during rescan")
 		}
 
 		// AcceptTransactionSet needs to be called in a goroutine to avoid a
@@ -85,7 +86,7 @@ func (h *Host) initRescan() error {
 		go func(i int) {
 			err := h.tpool.AcceptTransactionSet(allObligations[i].OriginTransactionSet)
 			if err != nil {
-				h.log.Println("Unable to submit contract transaction set after rescan:", soid)
+				h.log.With(persist.LogFieldContractID, soid).Println("Unable to submit contract transaction set after rescan:", err)
 			}
 		}(i)
 	}
@@ -116,6 +117,36 @@ func (h *Host) initConsensusSubscription() error {
 	return nil
 }
 
+// isObligationTransaction returns true if txn forms, revises or proves one of
+// the host's storage obligations.
+func isObligationTransaction(tx *bolt.Tx, txn types.Transaction) bool {
+	for i := range txn.FileContracts {
+		if _, err := getStorageObligation(tx, txn.FileContractID(uint64(i))); err == nil {
+			return true
+		}
+	}
+	for _, fcr := range txn.FileContractRevisions {
+		if _, err := getStorageObligation(tx, fcr.ParentID); err == nil {
+			return true
+		}
+	}
+	for _, sp := range txn.StorageProofs {
+		if _, err := getStorageObligation(tx, sp.ParentID); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ReorgedTransactions returns the most recent transactions of storage
+// obligations that were reverted by a reorg and not confirmed again by the
+// same reorg, oldest first.
+func (h *Host) ReorgedTransactions() []modules.ReorgedTransaction {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]modules.ReorgedTransaction(nil), h.reorgedTransactions...)
+}
+
 // ProcessConsensusChange will be called by the consensus set every time there
 // is a change to the blockchain.
 func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
@@ -130,6 +161,19 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// efficient.
 	var actionItems []types.FileContractID
 	err := h.db.Update(func(tx *bolt.Tx) error {
+		// Remember the storage obligation transactions that are reverted and
+		// not applied again.
+		rts := cc.ReorgedTransactions(func(txn types.Transaction) bool {
+			return isObligationTransaction(tx, txn)
+		})
+		for _, rt := range rts {
+			h.log.Println("WARN: a storage obligation transaction has been reverted due to a reorg:", rt.TransactionID)
+		}
+		h.reorgedTransactions = append(h.reorgedTransactions, rts...)
+		if len(h.reorgedTransactions) > maxReorgedTransactions {
+			h.reorgedTransactions = h.reorgedTransactions[len(h.reorgedTransactions)-maxReorgedTransactions:]
+		}
+
 		for _, block := range cc.RevertedBlocks {
 			// Look for transactions relevant to open storage obligations.
 			for _, txn := range block.Transactions {
@@ -304,6 +348,10 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// change.
 	h.recentChange = cc.ID
 
+	// Alert the user if the wallet is locked while it is needed for the
+	// storage obligations of the host.
+	h.updateWalletAlert()
+
 	// Save the host.
 	err = h.saveSync()
 	if err != nil {
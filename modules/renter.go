@@ -25,6 +25,13 @@ const (
 	// renter's persistent data.
 	RenterDir = "renter"
 
+	// RenterJournalFile is the name of the journal in the renter's directory.
+	// The renter, its contractor and the contract set save their persist
+	// files through it, so that related updates, such as a new piece of a
+	// file and the revision of the contract that stores it, are applied
+	// atomically.
+	RenterJournalFile = "renter.journal"
+
 	// EstimatedFileContractTransactionSetSize is the estimated blockchain size
 	// of a transaction set between a renter and a host that contains a file
 	// contract. This transaction set will contain a setup transaction from each
@@ -316,6 +323,22 @@ type ContractorSpending struct {
 	PreviousSpending types.Currency `json:"previousspending"`
 }
 
+// RenterMetrics contains the depths of the work queues of the renter and the
+// number of failed operations of its workers.
+type RenterMetrics struct {
+	// DownloadQueueDepth and UploadQueueDepth are the number of chunks that
+	// are waiting to be distributed to the workers.
+	DownloadQueueDepth uint64 `json:"downloadqueuedepth"`
+	UploadQueueDepth   uint64 `json:"uploadqueuedepth"`
+
+	// Workers is the number of workers in the worker pool. The failure
+	// counters count every failed sector download and upload since the
+	// renter was started.
+	Workers                uint64 `json:"workers"`
+	WorkerDownloadFailures uint64 `json:"workerdownloadfailures"`
+	WorkerUploadFailures   uint64 `json:"workeruploadfailures"`
+}
+
 // A Renter uploads, tracks, repairs, and downloads a set of files for the
 // user.
 type Renter interface {
@@ -338,6 +361,11 @@ type Renter interface {
 	// OldContracts returns the oldContracts of the renter's hostContractor.
 	OldContracts() []RenterContract
 
+	// ReorgedTransactions returns the most recent contract transactions that
+	// were reverted by a reorg and not confirmed again by the same reorg,
+	// oldest first.
+	ReorgedTransactions() []ReorgedTransaction
+
 	// ContractUtility provides the contract utility for a given host key.
 	ContractUtility(pk types.SiaPublicKey) (ContractUtility, bool)
 
@@ -388,6 +416,10 @@ type Renter interface {
 	// renter.
 	LoadSharedFilesASCII(asciiSia string) ([]string, error)
 
+	// Metrics returns the queue depths and worker failure counters of the
+	// renter.
+	Metrics() RenterMetrics
+
 	// PriceEstimation estimates the cost in siacoins of performing various
 	// storage and data operations.
 	PriceEstimation() RenterPriceEstimation
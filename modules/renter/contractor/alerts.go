@@ -0,0 +1,92 @@
+package contractor
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+const (
+	// alertIDAllowance is the ID of the alert that is registered while the
+	// allowance is nearly or fully exhausted.
+	alertIDAllowance = modules.AlertID("allowance")
+
+	// alertIDRenewals is the ID of the alert that is registered while
+	// contract renewals are failing.
+	alertIDRenewals = modules.AlertID("renewals")
+
+	// allowanceAlertThreshold is the fraction of the allowance below which
+	// the remaining funds trigger a warning.
+	allowanceAlertThreshold = 0.1
+)
+
+// Alerts returns the alerts of the contractor.
+func (c *Contractor) Alerts() []modules.Alert {
+	return c.staticAlerter.Alerts()
+}
+
+// allowanceAlert returns the severity and message of the allowance alert of
+// a maintenance round, or SeverityUnknown if no alert should be registered.
+// skippedRenewals and skippedRefreshes are the numbers of expiring and empty
+// contracts that were not renewed for lack of funds, and formationSkipped is
+// whether new contracts were needed but could not be formed.
+func allowanceAlert(allowance modules.Allowance, fundsRemaining types.Currency, skippedRenewals, skippedRefreshes int, formationSkipped bool) (modules.AlertSeverity, string) {
+	switch {
+	case skippedRenewals > 0:
+		return modules.SeverityCritical, fmt.Sprintf("%v expiring contracts could not be renewed because the allowance is exhausted; the data stored with their hosts will be lost when they expire", skippedRenewals)
+	case skippedRefreshes > 0:
+		return modules.SeverityError, fmt.Sprintf("%v contracts that ran out of funds could not be refilled because the allowance is exhausted", skippedRefreshes)
+	case formationSkipped:
+		return modules.SeverityError, "new contracts are needed, but the allowance is too low to form them"
+	}
+	if allowance.Funds.IsZero() {
+		return modules.SeverityUnknown, ""
+	}
+	remaining, _ := new(big.Rat).SetFrac(fundsRemaining.Big(), allowance.Funds.Big()).Float64()
+	if remaining < allowanceAlertThreshold {
+		return modules.SeverityWarning, fmt.Sprintf("%.1f%% of the allowance remains for the current period", remaining*100)
+	}
+	return modules.SeverityUnknown, ""
+}
+
+// managedUpdateAllowanceAlert registers or unregisters the allowance alert
+// after a maintenance round.
+func (c *Contractor) managedUpdateAllowanceAlert(allowance modules.Allowance, fundsRemaining types.Currency, skippedRenewals, skippedRefreshes int, formationSkipped bool) {
+	severity, msg := allowanceAlert(allowance, fundsRemaining, skippedRenewals, skippedRefreshes, formationSkipped)
+	var changed bool
+	if severity == modules.SeverityUnknown {
+		changed = c.staticAlerter.UnregisterAlert(alertIDAllowance)
+	} else {
+		changed = c.staticAlerter.RegisterAlert(alertIDAllowance, msg, "", severity)
+	}
+	if changed {
+		c.managedSaveAlerts()
+	}
+}
+
+// managedUpdateRenewalAlert registers the renewal alert if any of the
+// renewals of a maintenance round failed, and unregisters it otherwise.
+func (c *Contractor) managedUpdateRenewalAlert(attempted, failed int, lastErr error) {
+	var changed bool
+	if failed == 0 {
+		changed = c.staticAlerter.UnregisterAlert(alertIDRenewals)
+	} else {
+		msg := fmt.Sprintf("%v of %v contract renewals failed", failed, attempted)
+		changed = c.staticAlerter.RegisterAlert(alertIDRenewals, msg, lastErr.Error(), modules.SeverityError)
+	}
+	if changed {
+		c.managedSaveAlerts()
+	}
+}
+
+// managedSaveAlerts saves the contractor after its alerts changed.
+func (c *Contractor) managedSaveAlerts() {
+	c.mu.Lock()
+	err := c.save()
+	c.mu.Unlock()
+	if err != nil {
+		c.log.Println("Unable to save the contractor after updating its alerts:", err)
+	}
+}
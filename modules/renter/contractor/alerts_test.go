@@ -0,0 +1,63 @@
+package contractor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+// TestAllowanceAlert tests the severity of the allowance alert for the
+// outcomes of a maintenance round.
+func TestAllowanceAlert(t *testing.T) {
+	allowance := modules.Allowance{Funds: types.NewCurrency64(1000), Hosts: 10}
+	tests := []struct {
+		allowance        modules.Allowance
+		fundsRemaining   types.Currency
+		skippedRenewals  int
+		skippedRefreshes int
+		formationSkipped bool
+		severity         modules.AlertSeverity
+	}{
+		{modules.Allowance{}, types.ZeroCurrency, 0, 0, false, modules.SeverityUnknown},
+		{allowance, types.NewCurrency64(500), 0, 0, false, modules.SeverityUnknown},
+		{allowance, types.NewCurrency64(100), 0, 0, false, modules.SeverityUnknown},
+		{allowance, types.NewCurrency64(99), 0, 0, false, modules.SeverityWarning},
+		{allowance, types.ZeroCurrency, 0, 0, false, modules.SeverityWarning},
+		{allowance, types.ZeroCurrency, 0, 0, true, modules.SeverityError},
+		{allowance, types.ZeroCurrency, 0, 2, true, modules.SeverityError},
+		{allowance, types.ZeroCurrency, 1, 2, true, modules.SeverityCritical},
+	}
+	for i, test := range tests {
+		severity, msg := allowanceAlert(test.allowance, test.fundsRemaining, test.skippedRenewals, test.skippedRefreshes, test.formationSkipped)
+		if severity != test.severity {
+			t.Errorf("%v: expected severity %v, got %v (%q)", i, test.severity, severity, msg)
+		} else if (severity == modules.SeverityUnknown) != (msg == "") {
+			t.Errorf("%v: unexpected message %q for severity %v", i, msg, severity)
+		}
+	}
+}
+
+// TestRenewalAlert tests that the renewal alert is registered while renewals
+// fail and unregistered once they succeed.
+func TestRenewalAlert(t *testing.T) {
+	c := &Contractor{
+		persist:       new(memPersist),
+		staticAlerter: modules.NewAlerter("contractor"),
+	}
+	c.managedUpdateRenewalAlert(3, 1, errors.New("host did not respond"))
+	alerts := c.Alerts()
+	if len(alerts) != 1 || alerts[0].Severity != modules.SeverityError || alerts[0].Cause != "host did not respond" {
+		t.Fatal("expected a renewal alert, got", alerts)
+	}
+	// The alert should have been saved.
+	if saved := c.persist.(*memPersist).Alerts; len(saved) != 1 {
+		t.Fatal("renewal alert was not saved:", saved)
+	}
+
+	c.managedUpdateRenewalAlert(3, 0, nil)
+	if alerts := c.Alerts(); len(alerts) != 0 {
+		t.Fatal("expected no alerts, got", alerts)
+	}
+}
@@ -6,6 +6,12 @@ import (
 	"github.com/acejam/Sia/types"
 )
 
+const (
+	// maxReorgedTransactions is the number of contract transactions reverted
+	// by reorgs that the contractor keeps in memory.
+	maxReorgedTransactions = 1000
+)
+
 // Constants related to contract formation parameters.
 var (
 	// consecutiveRenewalsBeforeReplacement is the number of times a contract
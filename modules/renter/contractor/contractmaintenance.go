@@ -11,6 +11,7 @@ import (
 	"github.com/acejam/Sia/build"
 	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/modules/renter/proto"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -162,7 +163,7 @@ func (c *Contractor) managedEstimateRenewFundingRequirements(contract modules.Re
 
 	// Get an estimate for how much money we will be charged before going into
 	// the transaction pool.
-	_, maxTxnFee := c.tpool.FeeEstimation()
+	_, maxTxnFee := c.tpool.FeeEstimationTarget(modules.FeeTargetContractFormation)
 	txnFees := maxTxnFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
 
 	// Add them all up and then return the estimate plus 33% for error margin
@@ -341,14 +342,14 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		txnBuilder.Drop()
 		// We need to return a funding value because money was spent on this
 		// host, even though the full process could not be completed.
-		c.log.Println("WARN: Attempted to form a new contract with a host that we already have a contrat with.")
+		c.log.With(persist.LogFieldHostPubKey, contract.HostPublicKey.String()).Println("WARN: Attempted to form a new contract with a host that we already have a contrat with.")
 		return contractFunding, modules.RenterContract{}, fmt.Errorf("We already have a contract with host %v", contract.HostPublicKey)
 	}
 	c.pubKeysToContractID[string(contract.HostPublicKey.Key)] = contract.ID
 	c.mu.Unlock()
 
 	contractValue := contract.RenterFunds
-	c.log.Printf("Formed contract %v with %v for %v", contract.ID, host.NetAddress, contractValue.HumanString())
+	c.log.With(persist.LogFieldContractID, contract.ID, persist.LogFieldHostPubKey, contract.HostPublicKey.String()).Printf("Formed contract with %v for %v", host.NetAddress, contractValue.HumanString())
 	return contractFunding, contract, nil
 }
 
@@ -469,11 +470,13 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 	if !exists {
 		return types.ZeroCurrency, errors.New("contract no longer exists")
 	}
+	hpk := oldContract.Metadata().HostPublicKey
+	log := c.log.With(persist.LogFieldContractID, id, persist.LogFieldHostPubKey, hpk.String())
 	// Return the contract if it's not useful for renewing.
 	oldUtility, ok := c.managedContractUtility(id)
 	if !ok || !oldUtility.GoodForRenew {
-		c.log.Printf("Contract %v slated for renew is marked not good for renew %v/%v",
-			id, ok, oldUtility.GoodForRenew)
+		log.Printf("Contract slated for renew is marked not good for renew %v/%v",
+			ok, oldUtility.GoodForRenew)
 		c.staticContracts.Return(oldContract)
 		return types.ZeroCurrency, errors.New("contract is marked not good for renew")
 	}
@@ -506,22 +509,20 @@ func (c *Contractor) managedRenewContract(renewInstructions fileContractRenewal,
 			oldUtility.Locked = true
 			err := oldContract.UpdateUtility(oldUtility)
 			if err != nil {
-				c.log.Println("WARN: failed to mark contract as !goodForRenew:", err)
+				log.Println("WARN: failed to mark contract as !goodForRenew:", err)
 			}
-			c.log.Printf("WARN: failed to renew %v, marked as bad: %v\n",
-				oldContract.Metadata().HostPublicKey, errRenew)
+			log.Printf("WARN: failed to renew contract, marked as bad: %v\n", errRenew)
 			c.staticContracts.Return(oldContract)
 			return types.ZeroCurrency, errors.AddContext(errRenew, "contract marked as bad for too many consecutive failed renew attempts")
 		}
 
 		// Seems like it doesn't have to be replaced yet. Log the
 		// failure and number of renews that have failed so far.
-		c.log.Printf("WARN: failed to renew contract %v [%v]: %v\n",
-			oldContract.Metadata().HostPublicKey, numRenews, errRenew)
+		log.Printf("WARN: failed to renew contract [%v]: %v\n", numRenews, errRenew)
 		c.staticContracts.Return(oldContract)
 		return types.ZeroCurrency, errors.AddContext(errRenew, "contract renewal with host was unsuccessful")
 	}
-	c.log.Printf("Renewed contract %v\n", id)
+	log.Printf("Renewed contract into %v\n", newContract.ID)
 
 	// Update the utility values for the new contract, and for the old
 	// contract.
@@ -587,11 +588,14 @@ func (c *Contractor) threadedContractMaintenance() {
 	c.managedCheckForDuplicates()
 	c.managedPrunePubkeyMap()
 
-	// Nothing to do if there are no hosts.
+	// Nothing to do if there are no hosts. Without an allowance there are no
+	// renewals that can fail, so the alerts of previous rounds are cleared.
 	c.mu.RLock()
 	wantedHosts := c.allowance.Hosts
 	c.mu.RUnlock()
 	if wantedHosts <= 0 {
+		c.managedUpdateAllowanceAlert(modules.Allowance{}, types.ZeroCurrency, 0, 0, false)
+		c.managedUpdateRenewalAlert(0, 0, nil)
 		return
 	}
 
@@ -723,26 +727,40 @@ func (c *Contractor) threadedContractMaintenance() {
 		fundsRemaining = allowance.Funds.Sub(spending.TotalAllocated)
 	}
 
+	// Alert the user if the allowance runs out, so that they can raise it
+	// before their contracts expire. The alert is updated when maintenance
+	// returns, and reflects the renewals and formations that were skipped for
+	// lack of funds.
+	var skippedRenewals, skippedRefreshes int
+	var formationSkipped bool
+	defer func() {
+		c.managedUpdateAllowanceAlert(allowance, fundsRemaining, skippedRenewals, skippedRefreshes, formationSkipped)
+	}()
+
 	// Go through the contracts we've assembled for renewal. Any contracts that
 	// need to be renewed because they are expiring (renewSet) get priority over
 	// contracts that need to be renewed because they have exhausted their funds
 	// (refreshSet). If there is not enough money available, the more expensive
 	// contracts will be skipped.
-	//
-	// TODO: We need some sort of global warning system so that we can alert the
-	// user to the fact that they do not have enough money to keep their
-	// contracts going in the event that we run out of funds.
+	var renewalsAttempted, renewalsFailed int
+	var renewalErr error
 	for _, renewal := range renewSet {
 		// Skip this renewal if we don't have enough funds remaining.
 		if renewal.amount.Cmp(fundsRemaining) > 0 {
+			skippedRenewals++
 			continue
 		}
 
-		// Renew one contract. The error is ignored because the renew function
-		// already will have logged the error, and in the event of an error,
-		// 'fundsSpent' will return '0'.
-		fundsSpent, _ := c.managedRenewContract(renewal, currentPeriod, allowance, blockHeight, endHeight)
+		// Renew one contract. The renew function already will have logged
+		// the error, and in the event of an error, 'fundsSpent' will return
+		// '0'. The error is only kept for the renewal alert.
+		fundsSpent, err := c.managedRenewContract(renewal, currentPeriod, allowance, blockHeight, endHeight)
 		fundsRemaining = fundsRemaining.Sub(fundsSpent)
+		renewalsAttempted++
+		if err != nil {
+			renewalsFailed++
+			renewalErr = err
+		}
 
 		// Return here if an interrupt or kill signal has been sent.
 		select {
@@ -756,14 +774,20 @@ func (c *Contractor) threadedContractMaintenance() {
 	for _, renewal := range refreshSet {
 		// Skip this renewal if we don't have enough funds remaining.
 		if renewal.amount.Cmp(fundsRemaining) > 0 {
+			skippedRefreshes++
 			continue
 		}
 
-		// Renew one contract. The error is ignored because the renew function
-		// already will have logged the error, and in the event of an error,
-		// 'fundsSpent' will return '0'.
-		fundsSpent, _ := c.managedRenewContract(renewal, currentPeriod, allowance, blockHeight, endHeight)
+		// Renew one contract. The renew function already will have logged
+		// the error, and in the event of an error, 'fundsSpent' will return
+		// '0'. The error is only kept for the renewal alert.
+		fundsSpent, err := c.managedRenewContract(renewal, currentPeriod, allowance, blockHeight, endHeight)
 		fundsRemaining = fundsRemaining.Sub(fundsSpent)
+		renewalsAttempted++
+		if err != nil {
+			renewalsFailed++
+			renewalErr = err
+		}
 
 		// Return here if an interrupt or kill signal has been sent.
 		select {
@@ -775,6 +799,8 @@ func (c *Contractor) threadedContractMaintenance() {
 		}
 	}
 
+	c.managedUpdateRenewalAlert(renewalsAttempted, renewalsFailed, renewalErr)
+
 	// Count the number of contracts which are good for uploading, and then make
 	// more as needed to fill the gap.
 	uploadContracts := 0
@@ -812,13 +838,14 @@ func (c *Contractor) threadedContractMaintenance() {
 		// Determine if we have enough money to form a new contract.
 		if fundsRemaining.Cmp(initialContractFunds) < 0 {
 			c.log.Println("WARN: need to form new contracts, but unable to because of a low allowance")
+			formationSkipped = true
 			break
 		}
 
 		// Attempt forming a contract with this host.
 		fundsSpent, newContract, err := c.managedNewContract(host, initialContractFunds, endHeight)
 		if err != nil {
-			c.log.Printf("Attempted to form a contract with %v, but negotiation failed: %v\n", host.NetAddress, err)
+			c.log.With(persist.LogFieldHostPubKey, host.PublicKey.String()).Printf("Attempted to form a contract with %v, but negotiation failed: %v\n", host.NetAddress, err)
 			continue
 		}
 		fundsRemaining = fundsRemaining.Sub(fundsSpent)
@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/modules/renter/proto"
 	"github.com/acejam/Sia/persist"
@@ -46,6 +47,10 @@ type Contractor struct {
 	tpool      transactionPool
 	wallet     wallet
 
+	// staticAlerter contains the alerts of the contractor. The alerts are
+	// persisted with the rest of the contractor.
+	staticAlerter *modules.GenericAlerter
+
 	// Only one thread should be performing contract maintenance at a time.
 	interruptMaintenance chan struct{}
 	maintenanceLock      siasync.TryMutex
@@ -63,6 +68,10 @@ type Contractor struct {
 	renewing            map[types.FileContractID]bool // prevent revising during renewal
 	revising            map[types.FileContractID]bool // prevent overlapping revisions
 
+	// reorgedTransactions contains the most recent contract transactions
+	// that were reverted by a reorg.
+	reorgedTransactions []modules.ReorgedTransaction
+
 	// renewedFrom links the new contract's ID to the old contract's ID
 	// renewedTo links the old contract's ID to the new contract's ID
 	staticContracts *proto.ContractSet
@@ -170,6 +179,18 @@ func (c *Contractor) Close() error {
 
 // New returns a new Contractor.
 func New(cs consensusSet, wallet walletShim, tpool transactionPool, hdb hostDB, persistDir string) (*Contractor, error) {
+	return newContractor(cs, wallet, tpool, hdb, persistDir, modules.ProdDependencies)
+}
+
+// NewEncrypted returns a new Contractor whose persist files and contracts are
+// encrypted with key. Plaintext persist files are encrypted when the
+// Contractor loads them.
+func NewEncrypted(cs consensusSet, wallet walletShim, tpool transactionPool, hdb hostDB, persistDir string, key crypto.TwofishKey) (*Contractor, error) {
+	return newContractor(cs, wallet, tpool, hdb, persistDir, modules.NewEncryptedDependencies(key))
+}
+
+// newContractor returns a new Contractor that persists its data using deps.
+func newContractor(cs consensusSet, wallet walletShim, tpool transactionPool, hdb hostDB, persistDir string, deps modules.Dependencies) (*Contractor, error) {
 	// Check for nil inputs.
 	if cs == nil {
 		return nil, errNilCS
@@ -186,25 +207,36 @@ func New(cs consensusSet, wallet walletShim, tpool transactionPool, hdb hostDB,
 		return nil, err
 	}
 
+	// Open the persist journal, which applies the updates that were
+	// interrupted by a crash. This must occur before converting the old
+	// persist file(s).
+	p, err := newPersist(persistDir, deps)
+	if err != nil {
+		return nil, err
+	}
+
 	// Convert the old persist file(s), if necessary. This must occur before
 	// loading the contract set.
 	if err := convertPersist(persistDir); err != nil {
+		p.close()
 		return nil, err
 	}
 
 	// Create the contract set.
-	contractSet, err := proto.NewContractSet(filepath.Join(persistDir, "contracts"), modules.ProdDependencies)
+	contractSet, err := proto.NewContractSet(filepath.Join(persistDir, "contracts"), deps)
 	if err != nil {
+		p.close()
 		return nil, err
 	}
 	// Create the logger.
 	logger, err := persist.NewFileLogger(filepath.Join(persistDir, "contractor.log"))
 	if err != nil {
+		p.close()
 		return nil, err
 	}
 
-	// Create Contractor using production dependencies.
-	return NewCustomContractor(cs, &WalletBridge{W: wallet}, tpool, hdb, contractSet, NewPersist(persistDir), logger, modules.ProdDependencies)
+	// Create Contractor using the provided dependencies.
+	return NewCustomContractor(cs, &WalletBridge{W: wallet}, tpool, hdb, contractSet, p, logger, deps)
 }
 
 // NewCustomContractor creates a Contractor using the provided dependencies.
@@ -219,6 +251,7 @@ func NewCustomContractor(cs consensusSet, w wallet, tp transactionPool, hdb host
 		tpool:      tp,
 		wallet:     w,
 
+		staticAlerter:        modules.NewAlerter("contractor"),
 		interruptMaintenance: make(chan struct{}),
 
 		staticContracts:     contractSet,
@@ -238,6 +271,9 @@ func NewCustomContractor(cs consensusSet, w wallet, tp transactionPool, hdb host
 		if err := c.staticContracts.Close(); err != nil {
 			c.log.Println("Failed to close contract set:", err)
 		}
+		if err := c.persist.close(); err != nil {
+			c.log.Println("Failed to close the persist journal:", err)
+		}
 		if err := c.log.Close(); err != nil {
 			fmt.Println("Failed to close the contractor logger:", err)
 		}
@@ -27,8 +27,9 @@ func (newStub) NextAddress() (uc types.UnlockConditions, err error)          { r
 func (newStub) StartTransaction() (tb modules.TransactionBuilder, err error) { return }
 
 // transaction pool stubs
-func (newStub) AcceptTransactionSet([]types.Transaction) error      { return nil }
-func (newStub) FeeEstimation() (a types.Currency, b types.Currency) { return }
+func (newStub) AcceptTransactionSet([]types.Transaction) error                             { return nil }
+func (newStub) FeeEstimation() (a types.Currency, b types.Currency)                        { return }
+func (newStub) FeeEstimationTarget(types.BlockHeight) (a types.Currency, b types.Currency) { return }
 
 // hdb stubs
 func (newStub) AllHosts() []modules.HostDBEntry                                 { return nil }
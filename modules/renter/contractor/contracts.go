@@ -63,6 +63,37 @@ func (c *Contractor) OldContracts() []modules.RenterContract {
 	return contracts
 }
 
+// isContractTransaction returns true if txn forms or revises one of the
+// contractor's contracts.
+func (c *Contractor) isContractTransaction(txn types.Transaction) bool {
+	isContract := func(id types.FileContractID) bool {
+		if _, exists := c.staticContracts.View(id); exists {
+			return true
+		}
+		_, exists := c.oldContracts[id]
+		return exists
+	}
+	for i := range txn.FileContracts {
+		if isContract(txn.FileContractID(uint64(i))) {
+			return true
+		}
+	}
+	for _, fcr := range txn.FileContractRevisions {
+		if isContract(fcr.ParentID) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReorgedTransactions returns the most recent contract transactions that were
+// reverted by a reorg and not confirmed again by the same reorg, oldest first.
+func (c *Contractor) ReorgedTransactions() []modules.ReorgedTransaction {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]modules.ReorgedTransaction(nil), c.reorgedTransactions...)
+}
+
 // ContractUtility returns the utility fields for the given contract.
 func (c *Contractor) ContractUtility(pk types.SiaPublicKey) (modules.ContractUtility, bool) {
 	c.mu.RLock()
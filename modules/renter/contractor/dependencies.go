@@ -23,6 +23,13 @@ type (
 		NextAddress() (types.UnlockConditions, error)
 		StartTransaction() (modules.TransactionBuilder, error)
 	}
+	// accountWalletShim is implemented by wallets that support named
+	// accounts. If the wallet has a renter account, the contractor funds its
+	// contracts from that account.
+	accountWalletShim interface {
+		AccountNextAddress(name string) (types.UnlockConditions, error)
+		StartAccountTransaction(name string) (modules.TransactionBuilder, error)
+	}
 	wallet interface {
 		NextAddress() (types.UnlockConditions, error)
 		StartTransaction() (transactionBuilder, error)
@@ -45,6 +52,7 @@ type (
 	transactionPool interface {
 		AcceptTransactionSet([]types.Transaction) error
 		FeeEstimation() (min types.Currency, max types.Currency)
+		FeeEstimationTarget(types.BlockHeight) (min types.Currency, max types.Currency)
 	}
 
 	hostDB interface {
@@ -60,6 +68,7 @@ type (
 	persister interface {
 		save(contractorPersist) error
 		load(*contractorPersist) error
+		close() error
 	}
 )
 
@@ -70,17 +79,39 @@ type WalletBridge struct {
 	W walletShim
 }
 
-// NextAddress computes and returns the next address of the wallet.
-func (ws *WalletBridge) NextAddress() (types.UnlockConditions, error) { return ws.W.NextAddress() }
+// NextAddress computes and returns the next address of the wallet. If the
+// wallet has a renter account, the address belongs to that account.
+func (ws *WalletBridge) NextAddress() (types.UnlockConditions, error) {
+	if aw, ok := ws.W.(accountWalletShim); ok {
+		uc, err := aw.AccountNextAddress(modules.WalletAccountRenter)
+		if err != modules.ErrUnknownWalletAccount {
+			return uc, err
+		}
+	}
+	return ws.W.NextAddress()
+}
 
 // StartTransaction creates a new transactionBuilder that can be used to create
-// and sign a transaction.
-func (ws *WalletBridge) StartTransaction() (transactionBuilder, error) { return ws.W.StartTransaction() }
+// and sign a transaction. If the wallet has a renter account, the transaction
+// is funded by that account.
+func (ws *WalletBridge) StartTransaction() (transactionBuilder, error) {
+	if aw, ok := ws.W.(accountWalletShim); ok {
+		txnBuilder, err := aw.StartAccountTransaction(modules.WalletAccountRenter)
+		if err != modules.ErrUnknownWalletAccount {
+			return txnBuilder, err
+		}
+	}
+	return ws.W.StartTransaction()
+}
 
 // stdPersist implements the persister interface. The filename required by
-// these functions is internal to stdPersist.
+// these functions is internal to stdPersist. The persist file is saved
+// through the journal that the contractor shares with the renter and the
+// contract set.
 type stdPersist struct {
+	deps     modules.Dependencies
 	filename string
+	journal  *persist.Journal
 }
 
 var persistMeta = persist.Metadata{
@@ -89,16 +120,37 @@ var persistMeta = persist.Metadata{
 }
 
 func (p *stdPersist) save(data contractorPersist) error {
-	return persist.SaveJSON(persistMeta, data, p.filename)
+	u, err := p.deps.JSONFileUpdate(persistMeta, data, p.filename)
+	if err != nil {
+		return err
+	}
+	return p.journal.Update(u)
 }
 
 func (p *stdPersist) load(data *contractorPersist) error {
-	return persist.LoadJSON(persistMeta, &data, p.filename)
+	return p.deps.LoadFile(persistMeta, &data, p.filename)
 }
 
-// NewPersist create a new stdPersist.
-func NewPersist(dir string) *stdPersist {
+func (p *stdPersist) close() error {
+	return p.journal.Close()
+}
+
+// NewPersist create a new stdPersist. It opens the journal in dir, which
+// applies the updates that were interrupted by a crash.
+func NewPersist(dir string) (*stdPersist, error) {
+	return newPersist(dir, modules.ProdDependencies)
+}
+
+// newPersist creates a new stdPersist that saves and loads its file using
+// deps.
+func newPersist(dir string, deps modules.Dependencies) (*stdPersist, error) {
+	journal, err := persist.OpenJournal(filepath.Join(dir, modules.RenterJournalFile))
+	if err != nil {
+		return nil, err
+	}
 	return &stdPersist{
+		deps:     deps,
 		filename: filepath.Join(dir, "contractor.json"),
-	}
+		journal:  journal,
+	}, nil
 }
@@ -55,7 +55,9 @@ allowance will only take effect upon the next renewal.
 Modifications to file contracts are mediated through the Editor interface. An
 Editor maintains a network connection to a host, over which is sends
 modification requests, such as "delete sector 12." After each modification,
-the Editor revises the underlying file contract and saves it to disk.
+the Editor revises the underlying file contract and saves it to disk. An
+upload can save the updates of its caller, such as the new piece of a renter
+file, in the same journal transaction as the revised contract.
 
 The primary challenge of the contractor is that it must be smart enough for
 the user to feel comfortable allowing it to spend their money. Because
@@ -7,6 +7,7 @@ import (
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/modules/renter/proto"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 )
 
@@ -23,6 +24,11 @@ type Editor interface {
 	// returns the Merkle root of the data.
 	Upload(data []byte) (root crypto.Hash, err error)
 
+	// UploadWithUpdates is like Upload, but saves the persist updates that
+	// updates returns for the Merkle root of the data in the same journal
+	// transaction as the revised contract.
+	UploadWithUpdates(data []byte, updates func(crypto.Hash) []persist.Update) (root crypto.Hash, err error)
+
 	// Address returns the address of the host.
 	Address() modules.NetAddress
 
@@ -98,7 +104,14 @@ func (he *hostEditor) Close() error {
 }
 
 // Upload negotiates a revision that adds a sector to a file contract.
-func (he *hostEditor) Upload(data []byte) (_ crypto.Hash, err error) {
+func (he *hostEditor) Upload(data []byte) (crypto.Hash, error) {
+	return he.UploadWithUpdates(data, nil)
+}
+
+// UploadWithUpdates negotiates a revision that adds a sector to a file
+// contract and saves the updates for its Merkle root together with the
+// revision.
+func (he *hostEditor) UploadWithUpdates(data []byte, updates func(crypto.Hash) []persist.Update) (_ crypto.Hash, err error) {
 	he.mu.Lock()
 	defer he.mu.Unlock()
 	if he.invalid {
@@ -106,7 +119,7 @@ func (he *hostEditor) Upload(data []byte) (_ crypto.Hash, err error) {
 	}
 
 	// Perform the upload.
-	_, sectorRoot, err := he.editor.Upload(data)
+	_, sectorRoot, err := he.editor.UploadWithUpdates(data, updates)
 	if err != nil {
 		return crypto.Hash{}, err
 	}
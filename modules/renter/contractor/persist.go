@@ -14,6 +14,7 @@ import (
 
 // contractorPersist defines what Contractor data persists across sessions.
 type contractorPersist struct {
+	Alerts        []modules.Alert                 `json:"alerts"`
 	Allowance     modules.Allowance               `json:"allowance"`
 	BlockHeight   types.BlockHeight               `json:"blockheight"`
 	CurrentPeriod types.BlockHeight               `json:"currentperiod"`
@@ -26,6 +27,7 @@ type contractorPersist struct {
 // persistData returns the data in the Contractor that will be saved to disk.
 func (c *Contractor) persistData() contractorPersist {
 	data := contractorPersist{
+		Alerts:        c.staticAlerter.Alerts(),
 		Allowance:     c.allowance,
 		BlockHeight:   c.blockHeight,
 		CurrentPeriod: c.currentPeriod,
@@ -52,6 +54,7 @@ func (c *Contractor) load() error {
 	if err != nil {
 		return err
 	}
+	c.staticAlerter.LoadAlerts(data.Alerts)
 	c.allowance = data.Allowance
 	c.blockHeight = data.BlockHeight
 	c.currentPeriod = data.CurrentPeriod
@@ -90,10 +93,11 @@ func (c *Contractor) saveSync() error {
 // formats.
 func convertPersist(dir string) error {
 	// Try loading v1.3.1 persist. If it has the correct version number, no
-	// further action is necessary.
+	// further action is necessary. Encrypted persist files are always
+	// v1.3.1 or newer.
 	persistPath := filepath.Join(dir, "contractor.json")
 	err := persist.LoadJSON(persistMeta, nil, persistPath)
-	if err == nil {
+	if err == nil || err == persist.ErrEncrypted {
 		return nil
 	}
 
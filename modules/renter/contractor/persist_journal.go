@@ -17,6 +17,10 @@ package contractor
 // In the event of power failure or other serious disruption, the most recent
 // update set may be only partially written. Partially written update sets are
 // simply ignored when reading the journal.
+//
+// The journal is only read to convert it to the current persist format; the
+// contractor now saves its data through the persist.Journal that it shares
+// with the renter.
 
 import (
 	"encoding/json"
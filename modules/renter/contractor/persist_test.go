@@ -17,13 +17,16 @@ type memPersist contractorPersist
 
 func (m *memPersist) save(data contractorPersist) error { *m = memPersist(data); return nil }
 func (m memPersist) load(data *contractorPersist) error { *data = contractorPersist(m); return nil }
+func (m memPersist) close() error                       { return nil }
 
 // TestSaveLoad tests that the contractor can save and load itself.
 func TestSaveLoad(t *testing.T) {
 	// create contractor with mocked persist dependency
 	c := &Contractor{
-		persist: new(memPersist),
+		persist:       new(memPersist),
+		staticAlerter: modules.NewAlerter("contractor"),
 	}
+	c.staticAlerter.RegisterAlert(alertIDRenewals, "1 of 1 contract renewals failed", "foo", modules.SeverityError)
 
 	c.oldContracts = map[types.FileContractID]modules.RenterContract{
 		{0}: {ID: types.FileContractID{0}, HostPublicKey: types.SiaPublicKey{Key: []byte("foo")}},
@@ -47,10 +50,14 @@ func TestSaveLoad(t *testing.T) {
 	c.oldContracts = make(map[types.FileContractID]modules.RenterContract)
 	c.renewedFrom = make(map[types.FileContractID]types.FileContractID)
 	c.renewedTo = make(map[types.FileContractID]types.FileContractID)
+	c.staticAlerter = modules.NewAlerter("contractor")
 	err = c.load()
 	if err != nil {
 		t.Fatal(err)
 	}
+	if alerts := c.Alerts(); len(alerts) != 1 || alerts[0].ID != alertIDRenewals || alerts[0].Cause != "foo" {
+		t.Fatal("alerts were not restored properly:", alerts)
+	}
 	// Check that all fields were restored
 	_, ok0 := c.oldContracts[types.FileContractID{0}]
 	_, ok1 := c.oldContracts[types.FileContractID{1}]
@@ -66,8 +73,15 @@ func TestSaveLoad(t *testing.T) {
 		t.Fatal("renewedTo not restored properly:", c.renewedTo)
 	}
 	// use stdPersist instead of mock
-	c.persist = NewPersist(build.TempDir("contractor", t.Name()))
-	os.MkdirAll(build.TempDir("contractor", t.Name()), 0700)
+	dir := build.TempDir("contractor", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	c.persist, err = NewPersist(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.persist.close()
 
 	// save, clear, and reload
 	err = c.save()
@@ -119,7 +133,12 @@ func TestConvertPersist(t *testing.T) {
 
 	// load the persist
 	var p contractorPersist
-	err = NewPersist(dir).load(&p)
+	sp, err := NewPersist(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sp.close()
+	err = sp.load(&p)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2,6 +2,7 @@ package contractor
 
 import (
 	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 )
 
@@ -28,7 +29,7 @@ func (c *Contractor) managedArchiveContracts() {
 			c.oldContracts[id] = contract
 			c.mu.Unlock()
 			expired = append(expired, id)
-			c.log.Println("INFO: archived expired contract", id)
+			c.log.With(persist.LogFieldContractID, id, persist.LogFieldHostPubKey, contract.HostPublicKey.String()).Println("INFO: archived expired contract")
 		}
 	}
 
@@ -49,6 +50,14 @@ func (c *Contractor) managedArchiveContracts() {
 // is a change in the blockchain. Updates will always be called in order.
 func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 	c.mu.Lock()
+	rts := cc.ReorgedTransactions(c.isContractTransaction)
+	for _, rt := range rts {
+		c.log.Println("WARN: a contract transaction has been reverted due to a reorg:", rt.TransactionID)
+	}
+	c.reorgedTransactions = append(c.reorgedTransactions, rts...)
+	if len(c.reorgedTransactions) > maxReorgedTransactions {
+		c.reorgedTransactions = c.reorgedTransactions[len(c.reorgedTransactions)-maxReorgedTransactions:]
+	}
 	for _, block := range cc.RevertedBlocks {
 		if block.ID() != types.GenesisID {
 			c.blockHeight--
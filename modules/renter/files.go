@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"os"
 	"path/filepath"
 	"sync"
 
@@ -262,12 +261,10 @@ func (r *Renter) DeleteFile(nickname string) error {
 	delete(r.files, nickname)
 	delete(r.persist.Tracking, nickname)
 
-	err := persist.RemoveFile(filepath.Join(r.persistDir, f.name+ShareExtension))
+	err := r.saveSyncWith(persist.RemoveFileUpdate(filepath.Join(r.persistDir, f.name+ShareExtension)))
 	if err != nil {
-		r.log.Println("WARN: couldn't remove file :", err)
+		r.log.With(persist.LogFieldSiaPath, nickname).Println("WARN: couldn't remove file :", err)
 	}
-
-	r.saveSync()
 	r.mu.Unlock(lockID)
 
 	// delete the file's associated contract data.
@@ -429,10 +426,10 @@ func (r *Renter) RenameFile(currentName, newName string) error {
 		return ErrPathOverload
 	}
 
-	// Modify the file and save it to disk.
+	// Modify the file.
 	file.mu.Lock()
 	file.name = newName
-	err = r.saveFile(file)
+	u, err := r.fileUpdate(file)
 	file.mu.Unlock()
 	if err != nil {
 		return err
@@ -445,12 +442,9 @@ func (r *Renter) RenameFile(currentName, newName string) error {
 		delete(r.persist.Tracking, currentName)
 		r.persist.Tracking[newName] = t
 	}
-	err = r.saveSync()
-	if err != nil {
-		return err
-	}
 
-	// Delete the old .sia file.
+	// Save the file under its new name, delete the old .sia file and save
+	// the tracking data at once.
 	oldPath := filepath.Join(r.persistDir, currentName+ShareExtension)
-	return os.RemoveAll(oldPath)
+	return r.saveSyncWith(u, persist.RemoveFileUpdate(oldPath))
 }
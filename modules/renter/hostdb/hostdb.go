@@ -114,9 +114,13 @@ func NewCustomHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir stri
 	// The host tree is used to manage hosts and query them at random.
 	hdb.hostTree = hosttree.New(hdb.calculateHostWeight)
 
-	// Load the prior persistence structures.
+	// Load the prior persistence structures. A plaintext persist file is
+	// encrypted right away if the persist files of the hostdb are encrypted.
 	hdb.mu.Lock()
 	err = hdb.load()
+	if _, ok := deps.(modules.PersistEncrypter); ok && err == nil {
+		err = hdb.saveSync()
+	}
 	hdb.mu.Unlock()
 	if err != nil && !os.IsNotExist(err) {
 		return nil, err
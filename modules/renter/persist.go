@@ -6,11 +6,13 @@ import (
 	"encoding/base64"
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
 
 	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/encoding"
 	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/persist"
@@ -43,6 +45,14 @@ var (
 	shareHeader  = [15]byte{'S', 'i', 'a', ' ', 'S', 'h', 'a', 'r', 'e', 'd', ' ', 'F', 'i', 'l', 'e'}
 	shareVersion = "0.4"
 
+	// errEncryptedSiaFile is returned when loading an encrypted .sia file
+	// without the key to decrypt it.
+	errEncryptedSiaFile = errors.New(".sia file is encrypted")
+
+	// specifierEncryptedSiaFile precedes the encrypted contents of a .sia
+	// file in the renter directory. Shared .sia files are never encrypted.
+	specifierEncryptedSiaFile = types.Specifier{'e', 'n', 'c', 'r', 'y', 'p', 't', 'e', 'd', ' ', 's', 'i', 'a'}
+
 	// Persist Version Numbers
 	persistVersion040 = "0.4"
 	persistVersion133 = "1.3.3"
@@ -173,38 +183,68 @@ func (f *file) UnmarshalSia(r io.Reader) error {
 	return nil
 }
 
-// saveFile saves a file to the renter directory.
-func (r *Renter) saveFile(f *file) error {
+// fileUpdate returns the journal update that saves a file to the renter
+// directory. The contents of the file are encrypted if the persist files of
+// the renter are encrypted.
+func (r *Renter) fileUpdate(f *file) (persist.Update, error) {
 	if f.deleted {
-		return errors.New("can't save deleted file")
+		return persist.Update{}, errors.New("can't save deleted file")
 	}
-	// Create directory structure specified in nickname.
-	fullPath := filepath.Join(r.persistDir, f.name+ShareExtension)
-	err := os.MkdirAll(filepath.Dir(fullPath), 0700)
-	if err != nil {
-		return err
+	buf := new(bytes.Buffer)
+	if err := shareFiles([]*file{f}, buf); err != nil {
+		return persist.Update{}, err
 	}
+	data := buf.Bytes()
+	if pe, ok := r.deps.(modules.PersistEncrypter); ok {
+		data = append(append([]byte(nil), specifierEncryptedSiaFile[:]...), pe.EncryptPersist(data)...)
+	}
+	return persist.WriteFileUpdate(filepath.Join(r.persistDir, f.name+ShareExtension), data), nil
+}
 
-	// Open SafeFile handle.
-	handle, err := persist.NewSafeFile(filepath.Join(r.persistDir, f.name+ShareExtension))
+// saveFile saves a file to the renter directory.
+func (r *Renter) saveFile(f *file) error {
+	u, err := r.fileUpdate(f)
 	if err != nil {
 		return err
 	}
-	defer handle.Close()
+	return r.journal.Update(u)
+}
 
-	// Write file data.
-	err = shareFiles([]*file{f}, handle)
+// saveSync stores the current renter data to disk and then syncs to disk.
+func (r *Renter) saveSync() error {
+	return r.saveSyncWith()
+}
+
+// saveSyncWith stores the current renter data to disk together with updates,
+// such as the updates of the files whose tracking data changed. Either all of
+// them are saved, or none of them.
+func (r *Renter) saveSyncWith(updates ...persist.Update) error {
+	u, err := r.deps.JSONFileUpdate(settingsMetadata, r.persist, filepath.Join(r.persistDir, PersistFilename))
 	if err != nil {
 		return err
 	}
-
-	// Commit the SafeFile.
-	return handle.CommitSync()
+	return r.journal.Update(append(updates, u)...)
 }
 
-// saveSync stores the current renter data to disk and then syncs to disk.
-func (r *Renter) saveSync() error {
-	return persist.SaveJSON(settingsMetadata, r.persist, filepath.Join(r.persistDir, PersistFilename))
+// readSiaFile reads a .sia file from the renter directory, decrypting it if
+// it is encrypted.
+func (r *Renter) readSiaFile(path string) (io.Reader, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(b, specifierEncryptedSiaFile[:]) {
+		return bytes.NewReader(b), nil
+	}
+	pe, ok := r.deps.(modules.PersistEncrypter)
+	if !ok {
+		return nil, errEncryptedSiaFile
+	}
+	plaintext, err := pe.DecryptPersist(crypto.Ciphertext(b[types.SpecifierLen:]))
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(plaintext), nil
 }
 
 // loadSiaFiles walks through the directory searching for siafiles and loading
@@ -225,15 +265,15 @@ func (r *Renter) loadSiaFiles() error {
 			return nil
 		}
 
-		// Open the file.
-		file, err := os.Open(path)
+		// Read the file.
+		file, err := r.readSiaFile(path)
 		if err != nil {
 			r.log.Println("ERROR: could not open .sia file:", err)
 			return nil
 		}
-		defer file.Close()
 
-		// Load the file contents into the renter.
+		// Load the file contents into the renter. This saves the file again,
+		// encrypting it if the persist files of the renter are encrypted.
 		_, err = r.loadSharedFiles(file)
 		if err != nil {
 			r.log.Println("ERROR: could not load .sia file:", err)
@@ -248,7 +288,7 @@ func (r *Renter) loadSettings() error {
 	r.persist = persistence{
 		Tracking: make(map[string]trackedFile),
 	}
-	err := persist.LoadJSON(settingsMetadata, &r.persist, filepath.Join(r.persistDir, PersistFilename))
+	err := r.deps.LoadFile(settingsMetadata, &r.persist, filepath.Join(r.persistDir, PersistFilename))
 	if os.IsNotExist(err) {
 		// No persistence yet, set the defaults and continue.
 		r.persist.MaxDownloadSpeed = DefaultMaxDownloadSpeed
@@ -441,11 +481,28 @@ func (r *Renter) initPersist() error {
 		return err
 	}
 
+	// Open the journal, which applies the updates that were interrupted by a
+	// crash before the persist files are loaded.
+	r.journal, err = persist.OpenJournal(filepath.Join(r.persistDir, modules.RenterJournalFile))
+	if err != nil {
+		return err
+	}
+	r.tg.AfterStop(func() error {
+		return r.journal.Close()
+	})
+
 	// Load the prior persistence structures.
 	err = r.loadSettings()
 	if err != nil {
 		return err
 	}
+	// Encrypt a plaintext settings file right away if the persist files of
+	// the renter are encrypted.
+	if _, ok := r.deps.(modules.PersistEncrypter); ok {
+		if err := r.saveSync(); err != nil {
+			return err
+		}
+	}
 
 	// Load the siafiles into memory.
 	return r.loadSiaFiles()
@@ -1,7 +1,10 @@
 package proto
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -9,6 +12,7 @@ import (
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/encoding"
 	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/writeaheadlog"
@@ -23,6 +27,21 @@ const (
 	updateNameSetRoot   = "setRoot"
 )
 
+var (
+	// errContractHeaderEncrypted is returned when loading a contract whose
+	// header is encrypted without the key to decrypt it.
+	errContractHeaderEncrypted = errors.New("contract header is encrypted")
+
+	// errContractHeaderTooLarge is returned when an encoded contract header
+	// does not fit into the header section of a contract file.
+	errContractHeaderTooLarge = errors.New("contract header is too large")
+
+	// specifierEncryptedHeader precedes the encrypted header of a contract
+	// file. A plaintext header never starts with it, because the header
+	// starts with the number of siacoin inputs of its transaction.
+	specifierEncryptedHeader = types.Specifier{'e', 'n', 'c', 'r', 'y', 'p', 't', 'e', 'd', ' ', 'h', 'e', 'a', 'd', 'e', 'r'}
+)
+
 type updateSetHeader struct {
 	ID     types.FileContractID
 	Header contractHeader
@@ -63,6 +82,14 @@ type contractHeader struct {
 	Utility          modules.ContractUtility
 }
 
+// encryptedContractHeader is the header section of a contract file whose
+// header is encrypted at rest. Updates of the header in the WAL are not
+// encrypted; they are removed from the WAL once they are applied.
+type encryptedContractHeader struct {
+	Specifier  types.Specifier
+	Ciphertext crypto.Ciphertext
+}
+
 // v132ContractHeader is a contractHeader without the Utility field. This field
 // was added after v132 to be able to persist contract utilities.
 type v132ContractHeader struct {
@@ -134,7 +161,9 @@ type SafeContract struct {
 	unappliedTxns []*writeaheadlog.Transaction
 
 	headerFile *fileSection
+	journal    *persist.Journal
 	wal        *writeaheadlog.WAL
+	deps       modules.Dependencies
 	mu         sync.Mutex
 }
 
@@ -232,8 +261,12 @@ func (c *SafeContract) makeUpdateSetRoot(root crypto.Hash, index int) writeahead
 }
 
 func (c *SafeContract) applySetHeader(h contractHeader) error {
+	b, err := marshalContractHeader(h, c.deps)
+	if err != nil {
+		return err
+	}
 	headerBytes := make([]byte, contractHeaderSize)
-	copy(headerBytes, encoding.Marshal(h))
+	copy(headerBytes, b)
 	if _, err := c.headerFile.WriteAt(headerBytes, 0); err != nil {
 		return err
 	}
@@ -271,7 +304,10 @@ func (c *SafeContract) recordUploadIntent(rev types.FileContractRevision, root c
 	return t, nil
 }
 
-func (c *SafeContract) commitUpload(t *writeaheadlog.Transaction, signedTxn types.Transaction, root crypto.Hash, storageCost, bandwidthCost types.Currency) error {
+// commitUpload writes the new header and the root of an upload to the
+// contract file. They are saved through the journal together with updates, so
+// that either the contract and the updates are saved, or none of them.
+func (c *SafeContract) commitUpload(t *writeaheadlog.Transaction, signedTxn types.Transaction, root crypto.Hash, storageCost, bandwidthCost types.Currency, updates ...persist.Update) error {
 	// construct new header
 	c.headerMu.Lock()
 	newHeader := c.header
@@ -280,15 +316,27 @@ func (c *SafeContract) commitUpload(t *writeaheadlog.Transaction, signedTxn type
 	newHeader.StorageSpending = newHeader.StorageSpending.Add(storageCost)
 	newHeader.UploadSpending = newHeader.UploadSpending.Add(bandwidthCost)
 
-	if err := c.applySetHeader(newHeader); err != nil {
-		return err
-	}
-	if err := c.applySetRoot(root, c.merkleRoots.len()); err != nil {
+	b, err := marshalContractHeader(newHeader, c.deps)
+	if err != nil {
 		return err
 	}
-	if err := c.headerFile.Sync(); err != nil {
+	headerBytes := make([]byte, contractHeaderSize)
+	copy(headerBytes, b)
+	filename := c.headerFile.f.Name()
+	rootOffset := contractHeaderSize + fileOffsetFromRootIndex(c.merkleRoots.len())
+	updates = append([]persist.Update{
+		persist.WriteAtUpdate(filename, headerBytes, 0),
+		persist.WriteAtUpdate(filename, root[:], rootOffset),
+	}, updates...)
+	if err := c.journal.Update(updates...); err != nil {
 		return err
 	}
+
+	// The journal wrote the header and the root to the contract file.
+	c.headerMu.Lock()
+	c.header = newHeader
+	c.headerMu.Unlock()
+	c.merkleRoots.pushMemory(root)
 	if err := t.SignalUpdatesApplied(); err != nil {
 		return err
 	}
@@ -403,7 +451,11 @@ func (cs *ContractSet) managedInsertContract(h contractHeader, roots []crypto.Ha
 	headerSection := newFileSection(f, 0, contractHeaderSize)
 	rootsSection := newFileSection(f, contractHeaderSize, -1)
 	// write header
-	if _, err := headerSection.WriteAt(encoding.Marshal(h), 0); err != nil {
+	headerBytes, err := marshalContractHeader(h, cs.deps)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	if _, err := headerSection.WriteAt(headerBytes, 0); err != nil {
 		return modules.RenterContract{}, err
 	}
 	// write roots
@@ -420,7 +472,9 @@ func (cs *ContractSet) managedInsertContract(h contractHeader, roots []crypto.Ha
 		header:      h,
 		merkleRoots: merkleRoots,
 		headerFile:  headerSection,
+		journal:     cs.journal,
 		wal:         cs.wal,
+		deps:        cs.deps,
 	}
 	cs.mu.Lock()
 	cs.contracts[sc.header.ID()] = sc
@@ -441,7 +495,8 @@ func (cs *ContractSet) loadSafeContract(filename string, walTxns []*writeaheadlo
 
 	// read header
 	var header contractHeader
-	if err := encoding.NewDecoder(f).Decode(&header); err != nil {
+	encrypted, err := decodeContractHeader(f, &header, cs.deps)
+	if err != nil {
 		return err
 	} else if err := header.validate(); err != nil {
 		return err
@@ -485,7 +540,17 @@ func (cs *ContractSet) loadSafeContract(filename string, walTxns []*writeaheadlo
 		merkleRoots:   merkleRoots,
 		unappliedTxns: unappliedTxns,
 		headerFile:    headerSection,
+		journal:       cs.journal,
 		wal:           cs.wal,
+		deps:          cs.deps,
+	}
+	// Encrypt a plaintext header if the contract set is encrypted.
+	if _, ok := cs.deps.(modules.PersistEncrypter); ok && !encrypted {
+		if err := sc.applySetHeader(header); err != nil {
+			return err
+		} else if err := f.Sync(); err != nil {
+			return err
+		}
 	}
 	cs.contracts[sc.header.ID()] = sc
 	cs.pubKeys[string(header.HostPublicKey().Key)] = sc.header.ID()
@@ -634,3 +699,42 @@ func unmarshalHeader(b []byte, u *updateSetHeader) error {
 	}
 	return nil
 }
+
+// marshalContractHeader encodes h for the header section of a contract file.
+// The header is encrypted if deps implement modules.PersistEncrypter.
+func marshalContractHeader(h contractHeader, deps modules.Dependencies) ([]byte, error) {
+	b := encoding.Marshal(h)
+	if pe, ok := deps.(modules.PersistEncrypter); ok {
+		b = encoding.Marshal(encryptedContractHeader{
+			Specifier:  specifierEncryptedHeader,
+			Ciphertext: pe.EncryptPersist(b),
+		})
+	}
+	if len(b) > contractHeaderSize {
+		return nil, errContractHeaderTooLarge
+	}
+	return b, nil
+}
+
+// decodeContractHeader decodes the header section of a contract file read
+// from r into h. It returns whether the header was encrypted.
+func decodeContractHeader(r io.Reader, h *contractHeader, deps modules.Dependencies) (bool, error) {
+	br := bufio.NewReader(r)
+	prefix, err := br.Peek(types.SpecifierLen)
+	if err != nil || !bytes.Equal(prefix, specifierEncryptedHeader[:]) {
+		return false, encoding.NewDecoder(br).Decode(h)
+	}
+	pe, ok := deps.(modules.PersistEncrypter)
+	if !ok {
+		return true, errContractHeaderEncrypted
+	}
+	var eh encryptedContractHeader
+	if err := encoding.NewDecoder(br).Decode(&eh); err != nil {
+		return true, err
+	}
+	plaintext, err := pe.DecryptPersist(eh.Ciphertext)
+	if err != nil {
+		return true, errors.AddContext(err, "unable to decrypt contract header")
+	}
+	return true, encoding.Unmarshal(plaintext, h)
+}
@@ -2,6 +2,7 @@ package proto
 
 import (
 	"bytes"
+	"io/ioutil"
 	"path/filepath"
 	"reflect"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/encoding"
 	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 )
 
@@ -124,3 +126,142 @@ func TestContractUncommittedTxn(t *testing.T) {
 		t.Fatal("Merkle roots should match revised Merkle roots")
 	}
 }
+
+// TestContractCommitUpload tests that the revision of an upload is saved
+// together with the updates of the caller.
+func TestContractCommitUpload(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// create contract set with one contract
+	dir := build.TempDir(filepath.Join("proto", t.Name()))
+	cs, err := NewContractSet(dir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initialHeader := contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewRevisionNumber:    1,
+				NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{{}, {}},
+				},
+			}},
+		},
+	}
+	c, err := cs.managedInsertContract(initialHeader, []crypto.Hash{{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// commit an upload together with an update of another file
+	sc := cs.mustAcquire(t, c.ID)
+	revisedTxn := initialHeader.Transaction
+	revisedTxn.FileContractRevisions = []types.FileContractRevision{revisedTxn.FileContractRevisions[0]}
+	revisedTxn.FileContractRevisions[0].NewRevisionNumber = 2
+	revisedRoots := []crypto.Hash{{1}, {2}}
+	walTxn, err := sc.recordUploadIntent(revisedTxn.FileContractRevisions[0], revisedRoots[1], types.NewCurrency64(7), types.NewCurrency64(17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherFile := filepath.Join(dir, "other")
+	err = sc.commitUpload(walTxn, revisedTxn, revisedRoots[1], types.NewCurrency64(7), types.NewCurrency64(17), persist.WriteFileUpdate(otherFile, []byte("foo")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(otherFile); err != nil || string(b) != "foo" {
+		t.Fatal("update was not saved:", string(b), err)
+	}
+	cs.Return(sc)
+
+	// the revision is loaded after the contract set is reopened
+	cs.Close()
+	cs, err = NewContractSet(dir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	sc = cs.mustAcquire(t, c.ID)
+	merkleRoots, err := sc.merkleRoots.merkleRoots()
+	if err != nil {
+		t.Fatal("failed to get merkle roots:", err)
+	}
+	if !reflect.DeepEqual(merkleRoots, revisedRoots) {
+		t.Fatal("Merkle roots should match revised Merkle roots:", merkleRoots)
+	} else if sc.header.LastRevision().NewRevisionNumber != 2 {
+		t.Fatal("contract has the wrong revision:", sc.header.LastRevision().NewRevisionNumber)
+	} else if !sc.header.StorageSpending.Equals64(7) || !sc.header.UploadSpending.Equals64(17) {
+		t.Fatal("contract has the wrong spending:", sc.header.StorageSpending, sc.header.UploadSpending)
+	} else if len(sc.unappliedTxns) != 0 {
+		t.Fatal("expected 0 unappliedTxns, got", len(sc.unappliedTxns))
+	}
+}
+
+// TestContractEncryptedHeader tests that the headers of plaintext contracts
+// are encrypted when they are loaded by an encrypted contract set, and that
+// encrypted headers can't be loaded without the key.
+func TestContractEncryptedHeader(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// create a plaintext contract set with one contract
+	dir := build.TempDir(filepath.Join("proto", t.Name()))
+	cs, err := NewContractSet(dir, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewRevisionNumber:    1,
+				NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{{}, {}},
+				},
+			}},
+		},
+		StorageSpending: types.NewCurrency64(7),
+	}
+	c, err := cs.managedInsertContract(header, []crypto.Hash{{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.Close()
+
+	// reopen the set with encryption; the header should be encrypted
+	key := crypto.GenerateTwofishKey()
+	cs, err = NewContractSet(dir, modules.NewEncryptedDependencies(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := cs.mustAcquire(t, c.ID)
+	if !bytes.Equal(encoding.Marshal(sc.header), encoding.Marshal(header)) {
+		t.Fatal("contractHeader should match the plaintext contractHeader")
+	}
+	cs.Return(sc)
+	cs.Close()
+	contents, err := ioutil.ReadFile(filepath.Join(dir, c.ID.String()+contractExtension))
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.HasPrefix(contents, specifierEncryptedHeader[:]) {
+		t.Fatal("contract header was not encrypted")
+	}
+
+	// the encrypted header can't be loaded without the key
+	if _, err := NewContractSet(dir, modules.ProdDependencies); err == nil {
+		t.Fatal("expected an error when loading encrypted contracts without the key")
+	}
+
+	// the encrypted header is loaded with the key
+	cs, err = NewContractSet(dir, modules.NewEncryptedDependencies(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	sc = cs.mustAcquire(t, c.ID)
+	defer cs.Return(sc)
+	if !bytes.Equal(encoding.Marshal(sc.header), encoding.Marshal(header)) {
+		t.Fatal("contractHeader should match the plaintext contractHeader")
+	}
+}
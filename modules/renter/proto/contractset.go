@@ -7,6 +7,7 @@ import (
 
 	"github.com/acejam/Sia/build"
 	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 	"gitlab.com/NebulousLabs/ratelimit"
 
@@ -25,6 +26,12 @@ type ContractSet struct {
 	mu        sync.Mutex
 	rl        *ratelimit.RateLimit
 	wal       *writeaheadlog.WAL
+
+	// journal is the journal of the renter, which persists its data in the
+	// parent directory of the contract set. New revisions of uploads are
+	// saved through it, so that they can be saved together with the
+	// renter's files.
+	journal *persist.Journal
 }
 
 // Acquire looks up the contract for the specified host key and locks it before
@@ -150,7 +157,7 @@ func (cs *ContractSet) Close() error {
 		c.headerFile.Close()
 	}
 	_, err := cs.wal.CloseIncomplete()
-	return err
+	return errors.Compose(err, cs.journal.Close())
 }
 
 // NewContractSet returns a ContractSet storing its contracts in the specified
@@ -169,6 +176,13 @@ func NewContractSet(dir string, deps modules.Dependencies) (*ContractSet, error)
 	}
 	defer d.Close()
 
+	// Open the journal of the renter, which applies the updates that were
+	// interrupted by a crash before the contracts are loaded.
+	journal, err := persist.OpenJournal(filepath.Join(filepath.Dir(dir), modules.RenterJournalFile))
+	if err != nil {
+		return nil, err
+	}
+
 	// Load the WAL. Any recovered updates will be applied after loading
 	// contracts.
 	// COMPATv1.3.1RC2 Rename old wals to have the 'wal' extension if new file
@@ -185,9 +199,10 @@ func NewContractSet(dir string, deps modules.Dependencies) (*ContractSet, error)
 		contracts: make(map[types.FileContractID]*SafeContract),
 		pubKeys:   make(map[string]types.FileContractID),
 
-		deps: deps,
-		dir:  dir,
-		wal:  wal,
+		deps:    deps,
+		dir:     dir,
+		journal: journal,
+		wal:     wal,
 	}
 	// Set the initial rate limit to 'unlimited' bandwidth with 4kib packets.
 	cs.rl = ratelimit.NewRateLimit(0, 0, 0)
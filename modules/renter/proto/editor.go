@@ -9,6 +9,7 @@ import (
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/encoding"
 	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -58,7 +59,17 @@ func (he *Editor) Close() error {
 }
 
 // Upload negotiates a revision that adds a sector to a file contract.
-func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash, err error) {
+func (he *Editor) Upload(data []byte) (modules.RenterContract, crypto.Hash, error) {
+	return he.UploadWithUpdates(data, nil)
+}
+
+// UploadWithUpdates negotiates a revision that adds a sector to a file
+// contract, like Upload. Once the host has signed the revision, updates is
+// called with the Merkle root of the sector, and the persist updates it
+// returns are saved in the same journal transaction as the new revision. That
+// way a crash can't leave the contract and the files of the caller
+// inconsistent. updates may be nil.
+func (he *Editor) UploadWithUpdates(data []byte, updates func(crypto.Hash) []persist.Update) (_ modules.RenterContract, _ crypto.Hash, err error) {
 	// Acquire the contract.
 	sc, haveContract := he.contractSet.Acquire(he.contractID)
 	if !haveContract {
@@ -161,7 +172,11 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	}
 
 	// update contract
-	err = sc.commitUpload(walTxn, signedTxn, sectorRoot, sectorStoragePrice, sectorBandwidthPrice)
+	var persistUpdates []persist.Update
+	if updates != nil {
+		persistUpdates = updates(sectorRoot)
+	}
+	err = sc.commitUpload(walTxn, signedTxn, sectorRoot, sectorStoragePrice, sectorBandwidthPrice, persistUpdates...)
 	if err != nil {
 		return modules.RenterContract{}, crypto.Hash{}, err
 	}
@@ -30,7 +30,7 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 	}
 
 	// Calculate the anticipated transaction fee.
-	_, maxFee := tpool.FeeEstimation()
+	_, maxFee := tpool.FeeEstimationTarget(modules.FeeTargetContractFormation)
 	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
 
 	// Underflow check.
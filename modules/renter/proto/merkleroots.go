@@ -288,6 +288,13 @@ func (mr *merkleRoots) push(root crypto.Hash) error {
 	return nil
 }
 
+// pushMemory appends a root that was already written to disk to the
+// in-memory structure of the merkleRoots.
+func (mr *merkleRoots) pushMemory(root crypto.Hash) {
+	mr.appendRootMemory(root)
+	mr.numMerkleRoots++
+}
+
 // root returns the root of the merkle roots.
 func (mr *merkleRoots) root() crypto.Hash {
 	tree := crypto.NewTree()
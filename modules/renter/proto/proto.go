@@ -27,6 +27,7 @@ type (
 	transactionPool interface {
 		AcceptTransactionSet([]types.Transaction) error
 		FeeEstimation() (min types.Currency, max types.Currency)
+		FeeEstimationTarget(types.BlockHeight) (min types.Currency, max types.Currency)
 	}
 
 	hostDB interface {
@@ -33,7 +33,7 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 	}
 
 	// Calculate the anticipated transaction fee.
-	_, maxFee := tpool.FeeEstimation()
+	_, maxFee := tpool.FeeEstimationTarget(modules.FeeTargetContractFormation)
 	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
 
 	// Underflow check.
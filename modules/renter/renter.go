@@ -25,8 +25,10 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/modules/renter/contractor"
 	"github.com/acejam/Sia/modules/renter/hostdb"
@@ -107,6 +109,9 @@ type hostContractor interface {
 	// Allowance returns the current allowance
 	Allowance() modules.Allowance
 
+	// Alerts returns the alerts of the hostContractor.
+	Alerts() []modules.Alert
+
 	// Close closes the hostContractor.
 	Close() error
 
@@ -145,6 +150,10 @@ type hostContractor interface {
 	// allowing the retrieval of sectors.
 	Downloader(types.SiaPublicKey, <-chan struct{}) (contractor.Downloader, error)
 
+	// ReorgedTransactions returns the most recent contract transactions that
+	// were reverted by a reorg and not confirmed again by the same reorg.
+	ReorgedTransactions() []modules.ReorgedTransaction
+
 	// ResolveIDToPubKey returns the public key of a host given a contract id.
 	ResolveIDToPubKey(types.FileContractID) types.SiaPublicKey
 
@@ -176,6 +185,12 @@ type trackedFile struct {
 // might make sense to split the worker pool off into it's own struct entirely
 // the same way that we split of the memoryManager entirely.
 type Renter struct {
+	// Worker failure counters, reported by Metrics. They are accessed
+	// atomically and are kept at the top of the struct to guarantee their
+	// 64-bit alignment.
+	atomicWorkerDownloadFailures uint64
+	atomicWorkerUploadFailures   uint64
+
 	// File management.
 	//
 	// tracking contains a list of files that the user intends to maintain. By
@@ -213,6 +228,7 @@ type Renter struct {
 	g                 modules.Gateway
 	hostContractor    hostContractor
 	hostDB            hostDB
+	journal           *persist.Journal
 	log               *persist.Logger
 	persist           persistence
 	persistDir        string
@@ -228,6 +244,27 @@ func (r *Renter) Close() error {
 	return r.hostContractor.Close()
 }
 
+// Metrics returns the queue depths and worker failure counters of the renter.
+func (r *Renter) Metrics() modules.RenterMetrics {
+	r.downloadHeapMu.Lock()
+	downloadQueueDepth := r.downloadHeap.Len()
+	r.downloadHeapMu.Unlock()
+	r.uploadHeap.mu.Lock()
+	uploadQueueDepth := r.uploadHeap.heap.Len()
+	r.uploadHeap.mu.Unlock()
+	lockID := r.mu.RLock()
+	workers := len(r.workerPool)
+	r.mu.RUnlock(lockID)
+
+	return modules.RenterMetrics{
+		DownloadQueueDepth:     uint64(downloadQueueDepth),
+		UploadQueueDepth:       uint64(uploadQueueDepth),
+		Workers:                uint64(workers),
+		WorkerDownloadFailures: atomic.LoadUint64(&r.atomicWorkerDownloadFailures),
+		WorkerUploadFailures:   atomic.LoadUint64(&r.atomicWorkerUploadFailures),
+	}
+}
+
 // PriceEstimation estimates the cost in siacoins of performing various storage
 // and data operations.
 //
@@ -396,6 +433,10 @@ func (r *Renter) CancelContract(id types.FileContractID) error {
 	return r.hostContractor.CancelContract(id)
 }
 
+// Alerts returns the alerts of the renter, which are raised by the host
+// contractor.
+func (r *Renter) Alerts() []modules.Alert { return r.hostContractor.Alerts() }
+
 // Contracts returns an array of host contractor's staticContracts
 func (r *Renter) Contracts() []modules.RenterContract { return r.hostContractor.Contracts() }
 
@@ -404,6 +445,12 @@ func (r *Renter) OldContracts() []modules.RenterContract {
 	return r.hostContractor.OldContracts()
 }
 
+// ReorgedTransactions returns the most recent contract transactions of the
+// host contractor that were reverted by a reorg, oldest first.
+func (r *Renter) ReorgedTransactions() []modules.ReorgedTransaction {
+	return r.hostContractor.ReorgedTransactions()
+}
+
 // CurrentPeriod returns the host contractor's current period
 func (r *Renter) CurrentPeriod() types.BlockHeight { return r.hostContractor.CurrentPeriod() }
 
@@ -575,3 +622,20 @@ func New(g modules.Gateway, cs modules.ConsensusSet, wallet modules.Wallet, tpoo
 
 	return NewCustomRenter(g, cs, tpool, hdb, hc, persistDir, modules.ProdDependencies)
 }
+
+// NewEncrypted returns an initialized renter whose persist files, including
+// the .sia files, the contracts and the hostdb, are encrypted with key.
+// Plaintext persist files are encrypted when the renter loads them.
+func NewEncrypted(g modules.Gateway, cs modules.ConsensusSet, wallet modules.Wallet, tpool modules.TransactionPool, persistDir string, key crypto.TwofishKey) (*Renter, error) {
+	deps := modules.NewEncryptedDependencies(key)
+	hdb, err := hostdb.NewCustomHostDB(g, cs, persistDir, deps)
+	if err != nil {
+		return nil, err
+	}
+	hc, err := contractor.NewEncrypted(cs, wallet, tpool, hdb, persistDir, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCustomRenter(g, cs, tpool, hdb, hc, persistDir, deps)
+}
@@ -90,8 +90,10 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	r.persist.Tracking[up.SiaPath] = trackedFile{
 		RepairPath: up.Source,
 	}
-	r.saveSync()
-	err = r.saveFile(f)
+	u, err := r.fileUpdate(f)
+	if err == nil {
+		err = r.saveSyncWith(u)
+	}
 	r.mu.Unlock(lockID)
 	if err != nil {
 		return err
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/persist"
 
 	"gitlab.com/NebulousLabs/errors"
 )
@@ -304,10 +305,10 @@ func (r *Renter) managedFetchLogicalChunkData(chunk *unfinishedUploadChunk) erro
 	sr := io.NewSectionReader(osFile, chunk.offset, int64(chunk.length))
 	_, err = buf.ReadFrom(sr)
 	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF && download {
-		r.log.Debugln("failed to read file, downloading instead:", err)
+		r.log.With(persist.LogFieldSiaPath, chunk.renterFile.name).Debugln("failed to read file, downloading instead:", err)
 		return r.managedDownloadLogicalChunkData(chunk)
 	} else if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
-		r.log.Debugln("failed to read file locally:", err)
+		r.log.With(persist.LogFieldSiaPath, chunk.renterFile.name).Debugln("failed to read file locally:", err)
 		return errors.Extend(err, errors.New("failed to read file locally"))
 	}
 	chunk.logicalChunkData = buf
@@ -24,6 +24,7 @@ import (
 
 	"github.com/acejam/Sia/build"
 	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 )
 
@@ -215,7 +216,7 @@ func (r *Renter) buildUnfinishedChunks(f *file, hosts map[string]struct{}) []*un
 	if saveFile {
 		err := r.saveFile(f)
 		if err != nil {
-			r.log.Println("error while saving a file after pruning some contracts from it:", err)
+			r.log.With(persist.LogFieldSiaPath, f.name).Println("error while saving a file after pruning some contracts from it:", err)
 		}
 	}
 
@@ -263,7 +264,7 @@ func (r *Renter) managedBuildChunkHeap(hosts map[string]struct{}) {
 			// Check if local file is missing and redundancy is less than 1
 			// log warning to renter log
 			if _, err := os.Stat(tf.RepairPath); os.IsNotExist(err) && file.redundancy(offline, goodForRenew) < 1 {
-				r.log.Println("File not found on disk and possibly unrecoverable:", tf.RepairPath)
+				r.log.With(persist.LogFieldSiaPath, file.name).Println("File not found on disk and possibly unrecoverable:", tf.RepairPath)
 			}
 		}
 		file.mu.RUnlock()
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 )
 
@@ -56,6 +57,12 @@ type worker struct {
 	mu       sync.Mutex
 }
 
+// staticLog returns the logger of the renter with the contract and the host of
+// the worker as fields.
+func (w *worker) staticLog() *persist.Logger {
+	return w.renter.log.With(persist.LogFieldContractID, w.contract.ID, persist.LogFieldHostPubKey, w.hostPubKey.String())
+}
+
 // updateWorkerPool will grab the set of contracts from the contractor and
 // update the worker pool to match.
 func (r *Renter) managedUpdateWorkerPool() {
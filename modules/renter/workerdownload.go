@@ -29,14 +29,14 @@ func (w *worker) managedDownload(udc *unfinishedDownloadChunk) {
 	// unregistered with the chunk.
 	d, err := w.renter.hostContractor.Downloader(w.contract.HostPublicKey, w.renter.tg.StopChan())
 	if err != nil {
-		w.renter.log.Debugln("worker failed to create downloader:", err)
+		w.staticLog().Debugln("worker failed to create downloader:", err)
 		udc.managedUnregisterWorker(w)
 		return
 	}
 	defer d.Close()
 	pieceData, err := d.Sector(udc.staticChunkMap[string(w.contract.HostPublicKey.Key)].root)
 	if err != nil {
-		w.renter.log.Debugln("worker failed to download sector:", err)
+		w.staticLog().Debugln("worker failed to download sector:", err)
 		udc.managedUnregisterWorker(w)
 		return
 	}
@@ -54,7 +54,7 @@ func (w *worker) managedDownload(udc *unfinishedDownloadChunk) {
 	key := deriveKey(udc.masterKey, udc.staticChunkIndex, pieceIndex)
 	decryptedPiece, err := key.DecryptBytesInPlace(pieceData)
 	if err != nil {
-		w.renter.log.Debugln("worker failed to decrypt piece:", err)
+		w.staticLog().Debugln("worker failed to decrypt piece:", err)
 		udc.managedUnregisterWorker(w)
 		return
 	}
@@ -138,6 +138,7 @@ func (w *worker) managedQueueDownloadChunk(udc *unfinishedDownloadChunk) {
 // chunk, and then un-register the pieces that it grabbed. This function should
 // only be called when a worker download fails.
 func (udc *unfinishedDownloadChunk) managedUnregisterWorker(w *worker) {
+	atomic.AddUint64(&w.renter.atomicWorkerDownloadFailures, 1)
 	udc.mu.Lock()
 	udc.piecesRegistered--
 	udc.pieceUsage[udc.staticChunkMap[string(w.contract.HostPublicKey.Key)].index] = false
@@ -1,9 +1,12 @@
 package renter
 
 import (
+	"sync/atomic"
 	"time"
 
 	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/persist"
 )
 
 // managedDropChunk will remove a worker from the responsibility of tracking a chunk.
@@ -100,17 +103,66 @@ func (w *worker) managedUpload(uc *unfinishedUploadChunk, pieceIndex uint64) {
 	// Open an editing connection to the host.
 	e, err := w.renter.hostContractor.Editor(w.contract.HostPublicKey, w.renter.tg.StopChan())
 	if err != nil {
-		w.renter.log.Debugln("Worker failed to acquire an editor:", err)
+		w.staticLog().With(persist.LogFieldSiaPath, uc.renterFile.name).Debugln("Worker failed to acquire an editor:", err)
 		w.managedUploadFailed(uc, pieceIndex)
 		return
 	}
 	defer e.Close()
 
-	// Perform the upload, and update the failure stats based on the success of
-	// the upload attempt.
-	root, err := e.Upload(uc.physicalChunkData[pieceIndex])
+	// Perform the upload. The piece is added to the renter metadata in the
+	// same journal transaction as the revised contract, so that a crash can't
+	// leave the file and the contract inconsistent. The renter and the file
+	// stay locked until the transaction is saved.
+	addr := e.Address()
+	endHeight := e.EndHeight()
+	var id int
+	var locked, hadContract bool
+	var prevContract fileContract
+	_, err = e.UploadWithUpdates(uc.physicalChunkData[pieceIndex], func(root crypto.Hash) []persist.Update {
+		id = w.renter.mu.Lock()
+		uc.renterFile.mu.Lock()
+		locked = true
+
+		// Update the renter metadata.
+		prevContract, hadContract = uc.renterFile.contracts[w.contract.ID]
+		contract := prevContract
+		if !hadContract {
+			contract = fileContract{
+				ID:          w.contract.ID,
+				IP:          addr,
+				WindowStart: endHeight,
+			}
+		}
+		contract.Pieces = append(contract.Pieces, pieceData{
+			Chunk:      uc.index,
+			Piece:      pieceIndex,
+			MerkleRoot: root,
+		})
+		uc.renterFile.contracts[w.contract.ID] = contract
+		if uc.renterFile.deleted {
+			return nil
+		}
+		u, err := w.renter.fileUpdate(uc.renterFile)
+		if err != nil {
+			w.staticLog().With(persist.LogFieldSiaPath, uc.renterFile.name).Warn("unable to save the uploaded piece:", err)
+			return nil
+		}
+		return []persist.Update{u}
+	})
+	if locked {
+		if err != nil {
+			// The revision was not saved, so neither is the piece.
+			if hadContract {
+				uc.renterFile.contracts[w.contract.ID] = prevContract
+			} else {
+				delete(uc.renterFile.contracts, w.contract.ID)
+			}
+		}
+		uc.renterFile.mu.Unlock()
+		w.renter.mu.Unlock(id)
+	}
 	if err != nil {
-		w.renter.log.Debugln("Worker failed to upload via the editor:", err)
+		w.staticLog().With(persist.LogFieldSiaPath, uc.renterFile.name).Debugln("Worker failed to upload via the editor:", err)
 		w.managedUploadFailed(uc, pieceIndex)
 		return
 	}
@@ -118,29 +170,6 @@ func (w *worker) managedUpload(uc *unfinishedUploadChunk, pieceIndex uint64) {
 	w.uploadConsecutiveFailures = 0
 	w.mu.Unlock()
 
-	// Update the renter metadata.
-	addr := e.Address()
-	endHeight := e.EndHeight()
-	id := w.renter.mu.Lock()
-	uc.renterFile.mu.Lock()
-	contract, exists := uc.renterFile.contracts[w.contract.ID]
-	if !exists {
-		contract = fileContract{
-			ID:          w.contract.ID,
-			IP:          addr,
-			WindowStart: endHeight,
-		}
-	}
-	contract.Pieces = append(contract.Pieces, pieceData{
-		Chunk:      uc.index,
-		Piece:      pieceIndex,
-		MerkleRoot: root,
-	})
-	uc.renterFile.contracts[w.contract.ID] = contract
-	w.renter.saveFile(uc.renterFile)
-	uc.renterFile.mu.Unlock()
-	w.renter.mu.Unlock(id)
-
 	// Upload is complete. Update the state of the chunk and the renter's memory
 	// available to reflect the completed upload.
 	uc.mu.Lock()
@@ -223,6 +252,8 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 // managedUploadFailed is called if a worker failed to upload part of an unfinished
 // chunk.
 func (w *worker) managedUploadFailed(uc *unfinishedUploadChunk, pieceIndex uint64) {
+	atomic.AddUint64(&w.renter.atomicWorkerUploadFailures, 1)
+
 	// Mark the failure in the worker if the gateway says we are online. It's
 	// not the worker's fault if we are offline.
 	if w.renter.g.Online() {
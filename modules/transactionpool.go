@@ -2,7 +2,9 @@ package modules
 
 import (
 	"errors"
+	"time"
 
+	"github.com/acejam/Sia/build"
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/encoding"
 	"github.com/acejam/Sia/types"
@@ -19,6 +21,28 @@ const (
 	TransactionSizeLimit = 32e3
 )
 
+const (
+	// FeeTargetContractFormation is the confirmation target, in blocks, used
+	// when estimating the fees of file contract formation and renewal.
+	FeeTargetContractFormation = types.BlockHeight(6)
+
+	// FeeTargetDefault is the confirmation target, in blocks, used by
+	// FeeEstimation.
+	FeeTargetDefault = types.BlockHeight(3)
+)
+
+var (
+	// FeeEstimationWindow defines how far backwards in the blockchain the fee
+	// estimator looks when using blocks to figure out the appropriate fees to
+	// add to transactions. It is also the largest supported confirmation
+	// target; larger targets are treated as FeeEstimationWindow.
+	FeeEstimationWindow = build.Select(build.Var{
+		Standard: types.BlockHeight(144),
+		Dev:      types.BlockHeight(36),
+		Testing:  types.BlockHeight(6),
+	}).(types.BlockHeight)
+)
+
 var (
 	// ErrDuplicateTransactionSet is the error that gets returned if a
 	// duplicate transaction set is given to the transaction pool.
@@ -64,13 +88,58 @@ type (
 	TransactionSetID crypto.Hash
 
 	// A TransactionPoolDiff indicates the adding or removal of a transaction set to
-	// the transaction pool. The transactions in the pool are not persisted, so at
-	// startup modules should assume an empty transaction pool.
+	// the transaction pool. The unconfirmed sets are restored from disk when the
+	// transaction pool starts, and subscribers are informed about them like any
+	// other newly accepted set.
 	TransactionPoolDiff struct {
 		AppliedTransactions  []*UnconfirmedTransactionSet
 		RevertedTransactions []TransactionSetID
 	}
 
+	// TransactionPoolFeeBucket is a bucket of the fee-rate histogram of the
+	// transaction pool. It covers the unconfirmed sets with a fee rate of at
+	// least MinFeeRate and less than MaxFeeRate. The last bucket has no upper
+	// bound, and its MaxFeeRate is zero.
+	TransactionPoolFeeBucket struct {
+		MinFeeRate   types.Currency `json:"minfeerate"` // hastings / byte
+		MaxFeeRate   types.Currency `json:"maxfeerate"` // hastings / byte
+		Sets         int            `json:"sets"`
+		Transactions int            `json:"transactions"`
+		Size         uint64         `json:"size"` // bytes
+	}
+
+	// TransactionPoolSet describes an unconfirmed transaction set of the
+	// transaction pool. The transaction pool may merge transactions that
+	// don't depend on each other into a single set; Components contains the
+	// independent subsets of the set.
+	TransactionPoolSet struct {
+		ID             TransactionSetID        `json:"id"`
+		TransactionIDs []types.TransactionID   `json:"transactionids"`
+		Components     [][]types.TransactionID `json:"components"`
+		Size           uint64                  `json:"size"` // bytes
+		Fees           types.Currency          `json:"fees"`
+		FeeRate        types.Currency          `json:"feerate"` // hastings / byte
+		Local          bool                    `json:"local"`
+	}
+
+	// TransactionPoolStats contains statistics about the unconfirmed sets held
+	// by the transaction pool, and about the sets that were restored from disk
+	// and rebroadcast by the transaction pool.
+	TransactionPoolStats struct {
+		TransactionSets   int    `json:"transactionsets"`
+		Transactions      int    `json:"transactions"`
+		Size              uint64 `json:"size"` // bytes
+		LocalTransactions int    `json:"localtransactions"`
+
+		// RestoredSets and DroppedSets count the persisted sets that were
+		// accepted and rejected when the transaction pool was loaded.
+		RestoredSets int `json:"restoredsets"`
+		DroppedSets  int `json:"droppedsets"`
+
+		Rebroadcasts    uint64    `json:"rebroadcasts"`
+		LastRebroadcast time.Time `json:"lastrebroadcast"`
+	}
+
 	// UnconfirmedTransactionSet defines a new unconfirmed transaction that has
 	// been added to the transaction pool. ID is the ID of the set, IDs contains
 	// an ID for each transaction, eliminating the need to recompute it (because
@@ -110,13 +179,21 @@ type (
 		Close() error
 
 		// FeeEstimation returns an estimation for how high the transaction fee
-		// needs to be per byte. The minimum recommended targets getting accepted
-		// in ~3 blocks, and the maximum recommended targets getting accepted
-		// immediately. Taking the average has a moderate chance of being accepted
-		// within one block. The minimum has a strong chance of getting accepted
-		// within 10 blocks.
+		// needs to be per byte. It is equivalent to calling
+		// FeeEstimationTarget with FeeTargetDefault.
 		FeeEstimation() (minimumRecommended, maximumRecommended types.Currency)
 
+		// FeeEstimationTarget returns an estimation for how high the
+		// transaction fee needs to be per byte to be confirmed within target
+		// blocks. The estimation is based on the fee rates that got into recent
+		// blocks. The minimum has a moderate chance of being confirmed within
+		// target blocks, the maximum a strong chance.
+		FeeEstimationTarget(target types.BlockHeight) (minimumRecommended, maximumRecommended types.Currency)
+
+		// FeeHistogram returns a histogram of the fee rates of the unconfirmed
+		// sets in the transaction pool.
+		FeeHistogram() []TransactionPoolFeeBucket
+
 		// PurgeTransactionPool is a temporary function available to the miner. In
 		// the event that a miner mines an unacceptable block, the transaction pool
 		// will be purged to clear out the transaction pool and get rid of the
@@ -124,6 +201,10 @@ type (
 		// that make this condition necessary.
 		PurgeTransactionPool()
 
+		// Stats returns statistics about the unconfirmed sets in the
+		// transaction pool.
+		Stats() TransactionPoolStats
+
 		// Transaction returns the transaction and unconfirmed parents
 		// corresponding to the provided transaction id.
 		Transaction(id types.TransactionID) (txn types.Transaction, unconfirmedParents []types.Transaction, exists bool)
@@ -147,12 +228,37 @@ type (
 		// appears in.
 		TransactionSet(crypto.Hash) []types.Transaction
 
+		// TransactionSetInfo returns information about the unconfirmed set with
+		// the provided id, the transactions of the set, and a bool indicating
+		// if the set exists in the transaction pool.
+		TransactionSetInfo(TransactionSetID) (TransactionPoolSet, []types.Transaction, bool)
+
+		// TransactionSets returns information about all of the unconfirmed
+		// sets in the transaction pool, ordered by fee rate from highest to
+		// lowest.
+		TransactionSets() []TransactionPoolSet
+
 		// Unsubscribe removes a subscriber from the transaction pool.
 		// This is necessary for clean shutdown of the miner.
 		Unsubscribe(TransactionPoolSubscriber)
 	}
 )
 
+// MarshalJSON marshals a TransactionSetID as a hex string.
+func (id TransactionSetID) MarshalJSON() ([]byte, error) {
+	return crypto.Hash(id).MarshalJSON()
+}
+
+// String prints the TransactionSetID in hex.
+func (id TransactionSetID) String() string {
+	return crypto.Hash(id).String()
+}
+
+// UnmarshalJSON decodes the json hex string of the TransactionSetID.
+func (id *TransactionSetID) UnmarshalJSON(b []byte) error {
+	return (*crypto.Hash)(id).UnmarshalJSON(b)
+}
+
 // NewConsensusConflict returns a consensus conflict, which implements the
 // error interface.
 func NewConsensusConflict(s string) ConsensusConflict {
@@ -323,6 +323,14 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 //
 // TODO: Break into component sets when the set gets accepted.
 func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
+	return tp.managedAcceptTransactionSet(ts, true)
+}
+
+// managedAcceptTransactionSet adds a transaction set to the unconfirmed set of
+// transactions and relays it to connected peers. If local is true, the
+// transactions are marked as local and will be rebroadcast until they are
+// confirmed or expire.
+func (tp *TransactionPool) managedAcceptTransactionSet(ts []types.Transaction, local bool) error {
 	// assert on consensus set to get special method
 	cs, ok := tp.consensusSet.(interface {
 		LockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error
@@ -340,6 +348,11 @@ func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
 			tp.log.Debugln("Transaction set broadcast has failed:", err)
 			return err
 		}
+		if local {
+			for _, txn := range ts {
+				tp.localTransactions[txn.ID()] = struct{}{}
+			}
+		}
 		go tp.gateway.Broadcast("RelayTransactionSet", ts, tp.gateway.Peers())
 		// Notify subscribers of an accepted transaction set
 		tp.updateSubscribersTransactions()
@@ -377,5 +390,27 @@ func (tp *TransactionPool) relayTransactionSet(conn modules.PeerConn) error {
 		return err
 	}
 
-	return tp.AcceptTransactionSet(ts)
+	err = tp.managedAcceptTransactionSet(ts, false)
+	if score := misbehaviorScore(err); score > 0 {
+		tp.gateway.AddMisbehavior(conn.RPCAddr(), score, err.Error())
+	}
+	return err
+}
+
+// misbehaviorScore returns the misbehavior score that a peer incurs for
+// relaying a transaction set that was rejected with err. Rejections caused by
+// the state of the local pool, such as duplicates, conflicts with other
+// unconfirmed sets, insufficient fees or a full pool, do not indicate
+// misbehavior.
+func misbehaviorScore(err error) uint64 {
+	switch err {
+	case nil, modules.ErrDuplicateTransactionSet, errFullTransactionPool, errLowMinerFees, errObjectConflict:
+		return 0
+	case errEmptySet, modules.ErrLargeTransaction, modules.ErrLargeTransactionSet, modules.ErrInvalidArbPrefix:
+		return modules.MisbehaviorInvalidTransactionSet
+	}
+	if _, ok := err.(modules.ConsensusConflict); ok {
+		return modules.MisbehaviorConflictingTransactionSet
+	}
+	return 0
 }
@@ -776,3 +776,25 @@ func TestPartialConfirmationWeave(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestMisbehaviorScore probes the misbehaviorScore function.
+func TestMisbehaviorScore(t *testing.T) {
+	tests := []struct {
+		err   error
+		score uint64
+	}{
+		{nil, 0},
+		{modules.ErrDuplicateTransactionSet, 0},
+		{errLowMinerFees, 0},
+		{errFullTransactionPool, 0},
+		{errObjectConflict, 0},
+		{errEmptySet, modules.MisbehaviorInvalidTransactionSet},
+		{modules.ErrLargeTransactionSet, modules.MisbehaviorInvalidTransactionSet},
+		{modules.NewConsensusConflict("double spend"), modules.MisbehaviorConflictingTransactionSet},
+	}
+	for _, test := range tests {
+		if score := misbehaviorScore(test.err); score != test.score {
+			t.Errorf("%v: expected score %v, got %v", test.err, test.score, score)
+		}
+	}
+}
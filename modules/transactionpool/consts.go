@@ -41,10 +41,11 @@ const (
 
 // Constants related to fee estimation.
 const (
-	// blockFeeEstimationDepth defines how far backwards in the blockchain the
-	// fee estimator looks when using blocks to figure out the appropriate fees
-	// to add to transactions.
-	blockFeeEstimationDepth = 6
+	// feeConfidenceMax and feeConfidenceMin are the probabilities with which
+	// the maximum and minimum fee estimations are expected to be confirmed
+	// within the requested target.
+	feeConfidenceMax = 0.95
+	feeConfidenceMin = 0.5
 
 	// maxMultiplier defines the general gap between the maximum recommended fee
 	// and the minimum recommended fee.
@@ -71,6 +72,20 @@ var (
 	minEstimation = types.SiacoinPrecision.Div64(100).Div64(1e3)
 )
 
+// Variables related to querying the transaction pool.
+var (
+	// feeHistogramBounds are the lower bounds of the buckets of the fee-rate
+	// histogram. They double from minEstimation onwards, and the first bucket
+	// holds the sets that pay less than minEstimation.
+	feeHistogramBounds = func() []types.Currency {
+		bounds := []types.Currency{types.ZeroCurrency}
+		for i := uint64(0); i < 10; i++ {
+			bounds = append(bounds, minEstimation.Mul64(1<<i))
+		}
+		return bounds
+	}()
+)
+
 // Variables related to propagating transactions through the network.
 var (
 	// relayTransactionSetTimeout establishes the timeout for a relay
@@ -80,4 +95,12 @@ var (
 		Dev:      20 * time.Second,
 		Testing:  3 * time.Second,
 	}).(time.Duration)
+
+	// rebroadcastInterval is the interval at which the unconfirmed sets
+	// containing local transactions are rebroadcast to the gateway's peers.
+	rebroadcastInterval = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
 )
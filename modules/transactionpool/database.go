@@ -30,9 +30,18 @@ var (
 	// median.
 	bucketFeeMedian = []byte("FeeMedian")
 
+	// bucketLocalTransactions holds the ids of the unconfirmed transactions
+	// that were submitted to this node, rather than relayed by a peer. These
+	// transactions are rebroadcast until they are confirmed or expire.
+	bucketLocalTransactions = []byte("LocalTransactions")
+
 	// bucketRecentConsensusChange holds the most recent consensus change seen
 	// by the transaction pool.
 	bucketRecentConsensusChange = []byte("RecentConsensusChange")
+
+	// bucketUnconfirmedSets holds the unconfirmed transaction sets of the
+	// transaction pool, so that they can be restored after a restart.
+	bucketUnconfirmedSets = []byte("UnconfirmedSets")
 )
 
 // Explicitly named fields in the database.
@@ -73,9 +82,22 @@ var (
 type (
 	// medianPersist is the json object that gets stored in the database so that
 	// the transaction pool can persist its block based fee estimations.
+	// FeeHistory contains the inclusion fee rate of each block in the fee
+	// estimation window. RecentMedians and RecentMedianFee are only read, to
+	// seed the fee history of databases created by older versions.
 	medianPersist struct {
-		RecentMedians   []types.Currency
-		RecentMedianFee types.Currency
+		FeeHistory      []types.Currency `json:",omitempty"`
+		RecentMedians   []types.Currency `json:",omitempty"`
+		RecentMedianFee types.Currency   `json:",omitempty"`
+	}
+
+	// unconfirmedSetPersist is the object that gets stored in
+	// bucketUnconfirmedSets for every unconfirmed transaction set. Heights
+	// contains the height at which each transaction was first seen, so that
+	// restored transactions still expire after maxTxnAge.
+	unconfirmedSetPersist struct {
+		Transactions []types.Transaction
+		Heights      []types.BlockHeight
 	}
 )
 
@@ -106,6 +128,19 @@ func (tp *TransactionPool) getFeeMedian(tx *bolt.Tx) (medianPersist, error) {
 	return mp, nil
 }
 
+// getLocalTransactions returns the ids of the local transactions stored in the
+// database.
+func (tp *TransactionPool) getLocalTransactions(tx *bolt.Tx) (map[types.TransactionID]struct{}, error) {
+	ids := make(map[types.TransactionID]struct{})
+	err := tx.Bucket(bucketLocalTransactions).ForEach(func(k, _ []byte) error {
+		var id types.TransactionID
+		copy(id[:], k)
+		ids[id] = struct{}{}
+		return nil
+	})
+	return ids, err
+}
+
 // getRecentBlockID will fetch the most recent block id and most recent parent
 // id from the database.
 func (tp *TransactionPool) getRecentBlockID(tx *bolt.Tx) (recentID types.BlockID, err error) {
@@ -131,6 +166,21 @@ func (tp *TransactionPool) getRecentConsensusChange(tx *bolt.Tx) (cc modules.Con
 	return cc, nil
 }
 
+// getUnconfirmedSets returns all of the unconfirmed transaction sets stored in
+// the database.
+func (tp *TransactionPool) getUnconfirmedSets(tx *bolt.Tx) ([]unconfirmedSetPersist, error) {
+	var sets []unconfirmedSetPersist
+	err := tx.Bucket(bucketUnconfirmedSets).ForEach(func(_, v []byte) error {
+		var usp unconfirmedSetPersist
+		if err := encoding.Unmarshal(v, &usp); err != nil {
+			return errors.AddContext(err, "unable to unmarshal unconfirmed set")
+		}
+		sets = append(sets, usp)
+		return nil
+	})
+	return sets, err
+}
+
 // putBlockHeight updates the transaction pool's block height.
 func (tp *TransactionPool) putBlockHeight(tx *bolt.Tx, height types.BlockHeight) error {
 	tp.blockHeight = height
@@ -162,3 +212,41 @@ func (tp *TransactionPool) putRecentConsensusChange(tx *bolt.Tx, cc modules.Cons
 func (tp *TransactionPool) putTransaction(tx *bolt.Tx, id types.TransactionID) error {
 	return tx.Bucket(bucketConfirmedTransactions).Put(id[:], []byte{})
 }
+
+// putUnconfirmedSets replaces the unconfirmed transaction sets and the local
+// transactions stored in the database with the current contents of the
+// transaction pool.
+func (tp *TransactionPool) putUnconfirmedSets(tx *bolt.Tx) error {
+	for _, bucket := range [][]byte{bucketUnconfirmedSets, bucketLocalTransactions} {
+		if err := tx.DeleteBucket(bucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucket(bucket); err != nil {
+			return err
+		}
+	}
+	for setID, set := range tp.transactionSets {
+		usp := unconfirmedSetPersist{
+			Transactions: set,
+			Heights:      make([]types.BlockHeight, len(set)),
+		}
+		for i, txn := range set {
+			height, seen := tp.transactionHeights[txn.ID()]
+			if !seen {
+				height = tp.blockHeight
+			}
+			usp.Heights[i] = height
+		}
+		err := tx.Bucket(bucketUnconfirmedSets).Put(setID[:], encoding.Marshal(usp))
+		if err != nil {
+			return err
+		}
+	}
+	for id := range tp.localTransactions {
+		err := tx.Bucket(bucketLocalTransactions).Put(id[:], []byte{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
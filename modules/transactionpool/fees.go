@@ -0,0 +1,100 @@
+package transactionpool
+
+import (
+	"bytes"
+	"math"
+	"sort"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+// fees.go contains the block based fee estimator of the transaction pool. For
+// every block the transaction pool records the inclusion fee rate, the fee
+// rate below which a quarter of the block space (including unused space) was
+// spent. A transaction paying at least the inclusion fee rate of a block would
+// likely have been included in that block.
+//
+// Assuming that the inclusion fee rates of future blocks follow the
+// distribution of recent blocks, a fee rate that exceeds the q-quantile of the
+// history gets into any single block with probability q, and into at least one
+// of the next n blocks with probability 1-(1-q)^n. To be confirmed within n
+// blocks with probability p, q therefore needs to be 1-(1-p)^(1/n).
+
+// blockInclusionFeeRate returns the inclusion fee rate of a block with the
+// provided transactions.
+func blockInclusionFeeRate(txns []types.Transaction) types.Currency {
+	type feeSummary struct {
+		fee  types.Currency
+		size int
+	}
+	var fees []feeSummary
+	var totalSize int
+	b := new(bytes.Buffer)
+	for _, set := range findSets(txns) {
+		// Compile the fees for this set.
+		var feeSum types.Currency
+		var sizeSum int
+		for _, txn := range set {
+			txn.MarshalSia(b)
+			sizeSum += b.Len()
+			b.Reset()
+			for _, fee := range txn.MinerFees {
+				feeSum = feeSum.Add(fee)
+			}
+		}
+		fees = append(fees, feeSummary{
+			fee:  feeSum.Div64(uint64(sizeSum)),
+			size: sizeSum,
+		})
+		totalSize += sizeSum
+	}
+	// Add an extra zero-fee tranasction for any unused block space.
+	remaining := int(types.BlockSizeLimit) - totalSize
+	if remaining < 0 {
+		remaining = 0
+	}
+	fees = append(fees, feeSummary{
+		fee:  types.ZeroCurrency,
+		size: remaining, // fine if remaining is zero.
+	})
+	// Sort the fees by value and then scroll until the quarter of the block.
+	// It's going to be cheaper than the median, but it still got into a block.
+	sort.Slice(fees, func(i, j int) bool {
+		return fees[i].fee.Cmp(fees[j].fee) < 0
+	})
+	var progress int
+	for i := range fees {
+		progress += fees[i].size
+		if uint64(progress) > types.BlockSizeLimit/4 {
+			return fees[i].fee
+		}
+	}
+	return fees[len(fees)-1].fee
+}
+
+// targetFeeRate returns the fee rate that is confirmed within target blocks
+// with the provided probability, according to the history of inclusion fee
+// rates. The target is clamped to [1, modules.FeeEstimationWindow].
+func targetFeeRate(history []types.Currency, target types.BlockHeight, probability float64) types.Currency {
+	if len(history) == 0 {
+		return types.ZeroCurrency
+	}
+	if target < 1 {
+		target = 1
+	} else if target > modules.FeeEstimationWindow {
+		target = modules.FeeEstimationWindow
+	}
+	sorted := append([]types.Currency(nil), history...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	q := 1 - math.Pow(1-probability, 1/float64(target))
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
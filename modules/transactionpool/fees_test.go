@@ -0,0 +1,77 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+// TestTargetFeeRate probes the targetFeeRate function.
+func TestTargetFeeRate(t *testing.T) {
+	// An empty history doesn't recommend any fee.
+	if fee := targetFeeRate(nil, 1, feeConfidenceMax); !fee.IsZero() {
+		t.Fatal("expected zero fee for empty history, got", fee)
+	}
+
+	// Create a history of fee rates 1 through modules.FeeEstimationWindow, in reverse
+	// order to check that the history is sorted.
+	var history []types.Currency
+	for i := modules.FeeEstimationWindow; i > 0; i-- {
+		history = append(history, types.NewCurrency64(uint64(i)))
+	}
+
+	// Targeting the next block with high confidence should pick one of the
+	// highest fee rates.
+	next := targetFeeRate(history, 1, feeConfidenceMax)
+	if next.Cmp(types.NewCurrency64(uint64(modules.FeeEstimationWindow)-1)) < 0 {
+		t.Error("fee for the next block is too low:", next)
+	}
+
+	// The fee rate should not increase with the target, and should not
+	// increase when the confidence is lowered.
+	prev := next
+	for target := types.BlockHeight(2); target <= modules.FeeEstimationWindow; target++ {
+		fee := targetFeeRate(history, target, feeConfidenceMax)
+		if fee.Cmp(prev) > 0 {
+			t.Errorf("fee for target %v is higher than for target %v", target, target-1)
+		}
+		if low := targetFeeRate(history, target, feeConfidenceMin); low.Cmp(fee) > 0 {
+			t.Errorf("low confidence fee for target %v is higher than the high confidence fee", target)
+		}
+		prev = fee
+	}
+
+	// Targets are clamped to the estimation window.
+	if !targetFeeRate(history, 0, feeConfidenceMax).Equals(next) {
+		t.Error("target 0 was not treated as target 1")
+	}
+	if !targetFeeRate(history, modules.FeeEstimationWindow*10, feeConfidenceMax).Equals(prev) {
+		t.Error("large target was not clamped to the estimation window")
+	}
+}
+
+// TestBlockInclusionFeeRate checks that empty blocks have an inclusion fee
+// rate of zero, and that full blocks have the fee rate of their transactions.
+func TestBlockInclusionFeeRate(t *testing.T) {
+	if fee := blockInclusionFeeRate(nil); !fee.IsZero() {
+		t.Fatal("empty block has a non-zero inclusion fee rate:", fee)
+	}
+
+	// Fill most of the block with transactions that pay the same fee rate.
+	arb := make([]byte, 10e3)
+	var txns []types.Transaction
+	for i := 0; uint64(i+1)*10e3 < types.BlockSizeLimit*9/10; i++ {
+		txns = append(txns, types.Transaction{
+			ArbitraryData: [][]byte{append([]byte{byte(i), byte(i >> 8)}, arb...)},
+			MinerFees:     []types.Currency{types.SiacoinPrecision},
+		})
+	}
+	fee := blockInclusionFeeRate(txns)
+	if fee.IsZero() {
+		t.Fatal("full block has an inclusion fee rate of zero")
+	}
+	if fee.Cmp(types.SiacoinPrecision.Div64(10e3)) > 0 {
+		t.Fatal("inclusion fee rate is higher than the fee rate of the transactions:", fee)
+	}
+}
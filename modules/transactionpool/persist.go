@@ -38,10 +38,14 @@ func (tp *TransactionPool) threadedRegularSync() {
 }
 
 // syncDB commits the current global transaction and immediately begins a new
-// one.
+// one. The unconfirmed sets are written to the database before the commit.
 func (tp *TransactionPool) syncDB() {
+	err := tp.putUnconfirmedSets(tp.dbTx)
+	if err != nil {
+		tp.log.Println("ERROR: could not persist the unconfirmed sets:", err)
+	}
 	// Commit the existing tx.
-	err := tp.dbTx.Commit()
+	err = tp.dbTx.Commit()
 	if err != nil {
 		tp.log.Severe("ERROR: failed to apply database update:", err)
 		tp.dbTx.Rollback()
@@ -113,7 +117,7 @@ func (tp *TransactionPool) initPersist() error {
 	}
 	tp.tg.AfterStop(func() {
 		tp.mu.Lock()
-		err := tp.dbTx.Commit()
+		err := errors.Compose(tp.putUnconfirmedSets(tp.dbTx), tp.dbTx.Commit())
 		tp.mu.Unlock()
 		if err != nil {
 			tp.log.Println("Unable to close transaction properly during shutdown:", err)
@@ -130,6 +134,8 @@ func (tp *TransactionPool) initPersist() error {
 		bucketRecentConsensusChange,
 		bucketConfirmedTransactions,
 		bucketFeeMedian,
+		bucketLocalTransactions,
+		bucketUnconfirmedSets,
 	}
 	for _, bucket := range buckets {
 		_, err := tp.dbTx.CreateBucketIfNotExists(bucket)
@@ -172,8 +178,10 @@ func (tp *TransactionPool) initPersist() error {
 	// Just leave the fields empty if no fee median was found. They will be
 	// filled out.
 	if err != errNilFeeMedian {
-		tp.recentMedians = mp.RecentMedians
-		tp.recentMedianFee = mp.RecentMedianFee
+		tp.feeHistory = mp.FeeHistory
+		if len(tp.feeHistory) == 0 {
+			tp.feeHistory = mp.RecentMedians
+		}
 	}
 
 	// Subscribe to the consensus set using the most recent consensus change.
@@ -204,6 +212,72 @@ func (tp *TransactionPool) initPersist() error {
 	return nil
 }
 
+// managedRestoreUnconfirmedSets loads the unconfirmed sets that were persisted
+// before the last shutdown and re-validates them against the current
+// consensus set. Sets that are no longer valid, and transactions that are
+// older than maxTxnAge, are dropped.
+func (tp *TransactionPool) managedRestoreUnconfirmedSets() error {
+	cs, ok := tp.consensusSet.(interface {
+		LockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error
+	})
+	if !ok {
+		return errors.New("consensus set does not support LockedTryTransactionSet method")
+	}
+
+	tp.mu.Lock()
+	sets, err := tp.getUnconfirmedSets(tp.dbTx)
+	if err != nil {
+		tp.mu.Unlock()
+		return build.ExtendErr("unable to load the unconfirmed sets", err)
+	}
+	locals, err := tp.getLocalTransactions(tp.dbTx)
+	if err != nil {
+		tp.mu.Unlock()
+		return build.ExtendErr("unable to load the local transactions", err)
+	}
+	tp.localTransactions = locals
+	tp.mu.Unlock()
+
+	for _, usp := range sets {
+		err := cs.LockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+			tp.mu.Lock()
+			defer tp.mu.Unlock()
+
+			// Strip out the transactions that have expired.
+			var ts []types.Transaction
+			for i, txn := range usp.Transactions {
+				if i < len(usp.Heights) && tp.blockHeight > usp.Heights[i] && tp.blockHeight-usp.Heights[i] > maxTxnAge {
+					continue
+				}
+				ts = append(ts, txn)
+				if _, exists := tp.transactionHeights[txn.ID()]; !exists && i < len(usp.Heights) {
+					tp.transactionHeights[txn.ID()] = usp.Heights[i]
+				}
+			}
+			err := tp.acceptTransactionSet(ts, txnFn)
+			if err != nil {
+				for _, txn := range ts {
+					delete(tp.transactionHeights, txn.ID())
+				}
+				tp.droppedSets++
+				return err
+			}
+			tp.restoredSets++
+			return nil
+		})
+		if err != nil {
+			tp.log.Debugln("Dropping persisted transaction set:", err)
+		}
+	}
+
+	tp.mu.Lock()
+	tp.pruneLocalTransactions()
+	tp.updateSubscribersTransactions()
+	tp.mu.Unlock()
+	tp.log.Printf("Restored %v unconfirmed sets, dropped %v invalid sets", tp.restoredSets, tp.droppedSets)
+	return nil
+}
+
 // TransactionConfirmed returns true if the transaction has been seen on the
 // blockchain. Note, however, that the block containing the transaction may
 // later be invalidated by a reorg.
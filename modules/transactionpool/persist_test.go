@@ -4,12 +4,16 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/encoding"
 	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/persist"
 	"github.com/acejam/Sia/types"
 
 	"github.com/coreos/bbolt"
+	"gitlab.com/NebulousLabs/errors"
 )
 
 // TestRescan triggers a rescan in the transaction pool, verifying that the
@@ -93,3 +97,84 @@ func TestRescan(t *testing.T) {
 		t.Fatal("expecting modules.ErrDuplicateTransactionSet, got:", err)
 	}
 }
+
+// TestPersistUnconfirmedSets checks that unconfirmed sets survive a restart of
+// the transaction pool, that invalid sets are dropped when they are restored,
+// and that local sets are rebroadcast until they are confirmed.
+func TestPersistUnconfirmedSets(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Create a local transaction set using the wallet.
+	txns, err := tpt.wallet.SendSiacoins(types.NewCurrency64(100), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := tpt.tpool.Stats(); stats.TransactionSets != 1 || stats.LocalTransactions != len(txns) {
+		t.Fatal("wrong stats after sending coins:", stats)
+	}
+
+	// Restart the tpool with an additional invalid set in the database.
+	persistDir := tpt.tpool.persistDir
+	if err := tpt.tpool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err := persist.OpenDatabase(dbMetadata, filepath.Join(persistDir, dbFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		invalid := unconfirmedSetPersist{
+			Transactions: []types.Transaction{{
+				SiacoinInputs: []types.SiacoinInput{{ParentID: types.SiacoinOutputID{1}}},
+			}},
+			Heights: []types.BlockHeight{0},
+		}
+		return tx.Bucket(bucketUnconfirmedSets).Put([]byte("invalid"), encoding.Marshal(invalid))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool, err = New(tpt.cs, tpt.gateway, persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := tpt.tpool.Stats()
+	if stats.TransactionSets != 1 || stats.RestoredSets != 1 || stats.DroppedSets != 1 {
+		t.Fatal("unconfirmed sets were not restored correctly:", stats)
+	}
+	if stats.LocalTransactions != len(txns) {
+		t.Fatal("local transactions were not restored:", stats)
+	}
+	if _, _, exists := tpt.tpool.Transaction(txns[len(txns)-1].ID()); !exists {
+		t.Fatal("restored transaction is not in the pool")
+	}
+
+	// The local set should be rebroadcast.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if stats := tpt.tpool.Stats(); stats.Rebroadcasts == 0 || stats.LastRebroadcast.IsZero() {
+			return errors.New("local set was not rebroadcast")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the set is confirmed it is no longer local.
+	if _, err := tpt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := tpt.tpool.Stats(); stats.TransactionSets != 0 || stats.LocalTransactions != 0 {
+		t.Fatal("confirmed set is still in the pool:", stats)
+	}
+}
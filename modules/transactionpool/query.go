@@ -0,0 +1,115 @@
+package transactionpool
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+// query.go contains the methods used to inspect the unconfirmed sets of the
+// transaction pool.
+
+// setInfo returns a description of the unconfirmed set with the provided id.
+func (tp *TransactionPool) setInfo(id TransactionSetID, set []types.Transaction) modules.TransactionPoolSet {
+	info := modules.TransactionPoolSet{
+		ID:             modules.TransactionSetID(id),
+		TransactionIDs: make([]types.TransactionID, 0, len(set)),
+		Size:           uint64(len(encoding.Marshal(set))),
+	}
+	position := make(map[types.TransactionID]int)
+	for i, txn := range set {
+		txid := txn.ID()
+		position[txid] = i
+		info.TransactionIDs = append(info.TransactionIDs, txid)
+		for _, fee := range txn.MinerFees {
+			info.Fees = info.Fees.Add(fee)
+		}
+		if _, local := tp.localTransactions[txid]; local {
+			info.Local = true
+		}
+	}
+	if info.Size > 0 {
+		info.FeeRate = info.Fees.Div64(info.Size)
+	}
+
+	// Split the set into its independent components. findSets doesn't
+	// preserve the order of the components, so they are sorted by the
+	// position of their first transaction in the set.
+	for _, component := range findSets(set) {
+		ids := make([]types.TransactionID, 0, len(component))
+		for _, txn := range component {
+			ids = append(ids, txn.ID())
+		}
+		info.Components = append(info.Components, ids)
+	}
+	sort.Slice(info.Components, func(i, j int) bool {
+		return position[info.Components[i][0]] < position[info.Components[j][0]]
+	})
+	return info
+}
+
+// sortedSets returns the descriptions of all unconfirmed sets, ordered by fee
+// rate from highest to lowest.
+func (tp *TransactionPool) sortedSets() []modules.TransactionPoolSet {
+	sets := make([]modules.TransactionPoolSet, 0, len(tp.transactionSets))
+	for id, set := range tp.transactionSets {
+		sets = append(sets, tp.setInfo(id, set))
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		if c := sets[i].FeeRate.Cmp(sets[j].FeeRate); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(sets[i].ID[:], sets[j].ID[:]) < 0
+	})
+	return sets
+}
+
+// FeeHistogram returns a histogram of the fee rates of the unconfirmed sets in
+// the transaction pool.
+func (tp *TransactionPool) FeeHistogram() []modules.TransactionPoolFeeBucket {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+
+	buckets := make([]modules.TransactionPoolFeeBucket, len(feeHistogramBounds))
+	for i := range buckets {
+		buckets[i].MinFeeRate = feeHistogramBounds[i]
+		if i+1 < len(feeHistogramBounds) {
+			buckets[i].MaxFeeRate = feeHistogramBounds[i+1]
+		}
+	}
+	for _, set := range tp.sortedSets() {
+		// Find the last bucket whose lower bound is not above the fee rate.
+		i := sort.Search(len(buckets), func(i int) bool {
+			return buckets[i].MinFeeRate.Cmp(set.FeeRate) > 0
+		}) - 1
+		buckets[i].Sets++
+		buckets[i].Transactions += len(set.TransactionIDs)
+		buckets[i].Size += set.Size
+	}
+	return buckets
+}
+
+// TransactionSetInfo returns information about the unconfirmed set with the
+// provided id, the transactions of the set, and a bool indicating if the set
+// exists in the transaction pool.
+func (tp *TransactionPool) TransactionSetInfo(id modules.TransactionSetID) (modules.TransactionPoolSet, []types.Transaction, bool) {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	set, exists := tp.transactionSets[TransactionSetID(id)]
+	if !exists {
+		return modules.TransactionPoolSet{}, nil, false
+	}
+	txns := append([]types.Transaction(nil), set...)
+	return tp.setInfo(TransactionSetID(id), set), txns, true
+}
+
+// TransactionSets returns information about all of the unconfirmed sets in the
+// transaction pool, ordered by fee rate from highest to lowest.
+func (tp *TransactionPool) TransactionSets() []modules.TransactionPoolSet {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	return tp.sortedSets()
+}
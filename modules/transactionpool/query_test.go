@@ -0,0 +1,78 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+// TestTransactionSets checks that the unconfirmed sets are described and
+// sorted correctly, and that they are counted by the fee-rate histogram.
+func TestTransactionSets(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Create two independent transaction sets.
+	txns1, err := tpt.wallet.SendSiacoins(types.NewCurrency64(100), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns2, err := tpt.wallet.SendSiacoins(types.NewCurrency64(100), types.UnlockHash{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sets := tpt.tpool.TransactionSets()
+	var numTxns int
+	for i, set := range sets {
+		if i > 0 && set.FeeRate.Cmp(sets[i-1].FeeRate) > 0 {
+			t.Error("sets are not sorted by fee rate")
+		}
+		if !set.Local {
+			t.Error("set created by the wallet is not local")
+		}
+		if set.FeeRate.IsZero() || !set.FeeRate.Equals(set.Fees.Div64(set.Size)) {
+			t.Error("wrong fee rate:", set.FeeRate)
+		}
+		var componentTxns int
+		for _, component := range set.Components {
+			componentTxns += len(component)
+		}
+		if componentTxns != len(set.TransactionIDs) {
+			t.Error("components don't cover the set")
+		}
+		numTxns += len(set.TransactionIDs)
+
+		info, txns, exists := tpt.tpool.TransactionSetInfo(set.ID)
+		if !exists || info.ID != set.ID || len(txns) != len(set.TransactionIDs) {
+			t.Error("TransactionSetInfo returned the wrong set")
+		}
+	}
+	if numTxns != len(txns1)+len(txns2) {
+		t.Fatalf("expected %v transactions in the sets, got %v", len(txns1)+len(txns2), numTxns)
+	}
+	if _, _, exists := tpt.tpool.TransactionSetInfo(modules.TransactionSetID{}); exists {
+		t.Error("TransactionSetInfo returned a set that doesn't exist")
+	}
+
+	// Every set should be counted by exactly one bucket.
+	histogram := tpt.tpool.FeeHistogram()
+	if len(histogram) != len(feeHistogramBounds) {
+		t.Fatal("wrong number of buckets:", len(histogram))
+	}
+	var histSets, histTxns int
+	for _, b := range histogram {
+		histSets += b.Sets
+		histTxns += b.Transactions
+	}
+	if histSets != len(sets) || histTxns != numTxns {
+		t.Fatalf("histogram counts %v sets and %v transactions, expected %v and %v", histSets, histTxns, len(sets), numTxns)
+	}
+}
@@ -0,0 +1,84 @@
+package transactionpool
+
+import (
+	"time"
+
+	"github.com/acejam/Sia/types"
+)
+
+// rebroadcast.go contains the logic for rebroadcasting the local unconfirmed
+// sets of the transaction pool. Peers may have dropped a set while we were
+// offline, or may never have received it, so every set containing a local
+// transaction is periodically sent to the gateway's peers until it is
+// confirmed or expires.
+
+// pruneLocalTransactions removes all of the local transactions that are no
+// longer in the transaction pool, either because they were confirmed or
+// because they expired or became invalid.
+func (tp *TransactionPool) pruneLocalTransactions() {
+	if len(tp.localTransactions) == 0 {
+		return
+	}
+	pooled := make(map[types.TransactionID]struct{})
+	for _, set := range tp.transactionSets {
+		for _, txn := range set {
+			pooled[txn.ID()] = struct{}{}
+		}
+	}
+	for id := range tp.localTransactions {
+		if _, exists := pooled[id]; !exists {
+			delete(tp.localTransactions, id)
+		}
+	}
+}
+
+// localSets returns all of the unconfirmed sets that contain at least one
+// local transaction.
+func (tp *TransactionPool) localSets() [][]types.Transaction {
+	var sets [][]types.Transaction
+	for _, set := range tp.transactionSets {
+		for _, txn := range set {
+			if _, local := tp.localTransactions[txn.ID()]; local {
+				sets = append(sets, set)
+				break
+			}
+		}
+	}
+	return sets
+}
+
+// managedRebroadcast sends every unconfirmed set containing a local
+// transaction to the gateway's peers.
+func (tp *TransactionPool) managedRebroadcast() {
+	tp.mu.Lock()
+	tp.pruneLocalTransactions()
+	sets := tp.localSets()
+	if len(sets) > 0 {
+		tp.rebroadcasts++
+		tp.lastRebroadcast = time.Now()
+	}
+	tp.mu.Unlock()
+
+	for _, set := range sets {
+		tp.gateway.Broadcast("RelayTransactionSet", set, tp.gateway.Peers())
+	}
+	if len(sets) > 0 {
+		tp.log.Debugf("Rebroadcast %v local transaction sets", len(sets))
+	}
+}
+
+// threadedRebroadcast periodically rebroadcasts the local unconfirmed sets.
+func (tp *TransactionPool) threadedRebroadcast() {
+	if err := tp.tg.Add(); err != nil {
+		return
+	}
+	defer tp.tg.Done()
+	for {
+		select {
+		case <-tp.tg.StopChan():
+			return
+		case <-time.After(rebroadcastInterval):
+		}
+		tp.managedRebroadcast()
+	}
+}
@@ -2,6 +2,7 @@ package transactionpool
 
 import (
 	"errors"
+	"time"
 
 	"github.com/coreos/bbolt"
 	"gitlab.com/NebulousLabs/demotemutex"
@@ -52,10 +53,25 @@ type (
 		transactionSetDiffs map[TransactionSetID]*modules.ConsensusChange
 		transactionListSize int
 
+		// localTransactions contains the ids of the unconfirmed transactions
+		// that were submitted to this node rather than relayed by a peer. The
+		// sets containing them are rebroadcast until they are confirmed or
+		// expire.
+		localTransactions map[types.TransactionID]struct{}
+
+		// Statistics about restoring and rebroadcasting unconfirmed sets.
+		restoredSets    int
+		droppedSets     int
+		rebroadcasts    uint64
+		lastRebroadcast time.Time
+
 		// Variables related to the blockchain.
-		blockHeight     types.BlockHeight
-		recentMedians   []types.Currency
-		recentMedianFee types.Currency // SC per byte
+		blockHeight types.BlockHeight
+
+		// feeHistory contains the inclusion fee rate, in hastings per byte, of
+		// each of the most recent blocks, oldest first. It holds at most
+		// modules.FeeEstimationWindow entries.
+		feeHistory []types.Currency
 
 		// The consensus change index tracks how many consensus changes have
 		// been sent to the transaction pool. When a new subscriber joins the
@@ -93,6 +109,7 @@ func New(cs modules.ConsensusSet, g modules.Gateway, persistDir string) (*Transa
 		transactionHeights:  make(map[types.TransactionID]types.BlockHeight),
 		transactionSets:     make(map[TransactionSetID][]types.Transaction),
 		transactionSetDiffs: make(map[TransactionSetID]*modules.ConsensusChange),
+		localTransactions:   make(map[types.TransactionID]struct{}),
 
 		persistDir: persistDir,
 	}
@@ -103,6 +120,14 @@ func New(cs modules.ConsensusSet, g modules.Gateway, persistDir string) (*Transa
 		return nil, err
 	}
 
+	// Restore the unconfirmed sets from the previous run and start
+	// rebroadcasting the local ones.
+	err = tp.managedRestoreUnconfirmedSets()
+	if err != nil {
+		return nil, err
+	}
+	go tp.threadedRebroadcast()
+
 	// Register RPCs
 	g.RegisterRPC("RelayTransactionSet", tp.relayTransactionSet)
 	tp.tg.OnStop(func() {
@@ -119,8 +144,15 @@ func (tp *TransactionPool) Close() error {
 
 // FeeEstimation returns an estimation for what fee should be applied to
 // transactions. It returns a minimum and maximum estimated fee per transaction
-// byte.
+// byte, targeting confirmation within FeeTargetDefault blocks.
 func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
+	return tp.FeeEstimationTarget(modules.FeeTargetDefault)
+}
+
+// FeeEstimationTarget returns an estimation for what fee should be applied to
+// transactions to be confirmed within target blocks. It returns a minimum and
+// maximum estimated fee per transaction byte.
+func (tp *TransactionPool) FeeEstimationTarget(target types.BlockHeight) (min, max types.Currency) {
 	err := tp.tg.Add()
 	if err != nil {
 		return
@@ -130,9 +162,10 @@ func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
 	defer tp.mu.Unlock()
 
 	// Use three methods to determine an acceptable fee, and then take the
-	// largest result of the two methods. The first method checks the historic
-	// blocks, to make sure that we don't under-estimate the number of fees
-	// needed in the event that we just purged the tpool.
+	// largest result of the three methods. The first method checks the
+	// historic blocks, and picks the fee rates that would have been included
+	// within the target number of blocks with a moderate and a strong
+	// probability.
 	//
 	// The second method looks at the existing tpool. Sudden congestion won't be
 	// represented on the blockchain right away, but should be immediately
@@ -143,9 +176,9 @@ func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
 	// event of empty blocks, there should still be some fees being added to the
 	// chain.
 
-	// Set the minimum fee to the numbers recommended by the blockchain.
-	min = tp.recentMedianFee
-	max = tp.recentMedianFee.Mul64(maxMultiplier)
+	// Set the fees to the numbers recommended by the blockchain.
+	min = targetFeeRate(tp.feeHistory, target, feeConfidenceMin)
+	max = targetFeeRate(tp.feeHistory, target, feeConfidenceMax)
 
 	// Method two: use 'requiredFeesToExtendPool'.
 	required := tp.requiredFeesToExtendTpool()
@@ -165,10 +198,32 @@ func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
 	if max.Cmp(minEstimation.Mul64(maxMultiplier)) < 0 {
 		max = minEstimation.Mul64(maxMultiplier)
 	}
+	if max.Cmp(min) < 0 {
+		max = min
+	}
 
 	return
 }
 
+// Stats returns statistics about the unconfirmed sets in the transaction pool.
+func (tp *TransactionPool) Stats() modules.TransactionPoolStats {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	stats := modules.TransactionPoolStats{
+		TransactionSets:   len(tp.transactionSets),
+		Size:              uint64(tp.transactionListSize),
+		LocalTransactions: len(tp.localTransactions),
+		RestoredSets:      tp.restoredSets,
+		DroppedSets:       tp.droppedSets,
+		Rebroadcasts:      tp.rebroadcasts,
+		LastRebroadcast:   tp.lastRebroadcast,
+	}
+	for _, set := range tp.transactionSets {
+		stats.Transactions += len(set)
+	}
+	return stats
+}
+
 // TransactionList returns a list of all transactions in the transaction pool.
 // The transactions are provided in an order that can acceptably be put into a
 // block.
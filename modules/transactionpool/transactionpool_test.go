@@ -230,8 +230,8 @@ func TestFeeEstimation(t *testing.T) {
 
 	// Prepare a bunch of outputs for a series of graphs to fill up the
 	// transaction pool.
-	graphLens := 400                                                                     // 80 kb per graph
-	numGraphs := int(types.BlockSizeLimit) * blockFeeEstimationDepth / (graphLens * 206) // Enough to fill 'estimation depth' blocks.
+	graphLens := 400                                                                              // 80 kb per graph
+	numGraphs := int(types.BlockSizeLimit) * int(modules.FeeEstimationWindow) / (graphLens * 206) // Enough to fill 'estimation depth' blocks.
 	graphFund := types.SiacoinPrecision.Mul64(1000)
 	var outputs []types.SiacoinOutput
 	for i := 0; i < numGraphs+1; i++ {
@@ -278,9 +278,9 @@ func TestFeeEstimation(t *testing.T) {
 	// One block at a time, add graphs to the tpool and blockchain. Then check
 	// the median fee estimation and see that it's the right value.
 	var prevMin types.Currency
-	for i := 0; i < blockFeeEstimationDepth; i++ {
+	for i := 0; i < int(modules.FeeEstimationWindow); i++ {
 		// Insert enough graphs to fill a block.
-		for j := 0; j < numGraphs/blockFeeEstimationDepth; j++ {
+		for j := 0; j < numGraphs/int(modules.FeeEstimationWindow); j++ {
 			err = tpt.tpool.AcceptTransactionSet(graphs[0])
 			if err != nil {
 				t.Fatal(err)
@@ -302,7 +302,7 @@ func TestFeeEstimation(t *testing.T) {
 
 		// If we're over halfway through the depth, the suggested fee should
 		// start to exceed the default.
-		if i > blockFeeEstimationDepth/2 {
+		if i > int(modules.FeeEstimationWindow)/2 {
 			if min.Cmp(minEstimation) <= 0 {
 				t.Error("fee estimation does not seem to be increasing")
 			}
@@ -331,7 +331,7 @@ func TestFeeEstimation(t *testing.T) {
 
 	// Mine a few blocks and then check that the fee estimation has returned to
 	// minimum as congestion clears up.
-	for i := 0; i < (blockFeeEstimationDepth/2)+1; i++ {
+	for i := 0; i < (int(modules.FeeEstimationWindow)/2)+1; i++ {
 		_, err = tpt.miner.AddBlock()
 		if err != nil {
 			t.Fatal(err)
@@ -1,9 +1,7 @@
 package transactionpool
 
 import (
-	"bytes"
 	"fmt"
-	"sort"
 
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/modules"
@@ -208,13 +206,12 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 			}
 		}
 
-		// Pull the transactions out of the fee summary. For estimating only
-		// over 10 blocks, it is extremely likely that there will be more
-		// applied blocks than reverted blocks, and if there aren't (a height
-		// decreasing reorg), there will be more than 10 applied blocks.
-		if len(tp.recentMedians) > 0 {
-			// Strip out all of the transactions in this block.
-			tp.recentMedians = tp.recentMedians[:len(tp.recentMedians)-1]
+		// Pull the block out of the fee history. It is extremely likely that
+		// there will be more applied blocks than reverted blocks, and if there
+		// aren't (a height decreasing reorg), the history will refill as new
+		// blocks are applied.
+		if len(tp.feeHistory) > 0 {
+			tp.feeHistory = tp.feeHistory[:len(tp.feeHistory)-1]
 		}
 	}
 	for _, block := range cc.AppliedBlocks {
@@ -235,70 +232,13 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 			}
 		}
 
-		// Find the median transaction fee for this block.
-		type feeSummary struct {
-			fee  types.Currency
-			size int
-		}
-		var fees []feeSummary
-		var totalSize int
-		txnSets := findSets(block.Transactions)
-		for _, set := range txnSets {
-			// Compile the fees for this set.
-			var feeSum types.Currency
-			var sizeSum int
-			b := new(bytes.Buffer)
-			for _, txn := range set {
-				txn.MarshalSia(b)
-				sizeSum += b.Len()
-				b.Reset()
-				for _, fee := range txn.MinerFees {
-					feeSum = feeSum.Add(fee)
-				}
-			}
-			feeAvg := feeSum.Div64(uint64(sizeSum))
-			fees = append(fees, feeSummary{
-				fee:  feeAvg,
-				size: sizeSum,
-			})
-			totalSize += sizeSum
-		}
-		// Add an extra zero-fee tranasction for any unused block space.
-		remaining := int(types.BlockSizeLimit) - totalSize
-		fees = append(fees, feeSummary{
-			fee:  types.ZeroCurrency,
-			size: remaining, // fine if remaining is zero.
-		})
-		// Sort the fees by value and then scroll until the median.
-		sort.Slice(fees, func(i, j int) bool {
-			return fees[i].fee.Cmp(fees[j].fee) < 0
-		})
-		var progress int
-		for i := range fees {
-			progress += fees[i].size
-			// Instead of grabbing the full median, look at the 75%-ile. It's
-			// going to be cheaper than the 50%-ile, but it still got into a
-			// block.
-			if uint64(progress) > types.BlockSizeLimit/4 {
-				tp.recentMedians = append(tp.recentMedians, fees[i].fee)
-				break
-			}
-		}
-
-		// If there are more than 10 blocks recorded in the txnsPerBlock, strip
-		// off the oldest blocks.
-		for len(tp.recentMedians) > blockFeeEstimationDepth {
-			tp.recentMedians = tp.recentMedians[1:]
+		// Record the inclusion fee rate of this block, and strip off the
+		// oldest blocks if the history exceeds the estimation window.
+		tp.feeHistory = append(tp.feeHistory, blockInclusionFeeRate(block.Transactions))
+		if len(tp.feeHistory) > int(modules.FeeEstimationWindow) {
+			tp.feeHistory = tp.feeHistory[len(tp.feeHistory)-int(modules.FeeEstimationWindow):]
 		}
 	}
-	// Grab the median of the recent medians. Copy to a new slice so the sorting
-	// doesn't screw up the slice.
-	safeMedians := make([]types.Currency, len(tp.recentMedians))
-	copy(safeMedians, tp.recentMedians)
-	sort.Slice(safeMedians, func(i, j int) bool {
-		return safeMedians[i].Cmp(safeMedians[j]) < 0
-	})
-	tp.recentMedianFee = safeMedians[len(safeMedians)/2]
 
 	// Update all the on-disk structures.
 	err = tp.putRecentConsensusChange(tp.dbTx, cc.ID)
@@ -314,8 +254,7 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 		tp.log.Println("ERROR: could not update the block height:", err)
 	}
 	err = tp.putFeeMedian(tp.dbTx, medianPersist{
-		RecentMedians:   tp.recentMedians,
-		RecentMedianFee: tp.recentMedianFee,
+		FeeHistory: tp.feeHistory,
 	})
 	if err != nil {
 		tp.log.Println("ERROR: could not update the transaction pool median fee information:", err)
@@ -411,6 +350,9 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 		}
 	}
 
+	// Stop tracking the local transactions that were confirmed or dropped.
+	tp.pruneLocalTransactions()
+
 	// Inform subscribers that an update has executed.
 	tp.mu.Demote()
 	tp.updateSubscribersTransactions()
@@ -21,6 +21,29 @@ const (
 
 	// WalletDir is the directory that contains the wallet persistence.
 	WalletDir = "wallet"
+
+	// WalletAccountHost is the name of the wallet account that the host uses
+	// to fund its collateral, announcements and storage proofs. If the
+	// account does not exist, the host falls back to the wallet's default
+	// funds.
+	WalletAccountHost = "host"
+
+	// WalletEventConfirmed is the type of the event that reports that a
+	// payment reached a number of confirmations.
+	WalletEventConfirmed WalletEventType = "confirmed"
+
+	// WalletEventReverted is the type of the event that reports that a
+	// confirmed payment was removed from the blockchain by a reorg.
+	WalletEventReverted WalletEventType = "reverted"
+
+	// WalletEventUnconfirmed is the type of the event that reports that a
+	// payment appeared in the transaction pool.
+	WalletEventUnconfirmed WalletEventType = "unconfirmed"
+
+	// WalletAccountRenter is the name of the wallet account that the renter
+	// uses to form and renew its contracts. If the account does not exist,
+	// the renter falls back to the wallet's default funds.
+	WalletAccountRenter = "renter"
 )
 
 var (
@@ -42,6 +65,14 @@ var (
 	// complete the desired action.
 	ErrLowBalance = errors.New("insufficient balance")
 
+	// ErrUnknownWalletAccount is returned when a wallet account is requested
+	// that has not been created.
+	ErrUnknownWalletAccount = errors.New("wallet account does not exist")
+
+	// ErrUnknownWebhook is returned when a webhook is requested that has not
+	// been registered.
+	ErrUnknownWebhook = errors.New("webhook does not exist")
+
 	// ErrWalletShutdown is returned when a method can't continue execution due
 	// to the wallet shutting down.
 	ErrWalletShutdown = errors.New("wallet is shutting down")
@@ -104,6 +135,56 @@ type (
 		Outputs []ProcessedOutput `json:"outputs"`
 	}
 
+	// WalletAccount is a named account of the wallet. The addresses of an
+	// account are derived from the primary seed using a range of key indices
+	// that is reserved for the account, which keeps its outputs, balance and
+	// transaction history separate from the rest of the wallet.
+	WalletAccount struct {
+		Name     string `json:"name"`
+		Index    uint64 `json:"index"`
+		Progress uint64 `json:"progress"`
+
+		ConfirmedSiacoinBalance     types.Currency `json:"confirmedsiacoinbalance"`
+		UnconfirmedOutgoingSiacoins types.Currency `json:"unconfirmedoutgoingsiacoins"`
+		UnconfirmedIncomingSiacoins types.Currency `json:"unconfirmedincomingsiacoins"`
+
+		SiacoinClaimBalance types.Currency `json:"siacoinclaimbalance"`
+		SiafundBalance      types.Currency `json:"siafundbalance"`
+	}
+
+	// WalletEventType describes what happened to a payment that is reported by
+	// a WalletEvent.
+	WalletEventType string
+
+	// WalletEvent reports a change in the state of a payment to one of the
+	// addresses of the wallet. A transaction that pays to several wallet
+	// addresses results in one event per address. Events are numbered in the
+	// order they occur, starting at 1 every time the wallet is started.
+	WalletEvent struct {
+		ID            uint64              `json:"id"`
+		Type          WalletEventType     `json:"type"`
+		Address       types.UnlockHash    `json:"address"`
+		TransactionID types.TransactionID `json:"transactionid"`
+		FundType      types.Specifier     `json:"fundtype"`
+		Value         types.Currency      `json:"value"`
+		Height        types.BlockHeight   `json:"height"`
+		Confirmations types.BlockHeight   `json:"confirmations"`
+		Timestamp     types.Timestamp     `json:"timestamp"`
+	}
+
+	// WalletWebhook is an HTTP endpoint that the wallet posts its events to.
+	// Webhooks with 0 confirmations receive the events of unconfirmed
+	// payments, all other webhooks receive the confirmed event once a payment
+	// reached the requested number of confirmations. Every webhook receives
+	// revert events. If Addresses is empty, the webhook receives the events
+	// of all wallet addresses.
+	WalletWebhook struct {
+		ID            string             `json:"id"`
+		URL           string             `json:"url"`
+		Confirmations types.BlockHeight  `json:"confirmations"`
+		Addresses     []types.UnlockHash `json:"addresses"`
+	}
+
 	// TransactionBuilder is used to construct custom transactions. A transaction
 	// builder is initialized via 'RegisterTransaction' and then can be modified by
 	// adding funds or other fields. The transaction is completed by calling
@@ -333,12 +414,78 @@ type (
 		SweepSeed(seed Seed) (coins, funds types.Currency, err error)
 	}
 
+	// AccountManager manages the named accounts of the wallet. Outputs that
+	// belong to an account can only be spent through that account, and
+	// outputs that don't belong to any account are never used to fund
+	// account transactions.
+	AccountManager interface {
+		// Account returns the account with the given name, including its
+		// current balances.
+		Account(name string) (WalletAccount, error)
+
+		// AccountNextAddress returns a new address of the account.
+		AccountNextAddress(name string) (types.UnlockConditions, error)
+
+		// Accounts returns all accounts of the wallet, sorted by index.
+		Accounts() ([]WalletAccount, error)
+
+		// AccountTransactions returns all confirmed transactions that are
+		// related to the addresses of an account.
+		AccountTransactions(name string) ([]ProcessedTransaction, error)
+
+		// AccountUnconfirmedTransactions returns all unconfirmed transactions
+		// that are related to the addresses of an account.
+		AccountUnconfirmedTransactions(name string) ([]ProcessedTransaction, error)
+
+		// CreateAccount creates a new account. The index of the account is
+		// determined by the order in which accounts are created, so accounts
+		// need to be created in the same order to recover their funds from
+		// the primary seed.
+		CreateAccount(name string) (WalletAccount, error)
+
+		// RegisterAccountTransaction works like RegisterTransaction, but the
+		// returned builder only funds the transaction using the outputs of
+		// the account and sends any refunds back to the account.
+		RegisterAccountTransaction(name string, t types.Transaction, parents []types.Transaction) (TransactionBuilder, error)
+
+		// SendSiacoinsFromAccount works like SendSiacoins, but only spends
+		// the outputs of the account.
+		SendSiacoinsFromAccount(name string, amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// StartAccountTransaction is a convenience method that calls
+		// RegisterAccountTransaction(name, types.Transaction{}, nil).
+		StartAccountTransaction(name string) (TransactionBuilder, error)
+	}
+
+	// PaymentNotifier notifies merchants about payments to the addresses of
+	// the wallet, either through a long-polled event log or by posting the
+	// events to registered webhooks.
+	PaymentNotifier interface {
+		// Events returns the events with an ID greater than since. If there
+		// are no such events, Events blocks until a new event occurs or
+		// cancel is closed.
+		Events(since uint64, cancel <-chan struct{}) ([]WalletEvent, error)
+
+		// RegisterWebhook registers a URL that the wallet posts its events
+		// to. If secret is not empty, every request is signed with an
+		// HMAC-SHA256 of the request body.
+		RegisterWebhook(url, secret string, confirmations types.BlockHeight, addresses []types.UnlockHash) (WalletWebhook, error)
+
+		// RemoveWebhook removes a registered webhook.
+		RemoveWebhook(id string) error
+
+		// Webhooks returns all registered webhooks.
+		Webhooks() ([]WalletWebhook, error)
+	}
+
 	// Wallet stores and manages siacoins and siafunds. The wallet file is
 	// encrypted using a user-specified password. Common addresses are all
 	// derived from a single address seed.
 	Wallet interface {
+		AccountManager
 		EncryptionManager
 		KeyManager
+		PaymentNotifier
 
 		// Close permits clean shutdown during testing and serving.
 		Close() error
@@ -380,6 +527,11 @@ type (
 		// relative to the wallet.
 		UnconfirmedTransactions() ([]ProcessedTransaction, error)
 
+		// ReorgedTransactions returns the most recent wallet transactions
+		// that were reverted by a reorg and not confirmed again by the same
+		// reorg, oldest first.
+		ReorgedTransactions() ([]ReorgedTransaction, error)
+
 		// RegisterTransaction takes a transaction and its parents and returns
 		// a TransactionBuilder which can be used to expand the transaction.
 		RegisterTransaction(t types.Transaction, parents []types.Transaction) (TransactionBuilder, error)
@@ -418,9 +570,13 @@ type (
 		DustThreshold() (types.Currency, error)
 	}
 
-	// WalletSettings control the behavior of the Wallet.
+	// WalletSettings control the behavior of the Wallet. FeeTarget is the
+	// number of blocks that the transactions of the wallet should be
+	// confirmed within, which determines the fees they pay. If it is zero,
+	// FeeTargetDefault is used.
 	WalletSettings struct {
-		NoDefrag bool `json:"noDefrag"`
+		NoDefrag  bool              `json:"noDefrag"`
+		FeeTarget types.BlockHeight `json:"feeTarget"`
 	}
 )
 
@@ -0,0 +1,511 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/coreos/bbolt"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+const (
+	// accountKeySpacing is the number of key indices that are reserved for
+	// every account. The keys of the account with index i are generated from
+	// the primary seed starting at index i*accountKeySpacing. Index 0 is the
+	// range that the primary seed has always used for the default addresses
+	// of the wallet, so named accounts start at index 1.
+	accountKeySpacing = 1 << 40
+
+	// maxAccountNameLen is the maximum length of an account name.
+	maxAccountNameLen = 64
+)
+
+var (
+	errAccountExists      = errors.New("wallet account already exists")
+	errInvalidAccountName = errors.New("account names must consist of 1 to 64 letters, digits, '-' or '_'")
+)
+
+type (
+	// account is a named account of the wallet. progress is the number of
+	// keys that have been handed out by the account, and the lookahead maps
+	// the addresses of future keys to their offset within the account.
+	account struct {
+		name      string
+		index     uint64
+		progress  uint64
+		lookahead map[types.UnlockHash]uint64
+	}
+
+	// accountPersist is the metadata of an account that is stored in
+	// bucketAccounts. The keys of the account are not stored, they are
+	// regenerated from the primary seed when the wallet is unlocked.
+	accountPersist struct {
+		Name     string
+		Index    uint64
+		Progress uint64
+	}
+)
+
+// keyIndex returns the primary seed index of the i'th key of the account.
+func (a *account) keyIndex(i uint64) uint64 {
+	return a.index*accountKeySpacing + i
+}
+
+// persistData returns the data of the account that needs to be persisted.
+func (a *account) persistData() accountPersist {
+	return accountPersist{
+		Name:     a.name,
+		Index:    a.index,
+		Progress: a.progress,
+	}
+}
+
+// validateAccountName checks that name can be used as the name of an account.
+func validateAccountName(name string) error {
+	if len(name) == 0 || len(name) > maxAccountNameLen {
+		return errInvalidAccountName
+	}
+	for _, c := range name {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_':
+		default:
+			return errInvalidAccountName
+		}
+	}
+	return nil
+}
+
+// ownedBy reports whether the address uh is spendable by acct. A nil acct
+// refers to the default funds of the wallet, which consist of every wallet
+// address that doesn't belong to a named account.
+func (w *Wallet) ownedBy(uh types.UnlockHash, acct *account) bool {
+	if _, exists := w.keys[uh]; !exists {
+		return false
+	}
+	name, isAccountKey := w.accountKeys[uh]
+	if acct == nil {
+		return !isAccountKey
+	}
+	return isAccountKey && name == acct.name
+}
+
+// account returns the account with the given name. It must be called while
+// holding the lock.
+func (w *Wallet) account(name string) (*account, error) {
+	acct, exists := w.accounts[name]
+	if !exists && !w.unlocked && len(w.accounts) == 0 {
+		// Accounts are loaded when the wallet is unlocked for the first
+		// time.
+		return nil, modules.ErrLockedWallet
+	} else if !exists {
+		return nil, modules.ErrUnknownWalletAccount
+	}
+	return acct, nil
+}
+
+// integrateAccount generates the keys and the lookahead of an account and
+// loads them into the wallet.
+func (w *Wallet) integrateAccount(acct *account) {
+	for _, sk := range generateKeys(w.primarySeed, acct.keyIndex(0), acct.progress) {
+		uh := sk.UnlockConditions.UnlockHash()
+		w.keys[uh] = sk
+		w.accountKeys[uh] = acct.name
+	}
+	w.regenerateAccountLookahead(acct)
+	w.accounts[acct.name] = acct
+}
+
+// regenerateAccountLookahead creates future keys of an account, using the
+// same lookahead size that regenerateLookahead uses for the primary seed.
+func (w *Wallet) regenerateAccountLookahead(acct *account) {
+	start := acct.progress
+	maxKeys := maxLookahead(start)
+	existingKeys := uint64(len(acct.lookahead))
+
+	for i, k := range generateKeys(w.primarySeed, acct.keyIndex(start+existingKeys), maxKeys-existingKeys) {
+		acct.lookahead[k.UnlockConditions.UnlockHash()] = start + existingKeys + uint64(i)
+	}
+}
+
+// advanceAccountLookahead generates all keys of the account up to index and
+// adds them to the set of spendable keys. Returns true if a blockchain rescan
+// is required.
+func (w *Wallet) advanceAccountLookahead(tx *bolt.Tx, acct *account, index uint64) (bool, error) {
+	newProgress := index + 1
+	if newProgress <= acct.progress {
+		return false, nil
+	}
+
+	// Add spendable keys and remove them from lookahead
+	spendableKeys := generateKeys(w.primarySeed, acct.keyIndex(acct.progress), newProgress-acct.progress)
+	for _, key := range spendableKeys {
+		uh := key.UnlockConditions.UnlockHash()
+		w.keys[uh] = key
+		w.accountKeys[uh] = acct.name
+		delete(acct.lookahead, uh)
+	}
+
+	// Update the account progress
+	acct.progress = newProgress
+	if err := dbPutAccount(tx, acct.persistData()); err != nil {
+		return false, err
+	}
+
+	// Regenerate lookahead
+	w.regenerateAccountLookahead(acct)
+
+	return uint64(len(spendableKeys)) > lookaheadRescanThreshold, nil
+}
+
+// updateAccountLookaheads uses a consensus change to update the progress of
+// every account that received an output on one of its lookahead addresses.
+// Returns true if a blockchain rescan is required.
+func (w *Wallet) updateAccountLookaheads(tx *bolt.Tx, cc modules.ConsensusChange) (bool, error) {
+	needRescan := false
+	for _, acct := range w.accounts {
+		var largestIndex uint64
+		found := false
+		for _, diff := range cc.SiacoinOutputDiffs {
+			if index, ok := acct.lookahead[diff.SiacoinOutput.UnlockHash]; ok && (!found || index > largestIndex) {
+				largestIndex, found = index, true
+			}
+		}
+		for _, diff := range cc.SiafundOutputDiffs {
+			if index, ok := acct.lookahead[diff.SiafundOutput.UnlockHash]; ok && (!found || index > largestIndex) {
+				largestIndex, found = index, true
+			}
+		}
+		if !found {
+			continue
+		}
+		rescan, err := w.advanceAccountLookahead(tx, acct, largestIndex)
+		if err != nil {
+			return false, err
+		}
+		needRescan = needRescan || rescan
+	}
+	return needRescan, nil
+}
+
+// nextAccountAddresses fetches the next n addresses of an account.
+func (w *Wallet) nextAccountAddresses(tx *bolt.Tx, acct *account, n uint64) ([]types.UnlockConditions, error) {
+	// Check that the wallet has been unlocked.
+	if !w.unlocked {
+		return []types.UnlockConditions{}, modules.ErrLockedWallet
+	}
+
+	// Integrate the next keys into the wallet, and return the unlock
+	// conditions. Also remove new keys from the lookahead.
+	spendableKeys := generateKeys(w.primarySeed, acct.keyIndex(acct.progress), n)
+	ucs := make([]types.UnlockConditions, 0, len(spendableKeys))
+	for _, spendableKey := range spendableKeys {
+		uh := spendableKey.UnlockConditions.UnlockHash()
+		w.keys[uh] = spendableKey
+		w.accountKeys[uh] = acct.name
+		delete(acct.lookahead, uh)
+		ucs = append(ucs, spendableKey.UnlockConditions)
+	}
+	acct.progress += n
+	if err := dbPutAccount(tx, acct.persistData()); err != nil {
+		return []types.UnlockConditions{}, err
+	}
+	w.regenerateAccountLookahead(acct)
+
+	return ucs, nil
+}
+
+// nextAccountAddress fetches the next address of an account. If acct is nil,
+// the address is generated from the primary seed.
+func (w *Wallet) nextAccountAddress(tx *bolt.Tx, acct *account) (types.UnlockConditions, error) {
+	if acct == nil {
+		return w.nextPrimarySeedAddress(tx)
+	}
+	ucs, err := w.nextAccountAddresses(tx, acct, 1)
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	return ucs[0], nil
+}
+
+// relatedToAccount reports whether any of the wallet inputs or outputs of pt
+// belong to acct.
+func (w *Wallet) relatedToAccount(pt modules.ProcessedTransaction, acct *account) bool {
+	for _, input := range pt.Inputs {
+		if input.WalletAddress && w.ownedBy(input.RelatedAddress, acct) {
+			return true
+		}
+	}
+	for _, output := range pt.Outputs {
+		if output.WalletAddress && w.ownedBy(output.RelatedAddress, acct) {
+			return true
+		}
+	}
+	return false
+}
+
+// accountInfo computes the balances of an account. It must be called while
+// holding the lock.
+func (w *Wallet) accountInfo(acct *account, dustThreshold types.Currency) (modules.WalletAccount, error) {
+	info := modules.WalletAccount{
+		Name:     acct.name,
+		Index:    acct.index,
+		Progress: acct.progress,
+	}
+
+	err := dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if w.ownedBy(sco.UnlockHash, acct) && sco.Value.Cmp(dustThreshold) > 0 {
+			info.ConfirmedSiacoinBalance = info.ConfirmedSiacoinBalance.Add(sco.Value)
+		}
+	})
+	if err != nil {
+		return modules.WalletAccount{}, err
+	}
+
+	siafundPool, err := dbGetSiafundPool(w.dbTx)
+	if err != nil {
+		return modules.WalletAccount{}, err
+	}
+	err = dbForEachSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		if !w.ownedBy(sfo.UnlockHash, acct) {
+			return
+		}
+		info.SiafundBalance = info.SiafundBalance.Add(sfo.Value)
+		if sfo.ClaimStart.Cmp(siafundPool) > 0 {
+			return
+		}
+		info.SiacoinClaimBalance = info.SiacoinClaimBalance.Add(siafundPool.Sub(sfo.ClaimStart).Mul(sfo.Value).Div(types.SiafundCount))
+	})
+	if err != nil {
+		return modules.WalletAccount{}, err
+	}
+
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		for _, input := range upt.Inputs {
+			if input.FundType == types.SpecifierSiacoinInput && input.WalletAddress && w.ownedBy(input.RelatedAddress, acct) {
+				info.UnconfirmedOutgoingSiacoins = info.UnconfirmedOutgoingSiacoins.Add(input.Value)
+			}
+		}
+		for _, output := range upt.Outputs {
+			if output.FundType == types.SpecifierSiacoinOutput && output.WalletAddress && w.ownedBy(output.RelatedAddress, acct) && output.Value.Cmp(dustThreshold) > 0 {
+				info.UnconfirmedIncomingSiacoins = info.UnconfirmedIncomingSiacoins.Add(output.Value)
+			}
+		}
+	}
+	return info, nil
+}
+
+// Account returns the account with the given name, including its balances.
+func (w *Wallet) Account(name string) (modules.WalletAccount, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.WalletAccount{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return modules.WalletAccount{}, modules.ErrWalletShutdown
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	acct, err := w.account(name)
+	if err != nil {
+		return modules.WalletAccount{}, err
+	}
+	return w.accountInfo(acct, dustThreshold)
+}
+
+// Accounts returns all accounts of the wallet sorted by index.
+func (w *Wallet) Accounts() ([]modules.WalletAccount, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	accounts := make([]modules.WalletAccount, 0, len(w.accounts))
+	for _, acct := range w.accounts {
+		info, err := w.accountInfo(acct, dustThreshold)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, info)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Index < accounts[j].Index
+	})
+	return accounts, nil
+}
+
+// AccountNextAddress returns a new address of the account.
+func (w *Wallet) AccountNextAddress(name string) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	acct, err := w.account(name)
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	uc, err := w.nextAccountAddress(w.dbTx, acct)
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	return uc, w.syncDB()
+}
+
+// AccountTransactions returns all confirmed transactions that are related to
+// the addresses of an account.
+func (w *Wallet) AccountTransactions(name string) (pts []modules.ProcessedTransaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+	// ensure durability of reported transactions
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err = w.syncDB(); err != nil {
+		return
+	}
+	acct, err := w.account(name)
+	if err != nil {
+		return nil, err
+	}
+
+	it := dbProcessedTransactionsIterator(w.dbTx)
+	for it.next() {
+		if pt := it.value(); w.relatedToAccount(pt, acct) {
+			pts = append(pts, pt)
+		}
+	}
+	return pts, nil
+}
+
+// AccountUnconfirmedTransactions returns all unconfirmed transactions that are
+// related to the addresses of an account.
+func (w *Wallet) AccountUnconfirmedTransactions(name string) (pts []modules.ProcessedTransaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	acct, err := w.account(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		if w.relatedToAccount(pt, acct) {
+			pts = append(pts, pt)
+		}
+	}
+	return pts, nil
+}
+
+// CreateAccount creates a new account with the given name. The account gets
+// the next free index, so accounts need to be created in the same order to
+// recover their funds from the primary seed.
+func (w *Wallet) CreateAccount(name string) (modules.WalletAccount, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.WalletAccount{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := validateAccountName(name); err != nil {
+		return modules.WalletAccount{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.WalletAccount{}, modules.ErrLockedWallet
+	}
+	if _, exists := w.accounts[name]; exists {
+		return modules.WalletAccount{}, errAccountExists
+	}
+
+	// The sequence of the accounts bucket starts at 1, which leaves index 0
+	// to the default addresses of the primary seed.
+	index, err := w.dbTx.Bucket(bucketAccounts).NextSequence()
+	if err != nil {
+		return modules.WalletAccount{}, err
+	}
+	if build.DEBUG && index == 0 {
+		panic("account created with the index of the default addresses")
+	}
+	acct := &account{
+		name:      name,
+		index:     index,
+		lookahead: make(map[types.UnlockHash]uint64),
+	}
+	if err := dbPutAccount(w.dbTx, acct.persistData()); err != nil {
+		return modules.WalletAccount{}, err
+	}
+	w.integrateAccount(acct)
+	if err := w.syncDB(); err != nil {
+		return modules.WalletAccount{}, err
+	}
+	w.log.Printf("INFO: created wallet account %q with index %v", name, index)
+	return modules.WalletAccount{
+		Name:  acct.name,
+		Index: acct.index,
+	}, nil
+}
+
+// RegisterAccountTransaction takes a transaction and its parents and returns
+// a modules.TransactionBuilder that funds the transaction using only the
+// outputs of the account. Parent and refund outputs are sent to new addresses
+// of the account.
+func (w *Wallet) RegisterAccountTransaction(name string, t types.Transaction, parents []types.Transaction) (modules.TransactionBuilder, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	acct, err := w.account(name)
+	if err != nil {
+		return nil, err
+	}
+	tb := w.registerTransaction(t, parents)
+	tb.account = acct
+	return tb, nil
+}
+
+// SendSiacoinsFromAccount creates a transaction sending 'amount' to 'dest'
+// that is funded by the outputs of the account. The transaction is submitted
+// to the transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsFromAccount(name string, amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if name == "" {
+		return nil, modules.ErrUnknownWalletAccount
+	}
+	return w.managedSendSiacoins(name, amount, dest)
+}
+
+// StartAccountTransaction is a convenience function that calls
+// RegisterAccountTransaction(name, types.Transaction{}, nil).
+func (w *Wallet) StartAccountTransaction(name string) (modules.TransactionBuilder, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+	return w.RegisterAccountTransaction(name, types.Transaction{}, nil)
+}
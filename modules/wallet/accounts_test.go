@@ -0,0 +1,138 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+)
+
+// TestValidateAccountName probes the validateAccountName function.
+func TestValidateAccountName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"", false},
+		{"renter", true},
+		{"host-1_backup", true},
+		{"with space", false},
+		{"../escape", false},
+		{string(make([]byte, maxAccountNameLen+1)), false},
+	}
+	for _, test := range tests {
+		if err := validateAccountName(test.name); (err == nil) != test.valid {
+			t.Errorf("validateAccountName(%q): expected valid=%v, got %v", test.name, test.valid, err)
+		}
+	}
+}
+
+// TestWalletAccounts checks that the balance of an account is kept separate
+// from the default balance of the wallet.
+func TestWalletAccounts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Create an account. Creating it twice should fail.
+	acct, err := wt.wallet.CreateAccount("renter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acct.Index != 1 {
+		t.Fatal("expected first account to have index 1, got", acct.Index)
+	}
+	if _, err := wt.wallet.CreateAccount("renter"); err != errAccountExists {
+		t.Fatal("expected errAccountExists, got", err)
+	}
+	if _, err := wt.wallet.Account("host"); err != modules.ErrUnknownWalletAccount {
+		t.Fatal("expected ErrUnknownWalletAccount, got", err)
+	}
+
+	// Send coins from the default funds to the account.
+	uc, err := wt.wallet.AccountNextAddress("renter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fundValue := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.SendSiacoins(fundValue, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	acct, err = wt.wallet.Account("renter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !acct.ConfirmedSiacoinBalance.Equals(fundValue) {
+		t.Fatalf("expected account balance %v, got %v", fundValue, acct.ConfirmedSiacoinBalance)
+	}
+	txns, err := wt.wallet.AccountTransactions("renter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) == 0 {
+		t.Fatal("funding transaction is missing from the account transactions")
+	}
+
+	// The account should not be able to spend more than its own balance, even
+	// though the wallet holds more coins.
+	_, err = wt.wallet.SendSiacoinsFromAccount("renter", fundValue.Mul64(2), types.UnlockHash{})
+	if err == nil {
+		t.Fatal("account was able to spend more than its balance")
+	}
+
+	// Spend from the account. Only outputs of the account should be used, and
+	// the refund should go back to the account.
+	sendValue := types.SiacoinPrecision.Mul64(10)
+	txnSet, err := wt.wallet.SendSiacoinsFromAccount("renter", sendValue, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet.mu.Lock()
+	for _, txn := range txnSet {
+		for _, sci := range txn.SiacoinInputs {
+			if wt.wallet.accountKeys[sci.UnlockConditions.UnlockHash()] != "renter" {
+				t.Error("transaction is funded by an output that doesn't belong to the account")
+			}
+		}
+	}
+	wt.wallet.mu.Unlock()
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	acct, err = wt.wallet.Account("renter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acct.ConfirmedSiacoinBalance.Cmp(fundValue.Sub(sendValue)) >= 0 {
+		t.Fatal("account balance did not decrease")
+	}
+	if acct.ConfirmedSiacoinBalance.Cmp(fundValue.Sub(sendValue).Sub(types.SiacoinPrecision)) < 0 {
+		t.Fatal("refund was not sent back to the account")
+	}
+
+	// Lock and unlock the wallet. The account should be loaded again.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := wt.wallet.Accounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 || accounts[0].Name != "renter" {
+		t.Fatal("account was not reloaded after unlock:", accounts)
+	}
+	if !accounts[0].ConfirmedSiacoinBalance.Equals(acct.ConfirmedSiacoinBalance) {
+		t.Fatal("account balance changed after unlock")
+	}
+}
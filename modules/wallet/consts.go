@@ -1,6 +1,8 @@
 package wallet
 
 import (
+	"time"
+
 	"github.com/acejam/Sia/build"
 )
 
@@ -15,6 +17,29 @@ const (
 	// defragThreshold is the number of outputs a wallet is allowed before it is
 	// defragmented.
 	defragThreshold = 50
+
+	// maxWalletEvents is the number of payment events that the wallet keeps
+	// in memory. Clients that fall further behind miss the older events.
+	maxWalletEvents = 1000
+
+	// maxReorgedTransactions is the number of transactions reverted by
+	// reorgs that the wallet keeps in memory.
+	maxReorgedTransactions = 1000
+
+	// maxWebhookConfirmations is the largest number of confirmations that a
+	// webhook can wait for.
+	maxWebhookConfirmations = 1000
+
+	// webhookMaxAttempts is the number of times the wallet tries to deliver
+	// an event to a webhook before giving up.
+	webhookMaxAttempts = 6
+
+	// webhookSignatureHeader is the HTTP header that contains the hex-encoded
+	// HMAC-SHA256 of the request body if the webhook has a secret.
+	webhookSignatureHeader = "Sia-Signature"
+
+	// webhookTimeout is the timeout of a single webhook request.
+	webhookTimeout = 30 * time.Second
 )
 
 var (
@@ -26,6 +51,14 @@ var (
 		Testing:  uint64(40),
 	}).(uint64)
 
+	// webhookRetryInterval is the time the wallet waits before retrying a
+	// failed webhook request. The interval doubles after every attempt.
+	webhookRetryInterval = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
 	// lookaheadRescanThreshold is the number of keys in the lookahead that will be
 	// generated before a complete wallet rescan is initialized.
 	lookaheadRescanThreshold = build.Select(build.Var{
@@ -16,6 +16,9 @@ import (
 )
 
 var (
+	// bucketAccounts maps the name of a wallet account to its metadata. The
+	// sequence of the bucket is used to assign account indices.
+	bucketAccounts = []byte("bucketAccounts")
 	// bucketProcessedTransactions stores ProcessedTransactions in
 	// chronological order. Only transactions relevant to the wallet are
 	// stored. The key of this bucket is an autoincrementing integer.
@@ -42,8 +45,15 @@ var (
 	// bucketWallet contains various fields needed by the wallet, such as its
 	// UID, EncryptionVerification, and PrimarySeedFile.
 	bucketWallet = []byte("bucketWallet")
+	// bucketWebhooks maps the ID of a registered webhook to its URL, secret
+	// and filters.
+	bucketWebhooks = []byte("bucketWebhooks")
+	// bucketEvents maps the ID of a wallet event to the event. Only the most
+	// recent maxWalletEvents events are kept.
+	bucketEvents = []byte("bucketEvents")
 
 	dbBuckets = [][]byte{
+		bucketAccounts,
 		bucketProcessedTransactions,
 		bucketProcessedTxnIndex,
 		bucketAddrTransactions,
@@ -51,6 +61,8 @@ var (
 		bucketSiafundOutputs,
 		bucketSpentOutputs,
 		bucketWallet,
+		bucketWebhooks,
+		bucketEvents,
 	}
 
 	errNoKey = errors.New("key does not exist")
@@ -60,6 +72,8 @@ var (
 	keyConsensusChange        = []byte("keyConsensusChange")
 	keyConsensusHeight        = []byte("keyConsensusHeight")
 	keyEncryptionVerification = []byte("keyEncryptionVerification")
+	keyEventPayments          = []byte("keyEventPayments")
+	keyNextEventID            = []byte("keyNextEventID")
 	keyPrimarySeedFile        = []byte("keyPrimarySeedFile")
 	keyPrimarySeedProgress    = []byte("keyPrimarySeedProgress")
 	keySiafundPool            = []byte("keySiafundPool")
@@ -144,6 +158,8 @@ func dbReset(tx *bolt.Tx) error {
 	dbPutConsensusHeight(tx, 0)
 	dbPutConsensusChangeID(tx, modules.ConsensusChangeBeginning)
 	dbPutSiafundPool(tx, types.ZeroCurrency)
+	dbPutNextEventID(tx, 1)
+	dbPutEventPayments(tx, nil)
 
 	return nil
 }
@@ -232,6 +248,39 @@ func dbDeleteSpentOutput(tx *bolt.Tx, id types.OutputID) error {
 	return dbDelete(tx.Bucket(bucketSpentOutputs), id)
 }
 
+func dbPutAccount(tx *bolt.Tx, acct accountPersist) error {
+	return dbPut(tx.Bucket(bucketAccounts), acct.Name, acct)
+}
+func dbForEachAccount(tx *bolt.Tx, fn func(string, accountPersist)) error {
+	return dbForEach(tx.Bucket(bucketAccounts), fn)
+}
+
+func dbPutWebhook(tx *bolt.Tx, hook webhookPersist) error {
+	return dbPut(tx.Bucket(bucketWebhooks), hook.ID, hook)
+}
+func dbDeleteWebhook(tx *bolt.Tx, id string) error {
+	return dbDelete(tx.Bucket(bucketWebhooks), id)
+}
+func dbForEachWebhook(tx *bolt.Tx, fn func(string, webhookPersist)) error {
+	return dbForEach(tx.Bucket(bucketWebhooks), fn)
+}
+
+// dbPutEvent stores a wallet event.
+func dbPutEvent(tx *bolt.Tx, ev modules.WalletEvent) error {
+	return dbPut(tx.Bucket(bucketEvents), ev.ID, ev)
+}
+
+// dbDeleteEvent removes the wallet event with the given ID.
+func dbDeleteEvent(tx *bolt.Tx, id uint64) error {
+	return dbDelete(tx.Bucket(bucketEvents), id)
+}
+
+// dbForEachEvent calls fn for every stored wallet event. The events are not
+// visited in order.
+func dbForEachEvent(tx *bolt.Tx, fn func(uint64, modules.WalletEvent)) error {
+	return dbForEach(tx.Bucket(bucketEvents), fn)
+}
+
 func dbPutAddrTransactions(tx *bolt.Tx, addr types.UnlockHash, txns []uint64) error {
 	return dbPut(tx.Bucket(bucketAddrTransactions), addr, txns)
 }
@@ -466,6 +515,38 @@ func dbPutConsensusHeight(tx *bolt.Tx, height types.BlockHeight) error {
 	return tx.Bucket(bucketWallet).Put(keyConsensusHeight, encoding.Marshal(height))
 }
 
+// dbGetNextEventID returns the ID of the next wallet event.
+func dbGetNextEventID(tx *bolt.Tx) (id uint64, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keyNextEventID), &id)
+	return
+}
+
+// dbPutNextEventID stores the ID of the next wallet event.
+func dbPutNextEventID(tx *bolt.Tx, id uint64) error {
+	return tx.Bucket(bucketWallet).Put(keyNextEventID, encoding.Marshal(id))
+}
+
+// dbGetEventPayments returns the payments that the wallet keeps creating
+// confirmed events for.
+func dbGetEventPayments(tx *bolt.Tx) (payments []*eventPayment, err error) {
+	var persisted []eventPayment
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keyEventPayments), &persisted)
+	for i := range persisted {
+		payments = append(payments, &persisted[i])
+	}
+	return
+}
+
+// dbPutEventPayments stores the payments that the wallet keeps creating
+// confirmed events for.
+func dbPutEventPayments(tx *bolt.Tx, payments []*eventPayment) error {
+	persisted := make([]eventPayment, len(payments))
+	for i, p := range payments {
+		persisted[i] = *p
+	}
+	return tx.Bucket(bucketWallet).Put(keyEventPayments, encoding.Marshal(persisted))
+}
+
 // dbGetSiafundPool returns the value of the siafund pool.
 func dbGetSiafundPool(tx *bolt.Tx) (pool types.Currency, err error) {
 	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keySiafundPool), &pool)
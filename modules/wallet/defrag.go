@@ -20,7 +20,7 @@ func (w *Wallet) managedCreateDefragTransaction() ([]types.Transaction, error) {
 	if err != nil {
 		return nil, err
 	}
-	minFee, _ := w.tpool.FeeEstimation()
+	minFee, _ := w.managedFeeEstimation()
 
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -32,8 +32,10 @@ func (w *Wallet) managedCreateDefragTransaction() ([]types.Transaction, error) {
 
 	// Collect a value-sorted set of siacoin outputs.
 	var so sortedOutputs
+	// Outputs of named accounts are never defragged into the default
+	// addresses.
 	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
-		if w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
+		if w.ownedBy(sco.UnlockHash, nil) && w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
 			so.ids = append(so.ids, scoid)
 			so.outputs = append(so.outputs, sco)
 		}
@@ -109,6 +109,7 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 	var primarySeedProgress uint64
 	var auxiliarySeedFiles []seedFile
 	var unseededKeyFiles []spendableKeyFile
+	var accounts []accountPersist
 	err := func() error {
 		w.mu.Lock()
 		defer w.mu.Unlock()
@@ -145,7 +146,10 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 			return err
 		}
 
-		return nil
+		// accounts
+		return dbForEachAccount(w.dbTx, func(_ string, ap accountPersist) {
+			accounts = append(accounts, ap)
+		})
 	}()
 	if err != nil {
 		return err
@@ -165,6 +169,17 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 		w.primarySeed = primarySeed
 		w.regenerateLookahead(primarySeedProgress)
 
+		// accounts
+		w.accounts = make(map[string]*account)
+		for _, ap := range accounts {
+			w.integrateAccount(&account{
+				name:      ap.Name,
+				index:     ap.Index,
+				progress:  ap.Progress,
+				lookahead: make(map[types.UnlockHash]uint64),
+			})
+		}
+
 		// auxiliarySeedFiles
 		for _, sf := range auxiliarySeedFiles {
 			auxSeed, err := decryptSeedFile(masterKey, sf)
@@ -341,6 +356,8 @@ func (w *Wallet) Reset() error {
 	w.wipeSecrets()
 	w.keys = make(map[types.UnlockHash]spendableKey)
 	w.lookahead = make(map[types.UnlockHash]uint64)
+	w.accounts = make(map[string]*account)
+	w.accountKeys = make(map[types.UnlockHash]string)
 	w.seeds = []modules.Seed{}
 	w.unconfirmedProcessedTransactions = []modules.ProcessedTransaction{}
 	w.unlocked = false
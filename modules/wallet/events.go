@@ -0,0 +1,448 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+var (
+	errInvalidWebhookURL       = errors.New("webhook URL must be an absolute http or https URL")
+	errTooManyConfirmations    = errors.New("webhooks can't wait for more than 1000 confirmations")
+	errWebhookAddressNotWallet = errors.New("webhook address does not belong to the wallet")
+)
+
+type (
+	// eventPayment is a confirmed payment that the wallet keeps creating
+	// confirmed events for until it reaches the largest number of
+	// confirmations that any webhook is waiting for. The payments are stored
+	// in bucketWallet.
+	eventPayment struct {
+		Event         modules.WalletEvent
+		Confirmations types.BlockHeight
+	}
+
+	// webhookPersist is the data of a webhook that is stored in
+	// bucketWebhooks.
+	webhookPersist struct {
+		ID            string
+		URL           string
+		Secret        string
+		Confirmations types.BlockHeight
+		Addresses     []types.UnlockHash
+	}
+)
+
+// info returns the data of the webhook that is reported to API callers. The
+// secret is never reported.
+func (wp webhookPersist) info() modules.WalletWebhook {
+	return modules.WalletWebhook{
+		ID:            wp.ID,
+		URL:           wp.URL,
+		Confirmations: wp.Confirmations,
+		Addresses:     append([]types.UnlockHash{}, wp.Addresses...),
+	}
+}
+
+// wants reports whether the webhook should receive ev.
+func (wp webhookPersist) wants(ev modules.WalletEvent) bool {
+	switch ev.Type {
+	case modules.WalletEventUnconfirmed:
+		if wp.Confirmations != 0 {
+			return false
+		}
+	case modules.WalletEventConfirmed:
+		if wp.Confirmations != ev.Confirmations {
+			return false
+		}
+	}
+	if len(wp.Addresses) == 0 {
+		return true
+	}
+	for _, addr := range wp.Addresses {
+		if addr == ev.Address {
+			return true
+		}
+	}
+	return false
+}
+
+// paymentEvents creates an event for every wallet address that pt pays to.
+// Transactions that spend wallet outputs are not considered payments, which
+// keeps the refunds of the wallet's own transactions out of the event log.
+func paymentEvents(typ modules.WalletEventType, pt modules.ProcessedTransaction) []modules.WalletEvent {
+	for _, input := range pt.Inputs {
+		if input.WalletAddress {
+			return nil
+		}
+	}
+
+	type paymentKey struct {
+		addr     types.UnlockHash
+		fundType types.Specifier
+	}
+	var events []modules.WalletEvent
+	index := make(map[paymentKey]int)
+	for _, output := range pt.Outputs {
+		if !output.WalletAddress {
+			continue
+		}
+		if output.FundType != types.SpecifierSiacoinOutput && output.FundType != types.SpecifierSiafundOutput {
+			continue
+		}
+		key := paymentKey{output.RelatedAddress, output.FundType}
+		if i, exists := index[key]; exists {
+			events[i].Value = events[i].Value.Add(output.Value)
+			continue
+		}
+		ev := modules.WalletEvent{
+			Type:          typ,
+			Address:       output.RelatedAddress,
+			TransactionID: pt.TransactionID,
+			FundType:      output.FundType,
+			Value:         output.Value,
+		}
+		if typ != modules.WalletEventUnconfirmed {
+			ev.Height = pt.ConfirmationHeight
+		}
+		index[key] = len(events)
+		events = append(events, ev)
+	}
+	return events
+}
+
+// eventConfirmationDepths returns the numbers of confirmations that confirmed
+// events are created for.
+func (w *Wallet) eventConfirmationDepths() []types.BlockHeight {
+	depths := []types.BlockHeight{1}
+	seen := map[types.BlockHeight]bool{1: true}
+	for _, hook := range w.webhooks {
+		if hook.Confirmations > 1 && !seen[hook.Confirmations] {
+			seen[hook.Confirmations] = true
+			depths = append(depths, hook.Confirmations)
+		}
+	}
+	return depths
+}
+
+// publishEvent numbers ev, adds it to the event log, wakes up all long-polling
+// callers and posts the event to the webhooks that want it.
+func (w *Wallet) publishEvent(ev modules.WalletEvent) {
+	ev.ID = w.nextEventID
+	ev.Timestamp = types.CurrentTimestamp()
+	w.nextEventID++
+
+	w.events = append(w.events, ev)
+	if len(w.events) > maxWalletEvents {
+		w.events = w.events[len(w.events)-maxWalletEvents:]
+	}
+	if err := w.persistEvent(ev); err != nil {
+		w.log.Severe("ERROR: failed to persist wallet event:", err)
+		w.dbRollback = true
+	}
+	close(w.eventNotify)
+	w.eventNotify = make(chan struct{})
+
+	for _, hook := range w.webhooks {
+		if hook.wants(ev) {
+			go w.threadedDeliverWebhook(hook, ev)
+		}
+	}
+}
+
+// persistEvent adds ev to the stored event log, removes the event that fell
+// out of the log and stores the ID of the next event.
+func (w *Wallet) persistEvent(ev modules.WalletEvent) error {
+	if err := dbPutEvent(w.dbTx, ev); err != nil {
+		return err
+	}
+	if ev.ID > maxWalletEvents {
+		if err := dbDeleteEvent(w.dbTx, ev.ID-maxWalletEvents); err != nil {
+			return err
+		}
+	}
+	return dbPutNextEventID(w.dbTx, w.nextEventID)
+}
+
+// stagePaymentEvents stages the events of a transaction that was applied or
+// reverted by a consensus change. The events are published by
+// publishStagedEvents once the whole consensus change has been processed.
+func (w *Wallet) stagePaymentEvents(typ modules.WalletEventType, pt modules.ProcessedTransaction) {
+	w.stagedEvents = append(w.stagedEvents, paymentEvents(typ, pt)...)
+}
+
+// publishStagedEvents publishes the events of a consensus change and creates
+// the confirmed events of all tracked payments that reached a new number of
+// confirmations. Events are only published if the consensus set is synced, so
+// that the initial blockchain download doesn't flood the webhooks.
+func (w *Wallet) publishStagedEvents(synced bool, height types.BlockHeight) {
+	staged := w.stagedEvents
+	w.stagedEvents = nil
+
+	for _, ev := range staged {
+		switch ev.Type {
+		case modules.WalletEventReverted:
+			// Stop tracking the reverted payment, and make sure that it is
+			// tracked again if it is re-applied at the same height.
+			if ev.Height <= w.eventHeight {
+				w.eventHeight = ev.Height - 1
+			}
+			payments := w.eventPayments[:0]
+			for _, p := range w.eventPayments {
+				if p.Event.TransactionID != ev.TransactionID {
+					payments = append(payments, p)
+				}
+			}
+			w.eventPayments = payments
+			if synced {
+				w.publishEvent(ev)
+			}
+		case modules.WalletEventConfirmed:
+			if synced && ev.Height > w.eventHeight {
+				w.eventPayments = append(w.eventPayments, &eventPayment{Event: ev})
+			}
+		}
+	}
+	if height > w.eventHeight {
+		w.eventHeight = height
+	}
+
+	// Create the confirmed events of the tracked payments.
+	depths := w.eventConfirmationDepths()
+	var maxDepth types.BlockHeight
+	for _, d := range depths {
+		if d > maxDepth {
+			maxDepth = d
+		}
+	}
+	payments := w.eventPayments[:0]
+	for _, p := range w.eventPayments {
+		if height < p.Event.Height {
+			payments = append(payments, p)
+			continue
+		}
+		confirmations := height - p.Event.Height + 1
+		for _, d := range depths {
+			if p.Confirmations < d && d <= confirmations {
+				ev := p.Event
+				ev.Confirmations = d
+				w.publishEvent(ev)
+			}
+		}
+		if confirmations > p.Confirmations {
+			p.Confirmations = confirmations
+		}
+		if p.Confirmations < maxDepth {
+			payments = append(payments, p)
+		}
+	}
+	w.eventPayments = payments
+	if err := dbPutEventPayments(w.dbTx, w.eventPayments); err != nil {
+		w.log.Severe("ERROR: failed to persist event payments:", err)
+		w.dbRollback = true
+	}
+}
+
+// publishUnconfirmedEvents publishes the unconfirmed events of the
+// transactions that appeared in the transaction pool for the first time, and
+// forgets the transactions that have left the pool. It must be called while
+// holding the lock.
+func (w *Wallet) publishUnconfirmedEvents() {
+	current := make(map[types.TransactionID]struct{}, len(w.unconfirmedProcessedTransactions))
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		current[pt.TransactionID] = struct{}{}
+		if _, exists := w.eventUnconfirmed[pt.TransactionID]; exists {
+			continue
+		}
+		w.eventUnconfirmed[pt.TransactionID] = struct{}{}
+		for _, ev := range paymentEvents(modules.WalletEventUnconfirmed, pt) {
+			w.publishEvent(ev)
+		}
+	}
+	for txid := range w.eventUnconfirmed {
+		if _, exists := current[txid]; !exists {
+			delete(w.eventUnconfirmed, txid)
+		}
+	}
+}
+
+// threadedDeliverWebhook posts ev to a webhook. Failed requests are retried
+// with an exponential backoff.
+func (w *Wallet) threadedDeliverWebhook(hook webhookPersist, ev modules.WalletEvent) {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	body, err := json.Marshal(ev)
+	if err != nil {
+		w.log.Println("ERROR: failed to encode wallet event:", err)
+		return
+	}
+	var signature string
+	if hook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(hook.Secret))
+		mac.Write(body)
+		signature = hex.EncodeToString(mac.Sum(nil))
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	interval := webhookRetryInterval
+	for attempt := 0; attempt < webhookMaxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(interval):
+			case <-w.tg.StopChan():
+				return
+			}
+			interval *= 2
+		}
+		req, err := http.NewRequest("POST", hook.URL, bytes.NewReader(body))
+		if err != nil {
+			w.log.Println("ERROR: failed to create webhook request:", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "Sia-Agent")
+		if signature != "" {
+			req.Header.Set(webhookSignatureHeader, signature)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			w.log.Debugf("webhook %v: attempt %v to deliver event %v failed: %v", hook.ID, attempt+1, ev.ID, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return
+		}
+		w.log.Debugf("webhook %v: attempt %v to deliver event %v failed: %v", hook.ID, attempt+1, ev.ID, resp.Status)
+	}
+	w.log.Printf("WARN: giving up on delivering event %v to webhook %v", ev.ID, hook.ID)
+}
+
+// Events returns the events with an ID greater than since. If there are no
+// such events, Events blocks until a new event occurs or cancel is closed.
+func (w *Wallet) Events(since uint64, cancel <-chan struct{}) ([]modules.WalletEvent, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	for {
+		w.mu.RLock()
+		var events []modules.WalletEvent
+		for _, ev := range w.events {
+			if ev.ID > since {
+				events = append(events, ev)
+			}
+		}
+		notify := w.eventNotify
+		w.mu.RUnlock()
+		if len(events) > 0 {
+			return events, nil
+		}
+
+		select {
+		case <-notify:
+		case <-cancel:
+			return nil, nil
+		case <-w.tg.StopChan():
+			return nil, modules.ErrWalletShutdown
+		}
+	}
+}
+
+// RegisterWebhook registers a URL that the wallet posts its events to.
+func (w *Wallet) RegisterWebhook(rawurl, secret string, confirmations types.BlockHeight, addresses []types.UnlockHash) (modules.WalletWebhook, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.WalletWebhook{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	u, err := url.Parse(rawurl)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+		return modules.WalletWebhook{}, errInvalidWebhookURL
+	}
+	if confirmations > maxWebhookConfirmations {
+		return modules.WalletWebhook{}, errTooManyConfirmations
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, addr := range addresses {
+		if !w.isWalletAddress(addr) {
+			return modules.WalletWebhook{}, errWebhookAddressNotWallet
+		}
+	}
+	hook := webhookPersist{
+		ID:            hex.EncodeToString(fastrand.Bytes(8)),
+		URL:           rawurl,
+		Secret:        secret,
+		Confirmations: confirmations,
+		Addresses:     addresses,
+	}
+	if err := dbPutWebhook(w.dbTx, hook); err != nil {
+		return modules.WalletWebhook{}, err
+	}
+	if err := w.syncDB(); err != nil {
+		return modules.WalletWebhook{}, err
+	}
+	w.webhooks[hook.ID] = hook
+	w.log.Printf("INFO: registered webhook %v for %v", hook.ID, u.Host)
+	return hook.info(), nil
+}
+
+// RemoveWebhook removes a registered webhook.
+func (w *Wallet) RemoveWebhook(id string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, exists := w.webhooks[id]; !exists {
+		return modules.ErrUnknownWebhook
+	}
+	if err := dbDeleteWebhook(w.dbTx, id); err != nil {
+		return err
+	}
+	if err := w.syncDB(); err != nil {
+		return err
+	}
+	delete(w.webhooks, id)
+	w.log.Printf("INFO: removed webhook %v", id)
+	return nil
+}
+
+// Webhooks returns all registered webhooks.
+func (w *Wallet) Webhooks() ([]modules.WalletWebhook, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	hooks := make([]modules.WalletWebhook, 0, len(w.webhooks))
+	for _, hook := range w.webhooks {
+		hooks = append(hooks, hook.info())
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].ID < hooks[j].ID
+	})
+	return hooks, nil
+}
@@ -0,0 +1,319 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/types"
+
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// timeoutChan returns a channel that is closed after a few seconds. It is used
+// to prevent tests from blocking forever in Events.
+func timeoutChan() <-chan struct{} {
+	c := make(chan struct{})
+	time.AfterFunc(5*time.Second, func() { close(c) })
+	return c
+}
+
+// TestPaymentEvents probes the paymentEvents function.
+func TestPaymentEvents(t *testing.T) {
+	addr1 := types.UnlockHash{1}
+	addr2 := types.UnlockHash{2}
+	pt := modules.ProcessedTransaction{
+		TransactionID:      types.TransactionID{3},
+		ConfirmationHeight: 10,
+		Outputs: []modules.ProcessedOutput{
+			{FundType: types.SpecifierSiacoinOutput, WalletAddress: true, RelatedAddress: addr1, Value: types.NewCurrency64(5)},
+			{FundType: types.SpecifierSiacoinOutput, WalletAddress: true, RelatedAddress: addr1, Value: types.NewCurrency64(7)},
+			{FundType: types.SpecifierSiacoinOutput, WalletAddress: true, RelatedAddress: addr2, Value: types.NewCurrency64(1)},
+			{FundType: types.SpecifierSiacoinOutput, WalletAddress: false, RelatedAddress: types.UnlockHash{4}, Value: types.NewCurrency64(1)},
+			{FundType: types.SpecifierMinerFee, Value: types.NewCurrency64(1)},
+		},
+	}
+
+	// Outputs to the same address should be combined.
+	events := paymentEvents(modules.WalletEventConfirmed, pt)
+	if len(events) != 2 {
+		t.Fatal("expected 2 events, got", len(events))
+	}
+	if events[0].Address != addr1 || !events[0].Value.Equals64(12) || events[0].Height != 10 {
+		t.Error("wrong first event:", events[0])
+	}
+	if events[1].Address != addr2 || !events[1].Value.Equals64(1) {
+		t.Error("wrong second event:", events[1])
+	}
+
+	// Unconfirmed events don't have a height.
+	events = paymentEvents(modules.WalletEventUnconfirmed, pt)
+	if len(events) != 2 || events[0].Height != 0 {
+		t.Error("unconfirmed event has a height")
+	}
+
+	// Transactions that spend wallet outputs are not payments.
+	pt.Inputs = []modules.ProcessedInput{{FundType: types.SpecifierSiacoinInput, WalletAddress: true}}
+	if events := paymentEvents(modules.WalletEventConfirmed, pt); len(events) != 0 {
+		t.Error("transaction funded by the wallet created events")
+	}
+}
+
+// TestWalletEvents checks that a payment creates unconfirmed, confirmed and
+// reverted events, and that the events are posted to webhooks.
+func TestWalletEvents(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Create a second wallet that receives the payment.
+	receiverDir := filepath.Join(build.TempDir(modules.WalletDir, t.Name()), "receiver")
+	if err := os.RemoveAll(receiverDir); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(wt.cs, wt.tpool, receiverDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	var masterKey crypto.TwofishKey
+	fastrand.Read(masterKey[:])
+	if _, err := w.Encrypt(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	uc, err := w.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := uc.UnlockHash()
+
+	// Register a webhook that waits for 2 confirmations.
+	secret := "foo"
+	received := make(chan modules.WalletEvent, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if req.Header.Get(webhookSignatureHeader) != hex.EncodeToString(mac.Sum(nil)) {
+			t.Error("webhook request has a wrong signature")
+		}
+		var ev modules.WalletEvent
+		if err := json.Unmarshal(body, &ev); err != nil {
+			t.Error(err)
+		}
+		received <- ev
+	}))
+	defer srv.Close()
+	hook, err := w.RegisterWebhook(srv.URL, secret, 2, []types.UnlockHash{addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.RegisterWebhook("ftp://foo", "", 1, nil); err != errInvalidWebhookURL {
+		t.Fatal("expected errInvalidWebhookURL, got", err)
+	}
+
+	// Pay the second wallet.
+	value := types.SiacoinPrecision.Mul64(10)
+	if _, err := wt.wallet.SendSiacoins(value, addr); err != nil {
+		t.Fatal(err)
+	}
+	events, err := w.Events(0, timeoutChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != modules.WalletEventUnconfirmed || events[0].Address != addr || !events[0].Value.Equals(value) {
+		t.Fatal("expected unconfirmed event, got", events)
+	}
+	lastID := events[0].ID
+
+	// The sender shouldn't get an event for its refund.
+	closed := make(chan struct{})
+	close(closed)
+	if events, _ := wt.wallet.Events(0, closed); len(events) != 0 {
+		t.Fatal("sender created events:", events)
+	}
+
+	// Mine the payment.
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	events, err = w.Events(lastID, timeoutChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Type != modules.WalletEventConfirmed || events[0].Confirmations != 1 {
+		t.Fatal("expected confirmed event, got", events)
+	}
+	lastID = events[0].ID
+
+	// Events should block until a new event occurs.
+	cancel := make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() { close(cancel) })
+	if events, err := w.Events(lastID, cancel); err != nil || len(events) != 0 {
+		t.Fatal("expected Events to time out, got", events, err)
+	}
+
+	// After another block the webhook should be called.
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-received:
+		if ev.Type != modules.WalletEventConfirmed || ev.Confirmations != 2 || ev.Address != addr {
+			t.Fatal("webhook received wrong event:", ev)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("webhook wasn't called")
+	}
+
+	// Revert the block containing the payment.
+	w.mu.Lock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	w.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, exists := wt.cs.BlockAtHeight(height - 1)
+	if !exists {
+		t.Fatal("block doesn't exist")
+	}
+	tip := wt.cs.CurrentBlock()
+	w.ProcessConsensusChange(modules.ConsensusChange{
+		RevertedBlocks: []types.Block{tip, block},
+		Synced:         true,
+	})
+	events, err = w.Events(lastID, timeoutChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reverted bool
+	for _, ev := range events {
+		reverted = reverted || ev.Type == modules.WalletEventReverted
+	}
+	if !reverted {
+		t.Fatal("expected reverted event, got", events)
+	}
+	select {
+	case ev := <-received:
+		if ev.Type != modules.WalletEventReverted {
+			t.Fatal("webhook received wrong event:", ev)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("webhook wasn't called")
+	}
+	lastID = events[len(events)-1].ID
+
+	// Re-apply the blocks. The payment should be confirmed again at the same
+	// height.
+	w.ProcessConsensusChange(modules.ConsensusChange{
+		AppliedBlocks: []types.Block{block, tip},
+		Synced:        true,
+	})
+	events, err = w.Events(lastID, timeoutChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != modules.WalletEventConfirmed || events[0].Height != height-1 || events[1].Confirmations != 2 {
+		t.Fatal("expected confirmed events, got", events)
+	}
+	select {
+	case ev := <-received:
+		if ev.Type != modules.WalletEventConfirmed || ev.Confirmations != 2 || ev.Address != addr {
+			t.Fatal("webhook received wrong event:", ev)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("webhook wasn't called")
+	}
+
+	// Remove the webhook.
+	if err := w.RemoveWebhook(hook.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.RemoveWebhook(hook.ID); err != modules.ErrUnknownWebhook {
+		t.Fatal("expected ErrUnknownWebhook, got", err)
+	}
+	if hooks, err := w.Webhooks(); err != nil || len(hooks) != 0 {
+		t.Fatal("webhook wasn't removed", hooks, err)
+	}
+}
+
+// TestWalletEventsPersist checks that the event log, the event IDs and the
+// tracked payments survive a restart of the wallet.
+func TestWalletEventsPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	dir := filepath.Join(build.TempDir(modules.WalletDir, t.Name()), "events")
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(wt.cs, wt.tpool, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Publish an event and track a payment that a webhook waits for 3
+	// confirmations of.
+	w.mu.Lock()
+	w.webhooks["foo"] = webhookPersist{ID: "foo", URL: "http://localhost", Confirmations: 3}
+	w.publishEvent(modules.WalletEvent{Type: modules.WalletEventUnconfirmed})
+	height := w.eventHeight + 1
+	w.stagedEvents = []modules.WalletEvent{{Type: modules.WalletEventConfirmed, Height: height}}
+	w.publishStagedEvents(true, height)
+	events := append([]modules.WalletEvent(nil), w.events...)
+	nextEventID := w.nextEventID
+	w.mu.Unlock()
+	if len(events) != 2 || nextEventID != 3 {
+		t.Fatal("expected 2 events, got", events)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err = New(wt.cs, wt.tpool, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.events) != len(events) {
+		t.Fatal("event log wasn't persisted:", w.events)
+	}
+	for i, ev := range w.events {
+		if ev.ID != events[i].ID || ev.Type != events[i].Type || ev.Timestamp != events[i].Timestamp {
+			t.Fatal("event log wasn't persisted:", w.events)
+		}
+	}
+	if w.nextEventID != nextEventID {
+		t.Fatal("expected next event ID", nextEventID, "got", w.nextEventID)
+	}
+	if len(w.eventPayments) != 1 || w.eventPayments[0].Confirmations != 1 || w.eventPayments[0].Event.Height != height {
+		t.Fatal("event payments weren't persisted:", w.eventPayments)
+	}
+}
@@ -15,6 +15,15 @@ type sortedOutputs struct {
 	outputs []types.SiacoinOutput
 }
 
+// managedFeeEstimation returns the fee estimation of the transaction pool for
+// the confirmation target of the wallet.
+func (w *Wallet) managedFeeEstimation() (min, max types.Currency) {
+	w.mu.RLock()
+	target := w.feeTarget
+	w.mu.RUnlock()
+	return w.tpool.FeeEstimationTarget(target)
+}
+
 // DustThreshold returns the quantity per byte below which a Currency is
 // considered to be Dust.
 func (w *Wallet) DustThreshold() (types.Currency, error) {
@@ -23,7 +32,7 @@ func (w *Wallet) DustThreshold() (types.Currency, error) {
 	}
 	defer w.tg.Done()
 
-	minFee, _ := w.tpool.FeeEstimation()
+	minFee, _ := w.managedFeeEstimation()
 	return minFee.Mul64(3), nil
 }
 
@@ -113,7 +122,13 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 		return nil, err
 	}
 	defer w.tg.Done()
+	return w.managedSendSiacoins("", amount, dest)
+}
 
+// managedSendSiacoins creates a transaction sending 'amount' to 'dest' that is
+// funded by the named account. An empty account name funds the transaction
+// from the outputs that don't belong to any account.
+func (w *Wallet) managedSendSiacoins(account string, amount types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
 	w.mu.RLock()
 	unlocked := w.unlocked
 	w.mu.RUnlock()
@@ -122,14 +137,19 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 		return nil, modules.ErrLockedWallet
 	}
 
-	_, tpoolFee := w.tpool.FeeEstimation()
+	_, tpoolFee := w.managedFeeEstimation()
 	tpoolFee = tpoolFee.Mul64(750) // Estimated transaction size in bytes
 	output := types.SiacoinOutput{
 		Value:      amount,
 		UnlockHash: dest,
 	}
 
-	txnBuilder, err := w.StartTransaction()
+	var txnBuilder modules.TransactionBuilder
+	if account == "" {
+		txnBuilder, err = w.StartTransaction()
+	} else {
+		txnBuilder, err = w.StartAccountTransaction(account)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +214,7 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	}()
 
 	// Add estimated transaction fee.
-	_, tpoolFee := w.tpool.FeeEstimation()
+	_, tpoolFee := w.managedFeeEstimation()
 	tpoolFee = tpoolFee.Mul64(2)                              // We don't want send-to-many transactions to fail.
 	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
 	txnBuilder.AddMinerFee(tpoolFee)
@@ -256,7 +276,7 @@ func (w *Wallet) SendSiafunds(amount types.Currency, dest types.UnlockHash) (txn
 		return nil, modules.ErrLockedWallet
 	}
 
-	_, tpoolFee := w.tpool.FeeEstimation()
+	_, tpoolFee := w.managedFeeEstimation()
 	tpoolFee = tpoolFee.Mul64(750) // Estimated transaction size in bytes
 	tpoolFee = tpoolFee.Mul64(5)   // use large fee to ensure siafund transactions are selected by miners
 	output := types.SiafundOutput{
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/encoding"
@@ -74,6 +75,12 @@ func (w *Wallet) openDB(filename string) (err error) {
 		if wb.Get(keySiafundPool) == nil {
 			wb.Put(keySiafundPool, encoding.Marshal(types.ZeroCurrency))
 		}
+		if wb.Get(keyNextEventID) == nil {
+			wb.Put(keyNextEventID, encoding.Marshal(uint64(1)))
+		}
+		if wb.Get(keyEventPayments) == nil {
+			wb.Put(keyEventPayments, encoding.Marshal([]eventPayment{}))
+		}
 
 		// build the bucketAddrTransactions bucket if necessary
 		if buildAddrTxns {
@@ -144,6 +151,44 @@ func (w *Wallet) initPersist() error {
 		}
 	}
 
+	// Load the webhooks. Events are only created for blocks that the wallet
+	// hasn't processed yet. A new wallet starts at the height of the
+	// consensus set, so that the initial scan doesn't repeat the history of
+	// restored addresses.
+	err = dbForEachWebhook(w.dbTx, func(id string, hook webhookPersist) {
+		w.webhooks[id] = hook
+	})
+	if err != nil {
+		return err
+	}
+	w.eventHeight, err = dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return err
+	}
+	if w.eventHeight == 0 {
+		w.eventHeight = w.cs.Height()
+	}
+
+	// Load the event log and the payments that are still waiting for
+	// confirmations.
+	err = dbForEachEvent(w.dbTx, func(_ uint64, ev modules.WalletEvent) {
+		w.events = append(w.events, ev)
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(w.events, func(i, j int) bool {
+		return w.events[i].ID < w.events[j].ID
+	})
+	w.nextEventID, err = dbGetNextEventID(w.dbTx)
+	if err != nil {
+		return err
+	}
+	w.eventPayments, err = dbGetEventPayments(w.dbTx)
+	if err != nil {
+		return err
+	}
+
 	// ensure that the final db transaction is committed when the wallet closes
 	err = w.tg.AfterStop(func() error {
 		var err error
@@ -242,6 +287,8 @@ func (w *Wallet) convertPersistFrom112To120(dbFilename, compatFilename string) e
 		// triggered
 		dbPutConsensusHeight(tx, 0)
 		dbPutConsensusChangeID(tx, modules.ConsensusChangeBeginning)
+		dbPutNextEventID(tx, 1)
+		dbPutEventPayments(tx, nil)
 		return nil
 	})
 	w.encrypted = true
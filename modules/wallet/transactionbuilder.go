@@ -44,7 +44,10 @@ type transactionBuilder struct {
 	siafundInputs         []int
 	transactionSignatures []int
 
-	wallet *Wallet
+	// account is the wallet account that funds the transaction. If account
+	// is nil, only outputs that don't belong to any account are used.
+	account *account
+	wallet  *Wallet
 }
 
 // addSignatures will sign a transaction using a spendable key, with support
@@ -134,6 +137,9 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 	// Collect a value-sorted set of siacoin outputs.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(tb.wallet.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if !tb.wallet.ownedBy(sco.UnlockHash, tb.account) {
+			return
+		}
 		so.ids = append(so.ids, scoid)
 		so.outputs = append(so.outputs, sco)
 	})
@@ -143,9 +149,9 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 	// Add all of the unconfirmed outputs as well.
 	for _, upt := range tb.wallet.unconfirmedProcessedTransactions {
 		for i, sco := range upt.Transaction.SiacoinOutputs {
-			// Determine if the output belongs to the wallet.
-			_, exists := tb.wallet.keys[sco.UnlockHash]
-			if !exists {
+			// Determine if the output belongs to the wallet and can be
+			// spent by the builder's account.
+			if !tb.wallet.ownedBy(sco.UnlockHash, tb.account) {
 				continue
 			}
 			so.ids = append(so.ids, upt.Transaction.SiacoinOutputID(uint64(i)))
@@ -199,7 +205,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 
 	// Create and add the output that will be used to fund the standard
 	// transaction.
-	parentUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+	parentUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 	if err != nil {
 		return err
 	}
@@ -212,7 +218,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 
 	// Create a refund output if needed.
 	if !amount.Equals(fund) {
-		refundUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+		refundUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 		if err != nil {
 			return err
 		}
@@ -282,6 +288,9 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 		} else if err := encoding.Unmarshal(sfoBytes, &sfo); err != nil {
 			return err
 		}
+		if !tb.wallet.ownedBy(sfo.UnlockHash, tb.account) {
+			continue
+		}
 
 		// Check that this output has not recently been spent by the wallet.
 		spendHeight, err := dbGetSpentOutput(tb.wallet.dbTx, types.OutputID(sfoid))
@@ -304,7 +313,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 		}
 
 		// Add a siafund input for this output.
-		parentClaimUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+		parentClaimUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 		if err != nil {
 			return err
 		}
@@ -332,7 +341,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 
 	// Create and add the output that will be used to fund the standard
 	// transaction.
-	parentUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+	parentUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 	if err != nil {
 		return err
 	}
@@ -344,7 +353,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 
 	// Create a refund output if needed.
 	if !amount.Equals(fund) {
-		refundUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+		refundUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 		if err != nil {
 			return err
 		}
@@ -361,7 +370,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 	}
 
 	// Add the exact output.
-	claimUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+	claimUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 	if err != nil {
 		return err
 	}
@@ -103,6 +103,18 @@ func (w *Wallet) Transaction(txid types.TransactionID) (pt modules.ProcessedTran
 	return
 }
 
+// ReorgedTransactions returns the most recent wallet transactions that were
+// reverted by a reorg and not confirmed again by the same reorg, oldest first.
+func (w *Wallet) ReorgedTransactions() ([]modules.ReorgedTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return append([]modules.ReorgedTransaction(nil), w.reorgedTransactions...), nil
+}
+
 // Transactions returns all transactions relevant to the wallet that were
 // confirmed in the range [startHeight, endHeight].
 func (w *Wallet) Transactions(startHeight, endHeight types.BlockHeight) (pts []modules.ProcessedTransaction, err error) {
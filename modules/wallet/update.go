@@ -78,7 +78,8 @@ func (w *Wallet) isWalletAddress(uh types.UnlockHash) bool {
 }
 
 // updateLookahead uses a consensus change to update the seed progress if one of the outputs
-// contains an unlock hash of the lookahead set. Returns true if a blockchain rescan is required
+// contains an unlock hash of the lookahead set. The lookaheads of the wallet's accounts are
+// updated as well. Returns true if a blockchain rescan is required
 func (w *Wallet) updateLookahead(tx *bolt.Tx, cc modules.ConsensusChange) (bool, error) {
 	var largestIndex uint64
 	for _, diff := range cc.SiacoinOutputDiffs {
@@ -95,11 +96,20 @@ func (w *Wallet) updateLookahead(tx *bolt.Tx, cc modules.ConsensusChange) (bool,
 			}
 		}
 	}
+	needRescan := false
 	if largestIndex > 0 {
-		return w.advanceSeedLookahead(largestIndex)
+		rescan, err := w.advanceSeedLookahead(largestIndex)
+		if err != nil {
+			return false, err
+		}
+		needRescan = rescan
 	}
 
-	return false, nil
+	rescan, err := w.updateAccountLookaheads(tx, cc)
+	if err != nil {
+		return false, err
+	}
+	return needRescan || rescan, nil
 }
 
 // updateConfirmedSet uses a consensus change to update the confirmed set of
@@ -158,6 +168,27 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 	return nil
 }
 
+// recordReorgedTransactions adds the wallet transactions that were reverted by
+// cc, and that cc doesn't apply again, to the reorged transactions.
+func (w *Wallet) recordReorgedTransactions(cc modules.ConsensusChange) {
+	staged := make(map[types.TransactionID]struct{}, len(w.stagedReverted))
+	for _, txid := range w.stagedReverted {
+		staged[txid] = struct{}{}
+	}
+	w.stagedReverted = nil
+	if len(staged) == 0 {
+		return
+	}
+	rts := cc.ReorgedTransactions(func(txn types.Transaction) bool {
+		_, exists := staged[txn.ID()]
+		return exists
+	})
+	w.reorgedTransactions = append(w.reorgedTransactions, rts...)
+	if len(w.reorgedTransactions) > maxReorgedTransactions {
+		w.reorgedTransactions = w.reorgedTransactions[len(w.reorgedTransactions)-maxReorgedTransactions:]
+	}
+}
+
 // revertHistory reverts any transaction history that was destroyed by reverted
 // blocks in the consensus change.
 func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
@@ -177,6 +208,8 @@ func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
 					w.log.Severe("Could not revert transaction:", err)
 					return err
 				}
+				w.stagePaymentEvents(modules.WalletEventReverted, pt)
+				w.stagedReverted = append(w.stagedReverted, txid)
 			}
 		}
 
@@ -425,6 +458,7 @@ func (w *Wallet) applyHistory(tx *bolt.Tx, cc modules.ConsensusChange) error {
 			if err != nil {
 				return errors.AddContext(err, "could not put processed transaction")
 			}
+			w.stagePaymentEvents(modules.WalletEventConfirmed, pt)
 		}
 	}
 
@@ -456,6 +490,13 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.log.Severe("ERROR: failed to revert consensus change:", err)
 		w.dbRollback = true
 	}
+	// A reorg lowers the height that events have been created for, so that
+	// payments in the new blocks create events again.
+	if len(cc.RevertedBlocks) > 0 {
+		if height, err := dbGetConsensusHeight(w.dbTx); err == nil && height < w.eventHeight {
+			w.eventHeight = height
+		}
+	}
 	if err := w.applyHistory(w.dbTx, cc); err != nil {
 		w.log.Severe("ERROR: failed to apply consensus change:", err)
 		w.dbRollback = true
@@ -464,6 +505,15 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.log.Severe("ERROR: failed to update consensus change ID:", err)
 		w.dbRollback = true
 	}
+	w.recordReorgedTransactions(cc)
+
+	// Publish the payment events of the consensus change.
+	if height, err := dbGetConsensusHeight(w.dbTx); err != nil {
+		w.log.Severe("ERROR: failed to get consensus height:", err)
+		w.stagedEvents = nil
+	} else {
+		w.publishStagedEvents(cc.Synced && !w.dbRollback, height)
+	}
 
 	if cc.Synced {
 		go w.threadedDefragWallet()
@@ -582,4 +632,5 @@ func (w *Wallet) ReceiveUpdatedUnconfirmedTransactions(diff *modules.Transaction
 			w.unconfirmedProcessedTransactions = append(w.unconfirmedProcessedTransactions, pt)
 		}
 	}
+	w.publishUnconfirmedEvents()
 }
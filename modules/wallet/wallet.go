@@ -75,6 +75,13 @@ type Wallet struct {
 	keys      map[types.UnlockHash]spendableKey
 	lookahead map[types.UnlockHash]uint64
 
+	// accounts contains the named accounts of the wallet, and accountKeys
+	// maps every generated account address to the name of its account.
+	// Account keys are also stored in keys, so that the rest of the wallet
+	// can track and sign for them like any other key.
+	accounts    map[string]*account
+	accountKeys map[types.UnlockHash]string
+
 	// unconfirmedProcessedTransactions tracks unconfirmed transactions.
 	//
 	// TODO: Replace this field with a linked list. Currently when a new
@@ -84,6 +91,34 @@ type Wallet struct {
 	unconfirmedSets                  map[modules.TransactionSetID][]types.TransactionID
 	unconfirmedProcessedTransactions []modules.ProcessedTransaction
 
+	// The following fields implement the payment notifications of the
+	// wallet. events is the log of the most recent events, and eventNotify
+	// is closed and replaced whenever a new event is added to the log.
+	// Confirmed payments are tracked in eventPayments until they reached the
+	// largest number of confirmations that any webhook is waiting for.
+	// Events of consensus changes are staged in stagedEvents until the whole
+	// change has been processed. eventHeight is the largest height that
+	// events have been created for, which prevents rescans from repeating
+	// old events. eventUnconfirmed contains the unconfirmed transactions that
+	// events have been created for. The event log, the tracked payments and
+	// nextEventID are stored in the database, so that event IDs keep
+	// increasing across restarts.
+	events           []modules.WalletEvent
+	eventHeight      types.BlockHeight
+	eventNotify      chan struct{}
+	eventPayments    []*eventPayment
+	eventUnconfirmed map[types.TransactionID]struct{}
+	nextEventID      uint64
+	stagedEvents     []modules.WalletEvent
+	webhooks         map[string]webhookPersist
+
+	// reorgedTransactions contains the most recent wallet transactions that
+	// were reverted by a reorg. The wallet transactions reverted by a
+	// consensus change are staged in stagedReverted until the whole change
+	// has been processed.
+	reorgedTransactions []modules.ReorgedTransaction
+	stagedReverted      []types.TransactionID
+
 	// The wallet's database tracks its seeds, keys, outputs, and
 	// transactions. A global db transaction is maintained in memory to avoid
 	// excessive disk writes. Any operations involving dbTx must hold an
@@ -111,6 +146,10 @@ type Wallet struct {
 	// defragDisabled determines if the wallet is set to defrag outputs once it
 	// reaches a certain threshold
 	defragDisabled bool
+
+	// feeTarget is the confirmation target that the fees of the wallet's
+	// transactions are estimated for.
+	feeTarget types.BlockHeight
 }
 
 // Height return the internal processed consensus height of the wallet
@@ -159,8 +198,17 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 		keys:      make(map[types.UnlockHash]spendableKey),
 		lookahead: make(map[types.UnlockHash]uint64),
 
+		accounts:    make(map[string]*account),
+		accountKeys: make(map[types.UnlockHash]string),
+
 		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
 
+		eventNotify:      make(chan struct{}),
+		eventUnconfirmed: make(map[types.TransactionID]struct{}),
+		nextEventID:      1,
+		webhooks:         make(map[string]webhookPersist),
+
+		feeTarget:  modules.FeeTargetDefault,
 		persistDir: persistDir,
 
 		deps: deps,
@@ -240,8 +288,11 @@ func (w *Wallet) Settings() (modules.WalletSettings, error) {
 		return modules.WalletSettings{}, modules.ErrWalletShutdown
 	}
 	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
 	return modules.WalletSettings{
-		NoDefrag: w.defragDisabled,
+		NoDefrag:  w.defragDisabled,
+		FeeTarget: w.feeTarget,
 	}, nil
 }
 
@@ -254,6 +305,10 @@ func (w *Wallet) SetSettings(s modules.WalletSettings) error {
 
 	w.mu.Lock()
 	w.defragDisabled = s.NoDefrag
+	w.feeTarget = s.FeeTarget
+	if w.feeTarget == 0 {
+		w.feeTarget = modules.FeeTargetDefault
+	}
 	w.mu.Unlock()
 	return nil
 }
@@ -642,3 +642,39 @@ func TestDistantWallets(t *testing.T) {
 		t.Fatal("wallet should not recognize coins sent to very high seed index")
 	}
 }
+
+// TestWalletFeeTarget checks that the wallet estimates its fees for the
+// confirmation target of its settings.
+func TestWalletFeeTarget(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	if s, err := wt.wallet.Settings(); err != nil || s.FeeTarget != modules.FeeTargetDefault {
+		t.Fatal("expected default fee target, got", s.FeeTarget, err)
+	}
+	if err := wt.wallet.SetSettings(modules.WalletSettings{FeeTarget: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := wt.wallet.Settings(); err != nil || s.FeeTarget != 5 {
+		t.Fatal("expected fee target 5, got", s.FeeTarget, err)
+	}
+	min, max := wt.wallet.managedFeeEstimation()
+	tpoolMin, tpoolMax := wt.tpool.FeeEstimationTarget(5)
+	if !min.Equals(tpoolMin) || !max.Equals(tpoolMax) {
+		t.Fatal("wallet doesn't estimate fees for its fee target")
+	}
+
+	// A zero target resets the target to the default.
+	if err := wt.wallet.SetSettings(modules.WalletSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := wt.wallet.Settings(); err != nil || s.FeeTarget != modules.FeeTargetDefault {
+		t.Fatal("expected default fee target, got", s.FeeTarget, err)
+	}
+}
@@ -3,30 +3,136 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/acejam/Sia/build"
 	"github.com/acejam/Sia/modules"
 )
 
+// The codes of the errors returned by the API. The codes are stable and can be
+// matched by clients, unlike the messages of the errors.
+const (
+	// ErrCodeInternal indicates that the daemon failed for a reason unrelated
+	// to the request.
+	ErrCodeInternal ErrorCode = "internal"
+
+	// ErrCodeInvalidParameter indicates that a parameter of the request could
+	// not be parsed or has an invalid value.
+	ErrCodeInvalidParameter ErrorCode = "invalid_parameter"
+
+	// ErrCodeMissingParameter indicates that a required parameter of the
+	// request was not provided.
+	ErrCodeMissingParameter ErrorCode = "missing_parameter"
+
+	// ErrCodeModuleFailure indicates that the request was valid, but the
+	// module rejected it or failed to perform it.
+	ErrCodeModuleFailure ErrorCode = "module_failure"
+
+	// ErrCodeNotFound indicates that the requested object does not exist.
+	ErrCodeNotFound ErrorCode = "not_found"
+
+	// ErrCodeUnauthorized indicates that the request failed to authenticate.
+	ErrCodeUnauthorized ErrorCode = "unauthorized"
+
+	// ErrCodeForbidden indicates that the request is not allowed to call the
+	// route.
+	ErrCodeForbidden ErrorCode = "forbidden"
+
+	// ErrCodeUnavailable indicates that the daemon is not ready to serve the
+	// request yet.
+	ErrCodeUnavailable ErrorCode = "unavailable"
+
+	// ErrCodeUnknownRoute indicates that the requested route does not exist.
+	ErrCodeUnknownRoute ErrorCode = "unknown_route"
+)
+
+const (
+	// APIVersion is the latest version of the API. Routes prefixed with
+	// /v2 are served with the latest version, all other routes with version
+	// 1.
+	APIVersion = 2
+
+	// APIVersionHeader is the header of a response that contains the version
+	// of the API that served the request. Daemons that predate version 2 of
+	// the API don't set it.
+	APIVersionHeader = "Sia-API-Version"
+
+	// apiVersionPrefix is the path prefix of the routes of the latest version.
+	apiVersionPrefix = "/v2"
+)
+
+var (
+	// The errors that clients can match the errors returned by the API
+	// against. Only the codes of the errors are compared, see Error.Is.
+	ErrInternal         = Error{Code: ErrCodeInternal}
+	ErrInvalidParameter = Error{Code: ErrCodeInvalidParameter}
+	ErrMissingParameter = Error{Code: ErrCodeMissingParameter}
+	ErrModuleFailure    = Error{Code: ErrCodeModuleFailure}
+	ErrNotFound         = Error{Code: ErrCodeNotFound}
+	ErrUnauthorized     = Error{Code: ErrCodeUnauthorized}
+	ErrForbidden        = Error{Code: ErrCodeForbidden}
+	ErrUnavailable      = Error{Code: ErrCodeUnavailable}
+	ErrUnknownRoute     = Error{Code: ErrCodeUnknownRoute}
+)
+
+// ErrorCode is a machine-readable code that identifies the kind of an Error.
+type ErrorCode string
+
 // Error is a type that is encoded as JSON and returned in an API response in
-// the event of an error. Only the Message field is required. More fields may
-// be added to this struct in the future for better error reporting.
+// the event of an error. Only the Message field is required. Version 1 of the
+// API only returns the Message field.
 type Error struct {
 	// Message describes the error in English. Typically it is set to
 	// `err.Error()`. This field is required.
 	Message string `json:"message"`
 
-	// TODO: add a Param field with the (omitempty option in the json tag)
-	// to indicate that the error was caused by an invalid, missing, or
-	// incorrect parameter. This is not trivial as the API does not
-	// currently do parameter validation itself. For example, the
-	// /gateway/connect endpoint relies on the gateway.Connect method to
-	// validate the netaddress. However, this prevents the API from knowing
-	// whether an error returned by gateway.Connect is because of a
-	// connection error or an invalid netaddress parameter. Validating
-	// parameters in the API is not sufficient, as a parameter's value may
-	// be valid or invalid depending on the current state of a module.
+	// Code identifies the kind of the error. If it is not set, it is derived
+	// from the HTTP status of the response.
+	Code ErrorCode `json:"code,omitempty"`
+
+	// Param is the name of the parameter that caused the error, if the error
+	// was caused by an invalid or missing parameter.
+	Param string `json:"param,omitempty"`
+}
+
+// HTTPStatus returns the HTTP status of the responses that contain an error
+// with the code.
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case ErrCodeInvalidParameter, ErrCodeMissingParameter:
+		return http.StatusBadRequest
+	case ErrCodeUnauthorized:
+		return http.StatusUnauthorized
+	case ErrCodeForbidden:
+		return http.StatusForbidden
+	case ErrCodeNotFound, ErrCodeUnknownRoute:
+		return http.StatusNotFound
+	case ErrCodeUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorCodeForStatus returns the code of the errors that are written with the
+// given HTTP status and without a code.
+func errorCodeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrCodeInvalidParameter
+	case http.StatusUnauthorized:
+		return ErrCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrCodeForbidden
+	case http.StatusNotFound:
+		return ErrCodeNotFound
+	case http.StatusServiceUnavailable:
+		return ErrCodeUnavailable
+	default:
+		return ErrCodeInternal
+	}
 }
 
 // Error implements the error interface for the Error type. It returns only the
@@ -35,6 +141,14 @@ func (err Error) Error() string {
 	return err.Message
 }
 
+// Is returns true if target is an Error with the same code as err. It allows
+// errors returned by the API to be matched against ErrNotFound,
+// ErrInvalidParameter etc. with errors.Is.
+func (err Error) Is(target error) bool {
+	t, ok := target.(Error)
+	return ok && t.Code != "" && t.Code == err.Code
+}
+
 // HttpGET is a utility function for making http get requests to sia with a
 // whitelisted user-agent. A non-2xx response does not return an error.
 func HttpGET(url string) (resp *http.Response, err error) {
@@ -97,7 +211,9 @@ type API struct {
 	tpool    modules.TransactionPool
 	wallet   modules.Wallet
 
+	tokens *TokenStore
 	router http.Handler
+	routes []string
 }
 
 // api.ServeHTTP implements the http.Handler interface.
@@ -105,10 +221,17 @@ func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	api.router.ServeHTTP(w, r)
 }
 
+// Routes returns the routes registered by the API in the form "METHOD /path",
+// e.g. "GET /renter/file/*siapath".
+func (api *API) Routes() []string {
+	return append([]string(nil), api.routes...)
+}
+
 // New creates a new Sia API from the provided modules.  The API will require
 // authentication using HTTP basic auth for certain endpoints of the supplied
-// password is not the empty string.  Usernames are ignored for authentication.
-func New(requiredUserAgent string, requiredPassword string, cs modules.ConsensusSet, e modules.Explorer, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) *API {
+// password is not the empty string or if tokens contains API tokens.
+// Usernames are ignored for authentication. tokens may be nil.
+func New(requiredUserAgent string, requiredPassword string, tokens *TokenStore, cs modules.ConsensusSet, e modules.Explorer, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) *API {
 	api := &API{
 		cs:       cs,
 		explorer: e,
@@ -118,6 +241,8 @@ func New(requiredUserAgent string, requiredPassword string, cs modules.Consensus
 		renter:   r,
 		tpool:    tp,
 		wallet:   w,
+
+		tokens: tokens,
 	}
 
 	// Register API handlers
@@ -128,11 +253,45 @@ func New(requiredUserAgent string, requiredPassword string, cs modules.Consensus
 
 // UnrecognizedCallHandler handles calls to unknown pages (404).
 func UnrecognizedCallHandler(w http.ResponseWriter, req *http.Request) {
-	WriteError(w, Error{"404 - Refer to API.md"}, http.StatusNotFound)
+	WriteError(w, Error{Message: "404 - Refer to API.md", Code: ErrCodeUnknownRoute}, http.StatusNotFound)
+}
+
+// APIVersionHandler is middleware that serves the routes prefixed with /v2
+// with the latest version of the API by removing the prefix. The version that
+// serves a request is set in the Sia-API-Version header of the response.
+func APIVersionHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, apiVersionPrefix+"/") {
+			r := new(http.Request)
+			*r = *req
+			r.URL = new(url.URL)
+			*r.URL = *req.URL
+			r.URL.Path = strings.TrimPrefix(req.URL.Path, apiVersionPrefix)
+			r.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, apiVersionPrefix)
+			req = r
+			w.Header().Set(APIVersionHeader, strconv.Itoa(APIVersion))
+		} else if w.Header().Get(APIVersionHeader) == "" {
+			// The header is already set if the prefix was removed by an
+			// outer handler.
+			w.Header().Set(APIVersionHeader, "1")
+		}
+		h.ServeHTTP(w, req)
+	})
 }
 
-// WriteError an error to the API caller.
+// WriteError an error to the API caller. If the request is served by the
+// latest version of the API, the HTTP status is derived from the code of the
+// error, and code is only used if the error has no code. Version 1 of the API
+// uses code as the HTTP status and only returns the message of the error.
 func WriteError(w http.ResponseWriter, err Error, code int) {
+	if w.Header().Get(APIVersionHeader) == strconv.Itoa(APIVersion) {
+		if err.Code == "" {
+			err.Code = errorCodeForStatus(code)
+		}
+		code = err.Code.HTTPStatus()
+	} else {
+		err = Error{Message: err.Message}
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(code)
 	encodingErr := json.NewEncoder(w).Encode(err)
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestWriteError checks that the latest version of the API returns the code
+// and the parameter of an error with a status derived from the code, and that
+// version 1 only returns the message with the given status.
+func TestWriteError(t *testing.T) {
+	handler := APIVersionHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/foo" {
+			t.Error("prefix was not removed:", req.URL.Path)
+		}
+		WriteError(w, Error{Message: "bar", Code: ErrCodeNotFound, Param: "baz"}, http.StatusBadRequest)
+	}))
+	tests := []struct {
+		path    string
+		status  int
+		version string
+		err     Error
+	}{
+		{"/foo", http.StatusBadRequest, "1", Error{Message: "bar"}},
+		{"/v2/foo", http.StatusNotFound, "2", Error{Message: "bar", Code: ErrCodeNotFound, Param: "baz"}},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", test.path, nil))
+		if rec.Code != test.status {
+			t.Errorf("%v: expected status %v, got %v", test.path, test.status, rec.Code)
+		}
+		if v := rec.Header().Get(APIVersionHeader); v != test.version {
+			t.Errorf("%v: expected version %v, got %v", test.path, test.version, v)
+		}
+		var apiErr Error
+		if err := json.NewDecoder(rec.Body).Decode(&apiErr); err != nil {
+			t.Fatal(err)
+		}
+		if apiErr != test.err {
+			t.Errorf("%v: expected %+v, got %+v", test.path, test.err, apiErr)
+		}
+	}
+
+	// Errors without a code get the code of their status.
+	rec := httptest.NewRecorder()
+	rec.Header().Set(APIVersionHeader, "2")
+	WriteError(rec, Error{Message: "bar"}, http.StatusUnauthorized)
+	var apiErr Error
+	if err := json.NewDecoder(rec.Body).Decode(&apiErr); err != nil {
+		t.Fatal(err)
+	}
+	if apiErr.Code != ErrCodeUnauthorized || rec.Code != http.StatusUnauthorized {
+		t.Error("wrong code for status:", apiErr.Code, rec.Code)
+	}
+}
+
+// TestErrorIs checks that errors are matched by their code.
+func TestErrorIs(t *testing.T) {
+	err := error(Error{Message: "no such account", Code: ErrCodeNotFound})
+	if !errors.Is(err, ErrNotFound) {
+		t.Error("error does not match ErrNotFound")
+	}
+	if errors.Is(err, ErrInvalidParameter) {
+		t.Error("error matches ErrInvalidParameter")
+	}
+	if errors.Is(Error{Message: "foo"}, Error{Message: "bar"}) {
+		t.Error("errors without a code match")
+	}
+}
+
+// TestAPIVersion checks that the routes of the API are served under the /v2
+// prefix with typed errors.
+func TestAPIVersion(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// The routes are served under the prefix.
+	var cg ConsensusGET
+	if err := st.getAPI("/v2/consensus", &cg); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		call   string
+		status int
+		err    Error
+	}{
+		{"/consensus/blocks?height=foo", http.StatusBadRequest, Error{Message: "failed to parse block height"}},
+		{"/v2/consensus/blocks?height=foo", http.StatusBadRequest, Error{Message: "failed to parse block height", Code: ErrCodeInvalidParameter, Param: "height"}},
+		{"/v2/consensus/blocks?height=1000000", http.StatusNotFound, Error{Message: "block doesn't exist", Code: ErrCodeNotFound}},
+		{"/v2/foo", http.StatusNotFound, Error{Message: "404 - Refer to API.md", Code: ErrCodeUnknownRoute}},
+	}
+	for _, test := range tests {
+		resp, err := HttpGET("http://" + st.server.listener.Addr().String() + test.call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		apiErr := decodeError(resp)
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("%v: expected status %v, got %v", test.call, test.status, resp.StatusCode)
+		}
+		if apiErr != test.err {
+			t.Errorf("%v: expected %+v, got %+v", test.call, test.err, apiErr)
+		}
+	}
+}
@@ -7,12 +7,18 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/acejam/Sia/node/api"
 	"gitlab.com/NebulousLabs/errors"
 )
 
+// apiVersionPrefix is the path prefix of the routes of the latest version of
+// the API.
+var apiVersionPrefix = "/v" + strconv.Itoa(api.APIVersion)
+
 // A Client makes requests to the siad HTTP API.
 type Client struct {
 	// Address is the API address of the siad server.
@@ -34,9 +40,12 @@ func New(address string) *Client {
 }
 
 // NewRequest constructs a request to the siad HTTP API, setting the correct
-// User-Agent and Basic Auth. The resource path must begin with /.
+// User-Agent and Basic Auth. The resource path must begin with /. Requests are
+// made to the latest version of the API, so that the errors returned by the
+// API carry a code. Requests sent with the methods of Client fall back to the
+// unversioned route if the daemon predates the latest version.
 func (c *Client) NewRequest(method, resource string, body io.Reader) (*http.Request, error) {
-	url := "http://" + c.Address + resource
+	url := "http://" + c.Address + apiVersionPrefix + resource
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return nil, err
@@ -52,6 +61,32 @@ func (c *Client) NewRequest(method, resource string, body io.Reader) (*http.Requ
 	return req, nil
 }
 
+// do sends req to siad. Daemons that predate the latest version of the API
+// report its routes as unknown routes, without setting the version header of
+// the response. In that case the request is retried without the version
+// prefix, and the errors returned by the daemon carry no code.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	res, err := http.DefaultClient.Do(req)
+	if err != nil || res.StatusCode != http.StatusNotFound || res.Header.Get(api.APIVersionHeader) != "" || !strings.HasPrefix(req.URL.Path, apiVersionPrefix+"/") {
+		return res, err
+	}
+	drainAndClose(res.Body)
+
+	unversioned := new(http.Request)
+	*unversioned = *req
+	unversioned.URL = new(url.URL)
+	*unversioned.URL = *req.URL
+	unversioned.URL.Path = strings.TrimPrefix(req.URL.Path, apiVersionPrefix)
+	unversioned.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, apiVersionPrefix)
+	if req.GetBody != nil {
+		unversioned.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return http.DefaultClient.Do(unversioned)
+}
+
 // drainAndClose reads rc until EOF and then closes it. drainAndClose should
 // always be called on HTTP response bodies, because if the body is not fully
 // read, the underlying connection can't be reused.
@@ -60,10 +95,19 @@ func drainAndClose(rc io.ReadCloser) {
 	rc.Close()
 }
 
-// readAPIError decodes and returns an api.Error.
-func readAPIError(r io.Reader) error {
+// readAPIError decodes and returns the api.Error of a response. The error can
+// be matched against the errors of the api package, e.g. api.ErrNotFound,
+// with errors.Is. Calls to unknown routes return an error with the
+// api.ErrCodeUnknownRoute code.
+func readAPIError(res *http.Response, resource string) error {
 	var apiErr api.Error
-	if err := json.NewDecoder(r).Decode(&apiErr); err != nil {
+	err := json.NewDecoder(res.Body).Decode(&apiErr)
+	if res.StatusCode == http.StatusNotFound && (err != nil || apiErr.Code == "" || apiErr.Code == api.ErrCodeUnknownRoute) {
+		return api.Error{
+			Message: "API call not recognized: " + resource,
+			Code:    api.ErrCodeUnknownRoute,
+		}
+	} else if err != nil {
 		return errors.AddContext(err, "could not read error response")
 	}
 	return apiErr
@@ -76,20 +120,16 @@ func (c *Client) getRawResponse(resource string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, errors.AddContext(err, "request failed")
 	}
 	defer drainAndClose(res.Body)
 
-	if res.StatusCode == http.StatusNotFound {
-		return nil, errors.New("API call not recognized: " + resource)
-	}
-
 	// If the status code is not 2xx, decode and return the accompanying
 	// api.Error.
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		return nil, readAPIError(res.Body)
+		return nil, readAPIError(res, resource)
 	}
 
 	if res.StatusCode == http.StatusNoContent {
@@ -108,20 +148,16 @@ func (c *Client) getRawPartialResponse(resource string, from, to uint64) ([]byte
 	}
 	req.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", from, to))
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, errors.AddContext(err, "request failed")
 	}
 	defer drainAndClose(res.Body)
 
-	if res.StatusCode == http.StatusNotFound {
-		return nil, errors.New("API call not recognized: " + resource)
-	}
-
 	// If the status code is not 2xx, decode and return the accompanying
 	// api.Error.
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		return nil, readAPIError(res.Body)
+		return nil, readAPIError(res, resource)
 	}
 
 	if res.StatusCode == http.StatusNoContent {
@@ -153,6 +189,30 @@ func (c *Client) get(resource string, obj interface{}) error {
 	return nil
 }
 
+// getStatus requests the specified resource and decodes the response into obj
+// like get. Unlike get, a 503 Service Unavailable response is decoded into obj
+// as well, since the health and readiness routes describe why the daemon is
+// unavailable in their regular response.
+func (c *Client) getStatus(resource string, obj interface{}) error {
+	req, err := c.NewRequest("GET", resource, nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.do(req)
+	if err != nil {
+		return errors.AddContext(err, "request failed")
+	}
+	defer drainAndClose(res.Body)
+
+	if (res.StatusCode < 200 || res.StatusCode > 299) && res.StatusCode != http.StatusServiceUnavailable {
+		return readAPIError(res, resource)
+	}
+	if err := json.NewDecoder(res.Body).Decode(obj); err != nil {
+		return errors.AddContext(err, "could not read response")
+	}
+	return nil
+}
+
 // postRawResponse requests the specified resource. The response, if provided,
 // will be returned in a byte slice
 func (c *Client) postRawResponse(resource string, data string) ([]byte, error) {
@@ -162,20 +222,16 @@ func (c *Client) postRawResponse(resource string, data string) ([]byte, error) {
 	}
 	// TODO: is this necessary?
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, errors.AddContext(err, "request failed")
 	}
 	defer drainAndClose(res.Body)
 
-	if res.StatusCode == http.StatusNotFound {
-		return nil, errors.New("API call not recognized: " + resource)
-	}
-
 	// If the status code is not 2xx, decode and return the accompanying
 	// api.Error.
 	if res.StatusCode < 200 || res.StatusCode > 299 {
-		return nil, readAPIError(res.Body)
+		return nil, readAPIError(res, resource)
 	}
 
 	if res.StatusCode == http.StatusNoContent {
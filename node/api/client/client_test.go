@@ -0,0 +1,70 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/acejam/Sia/node/api"
+)
+
+// TestClientUnversionedFallback checks that the client retries requests
+// without the version prefix against a daemon that predates the latest version
+// of the API, and that the errors returned by such a daemon carry no code.
+func TestClientUnversionedFallback(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.Method+" "+req.URL.Path)
+		switch req.URL.Path {
+		case "/foo":
+			w.Write([]byte(`{"bar":"baz"}`))
+		case "/qux":
+			body, _ := ioutil.ReadAll(req.Body)
+			if string(body) != "quux=1" {
+				t.Error("request body was not sent again:", string(body))
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"qux failed"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"404 - Refer to API.md"}`))
+		}
+	}))
+	defer srv.Close()
+	c := New(srv.Listener.Addr().String())
+
+	// A successful request falls back to the unversioned route.
+	var obj struct {
+		Bar string `json:"bar"`
+	}
+	if err := c.get("/foo", &obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj.Bar != "baz" {
+		t.Fatal("wrong response:", obj)
+	}
+	if len(paths) != 2 || paths[0] != "GET /v2/foo" || paths[1] != "GET /foo" {
+		t.Fatal("request did not fall back to the unversioned route:", paths)
+	}
+
+	// An error of the unversioned route is returned without a code.
+	paths = nil
+	err := c.post("/qux", "quux=1", nil)
+	apiErr, ok := err.(api.Error)
+	if !ok {
+		t.Fatal("expected an api.Error, got", err)
+	}
+	if apiErr.Message != "qux failed" || apiErr.Code != "" {
+		t.Fatalf("wrong error: %+v", apiErr)
+	}
+	if len(paths) != 2 || paths[0] != "POST /v2/qux" || paths[1] != "POST /qux" {
+		t.Fatal("request did not fall back to the unversioned route:", paths)
+	}
+
+	// Unknown routes are still reported as such.
+	err = c.get("/corge", nil)
+	if apiErr, ok := err.(api.Error); !ok || apiErr.Code != api.ErrCodeUnknownRoute {
+		t.Fatal("expected an unknown route error, got", err)
+	}
+}
@@ -1,12 +1,45 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
+	"sync"
+	"time"
 
+	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/node/api"
 	"github.com/acejam/Sia/types"
+
+	"gitlab.com/NebulousLabs/errors"
 )
 
+// consensusSubscribeRetryInterval is the time that ConsensusSubscribe waits
+// before reconnecting after the stream of consensus changes was interrupted.
+var consensusSubscribeRetryInterval = 5 * time.Second
+
+// A ConsensusChangeStream is a stream of consensus changes returned by the
+// /consensus/subscribe api resource.
+type ConsensusChangeStream struct {
+	body      io.ReadCloser
+	dec       *json.Decoder
+	closeOnce sync.Once
+}
+
+// Next blocks until the next consensus change of the stream is received.
+func (s *ConsensusChangeStream) Next() (cc api.ConsensusChange, err error) {
+	err = s.dec.Decode(&cc)
+	return
+}
+
+// Close closes the stream.
+func (s *ConsensusChangeStream) Close() (err error) {
+	s.closeOnce.Do(func() { err = s.body.Close() })
+	return
+}
+
 // ConsensusGet requests the /consensus api resource
 func (c *Client) ConsensusGet() (cg api.ConsensusGET, err error) {
 	err = c.get("/consensus", &cg)
@@ -24,3 +57,143 @@ func (c *Client) ConsensusBlocksHeightGet(height types.BlockHeight) (cbg api.Con
 	err = c.get("/consensus/blocks?height="+fmt.Sprint(height), &cbg)
 	return
 }
+
+// ConsensusDelayedOutputsGet requests the /consensus/delayedoutputs/:height
+// api resource
+func (c *Client) ConsensusDelayedOutputsGet(height types.BlockHeight) (cdg api.ConsensusDelayedOutputsGET, err error) {
+	err = c.get(fmt.Sprintf("/consensus/delayedoutputs/%v", height), &cdg)
+	return
+}
+
+// ConsensusFileContractGet requests the /consensus/filecontracts/:id api
+// resource
+func (c *Client) ConsensusFileContractGet(id types.FileContractID) (cfg api.ConsensusFileContractGET, err error) {
+	err = c.get("/consensus/filecontracts/"+id.String(), &cfg)
+	return
+}
+
+// ConsensusSiacoinOutputGet requests the /consensus/siacoinoutputs/:id api
+// resource
+func (c *Client) ConsensusSiacoinOutputGet(id types.SiacoinOutputID) (csg api.ConsensusBlocksGetSiacoinOutput, err error) {
+	err = c.get("/consensus/siacoinoutputs/"+id.String(), &csg)
+	return
+}
+
+// ConsensusSiafundOutputGet requests the /consensus/siafundoutputs/:id api
+// resource
+func (c *Client) ConsensusSiafundOutputGet(id types.SiafundOutputID) (csg api.ConsensusSiafundOutputGET, err error) {
+	err = c.get("/consensus/siafundoutputs/"+id.String(), &csg)
+	return
+}
+
+// ConsensusReorgsGet requests the /consensus/reorgs api resource
+func (c *Client) ConsensusReorgsGet() (crg api.ConsensusReorgsGET, err error) {
+	err = c.get("/consensus/reorgs", &crg)
+	return
+}
+
+// ConsensusSiafundPoolGet requests the /consensus/siafundpool api resource
+func (c *Client) ConsensusSiafundPoolGet() (csg api.ConsensusSiafundPoolGET, err error) {
+	err = c.get("/consensus/siafundpool", &csg)
+	return
+}
+
+// ConsensusSnapshotExportPost uses the /consensus/snapshot/export endpoint to
+// write a snapshot of the consensus set at the given height to destination.
+func (c *Client) ConsensusSnapshotExportPost(height types.BlockHeight, destination string) (csp api.ConsensusSnapshotPOST, err error) {
+	values := url.Values{}
+	values.Set("height", fmt.Sprint(height))
+	values.Set("destination", destination)
+	err = c.post("/consensus/snapshot/export", values.Encode(), &csp)
+	return
+}
+
+// ConsensusSnapshotImportPost uses the /consensus/snapshot/import endpoint to
+// bootstrap the consensus set from the snapshot at source. An empty checksum
+// is not checked.
+func (c *Client) ConsensusSnapshotImportPost(source string, id types.BlockID, checksum crypto.Hash) (csp api.ConsensusSnapshotPOST, err error) {
+	values := url.Values{}
+	values.Set("source", source)
+	values.Set("blockid", id.String())
+	if checksum != (crypto.Hash{}) {
+		values.Set("checksum", checksum.String())
+	}
+	err = c.post("/consensus/snapshot/import", values.Encode(), &csp)
+	return
+}
+
+// ConsensusSubscribeGet requests the /consensus/subscribe api resource and
+// returns the stream of consensus changes following the change with the given
+// id. The stream must be closed by the caller.
+func (c *Client) ConsensusSubscribeGet(start modules.ConsensusChangeID) (*ConsensusChangeStream, error) {
+	values := url.Values{}
+	switch start {
+	case modules.ConsensusChangeBeginning:
+	case modules.ConsensusChangeRecent:
+		values.Set("changeid", "recent")
+	default:
+		values.Set("changeid", crypto.Hash(start).String())
+	}
+	req, err := c.NewRequest("GET", "/consensus/subscribe?"+values.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(req)
+	if err != nil {
+		return nil, errors.AddContext(err, "request failed")
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer drainAndClose(res.Body)
+		return nil, readAPIError(res, "/consensus/subscribe")
+	}
+	return &ConsensusChangeStream{
+		body: res.Body,
+		dec:  json.NewDecoder(res.Body),
+	}, nil
+}
+
+// ConsensusSubscribe calls process for every consensus change following the
+// change with the given id. If the stream is interrupted, ConsensusSubscribe
+// reconnects and resumes from the last change that was processed.
+// ConsensusSubscribe returns when cancel is closed, when process returns an
+// error, or when the siad server rejects the subscription.
+func (c *Client) ConsensusSubscribe(start modules.ConsensusChangeID, process func(api.ConsensusChange) error, cancel <-chan struct{}) error {
+	for {
+		stream, err := c.ConsensusSubscribeGet(start)
+		if _, rejected := err.(api.Error); rejected {
+			return err
+		}
+		if err == nil {
+			// Close the stream when the subscription is cancelled, so that
+			// Next returns.
+			done := make(chan struct{})
+			go func() {
+				select {
+				case <-cancel:
+					stream.Close()
+				case <-done:
+				}
+			}()
+			for {
+				cc, err := stream.Next()
+				if err != nil {
+					break
+				}
+				if err := process(cc); err != nil {
+					close(done)
+					stream.Close()
+					return err
+				}
+				start = modules.ConsensusChangeID(cc.ID)
+			}
+			close(done)
+			stream.Close()
+		}
+
+		select {
+		case <-cancel:
+			return nil
+		case <-time.After(consensusSubscribeRetryInterval):
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package client
 
-import "github.com/acejam/Sia/node/api"
+import (
+	"net/url"
+	"strings"
+
+	"github.com/acejam/Sia/node/api"
+	"github.com/acejam/Sia/persist"
+)
 
 // DaemonVersionGet requests the /daemon/version resource
 func (c *Client) DaemonVersionGet() (dvg api.DaemonVersionGet, err error) {
@@ -25,3 +31,126 @@ func (c *Client) DaemonUpdatePost() (err error) {
 	err = c.post("/daemon/update", "", nil)
 	return
 }
+
+// DaemonTokensGet lists the API tokens of the daemon.
+func (c *Client) DaemonTokensGet() (dtg api.DaemonTokensGET, err error) {
+	err = c.get("/daemon/tokens", &dtg)
+	return
+}
+
+// DaemonTokensAddPost uses the /daemon/tokens endpoint to create an API token
+// with the given name and scopes.
+func (c *Client) DaemonTokensAddPost(name string, scopes []api.APIScope) (dtp api.DaemonTokensPOST, err error) {
+	strs := make([]string, len(scopes))
+	for i, s := range scopes {
+		strs[i] = string(s)
+	}
+	values := url.Values{}
+	values.Set("action", "add")
+	values.Set("name", name)
+	values.Set("scopes", strings.Join(strs, ","))
+	err = c.post("/daemon/tokens", values.Encode(), &dtp)
+	return
+}
+
+// DaemonTokensRemovePost uses the /daemon/tokens endpoint to remove the API
+// token with the given name.
+func (c *Client) DaemonTokensRemovePost(name string) (err error) {
+	values := url.Values{}
+	values.Set("action", "remove")
+	values.Set("name", name)
+	err = c.post("/daemon/tokens", values.Encode(), nil)
+	return
+}
+
+// DaemonLogLevelGet requests the /daemon/loglevel resource.
+func (c *Client) DaemonLogLevelGet() (dlg api.DaemonLogLevelGET, err error) {
+	err = c.get("/daemon/loglevel", &dlg)
+	return
+}
+
+// DaemonLogLevelPost uses the /daemon/loglevel endpoint to set the log level
+// of a module.
+func (c *Client) DaemonLogLevelPost(module string, level persist.LogLevel) (err error) {
+	values := url.Values{}
+	values.Set("module", module)
+	values.Set("level", level.String())
+	err = c.post("/daemon/loglevel", values.Encode(), nil)
+	return
+}
+
+// DaemonOpenAPIGet requests the OpenAPI specification of the API from the
+// /daemon/openapi resource.
+func (c *Client) DaemonOpenAPIGet() (spec api.OpenAPISpec, err error) {
+	err = c.get("/daemon/openapi", &spec)
+	return
+}
+
+// DaemonConfigGet requests the effective configuration of the daemon from the
+// /daemon/config resource.
+func (c *Client) DaemonConfigGet() (dcg api.DaemonConfigGET, err error) {
+	err = c.get("/daemon/config", &dcg)
+	return
+}
+
+// DaemonReloadPost uses the /daemon/reload endpoint to reload the config file
+// of the daemon.
+func (c *Client) DaemonReloadPost() (drp api.DaemonReloadPOST, err error) {
+	err = c.post("/daemon/reload", "", &drp)
+	return
+}
+
+// DaemonHealthGet requests the /daemon/health resource. The response is
+// returned while the daemon is loading as well.
+func (c *Client) DaemonHealthGet() (dhg api.DaemonHealthGET, err error) {
+	err = c.getStatus("/daemon/health", &dhg)
+	return
+}
+
+// DaemonReadyGet requests the readiness of the given modules from the
+// /daemon/ready resource. If no modules are given, all loaded modules are
+// checked. A daemon that is not ready returns the response with Ready set to
+// false rather than an error.
+func (c *Client) DaemonReadyGet(modules ...string) (drg api.DaemonReadyGET, err error) {
+	resource := "/daemon/ready"
+	if len(modules) > 0 {
+		values := url.Values{}
+		values.Set("modules", strings.Join(modules, ","))
+		resource += "?" + values.Encode()
+	}
+	err = c.getStatus(resource, &drg)
+	return
+}
+
+// DaemonAlertsGet requests the alerts of the daemon from the /daemon/alerts
+// resource.
+func (c *Client) DaemonAlertsGet() (dag api.DaemonAlertsGET, err error) {
+	err = c.get("/daemon/alerts", &dag)
+	return
+}
+
+// DaemonEncryptionGet requests whether the persist files of the host and the
+// renter are encrypted and unlocked from the /daemon/encryption resource.
+func (c *Client) DaemonEncryptionGet() (deg api.DaemonEncryptionGET, err error) {
+	err = c.get("/daemon/encryption", &deg)
+	return
+}
+
+// DaemonEncryptionPost uses the /daemon/encryption endpoint to encrypt the
+// persist files of the host and the renter with a key derived from
+// passphrase.
+func (c *Client) DaemonEncryptionPost(passphrase string) (err error) {
+	values := url.Values{}
+	values.Set("passphrase", passphrase)
+	err = c.post("/daemon/encryption", values.Encode(), nil)
+	return
+}
+
+// DaemonUnlockPost uses the /daemon/unlock endpoint to unlock the encrypted
+// persist files of the host and the renter.
+func (c *Client) DaemonUnlockPost(passphrase string) (err error) {
+	values := url.Values{}
+	values.Set("passphrase", passphrase)
+	err = c.post("/daemon/unlock", values.Encode(), nil)
+	return
+}
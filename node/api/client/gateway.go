@@ -1,6 +1,10 @@
 package client
 
 import (
+	"net/url"
+	"strconv"
+	"time"
+
 	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/node/api"
 	"gitlab.com/NebulousLabs/errors"
@@ -35,3 +39,44 @@ func (c *Client) GatewayGet() (gwg api.GatewayGET, err error) {
 	err = c.get("/gateway", &gwg)
 	return
 }
+
+// GatewayRateLimitPost uses the /gateway endpoint to change the gateway's
+// bandwidth limits. A limit of 0 disables the limit.
+func (c *Client) GatewayRateLimitPost(downloadSpeed, uploadSpeed int64) (err error) {
+	values := url.Values{}
+	values.Set("maxdownloadspeed", strconv.FormatInt(downloadSpeed, 10))
+	values.Set("maxuploadspeed", strconv.FormatInt(uploadSpeed, 10))
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
+
+// GatewayBlocklistGet requests the /gateway/blocklist api resource
+func (c *Client) GatewayBlocklistGet() (gbg api.GatewayBlocklistGET, err error) {
+	err = c.get("/gateway/blocklist", &gbg)
+	return
+}
+
+// GatewayBlocklistAddPost uses the /gateway/blocklist endpoint to add an IP
+// address or CIDR subnet to the gateway's blocklist. A zero duration blocks the
+// address permanently.
+func (c *Client) GatewayBlocklistAddPost(address string, duration time.Duration, reason string) (err error) {
+	values := url.Values{}
+	values.Set("action", "add")
+	values.Set("address", address)
+	if duration > 0 {
+		values.Set("duration", duration.String())
+	}
+	values.Set("reason", reason)
+	err = c.post("/gateway/blocklist", values.Encode(), nil)
+	return
+}
+
+// GatewayBlocklistRemovePost uses the /gateway/blocklist endpoint to remove an
+// IP address or CIDR subnet from the gateway's blocklist.
+func (c *Client) GatewayBlocklistRemovePost(address string) (err error) {
+	values := url.Values{}
+	values.Set("action", "remove")
+	values.Set("address", address)
+	err = c.post("/gateway/blocklist", values.Encode(), nil)
+	return
+}
@@ -118,6 +118,12 @@ func (c *Client) HostStorageFoldersResizePost(path string, size uint64) (err err
 	return
 }
 
+// HostReorgsGet requests the /host/reorgs endpoint.
+func (c *Client) HostReorgsGet() (hrg api.HostReorgsGET, err error) {
+	err = c.get("/host/reorgs", &hrg)
+	return
+}
+
 // HostStorageGet requests the /host/storage endpoint.
 func (c *Client) HostStorageGet() (sg api.StorageGET, err error) {
 	err = c.get("/host/storage", &sg)
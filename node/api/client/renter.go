@@ -163,6 +163,12 @@ func (c *Client) RenterPricesGet() (rpg api.RenterPricesGET, err error) {
 	return
 }
 
+// RenterReorgsGet requests the /renter/reorgs endpoint.
+func (c *Client) RenterReorgsGet() (rrg api.RenterReorgsGET, err error) {
+	err = c.get("/renter/reorgs", &rrg)
+	return
+}
+
 // RenterPostRateLimit uses the /renter endpoint to change the renter's bandwidth rate
 // limit.
 func (c *Client) RenterPostRateLimit(readBPS, writeBPS int64) (err error) {
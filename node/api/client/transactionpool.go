@@ -2,18 +2,34 @@ package client
 
 import (
 	"net/url"
+	"strconv"
 
 	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/node/api"
 	"github.com/acejam/Sia/types"
 )
 
+// TransactionPoolGet uses the /tpool endpoint to get statistics about the
+// transaction pool.
+func (c *Client) TransactionPoolGet() (tg api.TpoolGET, err error) {
+	err = c.get("/tpool", &tg)
+	return
+}
+
 // TransactionPoolFeeGet uses the /tpool/fee endpoint to get a fee estimation.
 func (c *Client) TransactionPoolFeeGet() (tfg api.TpoolFeeGET, err error) {
 	err = c.get("/tpool/fee", &tfg)
 	return
 }
 
+// TransactionPoolFeeTargetGet uses the /tpool/fee endpoint to get a fee
+// estimation for confirmation within target blocks.
+func (c *Client) TransactionPoolFeeTargetGet(target types.BlockHeight) (tfg api.TpoolFeeGET, err error) {
+	err = c.get("/tpool/fee?target="+strconv.FormatUint(uint64(target), 10), &tfg)
+	return
+}
+
 // TransactionPoolRawPost uses the /tpool/raw endpoint to send a raw
 // transaction to the transaction pool.
 func (c *Client) TransactionPoolRawPost(txn types.Transaction, parents types.Transaction) (err error) {
@@ -23,3 +39,38 @@ func (c *Client) TransactionPoolRawPost(txn types.Transaction, parents types.Tra
 	err = c.post("/tpool/raw", values.Encode(), nil)
 	return
 }
+
+// TransactionPoolHistogramGet uses the /tpool/histogram endpoint to get the
+// fee-rate histogram of the transaction pool.
+func (c *Client) TransactionPoolHistogramGet() (thg api.TpoolHistogramGET, err error) {
+	err = c.get("/tpool/histogram", &thg)
+	return
+}
+
+// TransactionPoolSetGet uses the /tpool/sets/:id endpoint to get an
+// unconfirmed set and its transactions.
+func (c *Client) TransactionPoolSetGet(id modules.TransactionSetID) (tsg api.TpoolSetGET, err error) {
+	err = c.get("/tpool/sets/"+id.String(), &tsg)
+	return
+}
+
+// TransactionPoolSetsGet uses the /tpool/sets endpoint to get the unconfirmed
+// sets of the transaction pool.
+func (c *Client) TransactionPoolSetsGet() (tsg api.TpoolSetsGET, err error) {
+	err = c.get("/tpool/sets", &tsg)
+	return
+}
+
+// TransactionPoolTransactionsGet uses the /tpool/transactions endpoint to get
+// a page of the unconfirmed transactions. If addr is not the empty unlock
+// hash, only transactions related to addr are returned.
+func (c *Client) TransactionPoolTransactionsGet(addr types.UnlockHash, offset, limit int) (ttg api.TpoolTransactionsGET, err error) {
+	values := url.Values{}
+	if addr != (types.UnlockHash{}) {
+		values.Set("address", addr.String())
+	}
+	values.Set("offset", strconv.Itoa(offset))
+	values.Set("limit", strconv.Itoa(limit))
+	err = c.get("/tpool/transactions?"+values.Encode(), &ttg)
+	return
+}
@@ -5,11 +5,59 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/acejam/Sia/node/api"
 	"github.com/acejam/Sia/types"
 )
 
+// WalletAccountAddressGet requests a new address of an account from the
+// /wallet/accounts/:name/address endpoint.
+func (c *Client) WalletAccountAddressGet(name string) (wag api.WalletAddressGET, err error) {
+	err = c.get("/wallet/accounts/"+name+"/address", &wag)
+	return
+}
+
+// WalletAccountGet requests an account and its balances from the
+// /wallet/accounts/:name endpoint.
+func (c *Client) WalletAccountGet(name string) (wag api.WalletAccountGET, err error) {
+	err = c.get("/wallet/accounts/"+name, &wag)
+	return
+}
+
+// WalletAccountSiacoinsPost uses the /wallet/accounts/:name/siacoins endpoint
+// to send money from an account to a single address.
+func (c *Client) WalletAccountSiacoinsPost(name string, amount types.Currency, destination types.UnlockHash) (wsp api.WalletSiacoinsPOST, err error) {
+	values := url.Values{}
+	values.Set("amount", amount.String())
+	values.Set("destination", destination.String())
+	err = c.post("/wallet/accounts/"+name+"/siacoins", values.Encode(), &wsp)
+	return
+}
+
+// WalletAccountTransactionsGet requests the transactions of an account from
+// the /wallet/accounts/:name/transactions endpoint.
+func (c *Client) WalletAccountTransactionsGet(name string) (wtg api.WalletAccountTransactionsGET, err error) {
+	err = c.get("/wallet/accounts/"+name+"/transactions", &wtg)
+	return
+}
+
+// WalletAccountsGet requests the accounts of the wallet from the
+// /wallet/accounts endpoint.
+func (c *Client) WalletAccountsGet() (wag api.WalletAccountsGET, err error) {
+	err = c.get("/wallet/accounts", &wag)
+	return
+}
+
+// WalletAccountsPost uses the /wallet/accounts endpoint to create a new
+// account.
+func (c *Client) WalletAccountsPost(name string) (wag api.WalletAccountGET, err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	err = c.post("/wallet/accounts", values.Encode(), &wag)
+	return
+}
+
 // WalletAddressGet requests a new address from the /wallet/address endpoint
 func (c *Client) WalletAddressGet() (wag api.WalletAddressGET, err error) {
 	err = c.get("/wallet/address", &wag)
@@ -33,6 +81,17 @@ func (c *Client) WalletChangePasswordPost(currentPassword, newPassword string) (
 	return
 }
 
+// WalletEventsGet requests the wallet events with an ID greater than since
+// from the /wallet/events endpoint. If there are no such events, the call
+// blocks for up to timeout seconds until a new event occurs.
+func (c *Client) WalletEventsGet(since uint64, timeout uint64) (weg api.WalletEventsGET, err error) {
+	values := url.Values{}
+	values.Set("since", strconv.FormatUint(since, 10))
+	values.Set("timeout", strconv.FormatUint(timeout, 10))
+	err = c.get("/wallet/events?"+values.Encode(), &weg)
+	return
+}
+
 // WalletInitPost uses the /wallet/init endpoint to initialize and encrypt a
 // wallet
 func (c *Client) WalletInitPost(password string, force bool) (wip api.WalletInitPOST, err error) {
@@ -66,6 +125,12 @@ func (c *Client) WalletLockPost() (err error) {
 	return
 }
 
+// WalletReorgsGet requests the /wallet/reorgs endpoint.
+func (c *Client) WalletReorgsGet() (wrg api.WalletReorgsGET, err error) {
+	err = c.get("/wallet/reorgs", &wrg)
+	return
+}
+
 // WalletSeedPost uses the /wallet/seed endpoint to add a seed to the wallet's list
 // of seeds.
 func (c *Client) WalletSeedPost(seed, password string) (err error) {
@@ -168,3 +233,32 @@ func (c *Client) Wallet033xPost(path, password string) (err error) {
 	err = c.post("/wallet/033x", values.Encode(), nil)
 	return
 }
+
+// WalletWebhooksGet requests the registered webhooks from the
+// /wallet/webhooks endpoint.
+func (c *Client) WalletWebhooksGet() (wwg api.WalletWebhooksGET, err error) {
+	err = c.get("/wallet/webhooks", &wwg)
+	return
+}
+
+// WalletWebhooksPost uses the /wallet/webhooks endpoint to register a webhook.
+func (c *Client) WalletWebhooksPost(hookURL, secret string, confirmations types.BlockHeight, addresses []types.UnlockHash) (wwp api.WalletWebhooksPOST, err error) {
+	addrs := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		addrs = append(addrs, addr.String())
+	}
+	values := url.Values{}
+	values.Set("url", hookURL)
+	values.Set("secret", secret)
+	values.Set("confirmations", fmt.Sprint(confirmations))
+	values.Set("addresses", strings.Join(addrs, ","))
+	err = c.post("/wallet/webhooks", values.Encode(), &wwp)
+	return
+}
+
+// WalletWebhooksRemovePost uses the /wallet/webhooks/remove/:id endpoint to
+// remove a webhook.
+func (c *Client) WalletWebhooksRemovePost(id string) (err error) {
+	err = c.post("/wallet/webhooks/remove/"+id, "", nil)
+	return
+}
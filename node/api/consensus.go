@@ -1,16 +1,35 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/types"
 
 	"github.com/julienschmidt/httprouter"
 )
 
+const (
+	// consensusStreamBuffer is the number of consensus changes that are
+	// buffered for a client of /consensus/subscribe.
+	consensusStreamBuffer = 100
+
+	// consensusStreamKeepAlive is the interval at which an empty line is
+	// written to idle clients of /consensus/subscribe, so that the connection
+	// isn't closed by proxies and disconnected clients are detected.
+	consensusStreamKeepAlive = 30 * time.Second
+)
+
 // ConsensusGET contains general information about the consensus set, with tags
 // to support idiomatic json encodings.
 type ConsensusGET struct {
@@ -19,6 +38,55 @@ type ConsensusGET struct {
 	CurrentBlock types.BlockID     `json:"currentblock"`
 	Target       types.Target      `json:"target"`
 	Difficulty   types.Currency    `json:"difficulty"`
+
+	// Progress of header-first synchronization.
+	HeaderHeight   types.BlockHeight `json:"headerheight"`
+	BodiesInFlight int               `json:"bodiesinflight"`
+
+	// Pruning and disk usage of the consensus set.
+	PruneDepth   types.BlockHeight `json:"prunedepth"`
+	PrunedHeight types.BlockHeight `json:"prunedheight"`
+	DiskUsage    uint64            `json:"diskusage"`
+}
+
+// ConsensusChange is a change to the consensus set that is streamed by
+// /consensus/subscribe. It contains all fields of a modules.ConsensusChange
+// that can be encoded as JSON.
+type ConsensusChange struct {
+	ID                         crypto.Hash                        `json:"id"`
+	RevertedBlocks             []types.Block                      `json:"revertedblocks"`
+	AppliedBlocks              []types.Block                      `json:"appliedblocks"`
+	SiacoinOutputDiffs         []modules.SiacoinOutputDiff        `json:"siacoinoutputdiffs"`
+	FileContractDiffs          []modules.FileContractDiff         `json:"filecontractdiffs"`
+	SiafundOutputDiffs         []modules.SiafundOutputDiff        `json:"siafundoutputdiffs"`
+	DelayedSiacoinOutputDiffs  []modules.DelayedSiacoinOutputDiff `json:"delayedsiacoinoutputdiffs"`
+	SiafundPoolDiffs           []modules.SiafundPoolDiff          `json:"siafundpooldiffs"`
+	ChildTarget                types.Target                       `json:"childtarget"`
+	MinimumValidChildTimestamp types.Timestamp                    `json:"minimumvalidchildtimestamp"`
+	Synced                     bool                               `json:"synced"`
+}
+
+// consensusStream is the consensus set subscriber of a client of
+// /consensus/subscribe. The consensus set must not be blocked by slow clients,
+// so the changes are buffered. A full buffer stops the subscription while
+// catching up, and changes that don't fit into the buffer once the stream is
+// live close the overflow channel, so that the stream can catch up again from
+// the last change that was written to the client.
+type consensusStream struct {
+	changes  chan modules.ConsensusChange
+	cancel   chan struct{}
+	overflow chan struct{}
+
+	cancelOnce   sync.Once
+	overflowOnce sync.Once
+}
+
+// ConsensusSnapshotPOST contains information about a consensus snapshot that
+// was exported or imported.
+type ConsensusSnapshotPOST struct {
+	Height   types.BlockHeight `json:"height"`
+	BlockID  types.BlockID     `json:"blockid"`
+	Checksum crypto.Hash       `json:"checksum"`
 }
 
 // ConsensusHeadersGET contains information from a blocks header.
@@ -85,6 +153,76 @@ type ConsensusBlocksGetSiafundOutput struct {
 	UnlockHash types.UnlockHash      `json:"unlockhash"`
 }
 
+// ConsensusSiafundOutputGET contains all fields of an unspent
+// types.SiafundOutput and an additional ID field.
+type ConsensusSiafundOutputGET struct {
+	ID         types.SiafundOutputID `json:"id"`
+	Value      types.Currency        `json:"value"`
+	UnlockHash types.UnlockHash      `json:"unlockhash"`
+	ClaimStart types.Currency        `json:"claimstart"`
+}
+
+// ConsensusFileContractGET contains the most recent revision of an open file
+// contract, and the index of the segment that its storage proof has to prove
+// once the proof window has started.
+type ConsensusFileContractGET struct {
+	ConsensusBlocksGetFileContract
+	StorageProofSegment *uint64 `json:"storageproofsegment,omitempty"`
+}
+
+// ConsensusSiafundPoolGET contains the current value of the siafund pool.
+type ConsensusSiafundPoolGET struct {
+	SiafundPool types.Currency `json:"siafundpool"`
+}
+
+// ConsensusReorgsGET contains the most recent reorganizations of the
+// blockchain, oldest first.
+type ConsensusReorgsGET struct {
+	Reorgs []modules.ReorgEvent `json:"reorgs"`
+}
+
+// ConsensusDelayedOutputsGET contains the delayed siacoin outputs that mature
+// at a given height.
+type ConsensusDelayedOutputsGET struct {
+	MaturityHeight        types.BlockHeight                 `json:"maturityheight"`
+	DelayedSiacoinOutputs []ConsensusBlocksGetSiacoinOutput `json:"delayedsiacoinoutputs"`
+}
+
+// consensusFileContract is a helper method that uses a types.FileContract and
+// its ID to create a ConsensusBlocksGetFileContract object.
+func consensusFileContract(fcid types.FileContractID, fc types.FileContract) ConsensusBlocksGetFileContract {
+	// Get the FileContract's valid proof outputs.
+	vpos := make([]ConsensusBlocksGetSiacoinOutput, 0, len(fc.ValidProofOutputs))
+	for j, vpo := range fc.ValidProofOutputs {
+		vpos = append(vpos, ConsensusBlocksGetSiacoinOutput{
+			ID:         fcid.StorageProofOutputID(types.ProofValid, uint64(j)),
+			Value:      vpo.Value,
+			UnlockHash: vpo.UnlockHash,
+		})
+	}
+	// Get the FileContract's missed proof outputs.
+	mpos := make([]ConsensusBlocksGetSiacoinOutput, 0, len(fc.MissedProofOutputs))
+	for j, mpo := range fc.MissedProofOutputs {
+		mpos = append(mpos, ConsensusBlocksGetSiacoinOutput{
+			ID:         fcid.StorageProofOutputID(types.ProofMissed, uint64(j)),
+			Value:      mpo.Value,
+			UnlockHash: mpo.UnlockHash,
+		})
+	}
+	return ConsensusBlocksGetFileContract{
+		ID:                 fcid,
+		FileSize:           fc.FileSize,
+		FileMerkleRoot:     fc.FileMerkleRoot,
+		WindowStart:        fc.WindowStart,
+		WindowEnd:          fc.WindowEnd,
+		Payout:             fc.Payout,
+		ValidProofOutputs:  vpos,
+		MissedProofOutputs: mpos,
+		UnlockHash:         fc.UnlockHash,
+		RevisionNumber:     fc.RevisionNumber,
+	}
+}
+
 // ConsensusBlocksGetFromBlock is a helper method that uses a types.Block and
 // types.BlockHeight to create a ConsensusBlocksGet object.
 func consensusBlocksGetFromBlock(b types.Block, h types.BlockHeight) ConsensusBlocksGet {
@@ -111,37 +249,7 @@ func consensusBlocksGetFromBlock(b types.Block, h types.BlockHeight) ConsensusBl
 		// Get the transaction's FileContracts.
 		fcos := make([]ConsensusBlocksGetFileContract, 0, len(t.FileContracts))
 		for i, fc := range t.FileContracts {
-			// Get the FileContract's valid proof outputs.
-			fcid := t.FileContractID(uint64(i))
-			vpos := make([]ConsensusBlocksGetSiacoinOutput, 0, len(fc.ValidProofOutputs))
-			for j, vpo := range fc.ValidProofOutputs {
-				vpos = append(vpos, ConsensusBlocksGetSiacoinOutput{
-					ID:         fcid.StorageProofOutputID(types.ProofValid, uint64(j)),
-					Value:      vpo.Value,
-					UnlockHash: vpo.UnlockHash,
-				})
-			}
-			// Get the FileContract's missed proof outputs.
-			mpos := make([]ConsensusBlocksGetSiacoinOutput, 0, len(fc.MissedProofOutputs))
-			for j, mpo := range fc.MissedProofOutputs {
-				mpos = append(mpos, ConsensusBlocksGetSiacoinOutput{
-					ID:         fcid.StorageProofOutputID(types.ProofMissed, uint64(j)),
-					Value:      mpo.Value,
-					UnlockHash: mpo.UnlockHash,
-				})
-			}
-			fcos = append(fcos, ConsensusBlocksGetFileContract{
-				ID:                 fcid,
-				FileSize:           fc.FileSize,
-				FileMerkleRoot:     fc.FileMerkleRoot,
-				WindowStart:        fc.WindowStart,
-				WindowEnd:          fc.WindowEnd,
-				Payout:             fc.Payout,
-				ValidProofOutputs:  vpos,
-				MissedProofOutputs: mpos,
-				UnlockHash:         fc.UnlockHash,
-				RevisionNumber:     fc.RevisionNumber,
-			})
+			fcos = append(fcos, consensusFileContract(t.FileContractID(uint64(i)), fc))
 		}
 		txns = append(txns, ConsensusBlocksGetTxn{
 			ID:                    t.ID(),
@@ -168,16 +276,90 @@ func consensusBlocksGetFromBlock(b types.Block, h types.BlockHeight) ConsensusBl
 	}
 }
 
+// newConsensusStream returns a consensusStream that buffers up to
+// consensusStreamBuffer changes.
+func newConsensusStream() *consensusStream {
+	return &consensusStream{
+		changes:  make(chan modules.ConsensusChange, consensusStreamBuffer),
+		cancel:   make(chan struct{}),
+		overflow: make(chan struct{}),
+	}
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber.
+func (s *consensusStream) ProcessConsensusChange(cc modules.ConsensusChange) {
+	select {
+	case s.changes <- cc:
+	default:
+		s.overflowOnce.Do(func() { close(s.overflow) })
+		return
+	}
+	if len(s.changes) == cap(s.changes) {
+		s.cancelOnce.Do(func() { close(s.cancel) })
+	}
+}
+
+// cancelled returns true if the subscription was stopped because the buffer
+// was full.
+func (s *consensusStream) cancelled() bool {
+	select {
+	case <-s.cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// consensusChange converts a modules.ConsensusChange to a ConsensusChange.
+func consensusChange(cc modules.ConsensusChange) ConsensusChange {
+	return ConsensusChange{
+		ID:                         crypto.Hash(cc.ID),
+		RevertedBlocks:             cc.RevertedBlocks,
+		AppliedBlocks:              cc.AppliedBlocks,
+		SiacoinOutputDiffs:         cc.SiacoinOutputDiffs,
+		FileContractDiffs:          cc.FileContractDiffs,
+		SiafundOutputDiffs:         cc.SiafundOutputDiffs,
+		DelayedSiacoinOutputDiffs:  cc.DelayedSiacoinOutputDiffs,
+		SiafundPoolDiffs:           cc.SiafundPoolDiffs,
+		ChildTarget:                cc.ChildTarget,
+		MinimumValidChildTimestamp: cc.MinimumValidChildTimestamp,
+		Synced:                     cc.Synced,
+	}
+}
+
+// scanConsensusChangeID parses the id of a consensus change. An empty string
+// refers to the beginning of the blockchain and "recent" to the most recent
+// change.
+func scanConsensusChangeID(s string) (modules.ConsensusChangeID, error) {
+	switch s {
+	case "":
+		return modules.ConsensusChangeBeginning, nil
+	case "recent":
+		return modules.ConsensusChangeRecent, nil
+	}
+	h, err := scanHash(s)
+	return modules.ConsensusChangeID(h), err
+}
+
 // consensusHandler handles the API calls to /consensus.
 func (api *API) consensusHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	cbid := api.cs.CurrentBlock().ID()
 	currentTarget, _ := api.cs.ChildTarget(cbid)
+	progress := api.cs.SyncProgress()
+	prune := api.cs.PruneStatus()
 	WriteJSON(w, ConsensusGET{
 		Synced:       api.cs.Synced(),
 		Height:       api.cs.Height(),
 		CurrentBlock: cbid,
 		Target:       currentTarget,
 		Difficulty:   currentTarget.Difficulty(),
+
+		HeaderHeight:   progress.HeaderHeight,
+		BodiesInFlight: progress.BodiesInFlight,
+
+		PruneDepth:   prune.PruneDepth,
+		PrunedHeight: prune.PrunedHeight,
+		DiskUsage:    prune.DiskUsage,
 	})
 }
 
@@ -187,10 +369,10 @@ func (api *API) consensusBlocksHandler(w http.ResponseWriter, req *http.Request,
 	// Get query params and check them.
 	id, height := req.FormValue("id"), req.FormValue("height")
 	if id != "" && height != "" {
-		WriteError(w, Error{"can't specify both id and height"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "can't specify both id and height", Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
 	}
 	if id == "" && height == "" {
-		WriteError(w, Error{"either id or height has to be provided"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "either id or height has to be provided", Code: ErrCodeMissingParameter}, http.StatusBadRequest)
 	}
 
 	var b types.Block
@@ -201,7 +383,7 @@ func (api *API) consensusBlocksHandler(w http.ResponseWriter, req *http.Request,
 	if id != "" {
 		var bid types.BlockID
 		if err := bid.LoadString(id); err != nil {
-			WriteError(w, Error{"failed to unmarshal blockid"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "failed to unmarshal blockid", Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
 			return
 		}
 		b, h, exists = api.cs.BlockByID(bid)
@@ -209,33 +391,336 @@ func (api *API) consensusBlocksHandler(w http.ResponseWriter, req *http.Request,
 	// Handle request by height
 	if height != "" {
 		if _, err := fmt.Sscan(height, &h); err != nil {
-			WriteError(w, Error{"failed to parse block height"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "failed to parse block height", Code: ErrCodeInvalidParameter, Param: "height"}, http.StatusBadRequest)
 			return
 		}
 		b, exists = api.cs.BlockAtHeight(types.BlockHeight(h))
 	}
 	// Check if block was found
 	if !exists {
-		WriteError(w, Error{"block doesn't exist"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "block doesn't exist", Code: ErrCodeNotFound}, http.StatusBadRequest)
 		return
 	}
 	// Write response
 	WriteJSON(w, consensusBlocksGetFromBlock(b, h))
 }
 
+// consensusSiacoinOutputsHandler handles the API calls to
+// /consensus/siacoinoutputs/:id.
+func (api *API) consensusSiacoinOutputsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{Message: "failed to unmarshal siacoin output id: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
+		return
+	}
+	id := types.SiacoinOutputID(hash)
+	sco, exists := api.cs.SiacoinOutput(id)
+	if !exists {
+		WriteError(w, Error{Message: "siacoin output doesn't exist", Code: ErrCodeNotFound}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusBlocksGetSiacoinOutput{
+		ID:         id,
+		Value:      sco.Value,
+		UnlockHash: sco.UnlockHash,
+	})
+}
+
+// consensusSiafundOutputsHandler handles the API calls to
+// /consensus/siafundoutputs/:id.
+func (api *API) consensusSiafundOutputsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{Message: "failed to unmarshal siafund output id: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
+		return
+	}
+	id := types.SiafundOutputID(hash)
+	sfo, exists := api.cs.SiafundOutput(id)
+	if !exists {
+		WriteError(w, Error{Message: "siafund output doesn't exist", Code: ErrCodeNotFound}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusSiafundOutputGET{
+		ID:         id,
+		Value:      sfo.Value,
+		UnlockHash: sfo.UnlockHash,
+		ClaimStart: sfo.ClaimStart,
+	})
+}
+
+// consensusFileContractsHandler handles the API calls to
+// /consensus/filecontracts/:id.
+func (api *API) consensusFileContractsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var fcid types.FileContractID
+	if err := fcid.LoadString(ps.ByName("id")); err != nil {
+		WriteError(w, Error{Message: "failed to unmarshal file contract id: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
+		return
+	}
+	fc, exists := api.cs.FileContract(fcid)
+	if !exists {
+		WriteError(w, Error{Message: "file contract doesn't exist", Code: ErrCodeNotFound}, http.StatusBadRequest)
+		return
+	}
+	fcg := ConsensusFileContractGET{
+		ConsensusBlocksGetFileContract: consensusFileContract(fcid, fc),
+	}
+	// The segment is only known once the proof window has started.
+	if segment, err := api.cs.StorageProofSegment(fcid); err == nil {
+		fcg.StorageProofSegment = &segment
+	}
+	WriteJSON(w, fcg)
+}
+
+// consensusSiafundPoolHandler handles the API calls to /consensus/siafundpool.
+func (api *API) consensusSiafundPoolHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, ConsensusSiafundPoolGET{
+		SiafundPool: api.cs.SiafundPool(),
+	})
+}
+
+// consensusReorgsHandler handles the API calls to /consensus/reorgs.
+func (api *API) consensusReorgsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	reorgs := api.cs.Reorgs()
+	if reorgs == nil {
+		reorgs = []modules.ReorgEvent{}
+	}
+	WriteJSON(w, ConsensusReorgsGET{
+		Reorgs: reorgs,
+	})
+}
+
+// consensusDelayedOutputsHandler handles the API calls to
+// /consensus/delayedoutputs/:height.
+func (api *API) consensusDelayedOutputsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var height types.BlockHeight
+	if _, err := fmt.Sscan(ps.ByName("height"), &height); err != nil {
+		WriteError(w, Error{Message: "failed to parse block height", Code: ErrCodeInvalidParameter, Param: "height"}, http.StatusBadRequest)
+		return
+	}
+	dscos, exists := api.cs.DelayedSiacoinOutputs(height)
+	if !exists {
+		WriteError(w, Error{Message: "no delayed siacoin outputs are tracked at that height", Code: ErrCodeNotFound}, http.StatusBadRequest)
+		return
+	}
+	dg := ConsensusDelayedOutputsGET{
+		MaturityHeight:        height,
+		DelayedSiacoinOutputs: make([]ConsensusBlocksGetSiacoinOutput, 0, len(dscos)),
+	}
+	for id, sco := range dscos {
+		dg.DelayedSiacoinOutputs = append(dg.DelayedSiacoinOutputs, ConsensusBlocksGetSiacoinOutput{
+			ID:         id,
+			Value:      sco.Value,
+			UnlockHash: sco.UnlockHash,
+		})
+	}
+	sort.Slice(dg.DelayedSiacoinOutputs, func(i, j int) bool {
+		return bytes.Compare(dg.DelayedSiacoinOutputs[i].ID[:], dg.DelayedSiacoinOutputs[j].ID[:]) < 0
+	})
+	WriteJSON(w, dg)
+}
+
+// consensusSubscribeHandler handles the API calls to /consensus/subscribe.
+// The consensus changes following the given change are streamed as
+// newline-delimited JSON, or as server-sent events if requested, until the
+// client disconnects.
+func (api *API) consensusSubscribeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	changeID := req.FormValue("changeid")
+	if lastEventID := req.Header.Get("Last-Event-ID"); changeID == "" && lastEventID != "" {
+		changeID = lastEventID
+	}
+	start, err := scanConsensusChangeID(changeID)
+	if err != nil {
+		WriteError(w, Error{Message: "failed to parse changeid: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "changeid"}, http.StatusBadRequest)
+		return
+	}
+	sse := req.FormValue("format") == "sse" || strings.Contains(req.Header.Get("Accept"), "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	write := func(cc modules.ConsensusChange) error {
+		b, err := json.Marshal(consensusChange(cc))
+		if err != nil {
+			return err
+		}
+		if sse {
+			_, err = fmt.Fprintf(w, "id: %v\ndata: %s\n\n", crypto.Hash(cc.ID), b)
+		} else {
+			_, err = fmt.Fprintf(w, "%s\n", b)
+		}
+		start = cc.ID
+		return err
+	}
+
+	started := false
+	for {
+		// Subscribe from the last change that was written. The subscription
+		// stops early if the client is too slow to keep up with the changes
+		// while catching up.
+		stream := newConsensusStream()
+		err := api.cs.ConsensusSetSubscribe(stream, start, stream.cancel)
+		live := err == nil
+		if err != nil && !stream.cancelled() {
+			if !started {
+				WriteError(w, Error{Message: "unable to subscribe to the consensus set: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+			}
+			return
+		}
+		if !started {
+			if sse {
+				w.Header().Set("Content-Type", "text/event-stream")
+			} else {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+			}
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+			flush()
+			started = true
+		}
+
+		if !live {
+			// Write the changes received while catching up, then subscribe
+			// again.
+			for len(stream.changes) > 0 {
+				if err := write(<-stream.changes); err != nil {
+					return
+				}
+			}
+			flush()
+			continue
+		}
+
+		// Write the changes as they arrive until the client disconnects or
+		// falls behind.
+		keepAlive := time.NewTicker(consensusStreamKeepAlive)
+		err = func() error {
+			defer keepAlive.Stop()
+			defer api.cs.Unsubscribe(stream)
+			for {
+				select {
+				case cc := <-stream.changes:
+					if err := write(cc); err != nil {
+						return err
+					}
+					flush()
+				case <-stream.overflow:
+					return nil
+				case <-keepAlive.C:
+					var err error
+					if sse {
+						_, err = fmt.Fprint(w, ":\n\n")
+					} else {
+						_, err = fmt.Fprint(w, "\n")
+					}
+					if err != nil {
+						return err
+					}
+					flush()
+				case <-req.Context().Done():
+					return req.Context().Err()
+				}
+			}
+		}()
+		if err != nil {
+			return
+		}
+		// The client fell behind. Write the buffered changes and catch up
+		// from the last one.
+		for len(stream.changes) > 0 {
+			if err := write(<-stream.changes); err != nil {
+				return
+			}
+		}
+		flush()
+	}
+}
+
 // consensusValidateTransactionsetHandler handles the API calls to
 // /consensus/validate/transactionset.
 func (api *API) consensusValidateTransactionsetHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var txnset []types.Transaction
 	err := json.NewDecoder(req.Body).Decode(&txnset)
 	if err != nil {
-		WriteError(w, Error{"could not decode transaction set: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "could not decode transaction set: " + err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
 		return
 	}
 	_, err = api.cs.TryTransactionSet(txnset)
 	if err != nil {
-		WriteError(w, Error{"transaction set validation failed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "transaction set validation failed: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
+
+// consensusSnapshotExportHandler handles the API calls to
+// /consensus/snapshot/export.
+func (api *API) consensusSnapshotExportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	destination := req.FormValue("destination")
+	if !filepath.IsAbs(destination) {
+		WriteError(w, Error{Message: "error when calling /consensus/snapshot/export: destination must be an absolute path", Code: ErrCodeInvalidParameter, Param: "destination"}, http.StatusBadRequest)
+		return
+	}
+	height := api.cs.Height()
+	if h := req.FormValue("height"); h != "" {
+		if _, err := fmt.Sscan(h, &height); err != nil {
+			WriteError(w, Error{Message: "failed to parse block height", Code: ErrCodeInvalidParameter, Param: "height"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	f, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /consensus/snapshot/export: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "destination"}, http.StatusBadRequest)
+		return
+	}
+	snap, err := api.cs.ExportSnapshot(f, height)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(destination)
+		WriteError(w, Error{Message: "error when calling /consensus/snapshot/export: " + err.Error(), Code: ErrCodeInternal}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusSnapshotPOST(snap))
+}
+
+// consensusSnapshotImportHandler handles the API calls to
+// /consensus/snapshot/import.
+func (api *API) consensusSnapshotImportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	source := req.FormValue("source")
+	if !filepath.IsAbs(source) {
+		WriteError(w, Error{Message: "error when calling /consensus/snapshot/import: source must be an absolute path", Code: ErrCodeInvalidParameter, Param: "source"}, http.StatusBadRequest)
+		return
+	}
+	var id types.BlockID
+	if err := id.LoadString(req.FormValue("blockid")); err != nil {
+		WriteError(w, Error{Message: "failed to unmarshal blockid: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "blockid"}, http.StatusBadRequest)
+		return
+	}
+	var checksum crypto.Hash
+	if c := req.FormValue("checksum"); c != "" {
+		if err := checksum.LoadString(c); err != nil {
+			WriteError(w, Error{Message: "failed to unmarshal checksum: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "checksum"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /consensus/snapshot/import: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "source"}, http.StatusBadRequest)
+		return
+	}
+	defer f.Close()
+	snap, err := api.cs.ImportSnapshot(f, id, checksum)
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /consensus/snapshot/import: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ConsensusSnapshotPOST(snap))
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"bufio"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/types"
 )
 
@@ -83,3 +87,135 @@ func TestConsensusValidateTransactionSet(t *testing.T) {
 		t.Fatal("expected validation error")
 	}
 }
+
+// TestConsensusQueryGET probes the GET calls that query the outputs and the
+// siafund pool of the consensus set.
+func TestConsensusQueryGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// The miner payout of the current block is delayed until it matures.
+	cb := st.cs.CurrentBlock()
+	payoutID := cb.MinerPayoutID(0)
+	var dg ConsensusDelayedOutputsGET
+	err = st.getAPI(fmt.Sprintf("/consensus/delayedoutputs/%v", st.cs.Height()+types.MaturityDelay), &dg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dg.DelayedSiacoinOutputs) != 1 || dg.DelayedSiacoinOutputs[0].ID != payoutID {
+		t.Fatal("miner payout is not a delayed output:", dg.DelayedSiacoinOutputs)
+	}
+	var sco ConsensusBlocksGetSiacoinOutput
+	if err := st.getAPI("/consensus/siacoinoutputs/"+payoutID.String(), &sco); err == nil {
+		t.Fatal("expected an error for a delayed output")
+	}
+	for i := types.BlockHeight(0); i < types.MaturityDelay; i++ {
+		if _, err := st.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.getAPI("/consensus/siacoinoutputs/"+payoutID.String(), &sco); err != nil {
+		t.Fatal(err)
+	}
+	if sco.ID != payoutID || !sco.Value.Equals(cb.MinerPayouts[0].Value) {
+		t.Fatal("wrong siacoin output returned:", sco)
+	}
+
+	var sfpg ConsensusSiafundPoolGET
+	if err := st.getAPI("/consensus/siafundpool", &sfpg); err != nil {
+		t.Fatal(err)
+	}
+	if !sfpg.SiafundPool.Equals(st.cs.SiafundPool()) {
+		t.Fatal("wrong siafund pool returned")
+	}
+	var fcg ConsensusFileContractGET
+	if err := st.getAPI("/consensus/filecontracts/"+types.FileContractID{}.String(), &fcg); err == nil {
+		t.Fatal("expected an error for an unknown file contract")
+	}
+}
+
+// TestConsensusStreamOverflow checks that a consensusStream stops catching up
+// when its buffer is full, and signals an overflow when a change doesn't fit.
+func TestConsensusStreamOverflow(t *testing.T) {
+	s := newConsensusStream()
+	for i := 0; i < consensusStreamBuffer; i++ {
+		if s.cancelled() {
+			t.Fatal("stream was cancelled before the buffer was full")
+		}
+		s.ProcessConsensusChange(modules.ConsensusChange{})
+	}
+	if !s.cancelled() {
+		t.Fatal("stream was not cancelled when the buffer was full")
+	}
+	select {
+	case <-s.overflow:
+		t.Fatal("stream overflowed before a change was dropped")
+	default:
+	}
+	s.ProcessConsensusChange(modules.ConsensusChange{})
+	select {
+	case <-s.overflow:
+	default:
+		t.Fatal("stream did not overflow")
+	}
+	if len(s.changes) != consensusStreamBuffer {
+		t.Fatal("wrong number of buffered changes:", len(s.changes))
+	}
+}
+
+// TestConsensusSubscribeSSE probes the GET call to /consensus/subscribe with
+// server-sent events.
+func TestConsensusSubscribeSSE(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	resp, err := HttpGET("http://" + st.server.listener.Addr().String() + "/consensus/subscribe?format=sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatal("wrong content type:", resp.Header.Get("Content-Type"))
+	}
+
+	// The first event applies the genesis block.
+	r := bufio.NewReader(resp.Body)
+	idLine, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataLine, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cc ConsensusChange
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data: ")), &cc); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(idLine) != "id: "+cc.ID.String() {
+		t.Fatal("event id does not match the change id:", idLine)
+	}
+	if len(cc.AppliedBlocks) != 1 || cc.AppliedBlocks[0].ID() != types.GenesisID {
+		t.Fatal("first change does not apply the genesis block")
+	}
+
+	// Invalid change ids are rejected.
+	var cg ConsensusGET
+	if err := st.getAPI("/consensus/subscribe?changeid=foo", &cg); err == nil {
+		t.Fatal("expected an error for an invalid change id")
+	}
+}
@@ -1,5 +1,10 @@
 package api
 
+import (
+	"github.com/acejam/Sia/modules"
+	"github.com/acejam/Sia/persist"
+)
+
 // DaemonVersionGet contains information about the running daemon's version.
 type DaemonVersionGet struct {
 	Version     string
@@ -13,3 +18,76 @@ type DaemonUpdateGet struct {
 	Available bool   `json:"available"`
 	Version   string `json:"version"`
 }
+
+// DaemonTokensGET lists the API tokens of the daemon.
+type DaemonTokensGET struct {
+	Tokens []APIToken `json:"tokens"`
+}
+
+// DaemonTokensPOST contains a newly created API token. The token is only
+// returned once.
+type DaemonTokensPOST struct {
+	Token string `json:"token"`
+}
+
+// DaemonLogLevelGET contains the log levels of the modules of the daemon.
+type DaemonLogLevelGET struct {
+	Levels map[string]persist.LogLevel `json:"levels"`
+}
+
+// DaemonConfigGET contains the effective configuration of the daemon in the
+// form of the config file. Siad contains the value of every flag after the
+// config file has been applied; the module sections contain the current
+// settings of the loaded modules.
+type DaemonConfigGET struct {
+	File      string            `json:"file"`
+	Siad      map[string]string `json:"siad"`
+	Gateway   map[string]string `json:"gateway"`
+	Host      map[string]string `json:"host"`
+	Renter    map[string]string `json:"renter"`
+	LogLevels map[string]string `json:"loglevels"`
+}
+
+// DaemonReloadPOST contains the result of reloading the config file. Applied
+// lists the sections that were applied, RestartRequired the settings that
+// changed but only take effect after a restart.
+type DaemonReloadPOST struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restartrequired"`
+}
+
+// DaemonHealthGET contains the health of the daemon. Status is "loading"
+// while the modules are being loaded, "locked" while the encrypted persist
+// files of the host and the renter are locked, and "ok" otherwise.
+type DaemonHealthGET struct {
+	Status  string   `json:"status"`
+	Modules []string `json:"modules"`
+}
+
+// ModuleReadiness contains whether a module is ready to be used and a
+// human-readable description of its state.
+type ModuleReadiness struct {
+	Ready  bool   `json:"ready"`
+	Status string `json:"status"`
+}
+
+// DaemonReadyGET contains the readiness of the checked modules. Ready is true
+// if every checked module is ready.
+type DaemonReadyGET struct {
+	Ready   bool                       `json:"ready"`
+	Modules map[string]ModuleReadiness `json:"modules"`
+}
+
+// DaemonAlertsGET contains the alerts of the loaded modules, most severe
+// first.
+type DaemonAlertsGET struct {
+	Alerts []modules.Alert `json:"alerts"`
+}
+
+// DaemonEncryptionGET contains whether the persist files of the host and the
+// renter are encrypted, and whether they are unlocked. Persist files that are
+// not encrypted are always unlocked.
+type DaemonEncryptionGET struct {
+	Encrypted bool `json:"encrypted"`
+	Unlocked  bool `json:"unlocked"`
+}
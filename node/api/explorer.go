@@ -1,8 +1,11 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/acejam/Sia/build"
 	"github.com/acejam/Sia/crypto"
@@ -12,6 +15,20 @@ import (
 	"github.com/julienschmidt/httprouter"
 )
 
+const (
+	// explorerPageDefaultLimit is the number of entries returned by a
+	// paginated explorer call if no limit is specified.
+	explorerPageDefaultLimit = 100
+
+	// explorerPageMaxLimit is the largest number of entries returned by a
+	// paginated explorer call.
+	explorerPageMaxLimit = 1000
+
+	// explorerBlocksMaxRange is the largest number of block facts returned by
+	// a call to /explorer/blocks.
+	explorerBlocksMaxRange = 1000
+)
+
 type (
 	// ExplorerBlock is a block with some extra information such as the id and
 	// height. This information is provided for programs that may not be
@@ -59,6 +76,34 @@ type (
 		Block ExplorerBlock `json:"block"`
 	}
 
+	// ExplorerAddressGET is the object returned by a GET request to
+	// /explorer/addresses/:addr.
+	ExplorerAddressGET struct {
+		modules.ExplorerAddress
+	}
+
+	// ExplorerRichListGET is the object returned by a GET request to
+	// /explorer/richlist.
+	ExplorerRichListGET struct {
+		Entries []modules.ExplorerRichListEntry `json:"entries"`
+	}
+
+	// ExplorerBlocksGET is the object returned by a GET request to
+	// /explorer/blocks.
+	ExplorerBlocksGET struct {
+		Facts []modules.BlockFacts `json:"facts"`
+	}
+
+	// ExplorerHistoryGET is the object returned by a GET request for the
+	// transactions of an address, an output or a file contract. Entries are
+	// ordered by height, and the transactions and blocks of the entries are
+	// listed in the same order. Blocks appear for miner payouts.
+	ExplorerHistoryGET struct {
+		modules.ExplorerHistoryPage
+		Blocks       []ExplorerBlock       `json:"blocks"`
+		Transactions []ExplorerTransaction `json:"transactions"`
+	}
+
 	// ExplorerHashGET is the object returned as a response to a GET request to
 	// /explorer/hash. The HashType will indicate whether the hash corresponds
 	// to a block id, a transaction id, a siacoin output id, a file contract
@@ -203,14 +248,14 @@ func (api *API) explorerBlocksHandler(w http.ResponseWriter, req *http.Request,
 	var height types.BlockHeight
 	_, err := fmt.Sscan(ps.ByName("height"), &height)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "height"}, http.StatusBadRequest)
 		return
 	}
 
 	// Fetch and return the explorer block.
 	block, exists := api.cs.BlockAtHeight(height)
 	if !exists {
-		WriteError(w, Error{"no block found at input height in call to /explorer/block"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "no block found at input height in call to /explorer/block", Code: ErrCodeNotFound}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, ExplorerBlockGET{
@@ -253,7 +298,7 @@ func (api *API) explorerHashHandler(w http.ResponseWriter, req *http.Request, ps
 	if err != nil {
 		addr, err := scanAddress(ps.ByName("hash"))
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "hash"}, http.StatusBadRequest)
 			return
 		}
 		hash = crypto.Hash(addr)
@@ -262,7 +307,7 @@ func (api *API) explorerHashHandler(w http.ResponseWriter, req *http.Request, ps
 	// TODO: lookups on the zero hash are too expensive to allow. Need a
 	// better way to handle this case.
 	if hash == (crypto.Hash{}) {
-		WriteError(w, Error{"can't lookup the empty unlock hash"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "can't lookup the empty unlock hash", Code: ErrCodeInvalidParameter, Param: "hash"}, http.StatusBadRequest)
 		return
 	}
 
@@ -348,7 +393,7 @@ func (api *API) explorerHashHandler(w http.ResponseWriter, req *http.Request, ps
 	}
 
 	// Hash not found, return an error.
-	WriteError(w, Error{"unrecognized hash used as input to /explorer/hash"}, http.StatusBadRequest)
+	WriteError(w, Error{Message: "unrecognized hash used as input to /explorer/hash", Code: ErrCodeNotFound}, http.StatusBadRequest)
 }
 
 // explorerHandler handles API calls to /explorer
@@ -358,3 +403,222 @@ func (api *API) explorerHandler(w http.ResponseWriter, req *http.Request, _ http
 		BlockFacts: facts,
 	})
 }
+
+// explorerAddressesHandler handles API calls to /explorer/addresses/:addr.
+func (api *API) explorerAddressesHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("addr"))
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "addr"}, http.StatusBadRequest)
+		return
+	}
+	ea, exists := api.explorer.Address(addr)
+	if !exists {
+		WriteError(w, Error{Message: "address does not appear in the blockchain", Code: ErrCodeNotFound}, http.StatusBadRequest)
+		return
+	}
+	if ea.SiacoinOutputs == nil {
+		ea.SiacoinOutputs = []types.SiacoinOutputID{}
+	}
+	if ea.SiafundOutputs == nil {
+		ea.SiafundOutputs = []types.SiafundOutputID{}
+	}
+	WriteJSON(w, ExplorerAddressGET{
+		ExplorerAddress: ea,
+	})
+}
+
+// explorerRichListHandler handles API calls to /explorer/richlist.
+func (api *API) explorerRichListHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	fundType := types.SpecifierSiacoinOutput
+	switch req.FormValue("type") {
+	case "", "siacoins":
+	case "siafunds":
+		fundType = types.SpecifierSiafundOutput
+	default:
+		WriteError(w, Error{Message: "type must be 'siacoins' or 'siafunds'", Code: ErrCodeInvalidParameter, Param: "type"}, http.StatusBadRequest)
+		return
+	}
+	var offset uint64
+	if o := req.FormValue("offset"); o != "" {
+		var err error
+		offset, err = strconv.ParseUint(o, 10, 64)
+		if err != nil {
+			WriteError(w, Error{Message: "parsing integer value for parameter `offset` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "offset"}, http.StatusBadRequest)
+			return
+		}
+	}
+	limit, err := scanPageLimit(req.FormValue("limit"))
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "limit"}, http.StatusBadRequest)
+		return
+	}
+
+	entries, err := api.explorer.RichList(fundType, offset, limit)
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /explorer/richlist: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []modules.ExplorerRichListEntry{}
+	}
+	WriteJSON(w, ExplorerRichListGET{
+		Entries: entries,
+	})
+}
+
+// scanPageLimit parses the limit of a paginated explorer call.
+func scanPageLimit(l string) (uint64, error) {
+	if l == "" {
+		return explorerPageDefaultLimit, nil
+	}
+	limit, err := strconv.ParseUint(l, 10, 64)
+	if err != nil {
+		return 0, errors.New("parsing integer value for parameter `limit` failed: " + err.Error())
+	}
+	if limit > explorerPageMaxLimit {
+		limit = explorerPageMaxLimit
+	}
+	return limit, nil
+}
+
+// scanHistoryQuery parses the query string of a call for the transactions of
+// an address, an output or a file contract.
+func scanHistoryQuery(req *http.Request) (q modules.ExplorerHistoryQuery, err error) {
+	q.EndHeight = types.BlockHeight(math.MaxUint64)
+	if s := req.FormValue("start"); s != "" {
+		if _, err := fmt.Sscan(s, &q.StartHeight); err != nil {
+			return modules.ExplorerHistoryQuery{}, errors.New("parsing integer value for parameter `start` failed: " + err.Error())
+		}
+	}
+	if e := req.FormValue("end"); e != "" {
+		if _, err := fmt.Sscan(e, &q.EndHeight); err != nil {
+			return modules.ExplorerHistoryQuery{}, errors.New("parsing integer value for parameter `end` failed: " + err.Error())
+		}
+	}
+	q.Cursor = req.FormValue("cursor")
+	q.Limit, err = scanPageLimit(req.FormValue("limit"))
+	return q, err
+}
+
+// writeExplorerHistory writes a page of the history of an address, an output
+// or a file contract.
+func (api *API) writeExplorerHistory(w http.ResponseWriter, page modules.ExplorerHistoryPage, err error) {
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	if page.Entries == nil {
+		page.Entries = []modules.ExplorerHistoryEntry{}
+	}
+	txids := make([]types.TransactionID, len(page.Entries))
+	for i, entry := range page.Entries {
+		txids[i] = entry.TransactionID
+	}
+	txns, blocks := api.buildTransactionSet(txids)
+	WriteJSON(w, ExplorerHistoryGET{
+		ExplorerHistoryPage: page,
+		Blocks:              blocks,
+		Transactions:        txns,
+	})
+}
+
+// explorerAddressTransactionsHandler handles API calls to
+// /explorer/addresses/:addr/transactions.
+func (api *API) explorerAddressTransactionsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("addr"))
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "addr"}, http.StatusBadRequest)
+		return
+	}
+	q, err := scanHistoryQuery(req)
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
+		return
+	}
+	page, err := api.explorer.UnlockHashTransactions(addr, q)
+	api.writeExplorerHistory(w, page, err)
+}
+
+// explorerSiacoinOutputTransactionsHandler handles API calls to
+// /explorer/siacoinoutputs/:id/transactions.
+func (api *API) explorerSiacoinOutputTransactionsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
+		return
+	}
+	q, err := scanHistoryQuery(req)
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
+		return
+	}
+	page, err := api.explorer.SiacoinOutputTransactions(types.SiacoinOutputID(id), q)
+	api.writeExplorerHistory(w, page, err)
+}
+
+// explorerFileContractTransactionsHandler handles API calls to
+// /explorer/filecontracts/:id/transactions.
+func (api *API) explorerFileContractTransactionsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
+		return
+	}
+	q, err := scanHistoryQuery(req)
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
+		return
+	}
+	page, err := api.explorer.FileContractTransactions(types.FileContractID(id), q)
+	api.writeExplorerHistory(w, page, err)
+}
+
+// explorerSiafundOutputTransactionsHandler handles API calls to
+// /explorer/siafundoutputs/:id/transactions.
+func (api *API) explorerSiafundOutputTransactionsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
+		return
+	}
+	q, err := scanHistoryQuery(req)
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
+		return
+	}
+	page, err := api.explorer.SiafundOutputTransactions(types.SiafundOutputID(id), q)
+	api.writeExplorerHistory(w, page, err)
+}
+
+// explorerBlocksRangeHandler handles API calls to /explorer/blocks.
+func (api *API) explorerBlocksRangeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var start types.BlockHeight
+	if s := req.FormValue("start"); s != "" {
+		if _, err := fmt.Sscan(s, &start); err != nil {
+			WriteError(w, Error{Message: "parsing integer value for parameter `start` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "start"}, http.StatusBadRequest)
+			return
+		}
+	}
+	end := start + explorerBlocksMaxRange - 1
+	if e := req.FormValue("end"); e != "" {
+		if _, err := fmt.Sscan(e, &end); err != nil {
+			WriteError(w, Error{Message: "parsing integer value for parameter `end` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "end"}, http.StatusBadRequest)
+			return
+		}
+	}
+	if end >= start && end-start >= explorerBlocksMaxRange {
+		end = start + explorerBlocksMaxRange - 1
+	}
+
+	facts, err := api.explorer.BlockFactsRange(start, end)
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /explorer/blocks: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	if facts == nil {
+		facts = []modules.BlockFacts{}
+	}
+	WriteJSON(w, ExplorerBlocksGET{
+		Facts: facts,
+	})
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/acejam/Sia/modules"
 
@@ -10,12 +12,21 @@ import (
 
 // GatewayGET contains the fields returned by a GET call to "/gateway".
 type GatewayGET struct {
-	NetAddress modules.NetAddress `json:"netaddress"`
-	Peers      []modules.Peer     `json:"peers"`
+	NetAddress       modules.NetAddress `json:"netaddress"`
+	Peers            []modules.Peer     `json:"peers"`
+	MaxDownloadSpeed int64              `json:"maxdownloadspeed"`
+	MaxUploadSpeed   int64              `json:"maxuploadspeed"`
 }
 
-// gatewayHandler handles the API call asking for the gatway status.
-func (api *API) gatewayHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+// GatewayBlocklistGET contains the fields returned by a GET call to
+// "/gateway/blocklist".
+type GatewayBlocklistGET struct {
+	Blocklist   []modules.GatewayBlocklistEntry `json:"blocklist"`
+	Misbehavior []modules.GatewayMisbehavior    `json:"misbehavior"`
+}
+
+// gatewayHandlerGET handles the API call asking for the gateway status.
+func (api *API) gatewayHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	peers := api.gateway.Peers()
 	// nil slices are marshalled as 'null' in JSON, whereas 0-length slices are
 	// marshalled as '[]'. The latter is preferred, indicating that the value
@@ -23,7 +34,39 @@ func (api *API) gatewayHandler(w http.ResponseWriter, req *http.Request, _ httpr
 	if peers == nil {
 		peers = make([]modules.Peer, 0)
 	}
-	WriteJSON(w, GatewayGET{api.gateway.Address(), peers})
+	downloadSpeed, uploadSpeed := api.gateway.RateLimits()
+	WriteJSON(w, GatewayGET{
+		NetAddress:       api.gateway.Address(),
+		Peers:            peers,
+		MaxDownloadSpeed: downloadSpeed,
+		MaxUploadSpeed:   uploadSpeed,
+	})
+}
+
+// gatewayHandlerPOST handles the API call changing the gateway's bandwidth
+// limits.
+func (api *API) gatewayHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	downloadSpeed, uploadSpeed := api.gateway.RateLimits()
+	// Scan the download speed limit. (optional parameter)
+	if d := req.FormValue("maxdownloadspeed"); d != "" {
+		if _, err := fmt.Sscan(d, &downloadSpeed); err != nil {
+			WriteError(w, Error{Message: "unable to parse downloadspeed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "maxdownloadspeed"}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Scan the upload speed limit. (optional parameter)
+	if u := req.FormValue("maxuploadspeed"); u != "" {
+		if _, err := fmt.Sscan(u, &uploadSpeed); err != nil {
+			WriteError(w, Error{Message: "unable to parse uploadspeed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "maxuploadspeed"}, http.StatusBadRequest)
+			return
+		}
+	}
+	err := api.gateway.SetRateLimits(downloadSpeed, uploadSpeed)
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
 }
 
 // gatewayConnectHandler handles the API call to add a peer to the gateway.
@@ -31,7 +74,7 @@ func (api *API) gatewayConnectHandler(w http.ResponseWriter, req *http.Request,
 	addr := modules.NetAddress(ps.ByName("netaddress"))
 	err := api.gateway.Connect(addr)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
@@ -43,9 +86,52 @@ func (api *API) gatewayDisconnectHandler(w http.ResponseWriter, req *http.Reques
 	addr := modules.NetAddress(ps.ByName("netaddress"))
 	err := api.gateway.Disconnect(addr)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
 	WriteSuccess(w)
 }
+
+// gatewayBlocklistHandlerGET handles the API call asking for the gateway's
+// blocklist and the misbehavior scores of its peers.
+func (api *API) gatewayBlocklistHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, GatewayBlocklistGET{
+		Blocklist:   api.gateway.Blocklist(),
+		Misbehavior: api.gateway.Misbehavior(),
+	})
+}
+
+// gatewayBlocklistHandlerPOST handles the API call to add an address or subnet
+// to the gateway's blocklist or to remove it.
+func (api *API) gatewayBlocklistHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	address := req.FormValue("address")
+	if address == "" {
+		WriteError(w, Error{Message: "address must be specified", Code: ErrCodeMissingParameter, Param: "address"}, http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch action := req.FormValue("action"); action {
+	case "add":
+		var duration time.Duration
+		if d := req.FormValue("duration"); d != "" {
+			duration, err = time.ParseDuration(d)
+			if err != nil {
+				WriteError(w, Error{Message: "unable to parse duration: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "duration"}, http.StatusBadRequest)
+				return
+			}
+		}
+		err = api.gateway.Block(address, duration, req.FormValue("reason"))
+	case "remove":
+		err = api.gateway.Unblock(address)
+	default:
+		WriteError(w, Error{Message: "action must be 'add' or 'remove', got '" + action + "'", Code: ErrCodeInvalidParameter, Param: "action"}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -15,7 +15,7 @@ import (
 var (
 	// errNoPath is returned when a call fails to provide a nonempty string
 	// for the path parameter.
-	errNoPath = Error{"path parameter is required"}
+	errNoPath = Error{Message: "path parameter is required", Code: ErrCodeMissingParameter, Param: "path"}
 
 	// errStorageFolderNotFound is returned if a call is made looking for a
 	// storage folder which does not appear to exist within the storage
@@ -48,6 +48,12 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostReorgsGET contains the most recent storage obligation transactions
+	// that were reverted by a reorg, oldest first.
+	HostReorgsGET struct {
+		Transactions []modules.ReorgedTransaction `json:"transactions"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
@@ -76,6 +82,17 @@ func (api *API) hostContractInfoHandler(w http.ResponseWriter, req *http.Request
 	WriteJSON(w, cg)
 }
 
+// hostReorgsHandler handles the API call to /host/reorgs.
+func (api *API) hostReorgsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	rts := api.host.ReorgedTransactions()
+	if rts == nil {
+		rts = []modules.ReorgedTransaction{}
+	}
+	WriteJSON(w, HostReorgsGET{
+		Transactions: rts,
+	})
+}
+
 // hostHandlerGET handles GET requests to the /host API endpoint, returning key
 // information about the host.
 func (api *API) hostHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -217,13 +234,13 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 func (api *API) hostEstimateScoreGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// This call requires a renter, check that it is present.
 	if api.renter == nil {
-		WriteError(w, Error{"cannot call /host/estimatescore without the renter module"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "cannot call /host/estimatescore without the renter module", Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
 	settings, err := api.parseHostSettings(req)
 	if err != nil {
-		WriteError(w, Error{"error parsing host settings: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error parsing host settings: " + err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
 		return
 	}
 	var totalStorage, remainingStorage uint64
@@ -267,13 +284,13 @@ func (api *API) hostEstimateScoreGET(w http.ResponseWriter, req *http.Request, _
 func (api *API) hostHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	settings, err := api.parseHostSettings(req)
 	if err != nil {
-		WriteError(w, Error{"error parsing host settings: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error parsing host settings: " + err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
 		return
 	}
 
 	err = api.host.SetInternalSettings(settings)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -289,7 +306,7 @@ func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _
 		err = api.host.Announce()
 	}
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -309,12 +326,12 @@ func (api *API) storageFoldersAddHandler(w http.ResponseWriter, req *http.Reques
 	var folderSize uint64
 	_, err := fmt.Sscan(req.FormValue("size"), &folderSize)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "size"}, http.StatusBadRequest)
 		return
 	}
 	err = api.host.AddStorageFolder(folderPath, folderSize)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -324,26 +341,26 @@ func (api *API) storageFoldersAddHandler(w http.ResponseWriter, req *http.Reques
 func (api *API) storageFoldersResizeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
 	if folderPath == "" {
-		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "path parameter is required", Code: ErrCodeMissingParameter, Param: "path"}, http.StatusBadRequest)
 		return
 	}
 
 	storageFolders := api.host.StorageFolders()
 	folderIndex, err := folderIndex(folderPath, storageFolders)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "path"}, http.StatusBadRequest)
 		return
 	}
 
 	var newSize uint64
 	_, err = fmt.Sscan(req.FormValue("newsize"), &newSize)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "newsize"}, http.StatusBadRequest)
 		return
 	}
 	err = api.host.ResizeStorageFolder(uint16(folderIndex), newSize, false)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -354,21 +371,21 @@ func (api *API) storageFoldersResizeHandler(w http.ResponseWriter, req *http.Req
 func (api *API) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
 	if folderPath == "" {
-		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "path parameter is required", Code: ErrCodeMissingParameter, Param: "path"}, http.StatusBadRequest)
 		return
 	}
 
 	storageFolders := api.host.StorageFolders()
 	folderIndex, err := folderIndex(folderPath, storageFolders)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "path"}, http.StatusBadRequest)
 		return
 	}
 
 	force := req.FormValue("force") == "true"
 	err = api.host.RemoveStorageFolder(uint16(folderIndex), force)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -379,12 +396,12 @@ func (api *API) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.Req
 func (api *API) storageSectorsDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	sectorRoot, err := scanHash(ps.ByName("merkleroot"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "merkleroot"}, http.StatusBadRequest)
 		return
 	}
 	err = api.host.DeleteSector(sectorRoot)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
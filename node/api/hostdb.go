@@ -47,7 +47,7 @@ type (
 func (api *API) hostdbHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	isc, err := api.renter.InitialScanComplete()
 	if err != nil {
-		WriteError(w, Error{"Failed to get initial scan status" + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "Failed to get initial scan status" + err.Error(), Code: ErrCodeModuleFailure}, http.StatusInternalServerError)
 		return
 	}
 	WriteJSON(w, HostdbGet{
@@ -68,7 +68,7 @@ func (api *API) hostdbActiveHandler(w http.ResponseWriter, req *http.Request, _
 		// Parse the value for 'numhosts'.
 		_, err := fmt.Sscan(req.FormValue("numhosts"), &numHosts)
 		if err != nil {
-			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter, Param: "numhosts"}, http.StatusBadRequest)
 			return
 		}
 
@@ -117,7 +117,7 @@ func (api *API) hostdbHostsHandler(w http.ResponseWriter, req *http.Request, ps
 
 	entry, exists := api.renter.Host(pk)
 	if !exists {
-		WriteError(w, Error{"requested host does not exist"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "requested host does not exist", Code: ErrCodeNotFound}, http.StatusBadRequest)
 		return
 	}
 	breakdown := api.renter.ScoreBreakdown(entry)
@@ -0,0 +1,256 @@
+package api
+
+// metrics.go exposes the operational metrics of all loaded modules at
+// /metrics in the Prometheus text exposition format. The names and labels of
+// the metrics are part of the API: metrics may be added, but existing metrics
+// must not be renamed or change their meaning.
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/acejam/Sia/types"
+)
+
+// metricsContentType is the content type of the Prometheus text format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// hostObligationStatuses are the statuses of the host's storage obligations,
+// as reported by modules.StorageObligation, and the label value of each status.
+var hostObligationStatuses = []struct {
+	status string
+	label  string
+}{
+	{"obligationUnresolved", "unresolved"},
+	{"obligationRejected", "rejected"},
+	{"obligationSucceeded", "succeeded"},
+	{"obligationFailed", "failed"},
+}
+
+type (
+	// metricsWriter writes metrics in the Prometheus text format.
+	metricsWriter struct {
+		buf bytes.Buffer
+	}
+
+	// metricLabel is a label of a metric sample.
+	metricLabel struct {
+		name  string
+		value string
+	}
+)
+
+// header writes the HELP and TYPE lines of a metric.
+func (mw *metricsWriter) header(name, typ, help string) {
+	fmt.Fprintf(&mw.buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a single sample of a metric.
+func (mw *metricsWriter) sample(name string, value interface{}, labels ...metricLabel) {
+	mw.buf.WriteString(name)
+	if len(labels) > 0 {
+		pairs := make([]string, len(labels))
+		for i, l := range labels {
+			pairs[i] = l.name + "=" + strconv.Quote(l.value)
+		}
+		mw.buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	mw.buf.WriteString(" " + formatMetricValue(value) + "\n")
+}
+
+// gauge writes a gauge with a single unlabeled sample.
+func (mw *metricsWriter) gauge(name, help string, value interface{}) {
+	mw.header(name, "gauge", help)
+	mw.sample(name, value)
+}
+
+// counter writes a counter with a single unlabeled sample.
+func (mw *metricsWriter) counter(name, help string, value interface{}) {
+	mw.header(name, "counter", help)
+	mw.sample(name, value)
+}
+
+// formatMetricValue formats the value of a sample. Currencies are written as
+// integers in hastings, booleans as 0 or 1.
+func formatMetricValue(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int:
+		return strconv.Itoa(v)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case types.BlockHeight:
+		return strconv.FormatUint(uint64(v), 10)
+	case types.Currency:
+		return v.String()
+	default:
+		panic(fmt.Sprintf("unsupported metric value type %T", value))
+	}
+}
+
+// metricsHandler handles the API call that returns the metrics of all loaded
+// modules.
+func (api *API) metricsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var mw metricsWriter
+	if api.cs != nil {
+		api.writeConsensusMetrics(&mw)
+	}
+	if api.gateway != nil {
+		api.writeGatewayMetrics(&mw)
+	}
+	if api.tpool != nil {
+		api.writeTpoolMetrics(&mw)
+	}
+	if api.wallet != nil {
+		api.writeWalletMetrics(&mw)
+	}
+	if api.renter != nil {
+		api.writeRenterMetrics(&mw)
+	}
+	if api.host != nil {
+		api.writeHostMetrics(&mw)
+	}
+	w.Header().Set("Content-Type", metricsContentType)
+	w.Write(mw.buf.Bytes())
+}
+
+// writeConsensusMetrics writes the metrics of the consensus set.
+func (api *API) writeConsensusMetrics(mw *metricsWriter) {
+	mw.gauge("sia_consensus_height", "Height of the current block.", api.cs.Height())
+	mw.gauge("sia_consensus_synced", "Whether the consensus set is synced with the network.", api.cs.Synced())
+}
+
+// writeGatewayMetrics writes the metrics of the gateway.
+func (api *API) writeGatewayMetrics(mw *metricsWriter) {
+	var inbound, outbound int
+	for _, p := range api.gateway.Peers() {
+		if p.Inbound {
+			inbound++
+		} else {
+			outbound++
+		}
+	}
+	mw.header("sia_gateway_peers", "gauge", "Number of connected peers.")
+	mw.sample("sia_gateway_peers", inbound, metricLabel{"direction", "inbound"})
+	mw.sample("sia_gateway_peers", outbound, metricLabel{"direction", "outbound"})
+	mw.gauge("sia_gateway_online", "Whether the gateway is connected to a non-local peer.", api.gateway.Online())
+}
+
+// writeTpoolMetrics writes the metrics of the transaction pool.
+func (api *API) writeTpoolMetrics(mw *metricsWriter) {
+	stats := api.tpool.Stats()
+	mw.gauge("sia_tpool_transaction_sets", "Number of unconfirmed transaction sets.", stats.TransactionSets)
+	mw.gauge("sia_tpool_transactions", "Number of unconfirmed transactions.", stats.Transactions)
+	mw.gauge("sia_tpool_size_bytes", "Size of the unconfirmed transactions.", stats.Size)
+
+	min, max := api.tpool.FeeEstimation()
+	mw.header("sia_tpool_fee_estimate_hastings_per_byte", "gauge", "Recommended transaction fee.")
+	mw.sample("sia_tpool_fee_estimate_hastings_per_byte", min, metricLabel{"bound", "minimum"})
+	mw.sample("sia_tpool_fee_estimate_hastings_per_byte", max, metricLabel{"bound", "maximum"})
+}
+
+// writeWalletMetrics writes the metrics of the wallet. The balances are only
+// written while the wallet is unlocked.
+func (api *API) writeWalletMetrics(mw *metricsWriter) {
+	unlocked, err := api.wallet.Unlocked()
+	if err != nil {
+		return
+	}
+	mw.gauge("sia_wallet_unlocked", "Whether the wallet is unlocked.", unlocked)
+	if !unlocked {
+		return
+	}
+	siacoins, siafunds, claims, err := api.wallet.ConfirmedBalance()
+	if err != nil {
+		return
+	}
+	outgoing, incoming, err := api.wallet.UnconfirmedBalance()
+	if err != nil {
+		return
+	}
+	mw.gauge("sia_wallet_siacoin_balance_hastings", "Confirmed siacoin balance of the wallet.", siacoins)
+	mw.gauge("sia_wallet_siafund_balance", "Confirmed siafund balance of the wallet.", siafunds)
+	mw.gauge("sia_wallet_siafund_claim_balance_hastings", "Siacoin claim balance of the siafunds of the wallet.", claims)
+	mw.header("sia_wallet_unconfirmed_siacoins_hastings", "gauge", "Siacoins of unconfirmed transactions of the wallet.")
+	mw.sample("sia_wallet_unconfirmed_siacoins_hastings", incoming, metricLabel{"direction", "incoming"})
+	mw.sample("sia_wallet_unconfirmed_siacoins_hastings", outgoing, metricLabel{"direction", "outgoing"})
+}
+
+// writeRenterMetrics writes the metrics of the renter.
+func (api *API) writeRenterMetrics(mw *metricsWriter) {
+	rm := api.renter.Metrics()
+	mw.gauge("sia_renter_download_queue_depth", "Number of chunks waiting to be downloaded.", rm.DownloadQueueDepth)
+	mw.gauge("sia_renter_upload_queue_depth", "Number of chunks waiting to be uploaded or repaired.", rm.UploadQueueDepth)
+	mw.gauge("sia_renter_workers", "Number of workers of the renter.", rm.Workers)
+	mw.counter("sia_renter_worker_download_failures_total", "Number of failed sector downloads of the workers.", rm.WorkerDownloadFailures)
+	mw.counter("sia_renter_worker_upload_failures_total", "Number of failed sector uploads of the workers.", rm.WorkerUploadFailures)
+	mw.gauge("sia_renter_contracts", "Number of active contracts of the renter.", len(api.renter.Contracts()))
+
+	spending := api.renter.PeriodSpending()
+	mw.header("sia_renter_spending_hastings", "gauge", "Spending of the renter in the current period.")
+	for _, s := range []struct {
+		category string
+		value    types.Currency
+	}{
+		{"contractfees", spending.ContractFees},
+		{"download", spending.DownloadSpending},
+		{"storage", spending.StorageSpending},
+		{"upload", spending.UploadSpending},
+		{"totalallocated", spending.TotalAllocated},
+		{"unspent", spending.Unspent},
+	} {
+		mw.sample("sia_renter_spending_hastings", s.value, metricLabel{"category", s.category})
+	}
+}
+
+// writeHostMetrics writes the metrics of the host.
+func (api *API) writeHostMetrics(mw *metricsWriter) {
+	var capacity, remaining, failedReads, failedWrites uint64
+	folders := api.host.StorageFolders()
+	for _, sf := range folders {
+		capacity += sf.Capacity
+		remaining += sf.CapacityRemaining
+		failedReads += sf.FailedReads
+		failedWrites += sf.FailedWrites
+	}
+	mw.gauge("sia_host_storage_folders", "Number of storage folders of the host.", len(folders))
+	mw.gauge("sia_host_storage_capacity_bytes", "Total capacity of the storage folders.", capacity)
+	mw.gauge("sia_host_storage_remaining_bytes", "Unused capacity of the storage folders.", remaining)
+	mw.counter("sia_host_storage_failed_reads_total", "Number of failed reads from the storage folders.", failedReads)
+	mw.counter("sia_host_storage_failed_writes_total", "Number of failed writes to the storage folders.", failedWrites)
+
+	counts := make(map[string]int)
+	for _, so := range api.host.StorageObligations() {
+		counts[so.ObligationStatus]++
+	}
+	mw.header("sia_host_storage_obligations", "gauge", "Number of storage obligations of the host.")
+	for _, s := range hostObligationStatuses {
+		mw.sample("sia_host_storage_obligations", counts[s.status], metricLabel{"status", s.label})
+	}
+
+	nm := api.host.NetworkMetrics()
+	mw.header("sia_host_rpc_calls_total", "counter", "Number of RPC calls made to the host.")
+	for _, rpc := range []struct {
+		name  string
+		value uint64
+	}{
+		{"download", nm.DownloadCalls},
+		{"error", nm.ErrorCalls},
+		{"formcontract", nm.FormContractCalls},
+		{"renew", nm.RenewCalls},
+		{"revise", nm.ReviseCalls},
+		{"settings", nm.SettingsCalls},
+		{"unrecognized", nm.UnrecognizedCalls},
+	} {
+		mw.sample("sia_host_rpc_calls_total", rpc.value, metricLabel{"rpc", rpc.name})
+	}
+}
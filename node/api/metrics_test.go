@@ -0,0 +1,59 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestMetrics checks that /metrics can be scraped without the Sia user agent
+// and that it reports the metrics of the loaded modules.
+func TestMetrics(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	req, err := http.NewRequest("GET", "http://"+st.server.listener.Addr().String()+"/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "Prometheus/2.0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("/metrics returned status", resp.StatusCode)
+	}
+	if resp.Header.Get("Content-Type") != metricsContentType {
+		t.Fatal("/metrics returned the wrong content type:", resp.Header.Get("Content-Type"))
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := string(body)
+	for _, sample := range []string{
+		"sia_consensus_height " + strconv.FormatUint(uint64(st.cs.Height()), 10),
+		`sia_gateway_peers{direction="inbound"} 0`,
+		"sia_tpool_transactions 0",
+		"sia_wallet_unlocked 1",
+		"sia_renter_workers 0",
+		`sia_host_storage_obligations{status="succeeded"} 0`,
+		`sia_host_rpc_calls_total{rpc="settings"}`,
+	} {
+		if !strings.Contains(metrics, "\n"+sample) {
+			t.Error("metrics do not contain", sample)
+		}
+	}
+}
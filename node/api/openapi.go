@@ -0,0 +1,555 @@
+package api
+
+// openapi.go generates the OpenAPI specification of the API from the route
+// table in routedocs.go. The schemas of the parameters and responses are
+// derived from the Go types of the handlers, so that the specification cannot
+// drift from the structs that are actually returned.
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/acejam/Sia/build"
+)
+
+// openAPIVersion is the version of the OpenAPI specification format.
+const openAPIVersion = "3.0.0"
+
+var (
+	// errUnknownOperation is returned by ValidateResponse if the spec does not
+	// contain the route.
+	errUnknownOperation = errors.New("route is not described by the spec")
+
+	// jsonMarshalerType and textMarshalerType are the interfaces of types
+	// that define their own JSON encoding.
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+type (
+	// An APIRoute describes a route of the API for the OpenAPI specification.
+	// Params are the names of the query string parameters; the path
+	// parameters are taken from Path. Body and Response are values of the
+	// types of the JSON request and response bodies. A nil Response describes
+	// a route that responds with 204 No Content, unless ContentType is set.
+	// ResponseStatuses are the error statuses that respond with a Response
+	// instead of an Error, e.g. the 503 of a failed readiness check.
+	APIRoute struct {
+		Method           string
+		Path             string
+		Summary          string
+		Params           []string
+		Body             interface{}
+		Response         interface{}
+		ResponseStatuses []int
+		ContentType      string
+	}
+
+	// OpenAPISpec is an OpenAPI 3.0 document describing the routes of the
+	// API.
+	OpenAPISpec struct {
+		OpenAPI    string                                  `json:"openapi"`
+		Info       OpenAPIInfo                             `json:"info"`
+		Servers    []OpenAPIServer                         `json:"servers"`
+		Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+		Components OpenAPIComponents                       `json:"components"`
+	}
+
+	// OpenAPIInfo contains the title and the version of the API.
+	OpenAPIInfo struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+
+	// OpenAPIServer is the base URL of the routes.
+	OpenAPIServer struct {
+		URL string `json:"url"`
+	}
+
+	// OpenAPIComponents contains the named schemas that are referenced by
+	// the operations.
+	OpenAPIComponents struct {
+		Schemas map[string]*OpenAPISchema `json:"schemas"`
+	}
+
+	// OpenAPIOperation describes a single method of a route.
+	OpenAPIOperation struct {
+		OperationID string                     `json:"operationId"`
+		Summary     string                     `json:"summary,omitempty"`
+		Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+		RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+		Responses   map[string]OpenAPIResponse `json:"responses"`
+	}
+
+	// OpenAPIParameter describes a path or query string parameter.
+	OpenAPIParameter struct {
+		Name     string         `json:"name"`
+		In       string         `json:"in"`
+		Required bool           `json:"required,omitempty"`
+		Schema   *OpenAPISchema `json:"schema"`
+	}
+
+	// OpenAPIRequestBody describes the body of a request.
+	OpenAPIRequestBody struct {
+		Required bool                        `json:"required,omitempty"`
+		Content  map[string]OpenAPIMediaType `json:"content"`
+	}
+
+	// OpenAPIResponse describes a response of an operation.
+	OpenAPIResponse struct {
+		Description string                      `json:"description"`
+		Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+	}
+
+	// OpenAPIMediaType contains the schema of a request or response body.
+	OpenAPIMediaType struct {
+		Schema *OpenAPISchema `json:"schema,omitempty"`
+	}
+
+	// OpenAPISchema is the schema of a JSON value. An object schema with
+	// Properties only allows the listed properties; an object schema without
+	// Properties or AdditionalProperties allows any properties.
+	OpenAPISchema struct {
+		Ref                  string                    `json:"$ref,omitempty"`
+		AllOf                []*OpenAPISchema          `json:"allOf,omitempty"`
+		Type                 string                    `json:"type,omitempty"`
+		Format               string                    `json:"format,omitempty"`
+		Nullable             bool                      `json:"nullable,omitempty"`
+		Items                *OpenAPISchema            `json:"items,omitempty"`
+		Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+		Required             []string                  `json:"required,omitempty"`
+		AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	}
+
+	// schemaGenerator derives schemas from Go types. Named structs are
+	// added to the components of the spec and referenced.
+	schemaGenerator struct {
+		schemas map[string]*OpenAPISchema
+	}
+)
+
+// OpenAPI returns the OpenAPI specification of the routes of the API,
+// extended by the given routes. siad uses the extra routes to describe the
+// /daemon routes it serves itself.
+func OpenAPI(extra ...APIRoute) OpenAPISpec {
+	g := schemaGenerator{schemas: make(map[string]*OpenAPISchema)}
+	spec := OpenAPISpec{
+		OpenAPI: openAPIVersion,
+		Info: OpenAPIInfo{
+			Title:   "Sia API",
+			Version: build.Version,
+		},
+		Servers: []OpenAPIServer{{URL: apiVersionPrefix}},
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+	errSchema := g.schema(reflect.TypeOf(Error{}))
+	for _, r := range append(append([]APIRoute(nil), apiRoutes...), extra...) {
+		op := &OpenAPIOperation{
+			OperationID: operationID(r.Method, r.Path),
+			Summary:     r.Summary,
+			Responses: map[string]OpenAPIResponse{
+				"default": {
+					Description: "error",
+					Content:     map[string]OpenAPIMediaType{"application/json": {Schema: errSchema}},
+				},
+			},
+		}
+		for _, name := range pathParams(r.Path) {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:     name,
+				In:       "path",
+				Required: true,
+				Schema:   &OpenAPISchema{Type: "string"},
+			})
+		}
+		for _, name := range r.Params {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:   name,
+				In:     "query",
+				Schema: &OpenAPISchema{Type: "string"},
+			})
+		}
+		if r.Body != nil {
+			op.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content:  map[string]OpenAPIMediaType{"application/json": {Schema: g.schema(reflect.TypeOf(r.Body))}},
+			}
+		}
+		switch {
+		case r.Response != nil:
+			content := map[string]OpenAPIMediaType{"application/json": {Schema: g.schema(reflect.TypeOf(r.Response))}}
+			op.Responses["200"] = OpenAPIResponse{
+				Description: "success",
+				Content:     content,
+			}
+			for _, status := range r.ResponseStatuses {
+				op.Responses[fmt.Sprint(status)] = OpenAPIResponse{
+					Description: http.StatusText(status),
+					Content:     content,
+				}
+			}
+		case r.ContentType != "":
+			op.Responses["200"] = OpenAPIResponse{
+				Description: "success",
+				Content:     map[string]OpenAPIMediaType{r.ContentType: {Schema: &OpenAPISchema{Type: "string", Format: "binary"}}},
+			}
+		default:
+			op.Responses["204"] = OpenAPIResponse{Description: "success"}
+		}
+
+		p := openAPIPath(r.Path)
+		if spec.Paths[p] == nil {
+			spec.Paths[p] = make(map[string]*OpenAPIOperation)
+		}
+		spec.Paths[p][strings.ToLower(r.Method)] = op
+	}
+	spec.Components.Schemas = g.schemas
+	return spec
+}
+
+// OpenAPIHandler handles the API call that returns the OpenAPI specification
+// of the API.
+func OpenAPIHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, OpenAPI())
+}
+
+// openAPIPath converts the path of an httprouter route to an OpenAPI path,
+// e.g. /renter/file/*siapath to /renter/file/{siapath}.
+func openAPIPath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathParams returns the names of the parameters of an httprouter route.
+func pathParams(p string) []string {
+	var params []string
+	for _, s := range strings.Split(p, "/") {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			params = append(params, s[1:])
+		}
+	}
+	return params
+}
+
+// operationID returns the ID of an operation, e.g. getRenterFileSiapath for
+// GET /renter/file/*siapath.
+func operationID(method, p string) string {
+	id := strings.ToLower(method)
+	for _, s := range strings.FieldsFunc(p, func(r rune) bool {
+		return r == '/' || r == ':' || r == '*'
+	}) {
+		id += strings.Title(s)
+	}
+	return id
+}
+
+// schemaName returns the name of the component schema of a named type, e.g.
+// types.Transaction.
+func schemaName(t reflect.Type) string {
+	return path.Base(t.PkgPath()) + "." + t.Name()
+}
+
+// marshalerSchema returns the schema of a type that defines its own JSON
+// encoding. The schema is inferred from the encoding of the zero value.
+func marshalerSchema(t reflect.Type) (s *OpenAPISchema) {
+	defer func() {
+		if recover() != nil {
+			s = &OpenAPISchema{}
+		}
+	}()
+	v := reflect.New(t)
+	var b []byte
+	var err error
+	if t.Implements(jsonMarshalerType) || v.Type().Implements(jsonMarshalerType) {
+		b, err = json.Marshal(v.Interface())
+	} else {
+		return &OpenAPISchema{Type: "string"}
+	}
+	if err != nil || len(b) == 0 {
+		return &OpenAPISchema{}
+	}
+	switch b[0] {
+	case '"':
+		return &OpenAPISchema{Type: "string"}
+	case '[':
+		return &OpenAPISchema{Type: "array", Items: &OpenAPISchema{}, Nullable: true}
+	case '{':
+		return &OpenAPISchema{Type: "object"}
+	case 't', 'f':
+		return &OpenAPISchema{Type: "boolean"}
+	case 'n':
+		return &OpenAPISchema{}
+	}
+	if strings.ContainsAny(string(b), ".eE") {
+		return &OpenAPISchema{Type: "number"}
+	}
+	return &OpenAPISchema{Type: "integer"}
+}
+
+// schema returns the schema of the JSON encoding of t.
+func (g *schemaGenerator) schema(t reflect.Type) *OpenAPISchema {
+	if t == timeType {
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	}
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface {
+		pt := reflect.PtrTo(t)
+		if t.Implements(jsonMarshalerType) || pt.Implements(jsonMarshalerType) ||
+			t.Implements(textMarshalerType) || pt.Implements(textMarshalerType) {
+			return marshalerSchema(t)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &OpenAPISchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte", Nullable: true}
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem()), Nullable: true}
+	case reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem()), Nullable: true}
+	case reflect.Ptr:
+		s := g.schema(t.Elem())
+		if s.Ref != "" {
+			return &OpenAPISchema{AllOf: []*OpenAPISchema{s}, Nullable: true}
+		}
+		ns := *s
+		ns.Nullable = true
+		return &ns
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// Add a placeholder first, so that recursive types terminate.
+			g.schemas[name] = &OpenAPISchema{Type: "object"}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + name}
+	default:
+		// Interfaces can contain any value.
+		return &OpenAPISchema{}
+	}
+}
+
+// structSchema returns the schema of the properties of a struct, following
+// the rules of encoding/json for embedded structs and struct tags.
+func (g *schemaGenerator) structSchema(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{
+		Type:       "object",
+		Properties: make(map[string]*OpenAPISchema),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			// The fields of embedded structs are promoted.
+			embedded := g.structSchema(ft)
+			for k, v := range embedded.Properties {
+				if _, ok := s.Properties[k]; !ok {
+					s.Properties[k] = v
+				}
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if f.PkgPath != "" {
+			// Unexported field.
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		omitempty, asString := false, false
+		for _, o := range opts[1:] {
+			omitempty = omitempty || o == "omitempty"
+			asString = asString || o == "string"
+		}
+		if asString {
+			s.Properties[name] = &OpenAPISchema{Type: "string"}
+		} else {
+			s.Properties[name] = g.schema(f.Type)
+		}
+		if !omitempty {
+			s.Required = append(s.Required, name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// operation returns the operation of the spec that serves a request with the
+// given method and path, e.g. GET /renter/file/foo/bar. A trailing parameter
+// matches the rest of the path if no route matches exactly, since the spec
+// does not distinguish catch-all parameters.
+func (spec OpenAPISpec) operation(method, reqPath string) *OpenAPIOperation {
+	segments := strings.Split(reqPath, "/")
+	var catchAll *OpenAPIOperation
+	for p, ops := range spec.Paths {
+		op, ok := ops[strings.ToLower(method)]
+		if !ok {
+			continue
+		}
+		pattern := strings.Split(p, "/")
+		match := len(pattern) <= len(segments)
+		for i := 0; match && i < len(pattern); i++ {
+			match = strings.HasPrefix(pattern[i], "{") || pattern[i] == segments[i]
+		}
+		if !match {
+			continue
+		} else if len(pattern) == len(segments) {
+			return op
+		} else if strings.HasPrefix(pattern[len(pattern)-1], "{") {
+			catchAll = op
+		}
+	}
+	return catchAll
+}
+
+// ValidateResponse checks that the JSON body of a response to a request with
+// the given method and path matches the schema of the response in the spec.
+func (spec OpenAPISpec) ValidateResponse(method, reqPath string, status int, body []byte) error {
+	op := spec.operation(method, reqPath)
+	if op == nil {
+		return errUnknownOperation
+	}
+	resp, ok := op.Responses[fmt.Sprint(status)]
+	if !ok && (status < 200 || status > 299) {
+		resp, ok = op.Responses["default"]
+	}
+	if !ok {
+		return fmt.Errorf("status %v is not described by the spec", status)
+	}
+	if len(resp.Content) == 0 {
+		if len(body) != 0 {
+			return errors.New("response has a body but the spec describes none")
+		}
+		return nil
+	}
+	media, ok := resp.Content["application/json"]
+	if !ok {
+		// Non-JSON bodies are not validated.
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return err
+	}
+	return spec.validate(media.Schema, v, "body")
+}
+
+// validate checks that v matches the schema s. at is the location of v that
+// is used in errors.
+func (spec OpenAPISpec) validate(s *OpenAPISchema, v interface{}, at string) error {
+	if s.Ref != "" {
+		ref, ok := spec.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		if !ok {
+			return fmt.Errorf("%v: unknown schema %v", at, s.Ref)
+		}
+		return spec.validate(ref, v, at)
+	}
+	if v == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%v: null is not allowed", at)
+	}
+	for _, sub := range s.AllOf {
+		if err := spec.validate(sub, v, at); err != nil {
+			return err
+		}
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%v: expected a boolean, got %T", at, v)
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok {
+			return fmt.Errorf("%v: expected a number, got %T", at, v)
+		} else if s.Type == "integer" && n != float64(int64(n)) && n < 1<<63 {
+			return fmt.Errorf("%v: expected an integer, got %v", at, n)
+		}
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%v: expected a string, got %T", at, v)
+		}
+	case "array":
+		a, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%v: expected an array, got %T", at, v)
+		}
+		for i, e := range a {
+			if err := spec.validate(s.Items, e, fmt.Sprintf("%v[%v]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "object":
+		o, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v: expected an object, got %T", at, v)
+		}
+		for _, name := range s.Required {
+			if _, ok := o[name]; !ok {
+				return fmt.Errorf("%v: missing property %q", at, name)
+			}
+		}
+		for name, pv := range o {
+			ps, ok := s.Properties[name]
+			if !ok && s.AdditionalProperties != nil {
+				ps, ok = s.AdditionalProperties, true
+			} else if !ok && s.Properties == nil {
+				continue
+			}
+			if !ok {
+				return fmt.Errorf("%v: unexpected property %q", at, name)
+			}
+			if err := spec.validate(ps, pv, at+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/acejam/Sia/types"
+)
+
+// TestOpenAPIRoutes checks that the OpenAPI specification describes exactly
+// the routes registered by the API.
+func TestOpenAPIRoutes(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var spec OpenAPISpec
+	if err := st.getAPI("/v2/daemon/openapi", &spec); err != nil {
+		t.Fatal(err)
+	}
+	registered := make(map[string]bool)
+	for _, route := range st.server.api.Routes() {
+		registered[route] = true
+		split := strings.SplitN(route, " ", 2)
+		if spec.Paths[openAPIPath(split[1])][strings.ToLower(split[0])] == nil {
+			t.Error("route is missing from the spec:", route)
+		}
+	}
+	for _, r := range apiRoutes {
+		if !registered[r.Method+" "+r.Path] {
+			t.Error("spec describes a route that is not registered:", r.Method, r.Path)
+		}
+	}
+
+	// The spec describes its own response.
+	resp, err := HttpGET("http://" + st.server.listener.Addr().String() + "/v2/daemon/openapi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.ValidateResponse("GET", "/daemon/openapi", resp.StatusCode, body); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestOpenAPIValidateResponse checks that responses are validated against the
+// schemas derived from the response types.
+func TestOpenAPIValidateResponse(t *testing.T) {
+	type inner struct {
+		Value types.Currency `json:"value"`
+	}
+	type response struct {
+		inner
+		Name     string            `json:"name"`
+		Height   types.BlockHeight `json:"height"`
+		Optional *inner            `json:"optional,omitempty"`
+		IDs      []types.BlockID   `json:"ids"`
+	}
+	spec := OpenAPI(APIRoute{Method: "GET", Path: "/foo/:id", Response: response{}, ResponseStatuses: []int{http.StatusServiceUnavailable}})
+	// Round-trip the spec, as clients of the API would see it.
+	b, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec = OpenAPISpec{}
+	if err := json.Unmarshal(b, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		status int
+		body   string
+		valid  bool
+	}{
+		{http.StatusOK, `{"value":"1","name":"bar","height":1,"ids":null}`, true},
+		{http.StatusOK, `{"value":"1","name":"bar","height":1,"ids":[],"optional":{"value":"2"}}`, true},
+		{http.StatusOK, `{"value":1,"name":"bar","height":1,"ids":null}`, false},
+		{http.StatusOK, `{"value":"1","name":"bar","ids":null}`, false},
+		{http.StatusOK, `{"value":"1","name":"bar","height":1,"ids":null,"extra":true}`, false},
+		{http.StatusOK, `{"value":"1","name":"bar","height":1.5,"ids":null}`, false},
+		{http.StatusOK, `{"value":"1","name":"bar","height":1,"ids":[1]}`, false},
+		{http.StatusNotFound, `{"message":"no such foo","code":"not_found"}`, true},
+		{http.StatusNotFound, `{"code":"not_found"}`, false},
+		{http.StatusNoContent, ``, false},
+		{http.StatusServiceUnavailable, `{"value":"1","name":"bar","height":1,"ids":null}`, true},
+		{http.StatusServiceUnavailable, `{"message":"unavailable","code":"unavailable"}`, false},
+	}
+	for _, test := range tests {
+		err := spec.ValidateResponse("GET", "/foo/baz", test.status, []byte(test.body))
+		if (err == nil) != test.valid {
+			t.Errorf("%v %v: expected valid=%v, got %v", test.status, test.body, test.valid, err)
+		}
+	}
+	if err := spec.ValidateResponse("GET", "/bar", http.StatusOK, nil); err != errUnknownOperation {
+		t.Error("expected errUnknownOperation, got", err)
+	}
+}
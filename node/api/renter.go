@@ -147,6 +147,12 @@ type (
 		modules.RenterPriceEstimation
 	}
 
+	// RenterReorgsGET contains the most recent contract transactions that
+	// were reverted by a reorg, oldest first.
+	RenterReorgsGET struct {
+		Transactions []modules.ReorgedTransaction `json:"transactions"`
+	}
+
 	// RenterShareASCII contains an ASCII-encoded .sia file.
 	RenterShareASCII struct {
 		ASCIIsia string `json:"asciisia"`
@@ -191,7 +197,7 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	if f := req.FormValue("funds"); f != "" {
 		funds, ok := scanAmount(f)
 		if !ok {
-			WriteError(w, Error{"unable to parse funds"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse funds", Code: ErrCodeInvalidParameter, Param: "funds"}, http.StatusBadRequest)
 			return
 		}
 		settings.Allowance.Funds = funds
@@ -200,10 +206,10 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	if h := req.FormValue("hosts"); h != "" {
 		var hosts uint64
 		if _, err := fmt.Sscan(h, &hosts); err != nil {
-			WriteError(w, Error{"unable to parse hosts: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse hosts: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "hosts"}, http.StatusBadRequest)
 			return
 		} else if hosts != 0 && hosts < requiredHosts {
-			WriteError(w, Error{fmt.Sprintf("insufficient number of hosts, need at least %v but have %v", recommendedHosts, hosts)}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprintf("insufficient number of hosts, need at least %v but have %v", recommendedHosts, hosts), Code: ErrCodeInvalidParameter, Param: "hosts"}, http.StatusBadRequest)
 		} else {
 			settings.Allowance.Hosts = hosts
 		}
@@ -215,22 +221,22 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	if p := req.FormValue("period"); p != "" {
 		var period types.BlockHeight
 		if _, err := fmt.Sscan(p, &period); err != nil {
-			WriteError(w, Error{"unable to parse period: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse period: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "period"}, http.StatusBadRequest)
 			return
 		}
 		settings.Allowance.Period = types.BlockHeight(period)
 	} else if settings.Allowance.Period == 0 {
-		WriteError(w, Error{"period needs to be set if it hasn't been set before"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "period needs to be set if it hasn't been set before", Code: ErrCodeMissingParameter, Param: "period"}, http.StatusBadRequest)
 		return
 	}
 	// Scan the renew window. (optional parameter)
 	if rw := req.FormValue("renewwindow"); rw != "" {
 		var renewWindow types.BlockHeight
 		if _, err := fmt.Sscan(rw, &renewWindow); err != nil {
-			WriteError(w, Error{"unable to parse renewwindow: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse renewwindow: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "renewwindow"}, http.StatusBadRequest)
 			return
 		} else if renewWindow != 0 && types.BlockHeight(renewWindow) < requiredRenewWindow {
-			WriteError(w, Error{fmt.Sprintf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprintf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow), Code: ErrCodeInvalidParameter, Param: "renewwindow"}, http.StatusBadRequest)
 			return
 		} else {
 			settings.Allowance.RenewWindow = types.BlockHeight(renewWindow)
@@ -243,7 +249,7 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	if d := req.FormValue("maxdownloadspeed"); d != "" {
 		var downloadSpeed int64
 		if _, err := fmt.Sscan(d, &downloadSpeed); err != nil {
-			WriteError(w, Error{"unable to parse downloadspeed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse downloadspeed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "maxdownloadspeed"}, http.StatusBadRequest)
 			return
 		}
 		settings.MaxDownloadSpeed = downloadSpeed
@@ -252,7 +258,7 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	if u := req.FormValue("maxuploadspeed"); u != "" {
 		var uploadSpeed int64
 		if _, err := fmt.Sscan(u, &uploadSpeed); err != nil {
-			WriteError(w, Error{"unable to parse uploadspeed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse uploadspeed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "maxuploadspeed"}, http.StatusBadRequest)
 			return
 		}
 		settings.MaxUploadSpeed = uploadSpeed
@@ -261,7 +267,7 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	if dcs := req.FormValue("streamcachesize"); dcs != "" {
 		var streamCacheSize uint64
 		if _, err := fmt.Sscan(dcs, &streamCacheSize); err != nil {
-			WriteError(w, Error{"unable to parse streamcachesize: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to parse streamcachesize: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "streamcachesize"}, http.StatusBadRequest)
 			return
 		}
 		settings.StreamCacheSize = streamCacheSize
@@ -269,7 +275,7 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 	// Set the settings in the renter.
 	err := api.renter.SetSettings(settings)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -279,12 +285,12 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 func (api *API) renterContractCancelHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var fcid types.FileContractID
 	if err := fcid.LoadString(req.FormValue("id")); err != nil {
-		WriteError(w, Error{"unable to parse id:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "unable to parse id:" + err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
 		return
 	}
 	err := api.renter.CancelContract(fcid)
 	if err != nil {
-		WriteError(w, Error{"unable to cancel contract:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "unable to cancel contract:" + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -305,12 +311,12 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 	// Parse flags
 	inactive, err := scanBool(req.FormValue("inactive"))
 	if err != nil {
-		WriteError(w, Error{"unable to parse inactive:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "unable to parse inactive:" + err.Error(), Code: ErrCodeInvalidParameter, Param: "inactive"}, http.StatusBadRequest)
 		return
 	}
 	expired, err := scanBool(req.FormValue("expired"))
 	if err != nil {
-		WriteError(w, Error{"unable to parse expired:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "unable to parse expired:" + err.Error(), Code: ErrCodeInvalidParameter, Param: "expired"}, http.StatusBadRequest)
 		return
 	}
 
@@ -433,7 +439,7 @@ func (api *API) renterClearDownloadsHandler(w http.ResponseWriter, req *http.Req
 	if beforeStr != "" {
 		beforeInt, err := strconv.ParseInt(beforeStr, 10, 64)
 		if err != nil {
-			WriteError(w, Error{"parsing integer value for parameter `before` failed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "parsing integer value for parameter `before` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "before"}, http.StatusBadRequest)
 			return
 		}
 		beforeTime = time.Unix(0, beforeInt)
@@ -441,7 +447,7 @@ func (api *API) renterClearDownloadsHandler(w http.ResponseWriter, req *http.Req
 	if afterStr != "" {
 		afterInt, err := strconv.ParseInt(afterStr, 10, 64)
 		if err != nil {
-			WriteError(w, Error{"parsing integer value for parameter `after` failed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "parsing integer value for parameter `after` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "after"}, http.StatusBadRequest)
 			return
 		}
 		afterTime = time.Unix(0, afterInt)
@@ -449,7 +455,7 @@ func (api *API) renterClearDownloadsHandler(w http.ResponseWriter, req *http.Req
 
 	err := api.renter.ClearDownloadHistory(afterTime, beforeTime)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -485,13 +491,13 @@ func (api *API) renterDownloadsHandler(w http.ResponseWriter, _ *http.Request, _
 func (api *API) renterLoadHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	source := req.FormValue("source")
 	if !filepath.IsAbs(source) {
-		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "source must be an absolute path", Code: ErrCodeInvalidParameter, Param: "source"}, http.StatusBadRequest)
 		return
 	}
 
 	files, err := api.renter.LoadSharedFiles(source)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
@@ -503,7 +509,7 @@ func (api *API) renterLoadHandler(w http.ResponseWriter, req *http.Request, _ ht
 func (api *API) renterLoadASCIIHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	files, err := api.renter.LoadSharedFilesASCII(req.FormValue("asciisia"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
@@ -515,7 +521,7 @@ func (api *API) renterLoadASCIIHandler(w http.ResponseWriter, req *http.Request,
 func (api *API) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	err := api.renter.RenameFile(strings.TrimPrefix(ps.ByName("siapath"), "/"), req.FormValue("newsiapath"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
@@ -526,7 +532,7 @@ func (api *API) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps
 func (api *API) renterFileHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	file, err := api.renter.File(strings.TrimPrefix(ps.ByName("siapath"), "/"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, RenterFile{
@@ -549,12 +555,23 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	})
 }
 
+// renterReorgsHandler handles the API call to /renter/reorgs.
+func (api *API) renterReorgsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	rts := api.renter.ReorgedTransactions()
+	if rts == nil {
+		rts = []modules.ReorgedTransaction{}
+	}
+	WriteJSON(w, RenterReorgsGET{
+		Transactions: rts,
+	})
+}
+
 // renterDeleteHandler handles the API call to delete a file entry from the
 // renter.
 func (api *API) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	err := api.renter.DeleteFile(strings.TrimPrefix(ps.ByName("siapath"), "/"))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
@@ -565,7 +582,7 @@ func (api *API) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps
 func (api *API) renterDownloadHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	params, err := parseDownloadParameters(w, req, ps)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
 		return
 	}
 	if params.Async {
@@ -574,7 +591,7 @@ func (api *API) renterDownloadHandler(w http.ResponseWriter, req *http.Request,
 		err = api.renter.Download(params)
 	}
 	if err != nil {
-		WriteError(w, Error{"download failed: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "download failed: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusInternalServerError)
 		return
 	}
 	if params.Httpwriter == nil {
@@ -659,13 +676,13 @@ func (api *API) renterShareHandler(w http.ResponseWriter, req *http.Request, ps
 	destination := req.FormValue("destination")
 	// Check that the destination path is absolute.
 	if !filepath.IsAbs(destination) {
-		WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "destination must be an absolute path", Code: ErrCodeInvalidParameter, Param: "destination"}, http.StatusBadRequest)
 		return
 	}
 
 	err := api.renter.ShareFiles(strings.Split(req.FormValue("siapaths"), ","), destination)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
@@ -677,7 +694,7 @@ func (api *API) renterShareHandler(w http.ResponseWriter, req *http.Request, ps
 func (api *API) renterShareASCIIHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	ascii, err := api.renter.ShareFilesASCII(strings.Split(req.FormValue("siapaths"), ","))
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, RenterShareASCII{
@@ -690,7 +707,7 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 	siaPath := strings.TrimPrefix(ps.ByName("siapath"), "/")
 	fileName, streamer, err := api.renter.Streamer(siaPath)
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("failed to create download streamer: %v", err)},
+		WriteError(w, Error{Message: fmt.Sprintf("failed to create download streamer: %v", err), Code: ErrCodeModuleFailure},
 			http.StatusInternalServerError)
 		return
 	}
@@ -701,7 +718,7 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	source := req.FormValue("source")
 	if !filepath.IsAbs(source) {
-		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "source must be an absolute path", Code: ErrCodeInvalidParameter, Param: "source"}, http.StatusBadRequest)
 		return
 	}
 
@@ -710,7 +727,7 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 	if req.FormValue("datapieces") != "" || req.FormValue("paritypieces") != "" {
 		// Check that both values have been supplied.
 		if req.FormValue("datapieces") == "" || req.FormValue("paritypieces") == "" {
-			WriteError(w, Error{"must provide both the datapieces parameter and the paritypieces parameter if specifying erasure coding parameters"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "must provide both the datapieces parameter and the paritypieces parameter if specifying erasure coding parameters", Code: ErrCodeMissingParameter}, http.StatusBadRequest)
 			return
 		}
 
@@ -718,31 +735,31 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		var dataPieces, parityPieces int
 		_, err := fmt.Sscan(req.FormValue("datapieces"), &dataPieces)
 		if err != nil {
-			WriteError(w, Error{"unable to read parameter 'datapieces': " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to read parameter 'datapieces': " + err.Error(), Code: ErrCodeInvalidParameter, Param: "datapieces"}, http.StatusBadRequest)
 			return
 		}
 		_, err = fmt.Sscan(req.FormValue("paritypieces"), &parityPieces)
 		if err != nil {
-			WriteError(w, Error{"unable to read parameter 'paritypieces': " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to read parameter 'paritypieces': " + err.Error(), Code: ErrCodeInvalidParameter, Param: "paritypieces"}, http.StatusBadRequest)
 			return
 		}
 
 		// Verify that sane values for parityPieces and redundancy are being
 		// supplied.
 		if parityPieces < requiredParityPieces {
-			WriteError(w, Error{fmt.Sprintf("a minimum of %v parity pieces is required, but %v parity pieces requested", parityPieces, requiredParityPieces)}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprintf("a minimum of %v parity pieces is required, but %v parity pieces requested", parityPieces, requiredParityPieces), Code: ErrCodeInvalidParameter, Param: "paritypieces"}, http.StatusBadRequest)
 			return
 		}
 		redundancy := float64(dataPieces+parityPieces) / float64(dataPieces)
 		if float64(dataPieces+parityPieces)/float64(dataPieces) < requiredRedundancy {
-			WriteError(w, Error{fmt.Sprintf("a redundancy of %.2f is required, but redundancy of %.2f supplied", redundancy, requiredRedundancy)}, http.StatusBadRequest)
+			WriteError(w, Error{Message: fmt.Sprintf("a redundancy of %.2f is required, but redundancy of %.2f supplied", redundancy, requiredRedundancy), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
 			return
 		}
 
 		// Create the erasure coder.
 		ec, err = renter.NewRSCode(dataPieces, parityPieces)
 		if err != nil {
-			WriteError(w, Error{"unable to encode file using the provided parameters: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "unable to encode file using the provided parameters: " + err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
 			return
 		}
 	}
@@ -754,7 +771,7 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		ErasureCode: ec,
 	})
 	if err != nil {
-		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "upload failed: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusInternalServerError)
 		return
 	}
 	WriteSuccess(w)
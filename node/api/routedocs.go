@@ -0,0 +1,155 @@
+package api
+
+import (
+	"github.com/acejam/Sia/types"
+)
+
+var (
+	// hostSettingsParams are the parameters of the calls that take host
+	// settings.
+	hostSettingsParams = []string{
+		"acceptingcontracts", "maxdownloadbatchsize", "maxduration", "maxrevisebatchsize", "netaddress", "windowsize",
+		"collateral", "collateralbudget", "maxcollateral",
+		"mincontractprice", "mindownloadbandwidthprice", "minstorageprice", "minuploadbandwidthprice",
+	}
+
+	// downloadParams are the parameters of the download calls.
+	downloadParams = []string{"destination", "offset", "length", "httpresp", "async"}
+
+	// historyParams are the parameters of the paginated explorer history
+	// calls.
+	historyParams = []string{"start", "end", "cursor", "limit"}
+
+	// apiRoutes describes the routes served by the API. It is the source of
+	// the OpenAPI specification served at /daemon/openapi and must be kept in
+	// sync with buildHTTPRoutes; TestOpenAPIRoutes enforces this.
+	apiRoutes = []APIRoute{
+		// Consensus API Calls
+		{Method: "GET", Path: "/consensus", Summary: "Get the current state of the consensus set.", Response: ConsensusGET{}},
+		{Method: "GET", Path: "/consensus/blocks", Summary: "Get a block by ID or height.", Params: []string{"id", "height"}, Response: ConsensusBlocksGet{}},
+		{Method: "GET", Path: "/consensus/delayedoutputs/:height", Summary: "Get the delayed siacoin outputs maturing at a height.", Response: ConsensusDelayedOutputsGET{}},
+		{Method: "GET", Path: "/consensus/filecontracts/:id", Summary: "Get a file contract.", Response: ConsensusFileContractGET{}},
+		{Method: "GET", Path: "/consensus/reorgs", Summary: "Get the recent reorgs of the consensus set.", Response: ConsensusReorgsGET{}},
+		{Method: "GET", Path: "/consensus/siacoinoutputs/:id", Summary: "Get an unspent siacoin output.", Response: ConsensusBlocksGetSiacoinOutput{}},
+		{Method: "GET", Path: "/consensus/siafundoutputs/:id", Summary: "Get an unspent siafund output.", Response: ConsensusSiafundOutputGET{}},
+		{Method: "GET", Path: "/consensus/siafundpool", Summary: "Get the siafund pool.", Response: ConsensusSiafundPoolGET{}},
+		{Method: "POST", Path: "/consensus/snapshot/export", Summary: "Export a snapshot of the consensus set.", Params: []string{"destination", "height"}, Response: ConsensusSnapshotPOST{}},
+		{Method: "POST", Path: "/consensus/snapshot/import", Summary: "Import a snapshot of the consensus set.", Params: []string{"source", "blockid", "checksum"}, Response: ConsensusSnapshotPOST{}},
+		{Method: "GET", Path: "/consensus/subscribe", Summary: "Stream the consensus changes.", Params: []string{"changeid", "format"}, ContentType: "application/x-ndjson"},
+		{Method: "POST", Path: "/consensus/validate/transactionset", Summary: "Validate a transaction set.", Body: []types.Transaction{}},
+
+		// Explorer API Calls
+		{Method: "GET", Path: "/explorer", Summary: "Get the status of the explorer.", Response: ExplorerGET{}},
+		{Method: "GET", Path: "/explorer/addresses/:addr", Summary: "Get the balances of an address.", Response: ExplorerAddressGET{}},
+		{Method: "GET", Path: "/explorer/addresses/:addr/transactions", Summary: "Get the transactions of an address.", Params: historyParams, Response: ExplorerHistoryGET{}},
+		{Method: "GET", Path: "/explorer/blocks", Summary: "Get the facts of a range of blocks.", Params: []string{"start", "end"}, Response: ExplorerBlocksGET{}},
+		{Method: "GET", Path: "/explorer/blocks/:height", Summary: "Get a block.", Response: ExplorerBlockGET{}},
+		{Method: "GET", Path: "/explorer/filecontracts/:id/transactions", Summary: "Get the transactions of a file contract.", Params: historyParams, Response: ExplorerHistoryGET{}},
+		{Method: "GET", Path: "/explorer/hashes/:hash", Summary: "Get the object with a hash.", Response: ExplorerHashGET{}},
+		{Method: "GET", Path: "/explorer/richlist", Summary: "Get the richest addresses.", Params: []string{"type", "offset", "limit"}, Response: ExplorerRichListGET{}},
+		{Method: "GET", Path: "/explorer/siacoinoutputs/:id/transactions", Summary: "Get the transactions of a siacoin output.", Params: historyParams, Response: ExplorerHistoryGET{}},
+		{Method: "GET", Path: "/explorer/siafundoutputs/:id/transactions", Summary: "Get the transactions of a siafund output.", Params: historyParams, Response: ExplorerHistoryGET{}},
+
+		// Gateway API Calls
+		{Method: "GET", Path: "/gateway", Summary: "Get the status of the gateway.", Response: GatewayGET{}},
+		{Method: "POST", Path: "/gateway", Summary: "Change the settings of the gateway.", Params: []string{"maxdownloadspeed", "maxuploadspeed"}},
+		{Method: "GET", Path: "/gateway/blocklist", Summary: "Get the blocked addresses.", Response: GatewayBlocklistGET{}},
+		{Method: "POST", Path: "/gateway/blocklist", Summary: "Block or unblock an address.", Params: []string{"address", "action", "duration", "reason"}},
+		{Method: "POST", Path: "/gateway/connect/:netaddress", Summary: "Connect to a peer."},
+		{Method: "POST", Path: "/gateway/disconnect/:netaddress", Summary: "Disconnect from a peer."},
+
+		// Host API Calls
+		{Method: "GET", Path: "/host", Summary: "Get the status of the host.", Response: HostGET{}},
+		{Method: "POST", Path: "/host", Summary: "Change the settings of the host.", Params: hostSettingsParams},
+		{Method: "POST", Path: "/host/announce", Summary: "Announce the host to the network.", Params: []string{"netaddress"}},
+		{Method: "GET", Path: "/host/contracts", Summary: "Get the contracts of the host.", Response: ContractInfoGET{}},
+		{Method: "GET", Path: "/host/estimatescore", Summary: "Estimate the score of the host with the given settings.", Params: hostSettingsParams, Response: HostEstimateScoreGET{}},
+		{Method: "GET", Path: "/host/reorgs", Summary: "Get the recent reorgs seen by the host.", Response: HostReorgsGET{}},
+		{Method: "GET", Path: "/host/storage", Summary: "Get the storage folders of the host.", Response: StorageGET{}},
+		{Method: "POST", Path: "/host/storage/folders/add", Summary: "Add a storage folder.", Params: []string{"path", "size"}},
+		{Method: "POST", Path: "/host/storage/folders/remove", Summary: "Remove a storage folder.", Params: []string{"path", "force"}},
+		{Method: "POST", Path: "/host/storage/folders/resize", Summary: "Resize a storage folder.", Params: []string{"path", "newsize"}},
+		{Method: "POST", Path: "/host/storage/sectors/delete/:merkleroot", Summary: "Delete a sector."},
+
+		// Metrics API Calls
+		{Method: "GET", Path: "/metrics", Summary: "Get the metrics of the modules.", ContentType: metricsContentType},
+
+		// Miner API Calls
+		{Method: "GET", Path: "/miner", Summary: "Get the status of the miner.", Response: MinerGET{}},
+		{Method: "GET", Path: "/miner/header", Summary: "Get a block header for work.", ContentType: "application/octet-stream"},
+		{Method: "POST", Path: "/miner/header", Summary: "Submit a solved block header."},
+		{Method: "GET", Path: "/miner/start", Summary: "Start the CPU miner."},
+		{Method: "GET", Path: "/miner/stop", Summary: "Stop the CPU miner."},
+
+		// Renter API Calls
+		{Method: "GET", Path: "/renter", Summary: "Get the settings and spending of the renter.", Response: RenterGET{}},
+		{Method: "POST", Path: "/renter", Summary: "Change the settings of the renter.", Params: []string{"funds", "hosts", "period", "renewwindow", "maxdownloadspeed", "maxuploadspeed", "streamcachesize"}},
+		{Method: "POST", Path: "/renter/contract/cancel", Summary: "Cancel a contract.", Params: []string{"id"}},
+		{Method: "GET", Path: "/renter/contracts", Summary: "Get the contracts of the renter.", Params: []string{"inactive", "expired"}, Response: RenterContracts{}},
+		{Method: "GET", Path: "/renter/downloads", Summary: "Get the download queue.", Response: RenterDownloadQueue{}},
+		{Method: "POST", Path: "/renter/downloads/clear", Summary: "Clear the download history.", Params: []string{"before", "after"}},
+		{Method: "GET", Path: "/renter/files", Summary: "Get the files of the renter.", Response: RenterFiles{}},
+		{Method: "GET", Path: "/renter/file/*siapath", Summary: "Get a file of the renter.", Response: RenterFile{}},
+		{Method: "GET", Path: "/renter/prices", Summary: "Get the estimated prices of the renter.", Response: RenterPricesGET{}},
+		{Method: "GET", Path: "/renter/reorgs", Summary: "Get the recent reorgs seen by the renter.", Response: RenterReorgsGET{}},
+		{Method: "POST", Path: "/renter/delete/*siapath", Summary: "Delete a file."},
+		{Method: "GET", Path: "/renter/download/*siapath", Summary: "Download a file.", Params: downloadParams, ContentType: "application/octet-stream"},
+		{Method: "GET", Path: "/renter/downloadasync/*siapath", Summary: "Download a file asynchronously.", Params: downloadParams},
+		{Method: "POST", Path: "/renter/rename/*siapath", Summary: "Rename a file.", Params: []string{"newsiapath"}},
+		{Method: "GET", Path: "/renter/stream/*siapath", Summary: "Stream a file.", ContentType: "application/octet-stream"},
+		{Method: "POST", Path: "/renter/upload/*siapath", Summary: "Upload a file.", Params: []string{"source", "datapieces", "paritypieces"}},
+
+		// HostDB API Calls
+		{Method: "GET", Path: "/hostdb", Summary: "Get the status of the host database.", Response: HostdbGet{}},
+		{Method: "GET", Path: "/hostdb/active", Summary: "Get the active hosts.", Params: []string{"numhosts"}, Response: HostdbActiveGET{}},
+		{Method: "GET", Path: "/hostdb/all", Summary: "Get all hosts.", Response: HostdbAllGET{}},
+		{Method: "GET", Path: "/hostdb/hosts/:pubkey", Summary: "Get a host.", Response: HostdbHostsGET{}},
+
+		// Transaction pool API Calls
+		{Method: "GET", Path: "/tpool", Summary: "Get the status of the transaction pool.", Response: TpoolGET{}},
+		{Method: "GET", Path: "/tpool/fee", Summary: "Get the recommended fees.", Params: []string{"target"}, Response: TpoolFeeGET{}},
+		{Method: "GET", Path: "/tpool/raw/:id", Summary: "Get a raw transaction and its parents.", Response: TpoolRawGET{}},
+		{Method: "POST", Path: "/tpool/raw", Summary: "Submit a raw transaction and its parents.", Params: []string{"parents", "transaction"}},
+		{Method: "GET", Path: "/tpool/confirmed/:id", Summary: "Check whether a transaction is confirmed.", Response: TpoolConfirmedGET{}},
+		{Method: "GET", Path: "/tpool/histogram", Summary: "Get the fee histogram of the transaction pool.", Response: TpoolHistogramGET{}},
+		{Method: "GET", Path: "/tpool/sets", Summary: "Get the transaction sets.", Response: TpoolSetsGET{}},
+		{Method: "GET", Path: "/tpool/sets/:id", Summary: "Get a transaction set.", Response: TpoolSetGET{}},
+		{Method: "GET", Path: "/tpool/transactions", Summary: "Get the transactions of the transaction pool.", Params: []string{"address", "offset", "limit"}, Response: TpoolTransactionsGET{}},
+
+		// Wallet API Calls
+		{Method: "GET", Path: "/wallet", Summary: "Get the status of the wallet.", Response: WalletGET{}},
+		{Method: "POST", Path: "/wallet/033x", Summary: "Load a v0.3.3.x wallet.", Params: []string{"source", "encryptionpassword"}},
+		{Method: "GET", Path: "/wallet/accounts", Summary: "Get the accounts of the wallet.", Response: WalletAccountsGET{}},
+		{Method: "POST", Path: "/wallet/accounts", Summary: "Create an account.", Params: []string{"name"}, Response: WalletAccountGET{}},
+		{Method: "GET", Path: "/wallet/accounts/:name", Summary: "Get an account.", Response: WalletAccountGET{}},
+		{Method: "GET", Path: "/wallet/accounts/:name/address", Summary: "Get a new address of an account.", Response: WalletAddressGET{}},
+		{Method: "POST", Path: "/wallet/accounts/:name/siacoins", Summary: "Send siacoins from an account.", Params: []string{"amount", "destination"}, Response: WalletSiacoinsPOST{}},
+		{Method: "GET", Path: "/wallet/accounts/:name/transactions", Summary: "Get the transactions of an account.", Response: WalletAccountTransactionsGET{}},
+		{Method: "GET", Path: "/wallet/address", Summary: "Get a new address.", Response: WalletAddressGET{}},
+		{Method: "GET", Path: "/wallet/addresses", Summary: "Get the addresses of the wallet.", Response: WalletAddressesGET{}},
+		{Method: "GET", Path: "/wallet/backup", Summary: "Back up the wallet.", Params: []string{"destination"}},
+		{Method: "GET", Path: "/wallet/events", Summary: "Wait for wallet events.", Params: []string{"since", "timeout"}, Response: WalletEventsGET{}},
+		{Method: "POST", Path: "/wallet/init", Summary: "Initialize the wallet.", Params: []string{"encryptionpassword", "force", "dictionary"}, Response: WalletInitPOST{}},
+		{Method: "POST", Path: "/wallet/init/seed", Summary: "Initialize the wallet from a seed.", Params: []string{"encryptionpassword", "dictionary", "seed", "force"}},
+		{Method: "POST", Path: "/wallet/lock", Summary: "Lock the wallet."},
+		{Method: "GET", Path: "/wallet/reorgs", Summary: "Get the transactions reverted by recent reorgs.", Response: WalletReorgsGET{}},
+		{Method: "POST", Path: "/wallet/seed", Summary: "Add a seed to the wallet.", Params: []string{"dictionary", "seed", "encryptionpassword"}},
+		{Method: "GET", Path: "/wallet/seeds", Summary: "Get the seeds of the wallet.", Params: []string{"dictionary"}, Response: WalletSeedsGET{}},
+		{Method: "POST", Path: "/wallet/siacoins", Summary: "Send siacoins.", Params: []string{"outputs", "amount", "destination"}, Response: WalletSiacoinsPOST{}},
+		{Method: "POST", Path: "/wallet/siafunds", Summary: "Send siafunds.", Params: []string{"amount", "destination"}, Response: WalletSiafundsPOST{}},
+		{Method: "POST", Path: "/wallet/siagkey", Summary: "Load siag key files.", Params: []string{"keyfiles", "encryptionpassword"}},
+		{Method: "POST", Path: "/wallet/sweep/seed", Summary: "Sweep the outputs of a seed into the wallet.", Params: []string{"dictionary", "seed"}, Response: WalletSweepPOST{}},
+		{Method: "GET", Path: "/wallet/transaction/:id", Summary: "Get a transaction.", Response: WalletTransactionGETid{}},
+		{Method: "GET", Path: "/wallet/transactions", Summary: "Get the transactions in a range of heights.", Params: []string{"startheight", "endheight"}, Response: WalletTransactionsGET{}},
+		{Method: "GET", Path: "/wallet/transactions/:addr", Summary: "Get the transactions of an address.", Response: WalletTransactionsGETaddr{}},
+		{Method: "GET", Path: "/wallet/verify/address/:addr", Summary: "Check whether an address is valid.", Response: WalletVerifyAddressGET{}},
+		{Method: "GET", Path: "/wallet/webhooks", Summary: "Get the webhooks of the wallet.", Response: WalletWebhooksGET{}},
+		{Method: "POST", Path: "/wallet/webhooks", Summary: "Register a webhook.", Params: []string{"confirmations", "addresses", "url", "secret"}, Response: WalletWebhooksPOST{}},
+		{Method: "POST", Path: "/wallet/webhooks/remove/:id", Summary: "Remove a webhook."},
+		{Method: "POST", Path: "/wallet/unlock", Summary: "Unlock the wallet.", Params: []string{"encryptionpassword"}},
+		{Method: "POST", Path: "/wallet/changepassword", Summary: "Change the password of the wallet.", Params: []string{"newpassword", "encryptionpassword"}},
+
+		// Daemon API Calls
+		{Method: "GET", Path: "/daemon/openapi", Summary: "Get the OpenAPI specification of the API.", Response: OpenAPISpec{}},
+	}
+)
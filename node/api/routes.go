@@ -13,83 +13,115 @@ import (
 // it connected the Router to the given api using the required
 // parameters: requiredUserAgent and requiredPassword
 func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword string) {
-	router := httprouter.New()
+	router := &routeRecorder{Router: httprouter.New()}
 
 	router.NotFound = http.HandlerFunc(UnrecognizedCallHandler)
 	router.RedirectTrailingSlash = false
 
+	// requireScope protects a route with the API password and the API tokens.
+	requireScope := func(h httprouter.Handle, scope APIScope) httprouter.Handle {
+		return RequireScope(h, requiredPassword, api.tokens, scope)
+	}
+
 	// Consensus API Calls
 	if api.cs != nil {
 		router.GET("/consensus", api.consensusHandler)
 		router.GET("/consensus/blocks", api.consensusBlocksHandler)
+		router.GET("/consensus/delayedoutputs/:height", api.consensusDelayedOutputsHandler)
+		router.GET("/consensus/filecontracts/:id", api.consensusFileContractsHandler)
+		router.GET("/consensus/reorgs", api.consensusReorgsHandler)
+		router.GET("/consensus/siacoinoutputs/:id", api.consensusSiacoinOutputsHandler)
+		router.GET("/consensus/siafundoutputs/:id", api.consensusSiafundOutputsHandler)
+		router.GET("/consensus/siafundpool", api.consensusSiafundPoolHandler)
+		router.POST("/consensus/snapshot/export", requireScope(api.consensusSnapshotExportHandler, ScopeAdmin))
+		router.POST("/consensus/snapshot/import", requireScope(api.consensusSnapshotImportHandler, ScopeAdmin))
+		router.GET("/consensus/subscribe", api.consensusSubscribeHandler)
 		router.POST("/consensus/validate/transactionset", api.consensusValidateTransactionsetHandler)
 	}
 
 	// Explorer API Calls
 	if api.explorer != nil {
 		router.GET("/explorer", api.explorerHandler)
+		router.GET("/explorer/addresses/:addr", api.explorerAddressesHandler)
+		router.GET("/explorer/addresses/:addr/transactions", api.explorerAddressTransactionsHandler)
+		router.GET("/explorer/blocks", api.explorerBlocksRangeHandler)
 		router.GET("/explorer/blocks/:height", api.explorerBlocksHandler)
+		router.GET("/explorer/filecontracts/:id/transactions", api.explorerFileContractTransactionsHandler)
 		router.GET("/explorer/hashes/:hash", api.explorerHashHandler)
+		router.GET("/explorer/richlist", api.explorerRichListHandler)
+		router.GET("/explorer/siacoinoutputs/:id/transactions", api.explorerSiacoinOutputTransactionsHandler)
+		router.GET("/explorer/siafundoutputs/:id/transactions", api.explorerSiafundOutputTransactionsHandler)
 	}
 
 	// Gateway API Calls
 	if api.gateway != nil {
-		router.GET("/gateway", api.gatewayHandler)
-		router.POST("/gateway/connect/:netaddress", RequirePassword(api.gatewayConnectHandler, requiredPassword))
-		router.POST("/gateway/disconnect/:netaddress", RequirePassword(api.gatewayDisconnectHandler, requiredPassword))
+		router.GET("/gateway", api.gatewayHandlerGET)
+		router.POST("/gateway", requireScope(api.gatewayHandlerPOST, ScopeAdmin))
+		router.GET("/gateway/blocklist", api.gatewayBlocklistHandlerGET)
+		router.POST("/gateway/blocklist", requireScope(api.gatewayBlocklistHandlerPOST, ScopeAdmin))
+		router.POST("/gateway/connect/:netaddress", requireScope(api.gatewayConnectHandler, ScopeAdmin))
+		router.POST("/gateway/disconnect/:netaddress", requireScope(api.gatewayDisconnectHandler, ScopeAdmin))
 	}
 
 	// Host API Calls
 	if api.host != nil {
 		// Calls directly pertaining to the host.
-		router.GET("/host", api.hostHandlerGET)                                                   // Get the host status.
-		router.POST("/host", RequirePassword(api.hostHandlerPOST, requiredPassword))              // Change the settings of the host.
-		router.POST("/host/announce", RequirePassword(api.hostAnnounceHandler, requiredPassword)) // Announce the host to the network.
-		router.GET("/host/contracts", api.hostContractInfoHandler)                                // Get info about contracts.
+		router.GET("/host", api.hostHandlerGET)                                         // Get the host status.
+		router.POST("/host", requireScope(api.hostHandlerPOST, ScopeHost))              // Change the settings of the host.
+		router.POST("/host/announce", requireScope(api.hostAnnounceHandler, ScopeHost)) // Announce the host to the network.
+		router.GET("/host/contracts", api.hostContractInfoHandler)                      // Get info about contracts.
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
+		router.GET("/host/reorgs", api.hostReorgsHandler)
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
-		router.POST("/host/storage/folders/add", RequirePassword(api.storageFoldersAddHandler, requiredPassword))
-		router.POST("/host/storage/folders/remove", RequirePassword(api.storageFoldersRemoveHandler, requiredPassword))
-		router.POST("/host/storage/folders/resize", RequirePassword(api.storageFoldersResizeHandler, requiredPassword))
-		router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(api.storageSectorsDeleteHandler, requiredPassword))
+		router.POST("/host/storage/folders/add", requireScope(api.storageFoldersAddHandler, ScopeHost))
+		router.POST("/host/storage/folders/remove", requireScope(api.storageFoldersRemoveHandler, ScopeHost))
+		router.POST("/host/storage/folders/resize", requireScope(api.storageFoldersResizeHandler, ScopeHost))
+		router.POST("/host/storage/sectors/delete/:merkleroot", requireScope(api.storageSectorsDeleteHandler, ScopeHost))
 	}
 
+	// Daemon API Calls
+	router.GET("/daemon/openapi", OpenAPIHandler)
+
+	// Metrics API Calls
+	router.GET("/metrics", api.metricsHandler)
+
 	// Miner API Calls
 	if api.miner != nil {
 		router.GET("/miner", api.minerHandler)
-		router.GET("/miner/header", RequirePassword(api.minerHeaderHandlerGET, requiredPassword))
-		router.POST("/miner/header", RequirePassword(api.minerHeaderHandlerPOST, requiredPassword))
-		router.GET("/miner/start", RequirePassword(api.minerStartHandler, requiredPassword))
-		router.GET("/miner/stop", RequirePassword(api.minerStopHandler, requiredPassword))
+		router.GET("/miner/header", requireScope(api.minerHeaderHandlerGET, ScopeAdmin))
+		router.POST("/miner/header", requireScope(api.minerHeaderHandlerPOST, ScopeAdmin))
+		router.GET("/miner/start", requireScope(api.minerStartHandler, ScopeAdmin))
+		router.GET("/miner/stop", requireScope(api.minerStopHandler, ScopeAdmin))
 	}
 
 	// Renter API Calls
 	if api.renter != nil {
 		router.GET("/renter", api.renterHandlerGET)
-		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
-		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
+		router.POST("/renter", requireScope(api.renterHandlerPOST, ScopeRenter))
+		router.POST("/renter/contract/cancel", requireScope(api.renterContractCancelHandler, ScopeRenter))
 		router.GET("/renter/contracts", api.renterContractsHandler)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
-		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
+		router.POST("/renter/downloads/clear", requireScope(api.renterClearDownloadsHandler, ScopeRenter))
 		router.GET("/renter/files", api.renterFilesHandler)
 		router.GET("/renter/file/*siapath", api.renterFileHandler)
 		router.GET("/renter/prices", api.renterPricesHandler)
+		router.GET("/renter/reorgs", api.renterReorgsHandler)
 
 		// TODO: re-enable these routes once the new .sia format has been
 		// standardized and implemented.
-		// router.POST("/renter/load", RequirePassword(api.renterLoadHandler, requiredPassword))
-		// router.POST("/renter/loadascii", RequirePassword(api.renterLoadAsciiHandler, requiredPassword))
-		// router.GET("/renter/share", RequirePassword(api.renterShareHandler, requiredPassword))
-		// router.GET("/renter/shareascii", RequirePassword(api.renterShareAsciiHandler, requiredPassword))
-
-		router.POST("/renter/delete/*siapath", RequirePassword(api.renterDeleteHandler, requiredPassword))
-		router.GET("/renter/download/*siapath", RequirePassword(api.renterDownloadHandler, requiredPassword))
-		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
-		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
+		// router.POST("/renter/load", requireScope(api.renterLoadHandler, ScopeRenter))
+		// router.POST("/renter/loadascii", requireScope(api.renterLoadAsciiHandler, ScopeRenter))
+		// router.GET("/renter/share", requireScope(api.renterShareHandler, ScopeRenter))
+		// router.GET("/renter/shareascii", requireScope(api.renterShareAsciiHandler, ScopeRenter))
+
+		router.POST("/renter/delete/*siapath", requireScope(api.renterDeleteHandler, ScopeRenter))
+		router.GET("/renter/download/*siapath", requireScope(api.renterDownloadHandler, ScopeRenter))
+		router.GET("/renter/downloadasync/*siapath", requireScope(api.renterDownloadAsyncHandler, ScopeRenter))
+		router.POST("/renter/rename/*siapath", requireScope(api.renterRenameHandler, ScopeRenter))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
-		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
+		router.POST("/renter/upload/*siapath", requireScope(api.renterUploadHandler, ScopeRenter))
 
 		// HostDB endpoints.
 		router.GET("/hostdb", api.hostdbHandler)
@@ -100,44 +132,77 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 
 	// Transaction pool API Calls
 	if api.tpool != nil {
+		router.GET("/tpool", api.tpoolHandlerGET)
 		router.GET("/tpool/fee", api.tpoolFeeHandlerGET)
 		router.GET("/tpool/raw/:id", api.tpoolRawHandlerGET)
 		router.POST("/tpool/raw", api.tpoolRawHandlerPOST)
 		router.GET("/tpool/confirmed/:id", api.tpoolConfirmedGET)
-
-		// TODO: re-enable this route once the transaction pool API has been finalized
-		//router.GET("/transactionpool/transactions", api.transactionpoolTransactionsHandler)
+		router.GET("/tpool/histogram", api.tpoolHistogramHandlerGET)
+		router.GET("/tpool/sets", api.tpoolSetsHandlerGET)
+		router.GET("/tpool/sets/:id", api.tpoolSetHandlerGET)
+		router.GET("/tpool/transactions", api.tpoolTransactionsHandlerGET)
 	}
 
 	// Wallet API Calls
 	if api.wallet != nil {
 		router.GET("/wallet", api.walletHandler)
-		router.POST("/wallet/033x", RequirePassword(api.wallet033xHandler, requiredPassword))
-		router.GET("/wallet/address", RequirePassword(api.walletAddressHandler, requiredPassword))
+		router.POST("/wallet/033x", requireScope(api.wallet033xHandler, ScopeWalletSpend))
+		router.GET("/wallet/accounts", api.walletAccountsHandlerGET)
+		router.POST("/wallet/accounts", requireScope(api.walletAccountsHandlerPOST, ScopeWalletSpend))
+		router.GET("/wallet/accounts/:name", api.walletAccountHandler)
+		router.GET("/wallet/accounts/:name/address", requireScope(api.walletAccountAddressHandler, ScopeWalletSpend))
+		router.POST("/wallet/accounts/:name/siacoins", requireScope(api.walletAccountSiacoinsHandler, ScopeWalletSpend))
+		router.GET("/wallet/accounts/:name/transactions", api.walletAccountTransactionsHandler)
+		router.GET("/wallet/address", requireScope(api.walletAddressHandler, ScopeWalletSpend))
 		router.GET("/wallet/addresses", api.walletAddressesHandler)
-		router.GET("/wallet/backup", RequirePassword(api.walletBackupHandler, requiredPassword))
-		router.POST("/wallet/init", RequirePassword(api.walletInitHandler, requiredPassword))
-		router.POST("/wallet/init/seed", RequirePassword(api.walletInitSeedHandler, requiredPassword))
-		router.POST("/wallet/lock", RequirePassword(api.walletLockHandler, requiredPassword))
-		router.POST("/wallet/seed", RequirePassword(api.walletSeedHandler, requiredPassword))
-		router.GET("/wallet/seeds", RequirePassword(api.walletSeedsHandler, requiredPassword))
-		router.POST("/wallet/siacoins", RequirePassword(api.walletSiacoinsHandler, requiredPassword))
-		router.POST("/wallet/siafunds", RequirePassword(api.walletSiafundsHandler, requiredPassword))
-		router.POST("/wallet/siagkey", RequirePassword(api.walletSiagkeyHandler, requiredPassword))
-		router.POST("/wallet/sweep/seed", RequirePassword(api.walletSweepSeedHandler, requiredPassword))
+		router.GET("/wallet/backup", requireScope(api.walletBackupHandler, ScopeWalletSpend))
+		router.GET("/wallet/events", api.walletEventsHandler)
+		router.POST("/wallet/init", requireScope(api.walletInitHandler, ScopeWalletSpend))
+		router.POST("/wallet/init/seed", requireScope(api.walletInitSeedHandler, ScopeWalletSpend))
+		router.POST("/wallet/lock", requireScope(api.walletLockHandler, ScopeWalletSpend))
+		router.GET("/wallet/reorgs", api.walletReorgsHandler)
+		router.POST("/wallet/seed", requireScope(api.walletSeedHandler, ScopeWalletSpend))
+		router.GET("/wallet/seeds", requireScope(api.walletSeedsHandler, ScopeWalletSpend))
+		router.POST("/wallet/siacoins", requireScope(api.walletSiacoinsHandler, ScopeWalletSpend))
+		router.POST("/wallet/siafunds", requireScope(api.walletSiafundsHandler, ScopeWalletSpend))
+		router.POST("/wallet/siagkey", requireScope(api.walletSiagkeyHandler, ScopeWalletSpend))
+		router.POST("/wallet/sweep/seed", requireScope(api.walletSweepSeedHandler, ScopeWalletSpend))
 		router.GET("/wallet/transaction/:id", api.walletTransactionHandler)
 		router.GET("/wallet/transactions", api.walletTransactionsHandler)
 		router.GET("/wallet/transactions/:addr", api.walletTransactionsAddrHandler)
 		router.GET("/wallet/verify/address/:addr", api.walletVerifyAddressHandler)
-		router.POST("/wallet/unlock", RequirePassword(api.walletUnlockHandler, requiredPassword))
-		router.POST("/wallet/changepassword", RequirePassword(api.walletChangePasswordHandler, requiredPassword))
+		router.GET("/wallet/webhooks", requireScope(api.walletWebhooksHandlerGET, ScopeReadOnly))
+		router.POST("/wallet/webhooks", requireScope(api.walletWebhooksHandlerPOST, ScopeWalletSpend))
+		router.POST("/wallet/webhooks/remove/:id", requireScope(api.walletWebhooksRemoveHandler, ScopeWalletSpend))
+		router.POST("/wallet/unlock", requireScope(api.walletUnlockHandler, ScopeWalletSpend))
+		router.POST("/wallet/changepassword", requireScope(api.walletChangePasswordHandler, ScopeWalletSpend))
 	}
 
-	// Apply UserAgent middleware and return the Router
-	api.router = cleanCloseHandler(RequireUserAgent(router, requiredUserAgent))
+	// Apply UserAgent and versioning middleware and return the Router
+	api.router = cleanCloseHandler(APIVersionHandler(RequireUserAgent(router, requiredUserAgent)))
+	api.routes = router.routes
 	return
 }
 
+// routeRecorder is an httprouter.Router that records the routes registered
+// with it, so that they can be checked against the OpenAPI specification.
+type routeRecorder struct {
+	*httprouter.Router
+	routes []string
+}
+
+// GET registers a GET route.
+func (rr *routeRecorder) GET(path string, handle httprouter.Handle) {
+	rr.routes = append(rr.routes, "GET "+path)
+	rr.Router.GET(path, handle)
+}
+
+// POST registers a POST route.
+func (rr *routeRecorder) POST(path string, handle httprouter.Handle) {
+	rr.routes = append(rr.routes, "POST "+path)
+	rr.Router.POST(path, handle)
+}
+
 // cleanCloseHandler wraps the entire API, ensuring that underlying conns are
 // not leaked if the remote end closes the connection before the underlying
 // handler finishes.
@@ -174,7 +239,7 @@ func cleanCloseHandler(next http.Handler) http.Handler {
 func RequireUserAgent(h http.Handler, ua string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if !strings.Contains(req.UserAgent(), ua) && !isUnrestricted(req) {
-			WriteError(w, Error{"Browser access disabled due to security vulnerability. Use Sia-UI or siac."}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "Browser access disabled due to security vulnerability. Use Sia-UI or siac.", Code: ErrCodeForbidden}, http.StatusBadRequest)
 			return
 		}
 		h.ServeHTTP(w, req)
@@ -193,14 +258,15 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 		_, pass, ok := req.BasicAuth()
 		if !ok || pass != password {
 			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
-			WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
+			WriteError(w, Error{Message: "API authentication failed.", Code: ErrCodeUnauthorized}, http.StatusUnauthorized)
 			return
 		}
 		h(w, req, ps)
 	}
 }
 
-// isUnrestricted checks if a request may bypass the useragent check.
+// isUnrestricted checks if a request may bypass the useragent check. The
+// metrics are unrestricted so that they can be scraped by Prometheus.
 func isUnrestricted(req *http.Request) bool {
-	return strings.HasPrefix(req.URL.Path, "/renter/stream/")
+	return strings.HasPrefix(req.URL.Path, "/renter/stream/") || req.URL.Path == "/metrics"
 }
@@ -57,6 +57,11 @@ func (srv *Server) APIAddress() string {
 	return srv.listener.Addr().String()
 }
 
+// APIRoutes returns the routes served by the server's API.
+func (srv *Server) APIRoutes() []string {
+	return srv.api.Routes()
+}
+
 // GatewayAddress returns the underlying node's gateway address
 func (srv *Server) GatewayAddress() modules.NetAddress {
 	return srv.node.Gateway.Address()
@@ -90,7 +95,7 @@ func New(APIaddr string, requiredUserAgent string, requiredPassword string, node
 	}
 
 	// Create the api for the server.
-	api := api.New(requiredUserAgent, requiredPassword, node.ConsensusSet, node.Explorer, node.Gateway, node.Host, node.Miner, node.Renter, node.TransactionPool, node.Wallet)
+	api := api.New(requiredUserAgent, requiredPassword, nil, node.ConsensusSet, node.Explorer, node.Gateway, node.Host, node.Miner, node.Renter, node.TransactionPool, node.Wallet)
 	srv := &Server{
 		api: api,
 		apiServer: &http.Server{
@@ -0,0 +1,313 @@
+package api
+
+// tokens.go implements named API tokens. Every token has a set of scopes that
+// determine which protected routes it may call. Tokens are sent as the
+// password of HTTP basic auth, just like the API password, and only the hash
+// of a token is stored on disk. The API password remains valid and grants
+// every scope.
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/acejam/Sia/crypto"
+	"github.com/acejam/Sia/persist"
+
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+const (
+	// ScopeReadOnly allows calling protected routes that do not modify any
+	// state. Every token has this scope implicitly.
+	ScopeReadOnly APIScope = "read-only"
+
+	// ScopeRenter allows calling the protected renter routes.
+	ScopeRenter APIScope = "renter"
+
+	// ScopeHost allows calling the protected host routes.
+	ScopeHost APIScope = "host"
+
+	// ScopeWalletSpend allows calling the protected wallet routes, including
+	// the routes that spend funds or reveal the seeds of the wallet.
+	ScopeWalletSpend APIScope = "wallet-spend"
+
+	// ScopeAdmin allows calling every protected route, including the routes
+	// that manage the tokens.
+	ScopeAdmin APIScope = "admin"
+)
+
+const (
+	// tokensFile is the name of the file in the sia directory that stores the
+	// hashed tokens.
+	tokensFile = "apitokens.json"
+
+	// tokensLogFile is the name of the audit log of the protected calls.
+	tokensLogFile = "apiaudit.log"
+
+	// tokenSecretSize is the number of random bytes of a token.
+	tokenSecretSize = 32
+)
+
+var (
+	// errTokenExists is returned when a token is added with the name of an
+	// existing token.
+	errTokenExists = errors.New("a token with that name already exists")
+
+	// errTokenNotFound is returned when an unknown token is removed.
+	errTokenNotFound = errors.New("no token with that name exists")
+
+	// errTokenNoName is returned when a token is added without a name.
+	errTokenNoName = errors.New("token name must not be empty")
+
+	// errTokenNoScopes is returned when a token is added without scopes.
+	errTokenNoScopes = errors.New("token must have at least one scope")
+
+	// errUnknownScope is returned when a token is added with an unknown
+	// scope.
+	errUnknownScope = errors.New("unknown token scope")
+
+	// tokensMetadata contains the header and version strings that identify
+	// the tokens file.
+	tokensMetadata = persist.Metadata{
+		Header:  "Sia API Tokens",
+		Version: "1.3.4",
+	}
+
+	// validScopes contains all scopes that can be given to a token.
+	validScopes = map[APIScope]struct{}{
+		ScopeReadOnly:    {},
+		ScopeRenter:      {},
+		ScopeHost:        {},
+		ScopeWalletSpend: {},
+		ScopeAdmin:       {},
+	}
+)
+
+type (
+	// APIScope is a permission of an API token.
+	APIScope string
+
+	// APIToken contains the name and the scopes of an API token. The token
+	// itself is only known when it is created.
+	APIToken struct {
+		Name    string     `json:"name"`
+		Scopes  []APIScope `json:"scopes"`
+		Created time.Time  `json:"created"`
+	}
+
+	// storedToken is an APIToken as it is stored on disk.
+	storedToken struct {
+		APIToken
+		Hash crypto.Hash `json:"hash"`
+	}
+
+	// A TokenStore stores the hashes of the API tokens of a daemon and writes
+	// the audit log of the protected API calls.
+	TokenStore struct {
+		log         *persist.Logger
+		persistPath string
+		tokens      map[crypto.Hash]storedToken
+		mu          sync.Mutex
+	}
+)
+
+// HasScope returns true if the token grants the scope.
+func (t APIToken) HasScope(scope APIScope) bool {
+	if scope == ScopeReadOnly {
+		return true
+	}
+	for _, s := range t.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTokenStore loads the tokens stored in dir and opens the audit log. An
+// empty dir refers to the working directory.
+func NewTokenStore(dir string) (*TokenStore, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+	log, err := persist.NewFileLogger(filepath.Join(dir, tokensLogFile))
+	if err != nil {
+		return nil, err
+	}
+	ts := &TokenStore{
+		log:         log,
+		persistPath: filepath.Join(dir, tokensFile),
+		tokens:      make(map[crypto.Hash]storedToken),
+	}
+
+	var tokens []storedToken
+	err = persist.LoadJSON(tokensMetadata, &tokens, ts.persistPath)
+	if err != nil && !os.IsNotExist(err) {
+		log.Close()
+		return nil, err
+	}
+	for _, t := range tokens {
+		ts.tokens[t.Hash] = t
+	}
+	return ts, nil
+}
+
+// Close closes the audit log.
+func (ts *TokenStore) Close() error {
+	return ts.log.Close()
+}
+
+// save stores the tokens on disk.
+func (ts *TokenStore) save() error {
+	tokens := make([]storedToken, 0, len(ts.tokens))
+	for _, t := range ts.tokens {
+		tokens = append(tokens, t)
+	}
+	return persist.SaveJSON(tokensMetadata, tokens, ts.persistPath)
+}
+
+// AddToken creates a new token with the given name and scopes. The returned
+// secret is the token that clients send as their API password; it is not
+// stored and cannot be retrieved later.
+func (ts *TokenStore) AddToken(name string, scopes []APIScope) (string, error) {
+	if name == "" {
+		return "", errTokenNoName
+	} else if len(scopes) == 0 {
+		return "", errTokenNoScopes
+	}
+	for _, s := range scopes {
+		if _, ok := validScopes[s]; !ok {
+			return "", errUnknownScope
+		}
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, t := range ts.tokens {
+		if t.Name == name {
+			return "", errTokenExists
+		}
+	}
+	secret := hex.EncodeToString(fastrand.Bytes(tokenSecretSize))
+	t := storedToken{
+		APIToken: APIToken{
+			Name:    name,
+			Scopes:  scopes,
+			Created: time.Now(),
+		},
+		Hash: crypto.HashBytes([]byte(secret)),
+	}
+	ts.tokens[t.Hash] = t
+	if err := ts.save(); err != nil {
+		delete(ts.tokens, t.Hash)
+		return "", err
+	}
+	ts.log.Printf("Added API token '%v' with scopes %v", name, scopes)
+	return secret, nil
+}
+
+// RemoveToken removes the token with the given name.
+func (ts *TokenStore) RemoveToken(name string) error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for h, t := range ts.tokens {
+		if t.Name != name {
+			continue
+		}
+		delete(ts.tokens, h)
+		if err := ts.save(); err != nil {
+			ts.tokens[h] = t
+			return err
+		}
+		ts.log.Printf("Removed API token '%v'", name)
+		return nil
+	}
+	return errTokenNotFound
+}
+
+// Tokens returns the tokens of the store, sorted by name.
+func (ts *TokenStore) Tokens() []APIToken {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	tokens := make([]APIToken, 0, len(ts.tokens))
+	for _, t := range ts.tokens {
+		tokens = append(tokens, t.APIToken)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Name < tokens[j].Name
+	})
+	return tokens
+}
+
+// authenticate returns the token with the given secret. It is safe to call
+// authenticate on a nil TokenStore.
+func (ts *TokenStore) authenticate(secret string) (APIToken, bool) {
+	if ts == nil {
+		return APIToken{}, false
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	t, ok := ts.tokens[crypto.HashBytes([]byte(secret))]
+	return t.APIToken, ok
+}
+
+// empty returns true if the store contains no tokens. A nil TokenStore is
+// empty.
+func (ts *TokenStore) empty() bool {
+	if ts == nil {
+		return true
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return len(ts.tokens) == 0
+}
+
+// logCall writes an audit log line for a protected call. It is safe to call
+// logCall on a nil TokenStore.
+func (ts *TokenStore) logCall(req *http.Request, caller string) {
+	if ts == nil {
+		return
+	}
+	ts.log.Printf("%v %v called by %v from %v", req.Method, req.URL.Path, caller, req.RemoteAddr)
+}
+
+// RequireScope is middleware that requires a request to authenticate with the
+// API password or with a token that has the given scope, using HTTP basic
+// auth. Usernames are ignored. If the password is empty and there are no
+// tokens, no authentication is required. Every authenticated call is written
+// to the audit log of the tokens.
+func RequireScope(h httprouter.Handle, password string, tokens *TokenStore, scope APIScope) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		if password == "" && tokens.empty() {
+			h(w, req, ps)
+			return
+		}
+		_, pass, ok := req.BasicAuth()
+		if ok && password != "" && pass == password {
+			tokens.logCall(req, "API password")
+			h(w, req, ps)
+			return
+		}
+		token, ok := tokens.authenticate(pass)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
+			WriteError(w, Error{Message: "API authentication failed.", Code: ErrCodeUnauthorized}, http.StatusUnauthorized)
+			return
+		} else if !token.HasScope(scope) {
+			WriteError(w, Error{Message: "API token '" + token.Name + "' does not have the " + string(scope) + " scope.", Code: ErrCodeForbidden}, http.StatusForbidden)
+			return
+		}
+		tokens.logCall(req, "token '"+token.Name+"'")
+		h(w, req, ps)
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/acejam/Sia/build"
+)
+
+// TestTokenStore checks that tokens can be added, authenticated, persisted
+// and removed.
+func TestTokenStore(t *testing.T) {
+	dir := build.TempDir("api", t.Name())
+	ts, err := NewTokenStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := ts.AddToken("renter", []APIScope{ScopeRenter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ts.AddToken("renter", []APIScope{ScopeHost}); err != errTokenExists {
+		t.Fatal("expected errTokenExists, got", err)
+	}
+	if _, err := ts.AddToken("foo", []APIScope{"foo"}); err != errUnknownScope {
+		t.Fatal("expected errUnknownScope, got", err)
+	}
+	if _, err := ts.AddToken("foo", nil); err != errTokenNoScopes {
+		t.Fatal("expected errTokenNoScopes, got", err)
+	}
+
+	token, ok := ts.authenticate(secret)
+	if !ok || token.Name != "renter" {
+		t.Fatal("token was not authenticated")
+	}
+	if !token.HasScope(ScopeRenter) || !token.HasScope(ScopeReadOnly) || token.HasScope(ScopeWalletSpend) {
+		t.Fatal("token has the wrong scopes:", token.Scopes)
+	}
+
+	// The tokens are persisted.
+	if err := ts.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ts, err = NewTokenStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+	if tokens := ts.Tokens(); len(tokens) != 1 || tokens[0].Name != "renter" {
+		t.Fatal("tokens were not persisted:", tokens)
+	}
+	if _, ok := ts.authenticate(secret); !ok {
+		t.Fatal("token was not authenticated after reloading")
+	}
+
+	if err := ts.RemoveToken("renter"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ts.authenticate(secret); ok {
+		t.Fatal("removed token was authenticated")
+	}
+	if err := ts.RemoveToken("renter"); err != errTokenNotFound {
+		t.Fatal("expected errTokenNotFound, got", err)
+	}
+}
+
+// TestRequireScope checks that RequireScope accepts the API password and
+// tokens with the required scope, and rejects all other requests.
+func TestRequireScope(t *testing.T) {
+	ts, err := NewTokenStore(build.TempDir("api", t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.Close()
+
+	handler := func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		WriteSuccess(w)
+	}
+	call := func(h httprouter.Handle, password string) int {
+		req := httptest.NewRequest("POST", "/wallet/siacoins", nil)
+		if password != "" {
+			req.SetBasicAuth("", password)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req, nil)
+		return rec.Code
+	}
+
+	// Without a password and without tokens, no authentication is required.
+	h := RequireScope(handler, "", ts, ScopeWalletSpend)
+	if code := call(h, ""); code != http.StatusNoContent {
+		t.Fatal("unauthenticated call failed without password and tokens:", code)
+	}
+
+	renter, err := ts.AddToken("renter", []APIScope{ScopeRenter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := ts.AddToken("admin", []APIScope{ScopeAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = RequireScope(handler, "password", ts, ScopeWalletSpend)
+	tests := []struct {
+		password string
+		code     int
+	}{
+		{"", http.StatusUnauthorized},
+		{"foo", http.StatusUnauthorized},
+		{"password", http.StatusNoContent},
+		{renter, http.StatusForbidden},
+		{admin, http.StatusNoContent},
+	}
+	for _, test := range tests {
+		if code := call(h, test.password); code != test.code {
+			t.Errorf("expected status %v for %q, got %v", test.code, test.password, code)
+		}
+	}
+}
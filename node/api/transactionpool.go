@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/base64"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -12,11 +13,29 @@ import (
 	"github.com/acejam/Sia/types"
 )
 
+const (
+	// tpoolTransactionsDefaultLimit is the number of transactions returned by
+	// /tpool/transactions if no limit is specified.
+	tpoolTransactionsDefaultLimit = 100
+
+	// tpoolTransactionsMaxLimit is the maximum number of transactions returned
+	// by a single call to /tpool/transactions.
+	tpoolTransactionsMaxLimit = 1000
+)
+
 type (
-	// TpoolFeeGET contains the current estimated fee
+	// TpoolGET contains statistics about the unconfirmed sets in the
+	// transaction pool.
+	TpoolGET struct {
+		modules.TransactionPoolStats
+	}
+
+	// TpoolFeeGET contains the current estimated fee for the requested
+	// confirmation target.
 	TpoolFeeGET struct {
-		Minimum types.Currency `json:"minimum"`
-		Maximum types.Currency `json:"maximum"`
+		Minimum types.Currency    `json:"minimum"`
+		Maximum types.Currency    `json:"maximum"`
+		Target  types.BlockHeight `json:"target"`
 	}
 
 	// TpoolRawGET contains the requested transaction encoded to the raw
@@ -27,6 +46,41 @@ type (
 		Transaction []byte              `json:"transaction"`
 	}
 
+	// TpoolHistogramGET contains the fee-rate histogram of the transaction
+	// pool.
+	TpoolHistogramGET struct {
+		Buckets []modules.TransactionPoolFeeBucket `json:"buckets"`
+	}
+
+	// TpoolSetGET contains an unconfirmed set and its transactions.
+	TpoolSetGET struct {
+		modules.TransactionPoolSet
+		Transactions []types.Transaction `json:"transactions"`
+	}
+
+	// TpoolSetsGET contains the unconfirmed sets of the transaction pool,
+	// ordered by fee rate from highest to lowest.
+	TpoolSetsGET struct {
+		Sets []modules.TransactionPoolSet `json:"sets"`
+	}
+
+	// TpoolTransaction is an unconfirmed transaction along with the set it
+	// belongs to and the fee rate of that set.
+	TpoolTransaction struct {
+		ID          types.TransactionID      `json:"id"`
+		SetID       modules.TransactionSetID `json:"setid"`
+		FeeRate     types.Currency           `json:"feerate"` // hastings / byte
+		Transaction types.Transaction        `json:"transaction"`
+	}
+
+	// TpoolTransactionsGET contains a page of the unconfirmed transactions of
+	// the transaction pool. Total is the number of transactions matching the
+	// filter.
+	TpoolTransactionsGET struct {
+		Transactions []TpoolTransaction `json:"transactions"`
+		Total        int                `json:"total"`
+	}
+
 	// TpoolConfirmedGET contains information about whether or not
 	// the transaction has been seen on the blockhain
 	TpoolConfirmedGET struct {
@@ -34,6 +88,32 @@ type (
 	}
 )
 
+// transactionRelatesToAddress returns true if the transaction spends from or
+// sends to the provided address.
+func transactionRelatesToAddress(txn types.Transaction, addr types.UnlockHash) bool {
+	for _, sci := range txn.SiacoinInputs {
+		if sci.UnlockConditions.UnlockHash() == addr {
+			return true
+		}
+	}
+	for _, sco := range txn.SiacoinOutputs {
+		if sco.UnlockHash == addr {
+			return true
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if sfi.UnlockConditions.UnlockHash() == addr {
+			return true
+		}
+	}
+	for _, sfo := range txn.SiafundOutputs {
+		if sfo.UnlockHash == addr {
+			return true
+		}
+	}
+	return false
+}
+
 // decodeTransactionID will decode a transaction id from a string.
 func decodeTransactionID(txidStr string) (types.TransactionID, error) {
 	txid := new(crypto.Hash)
@@ -44,13 +124,136 @@ func decodeTransactionID(txidStr string) (types.TransactionID, error) {
 	return types.TransactionID(*txid), nil
 }
 
-// tpoolFeeHandlerGET returns the current estimated fee. Transactions with
-// fees are lower than the estimated fee may take longer to confirm.
+// tpoolHandlerGET returns statistics about the unconfirmed sets in the
+// transaction pool, including the restored and rebroadcast sets.
+func (api *API) tpoolHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	WriteJSON(w, TpoolGET{
+		TransactionPoolStats: api.tpool.Stats(),
+	})
+}
+
+// tpoolHistogramHandlerGET returns a histogram of the fee rates of the
+// unconfirmed sets in the transaction pool.
+func (api *API) tpoolHistogramHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	WriteJSON(w, TpoolHistogramGET{
+		Buckets: api.tpool.FeeHistogram(),
+	})
+}
+
+// tpoolSetsHandlerGET returns the unconfirmed sets of the transaction pool.
+func (api *API) tpoolSetsHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	WriteJSON(w, TpoolSetsGET{
+		Sets: api.tpool.TransactionSets(),
+	})
+}
+
+// tpoolSetHandlerGET returns the unconfirmed set with the requested id and its
+// transactions.
+func (api *API) tpoolSetHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id crypto.Hash
+	if err := id.LoadString(ps.ByName("id")); err != nil {
+		WriteError(w, Error{Message: "error decoding set id:" + err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
+		return
+	}
+	set, txns, exists := api.tpool.TransactionSetInfo(modules.TransactionSetID(id))
+	if !exists {
+		WriteError(w, Error{Message: "set not found in transaction pool", Code: ErrCodeNotFound}, http.StatusNotFound)
+		return
+	}
+	WriteJSON(w, TpoolSetGET{
+		TransactionPoolSet: set,
+		Transactions:       txns,
+	})
+}
+
+// tpoolTransactionsHandlerGET returns a page of the unconfirmed transactions in
+// the transaction pool, optionally filtered by address. The transactions are
+// ordered by the fee rate of their set from highest to lowest.
+func (api *API) tpoolTransactionsHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the filter and pagination parameters.
+	var addr types.UnlockHash
+	filter := req.FormValue("address") != ""
+	if filter {
+		var err error
+		addr, err = scanAddress(req.FormValue("address"))
+		if err != nil {
+			WriteError(w, Error{Message: "error parsing address:" + err.Error(), Code: ErrCodeInvalidParameter, Param: "address"}, http.StatusBadRequest)
+			return
+		}
+	}
+	offset, limit := 0, tpoolTransactionsDefaultLimit
+	if o := req.FormValue("offset"); o != "" {
+		n, err := strconv.Atoi(o)
+		if err != nil || n < 0 {
+			WriteError(w, Error{Message: "unable to parse offset", Code: ErrCodeInvalidParameter, Param: "offset"}, http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+	if l := req.FormValue("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > tpoolTransactionsMaxLimit {
+			WriteError(w, Error{Message: "limit must be between 1 and " + strconv.Itoa(tpoolTransactionsMaxLimit), Code: ErrCodeInvalidParameter, Param: "limit"}, http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	// Collect the matching transactions.
+	var matches []TpoolTransaction
+	for _, set := range api.tpool.TransactionSets() {
+		_, txns, exists := api.tpool.TransactionSetInfo(set.ID)
+		if !exists {
+			continue
+		}
+		for i, txn := range txns {
+			if filter && !transactionRelatesToAddress(txn, addr) {
+				continue
+			}
+			matches = append(matches, TpoolTransaction{
+				ID:          set.TransactionIDs[i],
+				SetID:       set.ID,
+				FeeRate:     set.FeeRate,
+				Transaction: txn,
+			})
+		}
+	}
+
+	tg := TpoolTransactionsGET{
+		Transactions: []TpoolTransaction{},
+		Total:        len(matches),
+	}
+	if offset < len(matches) {
+		end := offset + limit
+		if end > len(matches) {
+			end = len(matches)
+		}
+		tg.Transactions = matches[offset:end]
+	}
+	WriteJSON(w, tg)
+}
+
+// tpoolFeeHandlerGET returns the current estimated fee for confirmation within
+// the requested number of blocks. Transactions with fees are lower than the
+// estimated fee may take longer to confirm.
 func (api *API) tpoolFeeHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-	min, max := api.tpool.FeeEstimation()
+	target := modules.FeeTargetDefault
+	if t := req.FormValue("target"); t != "" {
+		n, err := strconv.ParseUint(t, 10, 64)
+		if err != nil || n == 0 {
+			WriteError(w, Error{Message: "target must be a positive number of blocks", Code: ErrCodeInvalidParameter, Param: "target"}, http.StatusBadRequest)
+			return
+		}
+		target = types.BlockHeight(n)
+	}
+	if target > modules.FeeEstimationWindow {
+		target = modules.FeeEstimationWindow
+	}
+	min, max := api.tpool.FeeEstimationTarget(target)
 	WriteJSON(w, TpoolFeeGET{
 		Minimum: min,
 		Maximum: max,
+		Target:  target,
 	})
 }
 
@@ -59,12 +262,12 @@ func (api *API) tpoolFeeHandlerGET(w http.ResponseWriter, req *http.Request, ps
 func (api *API) tpoolRawHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	txid, err := decodeTransactionID(ps.ByName("id"))
 	if err != nil {
-		WriteError(w, Error{"error decoding transaction id:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error decoding transaction id:" + err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
 		return
 	}
 	txn, parents, exists := api.tpool.Transaction(txid)
 	if !exists {
-		WriteError(w, Error{"transaction not found in transaction pool"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "transaction not found in transaction pool", Code: ErrCodeNotFound}, http.StatusBadRequest)
 		return
 	}
 
@@ -95,12 +298,12 @@ func (api *API) tpoolRawHandlerPOST(w http.ResponseWriter, req *http.Request, _
 	var txn types.Transaction
 	err = encoding.Unmarshal(rawParents, &parents)
 	if err != nil {
-		WriteError(w, Error{"error decoding parents:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error decoding parents:" + err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
 		return
 	}
 	err = encoding.Unmarshal(rawTransaction, &txn)
 	if err != nil {
-		WriteError(w, Error{"error decoding transaction:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error decoding transaction:" + err.Error(), Code: ErrCodeInvalidParameter}, http.StatusBadRequest)
 		return
 	}
 	txnSet := append(parents, txn)
@@ -110,7 +313,7 @@ func (api *API) tpoolRawHandlerPOST(w http.ResponseWriter, req *http.Request, _
 	api.tpool.Broadcast(txnSet)
 	err = api.tpool.AcceptTransactionSet(txnSet)
 	if err != nil && err != modules.ErrDuplicateTransactionSet {
-		WriteError(w, Error{"error accepting transaction set:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error accepting transaction set:" + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -121,12 +324,12 @@ func (api *API) tpoolRawHandlerPOST(w http.ResponseWriter, req *http.Request, _
 func (api *API) tpoolConfirmedGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	txid, err := decodeTransactionID(ps.ByName("id"))
 	if err != nil {
-		WriteError(w, Error{"error decoding transaction id:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error decoding transaction id:" + err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
 		return
 	}
 	confirmed, err := api.tpool.TransactionConfirmed(txid)
 	if err != nil {
-		WriteError(w, Error{"error fetching transaction status:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error fetching transaction status:" + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, TpoolConfirmedGET{
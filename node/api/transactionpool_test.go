@@ -3,6 +3,8 @@ package api
 import (
 	"bytes"
 	"encoding/base64"
+	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"testing"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/acejam/Sia/build"
 	"github.com/acejam/Sia/encoding"
+	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/types"
 )
 
@@ -203,6 +206,52 @@ func TestTransactionPoolFee(t *testing.T) {
 	if !min.Equals(fees.Minimum) || !max.Equals(fees.Maximum) {
 		t.Fatal("fee mismatch")
 	}
+
+	// Targets beyond the estimation window are reported as the window.
+	err = st.getAPI(fmt.Sprintf("/tpool/fee?target=%v", modules.FeeEstimationWindow+10), &fees)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Target != modules.FeeEstimationWindow {
+		t.Fatalf("expected target %v, got %v", modules.FeeEstimationWindow, fees.Target)
+	}
+}
+
+// TestTransactionPoolSetNotFound checks that the /tpool/sets/:id endpoint
+// reports unknown sets as not found.
+func TestTransactionPoolSetNotFound(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	id := modules.TransactionSetID{}.String()
+	tests := []struct {
+		call string
+		err  Error
+	}{
+		{"/tpool/sets/" + id, Error{Message: "set not found in transaction pool"}},
+		{"/v2/tpool/sets/" + id, Error{Message: "set not found in transaction pool", Code: ErrCodeNotFound}},
+	}
+	for _, test := range tests {
+		resp, err := HttpGET("http://" + st.server.listener.Addr().String() + test.call)
+		if err != nil {
+			t.Fatal(err)
+		}
+		apiErr := decodeError(resp)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%v: expected status %v, got %v", test.call, http.StatusNotFound, resp.StatusCode)
+		}
+		if apiErr != test.err {
+			t.Errorf("%v: expected %+v, got %+v", test.call, test.err, apiErr)
+		}
+	}
 }
 
 // TestTransactionPoolConfirmed tests the /tpool/confirmed endpoint.
@@ -256,3 +305,26 @@ func TestTransactionPoolConfirmed(t *testing.T) {
 		t.Fatal("transaction should not be confirmed")
 	}
 }
+
+// TestTransactionRelatesToAddress probes the transactionRelatesToAddress
+// function.
+func TestTransactionRelatesToAddress(t *testing.T) {
+	uc := types.UnlockConditions{SignaturesRequired: 1}
+	addr := uc.UnlockHash()
+	tests := []struct {
+		txn     types.Transaction
+		related bool
+	}{
+		{types.Transaction{}, false},
+		{types.Transaction{SiacoinInputs: []types.SiacoinInput{{UnlockConditions: uc}}}, true},
+		{types.Transaction{SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: addr}}}, true},
+		{types.Transaction{SiacoinOutputs: []types.SiacoinOutput{{UnlockHash: types.UnlockHash{1}}}}, false},
+		{types.Transaction{SiafundInputs: []types.SiafundInput{{UnlockConditions: uc}}}, true},
+		{types.Transaction{SiafundOutputs: []types.SiafundOutput{{UnlockHash: addr}}}, true},
+	}
+	for i, test := range tests {
+		if transactionRelatesToAddress(test.txn, addr) != test.related {
+			t.Errorf("test %v: expected %v", i, test.related)
+		}
+	}
+}
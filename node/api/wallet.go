@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -8,6 +9,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/acejam/Sia/crypto"
 	"github.com/acejam/Sia/modules"
@@ -17,6 +19,16 @@ import (
 	"gitlab.com/NebulousLabs/entropy-mnemonics"
 )
 
+const (
+	// walletEventsDefaultTimeout is the time a call to /wallet/events waits
+	// for new events if no timeout is specified.
+	walletEventsDefaultTimeout = 30 * time.Second
+
+	// walletEventsMaxTimeout is the longest time a call to /wallet/events
+	// waits for new events.
+	walletEventsMaxTimeout = 5 * time.Minute
+)
+
 type (
 	// WalletGET contains general information about the wallet.
 	WalletGET struct {
@@ -35,6 +47,26 @@ type (
 		DustThreshold types.Currency `json:"dustthreshold"`
 	}
 
+	// WalletAccountGET contains an account returned by a call to
+	// /wallet/accounts/:name.
+	WalletAccountGET struct {
+		Account modules.WalletAccount `json:"account"`
+	}
+
+	// WalletAccountsGET contains the accounts of the wallet returned by a GET
+	// call to /wallet/accounts.
+	WalletAccountsGET struct {
+		Accounts []modules.WalletAccount `json:"accounts"`
+	}
+
+	// WalletAccountTransactionsGET contains the confirmed and unconfirmed
+	// transactions of an account returned by a call to
+	// /wallet/accounts/:name/transactions.
+	WalletAccountTransactionsGET struct {
+		ConfirmedTransactions   []modules.ProcessedTransaction `json:"confirmedtransactions"`
+		UnconfirmedTransactions []modules.ProcessedTransaction `json:"unconfirmedtransactions"`
+	}
+
 	// WalletAddressGET contains an address returned by a GET call to
 	// /wallet/address.
 	WalletAddressGET struct {
@@ -47,6 +79,18 @@ type (
 		Addresses []types.UnlockHash `json:"addresses"`
 	}
 
+	// WalletReorgsGET contains the most recent wallet transactions that were
+	// reverted by a reorg, oldest first.
+	WalletReorgsGET struct {
+		Transactions []modules.ReorgedTransaction `json:"transactions"`
+	}
+
+	// WalletEventsGET contains the events returned by a call to
+	// /wallet/events.
+	WalletEventsGET struct {
+		Events []modules.WalletEvent `json:"events"`
+	}
+
 	// WalletInitPOST contains the primary seed that gets generated during a
 	// POST call to /wallet/init.
 	WalletInitPOST struct {
@@ -100,6 +144,18 @@ type (
 		UnconfirmedTransactions []modules.ProcessedTransaction `json:"unconfirmedtransactions"`
 	}
 
+	// WalletWebhooksGET contains the webhooks returned by a GET call to
+	// /wallet/webhooks.
+	WalletWebhooksGET struct {
+		Webhooks []modules.WalletWebhook `json:"webhooks"`
+	}
+
+	// WalletWebhooksPOST contains the webhook that was registered by a POST
+	// call to /wallet/webhooks.
+	WalletWebhooksPOST struct {
+		Webhook modules.WalletWebhook `json:"webhook"`
+	}
+
 	// WalletVerifyAddressGET contains a bool indicating if the address passed to
 	// /wallet/verify/address/:addr is a valid address.
 	WalletVerifyAddressGET struct {
@@ -126,37 +182,37 @@ func encryptionKeys(seedStr string) (validKeys []crypto.TwofishKey) {
 func (api *API) walletHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	siacoinBal, siafundBal, siaclaimBal, err := api.wallet.ConfirmedBalance()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	siacoinsOut, siacoinsIn, err := api.wallet.UnconfirmedBalance()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	dustThreshold, err := api.wallet.DustThreshold()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	encrypted, err := api.wallet.Encrypted()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	unlocked, err := api.wallet.Unlocked()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	rescanning, err := api.wallet.Rescanning()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	height, err := api.wallet.Height()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet: %v", err), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletGET{
@@ -181,7 +237,7 @@ func (api *API) wallet033xHandler(w http.ResponseWriter, req *http.Request, _ ht
 	source := req.FormValue("source")
 	// Check that source is an absolute paths.
 	if !filepath.IsAbs(source) {
-		WriteError(w, Error{"error when calling /wallet/033x: source must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/033x: source must be an absolute path", Code: ErrCodeInvalidParameter, Param: "source"}, http.StatusBadRequest)
 		return
 	}
 	potentialKeys := encryptionKeys(req.FormValue("encryptionpassword"))
@@ -192,18 +248,126 @@ func (api *API) wallet033xHandler(w http.ResponseWriter, req *http.Request, _ ht
 			return
 		}
 		if err != nil && err != modules.ErrBadEncryptionKey {
-			WriteError(w, Error{"error when calling /wallet/033x: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/033x: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 			return
 		}
 	}
-	WriteError(w, Error{modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	WriteError(w, Error{Message: modules.ErrBadEncryptionKey.Error(), Code: ErrCodeInvalidParameter, Param: "encryptionpassword"}, http.StatusBadRequest)
+}
+
+// walletAccountsHandlerGET handles GET calls to /wallet/accounts.
+func (api *API) walletAccountsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	accounts, err := api.wallet.Accounts()
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAccountsGET{
+		Accounts: accounts,
+	})
+}
+
+// walletAccountsHandlerPOST handles POST calls to /wallet/accounts.
+func (api *API) walletAccountsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	account, err := api.wallet.CreateAccount(req.FormValue("name"))
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAccountGET{
+		Account: account,
+	})
+}
+
+// walletAccountHandler handles API calls to /wallet/accounts/:name.
+func (api *API) walletAccountHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	account, err := api.wallet.Account(ps.ByName("name"))
+	if err == modules.ErrUnknownWalletAccount {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts/:name: " + err.Error(), Code: ErrCodeNotFound}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts/:name: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAccountGET{
+		Account: account,
+	})
+}
+
+// walletAccountAddressHandler handles API calls to
+// /wallet/accounts/:name/address.
+func (api *API) walletAccountAddressHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	unlockConditions, err := api.wallet.AccountNextAddress(ps.ByName("name"))
+	if err == modules.ErrUnknownWalletAccount {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts/:name/address: " + err.Error(), Code: ErrCodeNotFound}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts/:name/address: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAddressGET{
+		Address: unlockConditions.UnlockHash(),
+	})
+}
+
+// walletAccountSiacoinsHandler handles API calls to
+// /wallet/accounts/:name/siacoins.
+func (api *API) walletAccountSiacoinsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{Message: "could not read amount from POST call to /wallet/accounts/:name/siacoins", Code: ErrCodeInvalidParameter, Param: "amount"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{Message: "could not read address from POST call to /wallet/accounts/:name/siacoins", Code: ErrCodeInvalidParameter, Param: "destination"}, http.StatusBadRequest)
+		return
+	}
+	txns, err := api.wallet.SendSiacoinsFromAccount(ps.ByName("name"), amount, dest)
+	if err == modules.ErrUnknownWalletAccount {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts/:name/siacoins: " + err.Error(), Code: ErrCodeNotFound}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts/:name/siacoins: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusInternalServerError)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		TransactionIDs: txids,
+	})
+}
+
+// walletAccountTransactionsHandler handles API calls to
+// /wallet/accounts/:name/transactions.
+func (api *API) walletAccountTransactionsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	name := ps.ByName("name")
+	confirmedTxns, err := api.wallet.AccountTransactions(name)
+	if err == modules.ErrUnknownWalletAccount {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts/:name/transactions: " + err.Error(), Code: ErrCodeNotFound}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts/:name/transactions: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	unconfirmedTxns, err := api.wallet.AccountUnconfirmedTransactions(name)
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/accounts/:name/transactions: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAccountTransactionsGET{
+		ConfirmedTransactions:   confirmedTxns,
+		UnconfirmedTransactions: unconfirmedTxns,
+	})
 }
 
 // walletAddressHandler handles API calls to /wallet/address.
 func (api *API) walletAddressHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	unlockConditions, err := api.wallet.NextAddress()
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/addresses: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/addresses: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletAddressGET{
@@ -215,7 +379,7 @@ func (api *API) walletAddressHandler(w http.ResponseWriter, req *http.Request, _
 func (api *API) walletAddressesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	addresses, err := api.wallet.AllAddresses()
 	if err != nil {
-		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet/addresses: %v", err)}, http.StatusBadRequest)
+		WriteError(w, Error{Message: fmt.Sprintf("Error when calling /wallet/addresses: %v", err), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletAddressesGET{
@@ -228,17 +392,70 @@ func (api *API) walletBackupHandler(w http.ResponseWriter, req *http.Request, _
 	destination := req.FormValue("destination")
 	// Check that the destination is absolute.
 	if !filepath.IsAbs(destination) {
-		WriteError(w, Error{"error when calling /wallet/backup: destination must be an absolute path"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/backup: destination must be an absolute path", Code: ErrCodeInvalidParameter, Param: "destination"}, http.StatusBadRequest)
 		return
 	}
 	err := api.wallet.CreateBackup(destination)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/backup: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/backup: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
 }
 
+// walletEventsHandler handles API calls to /wallet/events. If there are no
+// events newer than 'since', the call blocks until a new event occurs or the
+// timeout expires.
+func (api *API) walletEventsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var since uint64
+	if s := req.FormValue("since"); s != "" {
+		var err error
+		since, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			WriteError(w, Error{Message: "parsing integer value for parameter `since` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "since"}, http.StatusBadRequest)
+			return
+		}
+	}
+	timeout := walletEventsDefaultTimeout
+	if t := req.FormValue("timeout"); t != "" {
+		secs, err := strconv.ParseUint(t, 10, 64)
+		if err != nil {
+			WriteError(w, Error{Message: "parsing integer value for parameter `timeout` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "timeout"}, http.StatusBadRequest)
+			return
+		}
+		timeout = time.Duration(secs) * time.Second
+		if timeout > walletEventsMaxTimeout {
+			timeout = walletEventsMaxTimeout
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	defer cancel()
+	events, err := api.wallet.Events(since, ctx.Done())
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/events: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletEventsGET{
+		Events: events,
+	})
+}
+
+// walletReorgsHandler handles API calls to /wallet/reorgs.
+func (api *API) walletReorgsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	rts, err := api.wallet.ReorgedTransactions()
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/reorgs: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	if rts == nil {
+		rts = []modules.ReorgedTransaction{}
+	}
+	WriteJSON(w, WalletReorgsGET{
+		Transactions: rts,
+	})
+}
+
 // walletInitHandler handles API calls to /wallet/init.
 func (api *API) walletInitHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var encryptionKey crypto.TwofishKey
@@ -249,13 +466,13 @@ func (api *API) walletInitHandler(w http.ResponseWriter, req *http.Request, _ ht
 	if req.FormValue("force") == "true" {
 		err := api.wallet.Reset()
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/init: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 			return
 		}
 	}
 	seed, err := api.wallet.Encrypt(encryptionKey)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/init: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
@@ -265,7 +482,7 @@ func (api *API) walletInitHandler(w http.ResponseWriter, req *http.Request, _ ht
 	}
 	seedStr, err := modules.SeedToString(seed, dictID)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/init: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "dictionary"}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletInitPOST{
@@ -285,21 +502,21 @@ func (api *API) walletInitSeedHandler(w http.ResponseWriter, req *http.Request,
 	}
 	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/init/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/init/seed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "seed"}, http.StatusBadRequest)
 		return
 	}
 
 	if req.FormValue("force") == "true" {
 		err = api.wallet.Reset()
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/init/seed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/init/seed: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 			return
 		}
 	}
 
 	err = api.wallet.InitFromSeed(encryptionKey, seed)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/init/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/init/seed: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -314,7 +531,7 @@ func (api *API) walletSeedHandler(w http.ResponseWriter, req *http.Request, _ ht
 	}
 	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/seed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "seed"}, http.StatusBadRequest)
 		return
 	}
 
@@ -326,11 +543,11 @@ func (api *API) walletSeedHandler(w http.ResponseWriter, req *http.Request, _ ht
 			return
 		}
 		if err != nil && err != modules.ErrBadEncryptionKey {
-			WriteError(w, Error{"error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/seed: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 			return
 		}
 	}
-	WriteError(w, Error{"error when calling /wallet/seed: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	WriteError(w, Error{Message: "error when calling /wallet/seed: " + modules.ErrBadEncryptionKey.Error(), Code: ErrCodeInvalidParameter, Param: "encryptionpassword"}, http.StatusBadRequest)
 }
 
 // walletSiagkeyHandler handles API calls to /wallet/siagkey.
@@ -342,7 +559,7 @@ func (api *API) walletSiagkeyHandler(w http.ResponseWriter, req *http.Request, _
 	for _, keypath := range keyfiles {
 		// Check that all key paths are absolute paths.
 		if !filepath.IsAbs(keypath) {
-			WriteError(w, Error{"error when calling /wallet/siagkey: keyfiles contains a non-absolute path"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/siagkey: keyfiles contains a non-absolute path", Code: ErrCodeInvalidParameter, Param: "keyfiles"}, http.StatusBadRequest)
 			return
 		}
 	}
@@ -354,18 +571,18 @@ func (api *API) walletSiagkeyHandler(w http.ResponseWriter, req *http.Request, _
 			return
 		}
 		if err != nil && err != modules.ErrBadEncryptionKey {
-			WriteError(w, Error{"error when calling /wallet/siagkey: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/siagkey: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 			return
 		}
 	}
-	WriteError(w, Error{"error when calling /wallet/siagkey: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	WriteError(w, Error{Message: "error when calling /wallet/siagkey: " + modules.ErrBadEncryptionKey.Error(), Code: ErrCodeInvalidParameter, Param: "encryptionpassword"}, http.StatusBadRequest)
 }
 
 // walletLockHanlder handles API calls to /wallet/lock.
 func (api *API) walletLockHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	err := api.wallet.Lock()
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteSuccess(w)
@@ -381,26 +598,26 @@ func (api *API) walletSeedsHandler(w http.ResponseWriter, req *http.Request, _ h
 	// Get the primary seed information.
 	primarySeed, addrsRemaining, err := api.wallet.PrimarySeed()
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/seeds: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	primarySeedStr, err := modules.SeedToString(primarySeed, dictionary)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/seeds: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "dictionary"}, http.StatusBadRequest)
 		return
 	}
 
 	// Get the list of seeds known to the wallet.
 	allSeeds, err := api.wallet.AllSeeds()
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/seeds: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	var allSeedsStrs []string
 	for _, seed := range allSeeds {
 		str, err := modules.SeedToString(seed, dictionary)
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/seeds: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "dictionary"}, http.StatusBadRequest)
 			return
 		}
 		allSeedsStrs = append(allSeedsStrs, str)
@@ -418,37 +635,37 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 	if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
 		if req.FormValue("amount") != "" || req.FormValue("destination") != "" {
-			WriteError(w, Error{"cannot supply both 'outputs' and single amount+destination pair"}, http.StatusInternalServerError)
+			WriteError(w, Error{Message: "cannot supply both 'outputs' and single amount+destination pair", Code: ErrCodeInvalidParameter, Param: "outputs"}, http.StatusInternalServerError)
 			return
 		}
 
 		var outputs []types.SiacoinOutput
 		err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
 		if err != nil {
-			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, Error{Message: "could not decode outputs: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "outputs"}, http.StatusInternalServerError)
 			return
 		}
 		txns, err = api.wallet.SendSiacoinsMulti(outputs)
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, Error{Message: "error when calling /wallet/siacoins: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusInternalServerError)
 			return
 		}
 	} else {
 		// single amount + destination
 		amount, ok := scanAmount(req.FormValue("amount"))
 		if !ok {
-			WriteError(w, Error{"could not read amount from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "could not read amount from POST call to /wallet/siacoins", Code: ErrCodeInvalidParameter, Param: "amount"}, http.StatusBadRequest)
 			return
 		}
 		dest, err := scanAddress(req.FormValue("destination"))
 		if err != nil {
-			WriteError(w, Error{"could not read address from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "could not read address from POST call to /wallet/siacoins", Code: ErrCodeInvalidParameter, Param: "destination"}, http.StatusBadRequest)
 			return
 		}
 
 		txns, err = api.wallet.SendSiacoins(amount, dest)
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, Error{Message: "error when calling /wallet/siacoins: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusInternalServerError)
 			return
 		}
 
@@ -467,18 +684,18 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 func (api *API) walletSiafundsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	amount, ok := scanAmount(req.FormValue("amount"))
 	if !ok {
-		WriteError(w, Error{"could not read 'amount' from POST call to /wallet/siafunds"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "could not read 'amount' from POST call to /wallet/siafunds", Code: ErrCodeInvalidParameter, Param: "amount"}, http.StatusBadRequest)
 		return
 	}
 	dest, err := scanAddress(req.FormValue("destination"))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/siafunds: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "destination"}, http.StatusBadRequest)
 		return
 	}
 
 	txns, err := api.wallet.SendSiafunds(amount, dest)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, Error{Message: "error when calling /wallet/siafunds: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusInternalServerError)
 		return
 	}
 	var txids []types.TransactionID
@@ -499,13 +716,13 @@ func (api *API) walletSweepSeedHandler(w http.ResponseWriter, req *http.Request,
 	}
 	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/sweep/seed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "seed"}, http.StatusBadRequest)
 		return
 	}
 
 	coins, funds, err := api.wallet.SweepSeed(seed)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/sweep/seed: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletSweepPOST{
@@ -521,17 +738,17 @@ func (api *API) walletTransactionHandler(w http.ResponseWriter, req *http.Reques
 	jsonID := "\"" + ps.ByName("id") + "\""
 	err := id.UnmarshalJSON([]byte(jsonID))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transaction/id:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/transaction/id:" + err.Error(), Code: ErrCodeInvalidParameter, Param: "id"}, http.StatusBadRequest)
 		return
 	}
 
 	txn, ok, err := api.wallet.Transaction(id)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transaction/id:" + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/transaction/id:" + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	if !ok {
-		WriteError(w, Error{"error when calling /wallet/transaction/:id  :  transaction not found"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/transaction/:id  :  transaction not found", Code: ErrCodeNotFound}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletTransactionGETid{
@@ -543,13 +760,13 @@ func (api *API) walletTransactionHandler(w http.ResponseWriter, req *http.Reques
 func (api *API) walletTransactionsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	startheightStr, endheightStr := req.FormValue("startheight"), req.FormValue("endheight")
 	if startheightStr == "" || endheightStr == "" {
-		WriteError(w, Error{"startheight and endheight must be provided to a /wallet/transactions call."}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "startheight and endheight must be provided to a /wallet/transactions call.", Code: ErrCodeMissingParameter}, http.StatusBadRequest)
 		return
 	}
 	// Get the start and end blocks.
 	start, err := strconv.ParseUint(startheightStr, 10, 64)
 	if err != nil {
-		WriteError(w, Error{"parsing integer value for parameter `startheight` failed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "parsing integer value for parameter `startheight` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "startheight"}, http.StatusBadRequest)
 		return
 	}
 	// Check if endheightStr is set to -1. If it is, we use MaxUint64 as the
@@ -561,17 +778,17 @@ func (api *API) walletTransactionsHandler(w http.ResponseWriter, req *http.Reque
 		end, err = strconv.ParseUint(endheightStr, 10, 64)
 	}
 	if err != nil {
-		WriteError(w, Error{"parsing integer value for parameter `endheight` failed: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "parsing integer value for parameter `endheight` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "endheight"}, http.StatusBadRequest)
 		return
 	}
 	confirmedTxns, err := api.wallet.Transactions(types.BlockHeight(start), types.BlockHeight(end))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/transactions: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	unconfirmedTxns, err := api.wallet.UnconfirmedTransactions()
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/transactions: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 
@@ -589,18 +806,18 @@ func (api *API) walletTransactionsAddrHandler(w http.ResponseWriter, req *http.R
 	var addr types.UnlockHash
 	err := addr.UnmarshalJSON([]byte(jsonAddr))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/transactions: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "addr"}, http.StatusBadRequest)
 		return
 	}
 
 	confirmedATs, err := api.wallet.AddressTransactions(addr)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/transactions: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	unconfirmedATs, err := api.wallet.AddressUnconfirmedTransactions(addr)
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "error when calling /wallet/transactions: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletTransactionsGETaddr{
@@ -619,11 +836,11 @@ func (api *API) walletUnlockHandler(w http.ResponseWriter, req *http.Request, _
 			return
 		}
 		if err != nil && err != modules.ErrBadEncryptionKey {
-			WriteError(w, Error{"error when calling /wallet/unlock: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/unlock: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 			return
 		}
 	}
-	WriteError(w, Error{"error when calling /wallet/unlock: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	WriteError(w, Error{Message: "error when calling /wallet/unlock: " + modules.ErrBadEncryptionKey.Error(), Code: ErrCodeInvalidParameter, Param: "encryptionpassword"}, http.StatusBadRequest)
 }
 
 // walletChangePasswordHandler handles API calls to /wallet/changepassword
@@ -631,7 +848,7 @@ func (api *API) walletChangePasswordHandler(w http.ResponseWriter, req *http.Req
 	var newKey crypto.TwofishKey
 	newPassword := req.FormValue("newpassword")
 	if newPassword == "" {
-		WriteError(w, Error{"a password must be provided to newpassword"}, http.StatusBadRequest)
+		WriteError(w, Error{Message: "a password must be provided to newpassword", Code: ErrCodeMissingParameter, Param: "newpassword"}, http.StatusBadRequest)
 		return
 	}
 	newKey = crypto.TwofishKey(crypto.HashObject(newPassword))
@@ -644,11 +861,11 @@ func (api *API) walletChangePasswordHandler(w http.ResponseWriter, req *http.Req
 			return
 		}
 		if err != nil && err != modules.ErrBadEncryptionKey {
-			WriteError(w, Error{"error when calling /wallet/changepassword: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{Message: "error when calling /wallet/changepassword: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
 			return
 		}
 	}
-	WriteError(w, Error{"error when calling /wallet/changepassword: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	WriteError(w, Error{Message: "error when calling /wallet/changepassword: " + modules.ErrBadEncryptionKey.Error(), Code: ErrCodeInvalidParameter, Param: "encryptionpassword"}, http.StatusBadRequest)
 }
 
 // walletVerifyAddressHandler handles API calls to /wallet/verify/address/:addr.
@@ -658,3 +875,61 @@ func (api *API) walletVerifyAddressHandler(w http.ResponseWriter, req *http.Requ
 	err := new(types.UnlockHash).LoadString(addrString)
 	WriteJSON(w, WalletVerifyAddressGET{Valid: err == nil})
 }
+
+// walletWebhooksHandlerGET handles GET calls to /wallet/webhooks.
+func (api *API) walletWebhooksHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	hooks, err := api.wallet.Webhooks()
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/webhooks: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletWebhooksGET{
+		Webhooks: hooks,
+	})
+}
+
+// walletWebhooksHandlerPOST handles POST calls to /wallet/webhooks.
+func (api *API) walletWebhooksHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var confirmations uint64
+	if c := req.FormValue("confirmations"); c != "" {
+		var err error
+		confirmations, err = strconv.ParseUint(c, 10, 64)
+		if err != nil {
+			WriteError(w, Error{Message: "parsing integer value for parameter `confirmations` failed: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "confirmations"}, http.StatusBadRequest)
+			return
+		}
+	}
+	var addrs []types.UnlockHash
+	if a := req.FormValue("addresses"); a != "" {
+		for _, addrStr := range strings.Split(a, ",") {
+			addr, err := scanAddress(strings.TrimSpace(addrStr))
+			if err != nil {
+				WriteError(w, Error{Message: "could not read address from POST call to /wallet/webhooks: " + err.Error(), Code: ErrCodeInvalidParameter, Param: "addresses"}, http.StatusBadRequest)
+				return
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	hook, err := api.wallet.RegisterWebhook(req.FormValue("url"), req.FormValue("secret"), types.BlockHeight(confirmations), addrs)
+	if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/webhooks: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletWebhooksPOST{
+		Webhook: hook,
+	})
+}
+
+// walletWebhooksRemoveHandler handles API calls to
+// /wallet/webhooks/remove/:id.
+func (api *API) walletWebhooksRemoveHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	err := api.wallet.RemoveWebhook(ps.ByName("id"))
+	if err == modules.ErrUnknownWebhook {
+		WriteError(w, Error{Message: "error when calling /wallet/webhooks/remove: " + err.Error(), Code: ErrCodeNotFound}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		WriteError(w, Error{Message: "error when calling /wallet/webhooks/remove: " + err.Error(), Code: ErrCodeModuleFailure}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -294,7 +294,11 @@ func New(params NodeParams) (*Node, error) {
 		if err != nil {
 			return nil, err
 		}
-		hc, err := contractor.NewCustomContractor(cs, &contractor.WalletBridge{W: w}, tp, hdb, contractSet, contractor.NewPersist(persistDir), logger, contractorDeps)
+		cp, err := contractor.NewPersist(persistDir)
+		if err != nil {
+			return nil, err
+		}
+		hc, err := contractor.NewCustomContractor(cs, &contractor.WalletBridge{W: w}, tp, hdb, contractSet, cp, logger, contractorDeps)
 		if err != nil {
 			return nil, err
 		}
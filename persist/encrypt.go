@@ -0,0 +1,207 @@
+package persist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	// KeyFilename is the name of the file in the sia directory that stores
+	// the salt and verification of the key that encrypts the persist files of
+	// the modules. siad encrypts the persist files if and only if this file
+	// exists.
+	KeyFilename = "metadatakey.json"
+
+	// kdfArgon2id is the name of the argon2id key derivation function in the
+	// key file.
+	kdfArgon2id = "argon2id"
+
+	// encryptedVersionSuffix is appended to the version of the metadata of
+	// encrypted JSON files, so that encrypted files are never mistaken for
+	// plaintext files by LoadJSON.
+	encryptedVersionSuffix = "-encrypted"
+)
+
+var (
+	// ErrBadPassphrase is returned when a passphrase does not match the key
+	// of the key file.
+	ErrBadPassphrase = errors.New("passphrase does not match the metadata key")
+
+	// ErrEncrypted is returned by LoadJSON when loading a file that was saved
+	// with SaveEncryptedJSON.
+	ErrEncrypted = errors.New("file is encrypted")
+
+	// ErrKeyFileExists is returned when creating a key file in a directory
+	// that already contains one.
+	ErrKeyFileExists = errors.New("metadata key already exists")
+
+	// errUnknownKDF is returned when a key file uses a key derivation
+	// function that is not supported.
+	errUnknownKDF = errors.New("metadata key uses an unknown key derivation function")
+
+	// keyFileMetadata is the metadata of the key file.
+	keyFileMetadata = Metadata{
+		Header:  "Sia Metadata Key",
+		Version: "1.1",
+	}
+
+	// defaultKDFParams are the argon2id parameters of new key files. The
+	// memory is given in KiB. Testing builds use less memory to keep the
+	// tests fast.
+	defaultKDFParams = KDFParams{
+		Algorithm: kdfArgon2id,
+		Time:      1,
+		Memory: build.Select(build.Var{
+			Standard: uint32(64 * 1024),
+			Dev:      uint32(64 * 1024),
+			Testing:  uint32(1024),
+		}).(uint32),
+		Threads: 4,
+	}
+
+	// keyVerificationPlaintext is the plaintext used to verify passphrases.
+	// The key file stores the corresponding ciphertext, so that a passphrase
+	// can be checked by decrypting it.
+	keyVerificationPlaintext = make([]byte, 32)
+)
+
+type (
+	// A KeyFile stores what is needed to derive and verify the key that
+	// encrypts the persist files of the modules. The key itself is never
+	// stored.
+	KeyFile struct {
+		KDF          KDFParams         `json:"kdf"`
+		Salt         [32]byte          `json:"salt"`
+		Verification crypto.Ciphertext `json:"verification"`
+	}
+
+	// KDFParams are the parameters of the function that derives the key of
+	// a key file from a passphrase. Memory is given in KiB.
+	KDFParams struct {
+		Algorithm string `json:"algorithm"`
+		Time      uint32 `json:"time"`
+		Memory    uint32 `json:"memory"`
+		Threads   uint8  `json:"threads"`
+	}
+
+	// encryptedObject is the object of an encrypted JSON file.
+	encryptedObject struct {
+		Ciphertext crypto.Ciphertext `json:"ciphertext"`
+	}
+)
+
+// deriveKey derives the key that encrypts the persist files from a
+// passphrase and the salt and KDF parameters of the key file.
+func deriveKey(params KDFParams, salt [32]byte, passphrase string) (crypto.TwofishKey, error) {
+	if params.Algorithm != kdfArgon2id || params.Time == 0 || params.Threads == 0 {
+		return crypto.TwofishKey{}, errUnknownKDF
+	}
+	var key crypto.TwofishKey
+	copy(key[:], argon2.IDKey([]byte(passphrase), salt[:], params.Time, params.Memory, params.Threads, uint32(len(key))))
+	return key, nil
+}
+
+// encryptedMetadata returns the metadata of the encrypted version of a JSON
+// file.
+func encryptedMetadata(meta Metadata) Metadata {
+	return Metadata{
+		Header:  meta.Header,
+		Version: meta.Version + encryptedVersionSuffix,
+	}
+}
+
+// CreateKeyFile creates the key file in dir and returns the key derived from
+// passphrase. It returns ErrKeyFileExists if dir already contains a key file.
+func CreateKeyFile(dir, passphrase string) (crypto.TwofishKey, error) {
+	if passphrase == "" {
+		return crypto.TwofishKey{}, errors.New("passphrase must not be empty")
+	}
+	filename := filepath.Join(dir, KeyFilename)
+	if _, err := os.Stat(filename); err == nil {
+		return crypto.TwofishKey{}, ErrKeyFileExists
+	} else if !os.IsNotExist(err) {
+		return crypto.TwofishKey{}, err
+	}
+
+	kf := KeyFile{KDF: defaultKDFParams}
+	fastrand.Read(kf.Salt[:])
+	key, err := deriveKey(kf.KDF, kf.Salt, passphrase)
+	if err != nil {
+		return crypto.TwofishKey{}, err
+	}
+	kf.Verification = key.EncryptBytes(keyVerificationPlaintext)
+	if err := SaveJSON(keyFileMetadata, kf, filename); err != nil {
+		return crypto.TwofishKey{}, build.ExtendErr("unable to save metadata key", err)
+	}
+	return key, nil
+}
+
+// LoadKeyFile loads the key file in dir. If dir does not contain a key file,
+// the returned error satisfies os.IsNotExist.
+func LoadKeyFile(dir string) (KeyFile, error) {
+	var kf KeyFile
+	err := LoadJSON(keyFileMetadata, &kf, filepath.Join(dir, KeyFilename))
+	return kf, err
+}
+
+// Key derives the key from passphrase. It returns ErrBadPassphrase if the
+// passphrase is not the one the key file was created with.
+func (kf KeyFile) Key(passphrase string) (crypto.TwofishKey, error) {
+	key, err := deriveKey(kf.KDF, kf.Salt, passphrase)
+	if err != nil {
+		return crypto.TwofishKey{}, err
+	}
+	plaintext, err := key.DecryptBytes(kf.Verification)
+	if err != nil || !bytes.Equal(plaintext, keyVerificationPlaintext) {
+		return crypto.TwofishKey{}, ErrBadPassphrase
+	}
+	return key, nil
+}
+
+// encryptObject marshals object into json and encrypts it with key.
+func encryptObject(object interface{}, key crypto.TwofishKey) (encryptedObject, error) {
+	plaintext, err := json.Marshal(object)
+	if err != nil {
+		return encryptedObject{}, build.ExtendErr("unable to marshal the provided object", err)
+	}
+	return encryptedObject{Ciphertext: key.EncryptBytes(plaintext)}, nil
+}
+
+// SaveEncryptedJSON saves a json object to disk like SaveJSON, but encrypts
+// the object with key. The header and version of the file remain readable.
+func SaveEncryptedJSON(meta Metadata, object interface{}, filename string, key crypto.TwofishKey) error {
+	eo, err := encryptObject(object, key)
+	if err != nil {
+		return err
+	}
+	return SaveJSON(encryptedMetadata(meta), eo, filename)
+}
+
+// LoadEncryptedJSON loads a json object that was saved with
+// SaveEncryptedJSON. Files that were saved with SaveJSON are loaded as well,
+// so that the persist files of a module are encrypted the next time the
+// module saves them.
+func LoadEncryptedJSON(meta Metadata, object interface{}, filename string, key crypto.TwofishKey) error {
+	var eo encryptedObject
+	err := LoadJSON(encryptedMetadata(meta), &eo, filename)
+	if err == ErrBadVersion {
+		// The file has not been encrypted yet.
+		return LoadJSON(meta, object, filename)
+	} else if err != nil {
+		return err
+	}
+	plaintext, err := key.DecryptBytes(eo.Ciphertext)
+	if err != nil {
+		return build.ExtendErr("unable to decrypt persisted json object", err)
+	}
+	return json.Unmarshal(plaintext, object)
+}
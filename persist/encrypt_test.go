@@ -0,0 +1,123 @@
+package persist
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acejam/Sia/build"
+)
+
+// TestKeyFile tests creating and loading a key file and deriving its key.
+func TestKeyFile(t *testing.T) {
+	dir := filepath.Join(build.TempDir(persistDir), t.Name())
+	os.RemoveAll(dir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a key file, the persist files are not encrypted.
+	if _, err := LoadKeyFile(dir); !os.IsNotExist(err) {
+		t.Fatal("expected a not exist error, got", err)
+	}
+	if _, err := CreateKeyFile(dir, ""); err == nil {
+		t.Fatal("expected an error for an empty passphrase")
+	}
+	key, err := CreateKeyFile(dir, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CreateKeyFile(dir, "foo"); err != ErrKeyFileExists {
+		t.Fatal("expected ErrKeyFileExists, got", err)
+	}
+
+	kf, err := LoadKeyFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key2, err := kf.Key("foo"); err != nil {
+		t.Fatal(err)
+	} else if key2 != key {
+		t.Fatal("derived key does not match the key of the key file")
+	}
+	if _, err := kf.Key("bar"); err != ErrBadPassphrase {
+		t.Fatal("expected ErrBadPassphrase, got", err)
+	}
+
+	// The key file stores the parameters of the key derivation function.
+	if kf.KDF != defaultKDFParams {
+		t.Fatal("key file has the wrong KDF parameters:", kf.KDF)
+	}
+	kf.KDF.Algorithm = "sha256"
+	if _, err := kf.Key("foo"); err != errUnknownKDF {
+		t.Fatal("expected errUnknownKDF, got", err)
+	}
+}
+
+// TestSaveLoadEncryptedJSON tests that encrypted JSON files can only be
+// loaded with their key, and that plaintext files can be loaded as encrypted
+// files.
+func TestSaveLoadEncryptedJSON(t *testing.T) {
+	dir := filepath.Join(build.TempDir(persistDir), t.Name())
+	os.RemoveAll(dir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	key, err := CreateKeyFile(dir, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := Metadata{"Test Struct", "v1.2.1"}
+	type testStruct struct {
+		Secret []byte
+	}
+	obj := testStruct{Secret: []byte("secret key")}
+	filename := filepath.Join(dir, "obj.json")
+
+	// A plaintext file can be loaded as an encrypted file.
+	if err := SaveJSON(meta, obj, filename); err != nil {
+		t.Fatal(err)
+	}
+	var obj2 testStruct
+	if err := LoadEncryptedJSON(meta, &obj2, filename, key); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(obj2.Secret, obj.Secret) {
+		t.Fatal("persist mismatch")
+	}
+
+	// The encrypted file does not contain the plaintext.
+	if err := SaveEncryptedJSON(meta, obj, filename, key); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	} else if bytes.Contains(contents, obj.Secret) {
+		t.Fatal("encrypted file contains the plaintext")
+	}
+	obj2 = testStruct{}
+	if err := LoadEncryptedJSON(meta, &obj2, filename, key); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(obj2.Secret, obj.Secret) {
+		t.Fatal("persist mismatch")
+	}
+
+	// The encrypted file cannot be loaded as a plaintext file or with the
+	// wrong key.
+	if err := LoadJSON(meta, &obj2, filename); err != ErrEncrypted {
+		t.Fatal("expected ErrEncrypted, got", err)
+	}
+	otherDir := filepath.Join(dir, "other")
+	if err := os.MkdirAll(otherDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := CreateKeyFile(otherDir, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadEncryptedJSON(meta, &obj2, filename, otherKey); err == nil {
+		t.Fatal("expected an error when loading with the wrong key")
+	}
+}
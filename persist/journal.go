@@ -0,0 +1,347 @@
+package persist
+
+// A Journal makes updates to multiple files atomic. Every transaction is
+// written to the journal and synced before its updates are applied, so that a
+// transaction that was interrupted by a crash or power failure can be applied
+// again when the journal is opened.
+//
+// The journal starts with its metadata, followed by one transaction per line.
+// Every transaction contains the new contents of the files or file sections it
+// updates and a checksum. A transaction is committed once it is completely written to the
+// journal; transactions that were only partially written, and therefore have
+// no trailing newline or a bad checksum, were never committed and are
+// ignored. Since the updates of a transaction describe the full contents of
+// files or of sections of files, applying a transaction more than once has no
+// further effect.
+//
+// Once the updates of a transaction are applied, the journal is truncated
+// back to its metadata. A journal is shared by every module that opens it, so
+// that the updates of modules that persist their data in the same directory
+// are applied in order.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/acejam/Sia/build"
+	"github.com/acejam/Sia/crypto"
+)
+
+const (
+	// UpdateTypeRemoveFile is the type of an update that removes a file.
+	UpdateTypeRemoveFile = "removefile"
+
+	// UpdateTypeWriteAt is the type of an update that writes data to a file
+	// at an offset, leaving the rest of the file unchanged.
+	UpdateTypeWriteAt = "writeat"
+
+	// UpdateTypeWriteFile is the type of an update that replaces the contents
+	// of a file.
+	UpdateTypeWriteFile = "writefile"
+)
+
+var (
+	// ErrJournalClosed is returned when updating a journal that has been
+	// closed.
+	ErrJournalClosed = errors.New("journal has been closed")
+
+	// journalMetadata is the metadata of a journal.
+	journalMetadata = Metadata{
+		Header:  "Sia Journal",
+		Version: "1.0",
+	}
+
+	// openJournals contains the journals that are open, by filename, so that
+	// modules that open the same journal share it.
+	openJournals   = make(map[string]*Journal)
+	openJournalsMu sync.Mutex
+)
+
+type (
+	// A Journal is a write-ahead log of updates to files. It is safe for
+	// concurrent use.
+	Journal struct {
+		f         *os.File
+		filename  string
+		headerLen int64
+		refs      int
+		mu        sync.Mutex
+	}
+
+	// An Update is a modification of a single file.
+	Update struct {
+		Type     string `json:"type"`
+		Filename string `json:"filename"`
+		Data     []byte `json:"data,omitempty"`
+		Offset   int64  `json:"offset,omitempty"`
+	}
+
+	// journalTxn is a transaction of a journal.
+	journalTxn struct {
+		Updates  []Update    `json:"updates"`
+		Checksum crypto.Hash `json:"checksum"`
+	}
+)
+
+// checksum returns the checksum of the updates of a transaction.
+func (t journalTxn) checksum() crypto.Hash {
+	return crypto.HashObject(t.Updates)
+}
+
+// RemoveFileUpdate returns an update that removes filename.
+func RemoveFileUpdate(filename string) Update {
+	return Update{
+		Type:     UpdateTypeRemoveFile,
+		Filename: filename,
+	}
+}
+
+// WriteAtUpdate returns an update that writes data to filename at offset. The
+// file must exist.
+func WriteAtUpdate(filename string, data []byte, offset int64) Update {
+	return Update{
+		Type:     UpdateTypeWriteAt,
+		Filename: filename,
+		Data:     data,
+		Offset:   offset,
+	}
+}
+
+// WriteFileUpdate returns an update that replaces the contents of filename
+// with data, creating the file and its directory if they do not exist.
+func WriteFileUpdate(filename string, data []byte) Update {
+	return Update{
+		Type:     UpdateTypeWriteFile,
+		Filename: filename,
+		Data:     data,
+	}
+}
+
+// JSONFileUpdate returns an update that saves a json object to filename in
+// the format of SaveJSON. The file can be loaded with LoadJSON.
+func JSONFileUpdate(meta Metadata, object interface{}, filename string) (Update, error) {
+	data, err := marshalJSON(meta, object)
+	if err != nil {
+		return Update{}, err
+	}
+	return WriteFileUpdate(filename, data), nil
+}
+
+// EncryptedJSONFileUpdate returns an update that saves a json object to
+// filename in the format of SaveEncryptedJSON. The file can be loaded with
+// LoadEncryptedJSON.
+func EncryptedJSONFileUpdate(meta Metadata, object interface{}, filename string, key crypto.TwofishKey) (Update, error) {
+	eo, err := encryptObject(object, key)
+	if err != nil {
+		return Update{}, err
+	}
+	return JSONFileUpdate(encryptedMetadata(meta), eo, filename)
+}
+
+// apply applies the update to the filesystem and syncs the updated file.
+// Applying an update more than once has no further effect.
+func (u Update) apply() error {
+	switch u.Type {
+	case UpdateTypeRemoveFile:
+		err := os.Remove(u.Filename)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	case UpdateTypeWriteAt:
+		f, err := os.OpenFile(u.Filename, os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteAt(u.Data, u.Offset)
+		if err == nil {
+			err = f.Sync()
+		}
+		return build.ComposeErrors(err, f.Close())
+	case UpdateTypeWriteFile:
+		if err := os.MkdirAll(filepath.Dir(u.Filename), 0700); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(u.Filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(u.Data)
+		if err == nil {
+			err = f.Sync()
+		}
+		return build.ComposeErrors(err, f.Close())
+	}
+	return fmt.Errorf("unknown journal update type %q", u.Type)
+}
+
+// readJournal reads the committed transactions of a journal from r, which is
+// positioned after the metadata. Reading stops at the first transaction that
+// was not committed.
+func readJournal(r io.Reader) []journalTxn {
+	var txns []journalTxn
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			// The last transaction was only partially written.
+			return txns
+		}
+		var t journalTxn
+		if err := json.Unmarshal(line, &t); err != nil || t.Checksum != t.checksum() {
+			return txns
+		}
+		txns = append(txns, t)
+	}
+}
+
+// OpenJournal opens the journal at filename, creating it if it does not
+// exist. The committed transactions of the journal are applied before it is
+// returned. If the journal is open already, the open journal is returned and
+// must be closed once more.
+func OpenJournal(filename string) (*Journal, error) {
+	openJournalsMu.Lock()
+	defer openJournalsMu.Unlock()
+	if j, ok := openJournals[filename]; ok {
+		j.refs++
+		return j, nil
+	}
+
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, build.ExtendErr("unable to open journal", err)
+	}
+	j, err := openJournal(f, filename)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	openJournals[filename] = j
+	return j, nil
+}
+
+// openJournal applies the committed transactions of the journal in f and
+// truncates it.
+func openJournal(f *os.File, filename string) (*Journal, error) {
+	var header bytes.Buffer
+	enc := json.NewEncoder(&header)
+	enc.Encode(journalMetadata.Header)
+	enc.Encode(journalMetadata.Version)
+	j := &Journal{
+		f:         f,
+		filename:  filename,
+		headerLen: int64(header.Len()),
+		refs:      1,
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() == 0 {
+		// Write the metadata of a new journal.
+		if _, err := f.Write(header.Bytes()); err != nil {
+			return nil, build.ExtendErr("unable to write journal metadata", err)
+		}
+		return j, f.Sync()
+	}
+
+	// Read the metadata.
+	var meta Metadata
+	dec := json.NewDecoder(f)
+	if err := dec.Decode(&meta.Header); err != nil {
+		return nil, build.ExtendErr("unable to read journal header", err)
+	} else if meta.Header != journalMetadata.Header {
+		return nil, ErrBadHeader
+	}
+	if err := dec.Decode(&meta.Version); err != nil {
+		return nil, build.ExtendErr("unable to read journal version", err)
+	} else if meta.Version != journalMetadata.Version {
+		return nil, ErrBadVersion
+	}
+
+	// Apply the committed transactions, in the order they were written.
+	if _, err := f.Seek(j.headerLen, io.SeekStart); err != nil {
+		return nil, err
+	}
+	for _, t := range readJournal(f) {
+		for _, u := range t.Updates {
+			if err := u.apply(); err != nil {
+				return nil, build.ExtendErr("unable to apply journal update", err)
+			}
+		}
+	}
+	return j, j.truncate()
+}
+
+// truncate truncates the journal back to its metadata. The caller must hold
+// j.mu.
+func (j *Journal) truncate() error {
+	if err := j.f.Truncate(j.headerLen); err != nil {
+		return build.ExtendErr("unable to truncate journal", err)
+	}
+	if _, err := j.f.Seek(j.headerLen, io.SeekStart); err != nil {
+		return err
+	}
+	return j.f.Sync()
+}
+
+// Update applies updates atomically: either all of them are applied, or, if
+// siad crashes before the transaction is committed, none of them. Once Update
+// returns without an error, the updates are durable.
+func (j *Journal) Update(updates ...Update) error {
+	t := journalTxn{Updates: updates}
+	t.Checksum = t.checksum()
+	b, err := json.Marshal(t)
+	if err != nil {
+		return build.ExtendErr("unable to marshal journal transaction", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.f == nil {
+		return ErrJournalClosed
+	}
+
+	// Commit the transaction.
+	if _, err := j.f.Write(append(b, '\n')); err != nil {
+		return build.ExtendErr("unable to write journal transaction", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return build.ExtendErr("unable to sync journal", err)
+	}
+
+	// Apply the updates. If an update fails, the transaction remains in the
+	// journal until the next transaction is applied, so that it is applied
+	// again if siad crashes before then.
+	for _, u := range updates {
+		if err := u.apply(); err != nil {
+			return build.ExtendErr("unable to apply journal update", err)
+		}
+	}
+	return j.truncate()
+}
+
+// Close closes the journal once every module that opened it has closed it.
+func (j *Journal) Close() error {
+	openJournalsMu.Lock()
+	defer openJournalsMu.Unlock()
+	j.refs--
+	if j.refs > 0 {
+		return nil
+	}
+	delete(openJournals, j.filename)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
@@ -0,0 +1,203 @@
+package persist
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acejam/Sia/build"
+)
+
+// newJournalTestDir creates an empty directory for a journal test.
+func newJournalTestDir(t *testing.T) string {
+	dir := filepath.Join(build.TempDir(persistDir), t.Name())
+	os.RemoveAll(dir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestJournalUpdate tests that the updates of a transaction are applied and
+// that the journal is truncated afterwards.
+func TestJournalUpdate(t *testing.T) {
+	dir := newJournalTestDir(t)
+	filename := filepath.Join(dir, "test.journal")
+	j, err := OpenJournal(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	type testStruct struct {
+		One string
+		Two int
+	}
+	fileA := filepath.Join(dir, "a")
+	fileB := filepath.Join(dir, "sub", "b")
+	meta := Metadata{"Test Struct", "v1"}
+	u, err := JSONFileUpdate(meta, testStruct{"foo", 1}, fileB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Update(WriteFileUpdate(fileA, []byte("foo")), u); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(fileA); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(b, []byte("foo")) {
+		t.Fatal("file has wrong contents:", string(b))
+	}
+	var obj testStruct
+	if err := LoadJSON(meta, &obj, fileB); err != nil {
+		t.Fatal(err)
+	} else if obj.One != "foo" || obj.Two != 1 {
+		t.Fatal("persist mismatch:", obj)
+	}
+
+	// Remove a file and write another one in the same transaction.
+	if err := j.Update(RemoveFileUpdate(fileA), WriteFileUpdate(fileA+"2", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(fileA); !os.IsNotExist(err) {
+		t.Fatal("expected the file to be removed, got", err)
+	}
+	if _, err := os.Stat(fileA + "2"); err != nil {
+		t.Fatal(err)
+	}
+	// Overwrite a section of a file.
+	if err := j.Update(WriteAtUpdate(fileA+"2", []byte("bar"), 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Update(WriteAtUpdate(fileA+"2", []byte("fo"), 0)); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(fileA + "2"); err != nil {
+		t.Fatal(err)
+	} else if string(b) != "fobar" {
+		t.Fatal("file has wrong contents:", string(b))
+	}
+	// Removing a file that does not exist is not an error.
+	if err := j.Update(RemoveFileUpdate(fileA)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The journal only contains its metadata.
+	if stat, err := os.Stat(filename); err != nil {
+		t.Fatal(err)
+	} else if stat.Size() != j.headerLen {
+		t.Fatalf("expected journal of %v bytes, got %v", j.headerLen, stat.Size())
+	}
+}
+
+// TestJournalReplay tests that committed transactions are applied when a
+// journal is opened, and that partially written transactions are ignored.
+func TestJournalReplay(t *testing.T) {
+	dir := newJournalTestDir(t)
+	filename := filepath.Join(dir, "test.journal")
+	j, err := OpenJournal(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write a committed transaction, followed by a transaction with a bad
+	// checksum and a partially written one, as if siad crashed.
+	fileA := filepath.Join(dir, "a")
+	fileB := filepath.Join(dir, "b")
+	fileC := filepath.Join(dir, "c")
+	marshalTxn := func(updates ...Update) []byte {
+		txn := journalTxn{Updates: updates}
+		txn.Checksum = txn.checksum()
+		b, err := json.Marshal(txn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(b, '\n')
+	}
+	committed := marshalTxn(WriteFileUpdate(fileA, []byte("foo")), WriteFileUpdate(fileB, []byte("bar")))
+	badChecksum := marshalTxn(WriteFileUpdate(fileC, []byte("baz")))
+	badChecksum = bytes.Replace(badChecksum, []byte(fileC), []byte(fileB), 1)
+	partial := marshalTxn(WriteFileUpdate(fileC, []byte("baz")))
+	partial = partial[:len(partial)/2]
+
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range [][]byte{committed, badChecksum, partial} {
+		if _, err := f.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Open the journal again. Only the committed transaction is applied.
+	j, err = OpenJournal(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if b, err := ioutil.ReadFile(fileA); err != nil || string(b) != "foo" {
+		t.Fatal("committed transaction was not applied:", string(b), err)
+	}
+	if b, err := ioutil.ReadFile(fileB); err != nil || string(b) != "bar" {
+		t.Fatal("committed transaction was not applied:", string(b), err)
+	}
+	if _, err := os.Stat(fileC); !os.IsNotExist(err) {
+		t.Fatal("uncommitted transaction was applied")
+	}
+	if stat, err := os.Stat(filename); err != nil {
+		t.Fatal(err)
+	} else if stat.Size() != j.headerLen {
+		t.Fatal("journal was not truncated after it was applied")
+	}
+}
+
+// TestJournalShared tests that a journal that is opened twice is shared, and
+// that it is only closed once it has been closed twice.
+func TestJournalShared(t *testing.T) {
+	dir := newJournalTestDir(t)
+	filename := filepath.Join(dir, "test.journal")
+	j1, err := OpenJournal(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j2, err := OpenJournal(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j1 != j2 {
+		t.Fatal("expected the journal to be shared")
+	}
+
+	if err := j1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := j2.Update(WriteFileUpdate(filepath.Join(dir, "a"), nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := j2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := j2.Update(WriteFileUpdate(filepath.Join(dir, "a"), nil)); err != ErrJournalClosed {
+		t.Fatal("expected ErrJournalClosed, got", err)
+	}
+
+	// A closed journal can be opened again.
+	j3, err := OpenJournal(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j3.Close()
+	if j3 == j1 {
+		t.Fatal("expected a new journal")
+	}
+}
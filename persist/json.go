@@ -117,6 +117,9 @@ func readJSON(meta Metadata, object interface{}, filename string) error {
 	if err := dec.Decode(&version); err != nil {
 		return build.ExtendErr("unable to read version from persisted json object file", err)
 	}
+	if version == meta.Version+encryptedVersionSuffix {
+		return ErrEncrypted
+	}
 	if version != meta.Version {
 		return ErrBadVersion
 	}
@@ -205,7 +208,7 @@ func LoadJSON(meta Metadata, object interface{}, filename string) error {
 
 	// Try opening the primary file.
 	err = readJSON(meta, object, filename)
-	if err == ErrBadHeader || err == ErrBadVersion || os.IsNotExist(err) {
+	if err == ErrBadHeader || err == ErrBadVersion || err == ErrEncrypted || os.IsNotExist(err) {
 		return err
 	}
 	if err != nil {
@@ -220,6 +223,33 @@ func LoadJSON(meta Metadata, object interface{}, filename string) error {
 	return nil
 }
 
+// marshalJSON returns the contents of a persisted json object file: the
+// metadata, the checksum of the object and the object itself.
+func marshalJSON(meta Metadata, object interface{}) ([]byte, error) {
+	// Write the metadata to the buffer.
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	if err := enc.Encode(meta.Header); err != nil {
+		return nil, build.ExtendErr("unable to encode metadata header", err)
+	}
+	if err := enc.Encode(meta.Version); err != nil {
+		return nil, build.ExtendErr("unable to encode metadata version", err)
+	}
+
+	// Marshal the object into json and write the checksum + result to the
+	// buffer.
+	objBytes, err := json.MarshalIndent(object, "", "\t")
+	if err != nil {
+		return nil, build.ExtendErr("unable to marshal the provided object", err)
+	}
+	checksum := crypto.HashBytes(objBytes)
+	if err := enc.Encode(checksum); err != nil {
+		return nil, build.ExtendErr("unable to encode checksum", err)
+	}
+	buf.Write(objBytes)
+	return buf.Bytes(), nil
+}
+
 // SaveJSON will save a json object to disk in a durable, atomic way. The
 // resulting file will have a checksum of the data as the third line. If
 // manually editing files, the checksum line can be replaced with the 8
@@ -254,28 +284,10 @@ func SaveJSON(meta Metadata, object interface{}, filename string) error {
 		activeFilesMu.Unlock()
 	}()
 
-	// Write the metadata to the buffer.
-	buf := new(bytes.Buffer)
-	enc := json.NewEncoder(buf)
-	if err := enc.Encode(meta.Header); err != nil {
-		return build.ExtendErr("unable to encode metadata header", err)
-	}
-	if err := enc.Encode(meta.Version); err != nil {
-		return build.ExtendErr("unable to encode metadata version", err)
-	}
-
-	// Marshal the object into json and write the checksum + result to the
-	// buffer.
-	objBytes, err := json.MarshalIndent(object, "", "\t")
+	data, err := marshalJSON(meta, object)
 	if err != nil {
-		return build.ExtendErr("unable to marshal the provided object", err)
-	}
-	checksum := crypto.HashBytes(objBytes)
-	if err := enc.Encode(checksum); err != nil {
-		return build.ExtendErr("unable to encode checksum", err)
+		return err
 	}
-	buf.Write(objBytes)
-	data := buf.Bytes()
 
 	// Write out the data to the temp file, with a sync.
 	err = func() (err error) {
@@ -1,28 +1,287 @@
 package persist
 
+// log.go implements the structured loggers of the modules. Every log line is
+// a JSON object with the time, the level, the module, the caller and the
+// message of the entry, followed by the key/value fields of the entry. The
+// level of every module can be changed at runtime with SetLogLevel.
+
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/acejam/Sia/build"
 )
 
-// Logger is a wrapper for the standard library logger that enforces logging
-// with the Sia-standard settings. It also supports a Close method, which
-// attempts to close the underlying io.Writer.
-type Logger struct {
-	*log.Logger
-	w io.Writer
+// The levels of a log entry, from least to most severe.
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelCritical
+)
+
+// The names of the fields that are shared by the modules. Entries about a
+// contract, a host or a file should use these names so that they can be
+// correlated across modules.
+const (
+	LogFieldContractID = "contractid"
+	LogFieldHostPubKey = "hostpubkey"
+	LogFieldModule     = "module"
+	LogFieldSiaPath    = "siapath"
+)
+
+// logTimeFormat is the format of the time of a log entry.
+const logTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+var (
+	// errUnknownLogLevel is returned by ParseLogLevel if the level is not
+	// known.
+	errUnknownLogLevel = errors.New("unknown log level")
+
+	// errUnknownLogModule is returned by SetLogLevel if no logger of the
+	// module was created.
+	errUnknownLogModule = errors.New("no logger of that module exists")
+
+	// logLevelNames are the names of the log levels.
+	logLevelNames = []string{"debug", "info", "warn", "error", "critical"}
+
+	// logModules counts the open loggers of every module, and logLevels
+	// contains the levels that were set with SetLogLevel.
+	logLevels   = make(map[string]LogLevel)
+	logModules  = make(map[string]int)
+	logLevelsMu sync.RWMutex
+)
+
+type (
+	// LogLevel is the severity of a log entry.
+	LogLevel int
+
+	// Logger writes structured log entries of a module. It embeds a standard
+	// library logger, so that Print, Printf and Println can be used to write
+	// entries with the info level. The Close method attempts to close the
+	// underlying io.Writer.
+	Logger struct {
+		*log.Logger
+		fields []interface{}
+		module string
+		out    *logOutput
+	}
+
+	// logOutput is the writer shared by a logger and the loggers derived from
+	// it with With.
+	logOutput struct {
+		w  io.Writer
+		mu sync.Mutex
+	}
+
+	// printWriter receives the lines written by the embedded standard
+	// library logger and writes them as entries with the info level.
+	printWriter struct {
+		l *Logger
+	}
+)
+
+// String returns the name of the log level.
+func (ll LogLevel) String() string {
+	if ll < 0 || int(ll) >= len(logLevelNames) {
+		return fmt.Sprintf("LogLevel(%d)", int(ll))
+	}
+	return logLevelNames[ll]
+}
+
+// MarshalJSON marshals the log level as its name.
+func (ll LogLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ll.String())
+}
+
+// UnmarshalJSON unmarshals the name of a log level.
+func (ll *LogLevel) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	level, err := ParseLogLevel(s)
+	if err != nil {
+		return err
+	}
+	*ll = level
+	return nil
+}
+
+// ParseLogLevel returns the log level with the given name.
+func ParseLogLevel(s string) (LogLevel, error) {
+	for i, name := range logLevelNames {
+		if strings.EqualFold(s, name) {
+			return LogLevel(i), nil
+		}
+	}
+	return 0, errUnknownLogLevel
+}
+
+// defaultLogLevel is the level of the modules whose level was not set with
+// SetLogLevel. Debug entries are only written by default in debug builds.
+func defaultLogLevel() LogLevel {
+	if build.DEBUG {
+		return LogLevelDebug
+	}
+	return LogLevelInfo
+}
+
+// LogLevels returns the levels of all modules that have an open logger.
+func LogLevels() map[string]LogLevel {
+	logLevelsMu.RLock()
+	defer logLevelsMu.RUnlock()
+	levels := make(map[string]LogLevel, len(logModules))
+	for module := range logModules {
+		level, ok := logLevels[module]
+		if !ok {
+			level = defaultLogLevel()
+		}
+		levels[module] = level
+	}
+	return levels
+}
+
+// SetLogLevel sets the level of the loggers of a module. Entries below the
+// level are discarded. The level applies to every logger of the module,
+// including loggers that are created later.
+func SetLogLevel(module string, level LogLevel) error {
+	if level < LogLevelDebug || level > LogLevelCritical {
+		return errUnknownLogLevel
+	}
+	logLevelsMu.Lock()
+	defer logLevelsMu.Unlock()
+	if _, ok := logModules[module]; !ok {
+		return errUnknownLogModule
+	}
+	logLevels[module] = level
+	return nil
+}
+
+// registerLogModule records that a logger of the module was opened.
+func registerLogModule(module string) {
+	logLevelsMu.Lock()
+	logModules[module]++
+	logLevelsMu.Unlock()
+}
+
+// unregisterLogModule records that a logger of the module was closed.
+func unregisterLogModule(module string) {
+	logLevelsMu.Lock()
+	logModules[module]--
+	if logModules[module] <= 0 {
+		delete(logModules, module)
+	}
+	logLevelsMu.Unlock()
+}
+
+// enabled returns true if entries with the given level are written.
+func (l *Logger) enabled(level LogLevel) bool {
+	logLevelsMu.RLock()
+	min, ok := logLevels[l.module]
+	logLevelsMu.RUnlock()
+	if !ok {
+		min = defaultLogLevel()
+	}
+	return level >= min
+}
+
+// Write implements io.Writer. The standard library logger prefixes every line
+// with the file and line of the caller.
+func (pw printWriter) Write(b []byte) (int, error) {
+	line := strings.TrimSuffix(string(b), "\n")
+	var caller string
+	if i := strings.Index(line, ": "); i >= 0 {
+		caller, line = line[:i], line[i+2:]
+	}
+	if pw.l.enabled(LogLevelInfo) {
+		pw.l.write(LogLevelInfo, caller, line, nil)
+	}
+	return len(b), nil
+}
+
+// write writes an entry to the output of the logger.
+func (l *Logger) write(level LogLevel, caller, msg string, keyvals []interface{}) {
+	var buf bytes.Buffer
+	writeField := func(key string, value interface{}) {
+		b, err := json.Marshal(value)
+		if err != nil {
+			b, _ = json.Marshal(fmt.Sprint(value))
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(b)
+	}
+
+	buf.WriteByte('{')
+	writeField("time", time.Now().UTC().Format(logTimeFormat))
+	writeField("level", level.String())
+	writeField(LogFieldModule, l.module)
+	if caller != "" {
+		writeField("caller", caller)
+	}
+	writeField("msg", strings.TrimSuffix(msg, "\n"))
+	fields := append(append([]interface{}(nil), l.fields...), keyvals...)
+	for i := 0; i < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		var value interface{} = "MISSING"
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+		writeField(key, value)
+	}
+	buf.WriteString("}\n")
+
+	l.out.mu.Lock()
+	l.out.w.Write(buf.Bytes())
+	l.out.mu.Unlock()
+}
+
+// output writes an entry if its level is enabled. calldepth is the number of
+// stack frames between output and the caller that is recorded in the entry.
+func (l *Logger) output(level LogLevel, calldepth int, msg string, keyvals []interface{}) {
+	if !l.enabled(level) {
+		return
+	}
+	var caller string
+	if _, file, line, ok := runtime.Caller(calldepth + 1); ok {
+		caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+	l.write(level, caller, msg, keyvals)
+}
+
+// With returns a logger that adds the given key/value pairs to every entry.
+// The returned logger shares the output of l and must not be closed.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	child := &Logger{
+		fields: append(append([]interface{}(nil), l.fields...), keyvals...),
+		module: l.module,
+		out:    l.out,
+	}
+	child.Logger = log.New(printWriter{child}, "", log.Lshortfile)
+	return child
 }
 
 // Close logs a shutdown message and closes the Logger's underlying io.Writer,
 // if it is also an io.Closer.
 func (l *Logger) Close() error {
-	l.Output(2, "SHUTDOWN: Logging has terminated.")
-	if c, ok := l.w.(io.Closer); ok {
+	l.write(LogLevelInfo, "", "SHUTDOWN: Logging has terminated.", nil)
+	unregisterLogModule(l.module)
+	if c, ok := l.out.w.(io.Closer); ok {
 		return c.Close()
 	}
 	return nil
@@ -33,96 +292,82 @@ func (l *Logger) Close() error {
 // to os.Stderr and panic. Critical should only be called if there has been a
 // developer error, otherwise Severe should be called.
 func (l *Logger) Critical(v ...interface{}) {
-	l.Output(2, "CRITICAL: "+fmt.Sprintln(v...))
+	l.output(LogLevelCritical, 1, "CRITICAL: "+fmt.Sprintln(v...), nil)
 	build.Critical(v...)
 }
 
-// Debug is equivalent to Logger.Print when build.DEBUG is true. Otherwise it
-// is a no-op.
+// Debug logs a message with the debug level.
 func (l *Logger) Debug(v ...interface{}) {
-	if build.DEBUG {
-		l.Output(2, fmt.Sprint(v...))
-	}
+	l.output(LogLevelDebug, 1, fmt.Sprint(v...), nil)
 }
 
-// Debugf is equivalent to Logger.Printf when build.DEBUG is true. Otherwise it
-// is a no-op.
+// Debugf logs a formatted message with the debug level.
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	if build.DEBUG {
-		l.Output(2, fmt.Sprintf(format, v...))
-	}
+	l.output(LogLevelDebug, 1, fmt.Sprintf(format, v...), nil)
 }
 
-// Debugln is equivalent to Logger.Println when build.DEBUG is true. Otherwise
-// it is a no-op.
+// Debugln logs a message with the debug level.
 func (l *Logger) Debugln(v ...interface{}) {
-	if build.DEBUG {
-		l.Output(2, "[DEBUG] "+fmt.Sprintln(v...))
-	}
+	l.output(LogLevelDebug, 1, fmt.Sprintln(v...), nil)
 }
 
-// Severe logs a message with a SEVERE prefix. If debug mode is enabled, it
-// will also write the message to os.Stderr and panic. Severe should be called
-// if there is a severe problem with the user's machine or setup that should be
-// addressed ASAP but does not necessarily require that the machine crash or
-// exit.
-func (l *Logger) Severe(v ...interface{}) {
-	l.Output(2, "SEVERE: "+fmt.Sprintln(v...))
-	build.Severe(v...)
+// Infow logs a message and key/value pairs with the info level.
+func (l *Logger) Infow(msg string, keyvals ...interface{}) {
+	l.output(LogLevelInfo, 1, msg, keyvals)
 }
 
-// NewLogger returns a logger that can be closed. Calls should not be made to
-// the logger after 'Close' has been called.
-func NewLogger(w io.Writer) *Logger {
-	l := log.New(w, "", log.Ldate|log.Ltime|log.Lmicroseconds|log.Lshortfile|log.LUTC)
-	l.Output(3, "STARTUP: Logging has started. Siad Version "+build.Version) // Call depth is 3 because NewLogger is usually called by NewFileLogger
-	return &Logger{l, w}
+// Warn logs a message with the warn level.
+func (l *Logger) Warn(v ...interface{}) {
+	l.output(LogLevelWarn, 1, fmt.Sprintln(v...), nil)
 }
 
-// closeableFile wraps an os.File to perform sanity checks on its Write and
-// Close methods. When the checks are enabled, calls to Write or Close will
-// panic if they are called after the file has already been closed.
-type closeableFile struct {
-	*os.File
-	closed bool
-	mu     sync.RWMutex
+// Warnf logs a formatted message with the warn level.
+func (l *Logger) Warnf(format string, v ...interface{}) {
+	l.output(LogLevelWarn, 1, fmt.Sprintf(format, v...), nil)
 }
 
-// Close closes the file and sets the closed flag.
-func (cf *closeableFile) Close() error {
-	cf.mu.Lock()
-	defer cf.mu.Unlock()
-	// Sanity check - close should not have been called yet.
-	if cf.closed {
-		build.Critical("cannot close the file; already closed")
-	}
+// Warnw logs a message and key/value pairs with the warn level.
+func (l *Logger) Warnw(msg string, keyvals ...interface{}) {
+	l.output(LogLevelWarn, 1, msg, keyvals)
+}
 
-	// Ensure that all data has actually hit the disk.
-	if err := cf.Sync(); err != nil {
-		return err
-	}
-	cf.closed = true
-	return cf.File.Close()
+// Severe logs a message with a SEVERE prefix and the error level. If debug
+// mode is enabled, it will also write the message to os.Stderr and panic.
+// Severe should be called if there is a severe problem with the user's
+// machine or setup that should be addressed ASAP but does not necessarily
+// require that the machine crash or exit.
+func (l *Logger) Severe(v ...interface{}) {
+	l.output(LogLevelError, 1, "SEVERE: "+fmt.Sprintln(v...), nil)
+	build.Severe(v...)
+}
+
+// NewLogger returns a logger without a module that can be closed. Calls
+// should not be made to the logger after 'Close' has been called.
+func NewLogger(w io.Writer) *Logger {
+	return newModuleLogger(w, "")
 }
 
-// Write takes the input data and writes it to the file.
-func (cf *closeableFile) Write(b []byte) (int, error) {
-	cf.mu.RLock()
-	defer cf.mu.RUnlock()
-	// Sanity check - close should not have been called yet.
-	if cf.closed {
-		build.Critical("cannot write to the file after it has been closed")
+// newModuleLogger returns a logger of a module that writes to w.
+func newModuleLogger(w io.Writer, module string) *Logger {
+	l := &Logger{
+		module: module,
+		out:    &logOutput{w: w},
 	}
-	return cf.File.Write(b)
+	l.Logger = log.New(printWriter{l}, "", log.Lshortfile)
+	registerLogModule(module)
+	l.write(LogLevelInfo, "", "STARTUP: Logging has started. Siad Version "+build.Version, nil)
+	return l
 }
 
 // NewFileLogger returns a logger that logs to logFilename. The file is opened
-// in append mode, and created if it does not exist.
+// in append mode, and created if it does not exist. The module of the logger
+// is the name of the file without its extension, and the file is rotated
+// according to the current LogRotation settings.
 func NewFileLogger(logFilename string) (*Logger, error) {
-	logFile, err := os.OpenFile(logFilename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0660)
+	rf, err := openRotatingFile(logFilename)
 	if err != nil {
 		return nil, err
 	}
-	cf := &closeableFile{File: logFile}
-	return NewLogger(cf), nil
+	module := strings.TrimSuffix(filepath.Base(logFilename), filepath.Ext(logFilename))
+	return newModuleLogger(rf, module), nil
 }
@@ -1,11 +1,13 @@
 package persist
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/acejam/Sia/build"
 )
@@ -87,3 +89,139 @@ func TestLoggerCritical(t *testing.T) {
 	}()
 	fl.Critical("a critical message")
 }
+
+// TestLoggerFields checks that the entries of a logger are JSON objects that
+// contain the module, the level and the fields of the entry.
+func TestLoggerFields(t *testing.T) {
+	testdir := build.TempDir(persistDir, t.Name())
+	err := os.MkdirAll(testdir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fl, err := NewFileLogger(filepath.Join(testdir, "fields.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fl.With(LogFieldContractID, "foo").Warnw("TEST", LogFieldSiaPath, "bar")
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fileData, err := ioutil.ReadFile(filepath.Join(testdir, "fields.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(fileData)), "\n")
+	if len(lines) != 3 {
+		t.Fatal("logger did not create the correct number of lines:", len(lines))
+	}
+	var entry map[string]string
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"level":            "warn",
+		"msg":              "TEST",
+		LogFieldModule:     "fields",
+		LogFieldContractID: "foo",
+		LogFieldSiaPath:    "bar",
+	}
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Errorf("expected %v to be %q, got %q", k, v, entry[k])
+		}
+	}
+	if !strings.HasPrefix(entry["caller"], "log_test.go:") {
+		t.Error("wrong caller:", entry["caller"])
+	}
+}
+
+// TestSetLogLevel checks that entries below the level of a module are
+// discarded.
+func TestSetLogLevel(t *testing.T) {
+	testdir := build.TempDir(persistDir, t.Name())
+	err := os.MkdirAll(testdir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFilename := filepath.Join(testdir, "levels.log")
+	fl, err := NewFileLogger(logFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := LogLevels()["levels"]; !ok {
+		t.Fatal("module of the logger is not listed")
+	}
+	if err := SetLogLevel("levels", LogLevelWarn); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLogLevel("unknown", LogLevelWarn); err != errUnknownLogModule {
+		t.Fatal("expected errUnknownLogModule, got", err)
+	}
+	fl.Debugln("DEBUG")
+	fl.Println("INFO")
+	fl.Warn("WARN")
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := LogLevels()["levels"]; ok {
+		t.Fatal("module of the closed logger is still listed")
+	}
+
+	fileData, err := ioutil.ReadFile(logFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(fileData), "DEBUG") || strings.Contains(string(fileData), "INFO") {
+		t.Error("entries below the level were written")
+	}
+	if !strings.Contains(string(fileData), "WARN") {
+		t.Error("entry at the level was not written")
+	}
+
+	if _, err := ParseLogLevel("verbose"); err != errUnknownLogLevel {
+		t.Fatal("expected errUnknownLogLevel, got", err)
+	}
+}
+
+// TestLogRotation checks that log files are rotated once they exceed the
+// maximum size and that old files are removed.
+func TestLogRotation(t *testing.T) {
+	testdir := build.TempDir(persistDir, t.Name())
+	err := os.MkdirAll(testdir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := CurrentLogRotation()
+	SetLogRotation(LogRotation{MaxSize: 512, MaxBackups: 2})
+	defer SetLogRotation(old)
+
+	logFilename := filepath.Join(testdir, "rotation.log")
+	fl, err := NewFileLogger(logFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		fl.Println(strings.Repeat("x", 100))
+		// Ensure that the rotated files have distinct names.
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := os.Stat(logFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Size() > 512 {
+		t.Error("log file was not rotated:", stat.Size())
+	}
+	backups, err := filepath.Glob(logFilename + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Error("expected 2 rotated files, got", len(backups))
+	}
+}
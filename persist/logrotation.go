@@ -0,0 +1,185 @@
+package persist
+
+// logrotation.go implements the log files of the file loggers. A log file is
+// rotated once it exceeds the maximum size or age of the current
+// LogRotation settings: the file is renamed to include the time of the
+// rotation, and a new file is created. Old files are removed once there are
+// more than MaxBackups of them or once they are older than MaxAge.
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acejam/Sia/build"
+)
+
+// rotatedLogTimeFormat is the format of the time in the name of a rotated log
+// file.
+const rotatedLogTimeFormat = "2006-01-02T15-04-05.000"
+
+var (
+	// defaultLogRotation are the rotation settings of the file loggers if
+	// SetLogRotation is never called.
+	defaultLogRotation = build.Select(build.Var{
+		Standard: LogRotation{MaxSize: 100 << 20, MaxBackups: 10},
+		Dev:      LogRotation{MaxSize: 100 << 20, MaxBackups: 10},
+		Testing:  LogRotation{MaxSize: 1 << 20, MaxBackups: 2},
+	}).(LogRotation)
+
+	// logRotation contains the current rotation settings.
+	logRotation   = defaultLogRotation
+	logRotationMu sync.RWMutex
+)
+
+type (
+	// LogRotation contains the settings of the rotation of the log files.
+	// MaxSize is the size in bytes and MaxAge the age after which a log file
+	// is rotated. MaxBackups is the number of rotated files that are kept. A
+	// value of zero disables the respective limit.
+	LogRotation struct {
+		MaxSize    int64         `json:"maxsize"`
+		MaxAge     time.Duration `json:"maxage"`
+		MaxBackups int           `json:"maxbackups"`
+	}
+
+	// rotatingFile is a log file that is rotated according to the current
+	// LogRotation settings. Calls to Write or Close will panic if they are
+	// called after the file has already been closed.
+	rotatingFile struct {
+		*os.File
+		closed  bool
+		created time.Time
+		name    string
+		size    int64
+		mu      sync.Mutex
+	}
+)
+
+// CurrentLogRotation returns the current rotation settings of the log files.
+func CurrentLogRotation() LogRotation {
+	logRotationMu.RLock()
+	defer logRotationMu.RUnlock()
+	return logRotation
+}
+
+// SetLogRotation sets the rotation settings of all log files. The settings
+// take effect on the next write to a log file.
+func SetLogRotation(lr LogRotation) {
+	logRotationMu.Lock()
+	logRotation = lr
+	logRotationMu.Unlock()
+}
+
+// openRotatingFile opens the log file in append mode, and creates it if it
+// does not exist.
+func openRotatingFile(name string) (*rotatingFile, error) {
+	rf := &rotatingFile{name: name}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// open opens the log file.
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0660)
+	if err != nil {
+		return err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.File = f
+	rf.size = stat.Size()
+	rf.created = time.Now()
+	return nil
+}
+
+// rotate renames the log file, opens a new one and removes the rotated files
+// that exceed the limits of lr.
+func (rf *rotatingFile) rotate(lr LogRotation) error {
+	if err := rf.File.Close(); err != nil {
+		return err
+	}
+	rotated := rf.name + "." + time.Now().UTC().Format(rotatedLogTimeFormat)
+	if err := os.Rename(rf.name, rotated); err != nil {
+		// Keep writing to the old file.
+		if openErr := rf.open(); openErr != nil {
+			return openErr
+		}
+		return err
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	// Remove old files, newest first. The time in the name sorts
+	// lexicographically.
+	backups, err := filepath.Glob(rf.name + ".*")
+	if err != nil {
+		return err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	for i, backup := range backups {
+		if strings.HasSuffix(backup, tempSuffix) {
+			continue
+		}
+		tooMany := lr.MaxBackups > 0 && i >= lr.MaxBackups
+		tooOld := false
+		if lr.MaxAge > 0 {
+			if stat, err := os.Stat(backup); err == nil {
+				tooOld = time.Since(stat.ModTime()) > lr.MaxAge
+			}
+		}
+		if tooMany || tooOld {
+			os.Remove(backup)
+		}
+	}
+	return nil
+}
+
+// Close closes the file and sets the closed flag.
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	// Sanity check - close should not have been called yet.
+	if rf.closed {
+		build.Critical("cannot close the file; already closed")
+	}
+
+	// Ensure that all data has actually hit the disk.
+	if err := rf.Sync(); err != nil {
+		return err
+	}
+	rf.closed = true
+	return rf.File.Close()
+}
+
+// Write takes the input data and writes it to the file, rotating the file
+// first if it exceeds the limits of the current LogRotation settings.
+func (rf *rotatingFile) Write(b []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	// Sanity check - close should not have been called yet.
+	if rf.closed {
+		build.Critical("cannot write to the file after it has been closed")
+	}
+
+	lr := CurrentLogRotation()
+	tooLarge := lr.MaxSize > 0 && rf.size+int64(len(b)) > lr.MaxSize
+	tooOld := lr.MaxAge > 0 && time.Since(rf.created) > lr.MaxAge
+	if rf.size > 0 && (tooLarge || tooOld) {
+		if err := rf.rotate(lr); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.File.Write(b)
+	rf.size += int64(n)
+	return n, err
+}
@@ -1,10 +1,14 @@
 package consensus
 
 import (
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/acejam/Sia/modules"
 	"github.com/acejam/Sia/node"
+	"github.com/acejam/Sia/node/api"
 	"github.com/acejam/Sia/siatest"
 	"github.com/acejam/Sia/types"
 )
@@ -197,3 +201,95 @@ func TestConsensusBlocksIDGet(t *testing.T) {
 		}
 	}
 }
+
+// TestConsensusSubscribe tests the /consensus/subscribe endpoint and the
+// client helpers that follow the stream of consensus changes.
+func TestConsensusSubscribe(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testNode, err := siatest.NewNode(node.AllModules(consensusTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Mine enough blocks that the stream has to catch up several times.
+	for i := 0; i < 150; i++ {
+		if err := testNode.MineBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Follow the changes from the beginning until the target height is
+	// reached, checking that they form a chain.
+	errDone := errors.New("done")
+	target := cg.Height
+	var tip types.BlockID
+	var height types.BlockHeight
+	var lastChange modules.ConsensusChangeID
+	follow := func(cc api.ConsensusChange) error {
+		for _, b := range cc.RevertedBlocks {
+			if b.ID() != tip {
+				t.Fatal("reverted block is not the tip")
+			}
+			tip = b.ParentID
+			height--
+		}
+		for _, b := range cc.AppliedBlocks {
+			if b.ParentID != tip && b.ID() != types.GenesisID {
+				t.Fatal("applied block does not extend the tip")
+			}
+			tip = b.ID()
+			height++
+		}
+		lastChange = modules.ConsensusChangeID(cc.ID)
+		if height-1 == target {
+			return errDone
+		}
+		return nil
+	}
+	if err := testNode.ConsensusSubscribe(modules.ConsensusChangeBeginning, follow, nil); err != errDone {
+		t.Fatal("expected the subscription to reach the current block:", err)
+	}
+	if tip != cg.CurrentBlock {
+		t.Fatal("subscription did not reach the current block")
+	}
+
+	// Resume from the last change and receive a new block as it is mined.
+	target++
+	done := make(chan error, 1)
+	cancel := make(chan struct{})
+	go func() {
+		done <- testNode.ConsensusSubscribe(lastChange, follow, cancel)
+	}()
+	time.Sleep(time.Second)
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != errDone {
+			t.Fatal("expected the subscription to reach the new block:", err)
+		}
+		if cg, err = testNode.ConsensusGet(); err != nil || tip != cg.CurrentBlock {
+			t.Fatal("subscription did not reach the new block:", err)
+		}
+	case <-time.After(30 * time.Second):
+		close(cancel)
+		t.Fatal("new block was not streamed")
+	}
+
+	// Unknown changes are rejected.
+	if _, err := testNode.ConsensusSubscribeGet(modules.ConsensusChangeID{2}); err == nil {
+		t.Fatal("expected an error for an unknown change")
+	}
+}
@@ -0,0 +1,18 @@
+package daemon
+
+import (
+	"os"
+
+	"github.com/acejam/Sia/siatest"
+)
+
+// daemonTestDir creates a temporary testing directory for a daemon test. This
+// should only every be called once per test. Otherwise it will delete the
+// directory again.
+func daemonTestDir(testName string) string {
+	path := siatest.TestDir("daemon", testName)
+	if err := os.MkdirAll(path, 0777); err != nil {
+		panic(err)
+	}
+	return path
+}
@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/acejam/Sia/node"
+	"github.com/acejam/Sia/siatest"
+)
+
+// unvalidatedRoutes are the routes whose responses are not validated by
+// TestOpenAPIConformance because calling them has side effects or because
+// they don't return until the client disconnects.
+var unvalidatedRoutes = map[string]bool{
+	"GET /consensus/subscribe": true,
+	"GET /miner/start":         true,
+	"GET /wallet/events":       true,
+}
+
+// TestOpenAPIConformance checks that every route served by a node is
+// described by the OpenAPI specification served at /daemon/openapi, and that
+// the responses of the GET routes match the schemas of the spec.
+func TestOpenAPIConformance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	testNode, err := siatest.NewNode(node.AllModules(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	spec, err := testNode.DaemonOpenAPIGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replace the path parameters with values that refer to the genesis
+	// block, so that as many routes as possible return a successful response.
+	params := strings.NewReplacer(":height", "0", "*siapath", "foo", ":", "", "*", "")
+
+	for _, route := range testNode.APIRoutes() {
+		split := strings.SplitN(route, " ", 2)
+		method, path := split[0], split[1]
+		specPath := path
+		for _, s := range strings.Split(path, "/") {
+			if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+				specPath = strings.Replace(specPath, s, "{"+s[1:]+"}", 1)
+			}
+		}
+		if spec.Paths[specPath][strings.ToLower(method)] == nil {
+			t.Errorf("%v is not described by the spec", route)
+			continue
+		}
+		if method != "GET" || unvalidatedRoutes[route] {
+			continue
+		}
+
+		resource := params.Replace(path)
+		req, err := testNode.NewRequest(method, resource, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := spec.ValidateResponse(method, resource, resp.StatusCode, body); err != nil {
+			t.Errorf("%v %v: response %v does not match the spec: %v", method, resource, resp.StatusCode, err)
+		}
+	}
+}